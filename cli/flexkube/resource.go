@@ -110,7 +110,10 @@ func (r *Resource) getEtcd() (types.Resource, error) {
 			return nil, fmt.Errorf("etcd management not enabled in the configuration and state not found")
 		}
 
-		r.Etcd = &etcd.Cluster{}
+		// Configuration has been removed, so remove created containers.
+		r.Etcd = &etcd.Cluster{
+			AllowRemoveAll: true,
+		}
 	}
 
 	if r.State != nil && r.State.Etcd != nil {
@@ -158,7 +161,10 @@ func (r *Resource) getKubeletPool(name string) (types.Resource, error) {
 		return nil, fmt.Errorf("pool not configured and state not found")
 	}
 
-	pool := &kubelet.Pool{}
+	// If configuration has been removed, remove created containers.
+	pool := &kubelet.Pool{
+		AllowRemoveAll: true,
+	}
 
 	if configFound {
 		pool = configPool
@@ -213,7 +219,10 @@ func (r *Resource) getAPILoadBalancerPool(name string) (types.Resource, error) {
 		return nil, fmt.Errorf("pool not configured and state not found")
 	}
 
-	pool := &apiloadbalancer.APILoadBalancers{}
+	// If configuration has been removed, remove created containers.
+	pool := &apiloadbalancer.APILoadBalancers{
+		AllowRemoveAll: true,
+	}
 
 	if configFound {
 		pool = configPool
@@ -235,7 +244,10 @@ func (r *Resource) getContainers(name string) (types.Resource, error) {
 		return nil, fmt.Errorf("group not configured and state not found")
 	}
 
-	containers := &resource.Containers{}
+	// If configuration has been removed, remove created containers.
+	containers := &resource.Containers{
+		AllowRemoveAll: !configFound,
+	}
 
 	if configFound {
 		containers.Containers = *config
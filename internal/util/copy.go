@@ -0,0 +1,83 @@
+package util
+
+import (
+	"reflect"
+)
+
+// DeepCopy returns a copy of given value, which does not share any pointers, maps or
+// slices with the original value. Functions and channels are shared, as they can't
+// be copied. Values stored in interfaces are shared as well, as they are usually
+// implementations with internal state, like loggers or tracers, which must not be
+// detached from the original. Unexported struct fields are copied shallowly.
+//
+// Given value must not contain cycles.
+func DeepCopy(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	return deepCopy(reflect.ValueOf(v)).Interface()
+}
+
+func deepCopy(v reflect.Value) reflect.Value { //nolint:gocyclo
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+
+		n := reflect.New(v.Type().Elem())
+		n.Elem().Set(deepCopy(v.Elem()))
+
+		return n
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+
+		n := reflect.MakeMapWithSize(v.Type(), v.Len())
+
+		i := v.MapRange()
+		for i.Next() {
+			n.SetMapIndex(deepCopy(i.Key()), deepCopy(i.Value()))
+		}
+
+		return n
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+
+		n := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+
+		for i := 0; i < v.Len(); i++ {
+			n.Index(i).Set(deepCopy(v.Index(i)))
+		}
+
+		return n
+	case reflect.Array:
+		n := reflect.New(v.Type()).Elem()
+
+		for i := 0; i < v.Len(); i++ {
+			n.Index(i).Set(deepCopy(v.Index(i)))
+		}
+
+		return n
+	case reflect.Struct:
+		n := reflect.New(v.Type()).Elem()
+		n.Set(v)
+
+		for i := 0; i < v.NumField(); i++ {
+			// Unexported fields can't be set, so they stay shallow copied.
+			if !n.Field(i).CanSet() {
+				continue
+			}
+
+			n.Field(i).Set(deepCopy(v.Field(i)))
+		}
+
+		return n
+	default:
+		return v
+	}
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type copyTestStruct struct {
+	Map       map[string]string
+	Slice     []string
+	Pointer   *copyTestStruct
+	Function  func() string
+	Interface fmt.Stringer
+}
+
+type copyTestStringer struct {
+	value string
+}
+
+func (s *copyTestStringer) String() string {
+	return s.value
+}
+
+func TestDeepCopy(t *testing.T) {
+	t.Parallel()
+
+	f := func() string {
+		return expectedValueString
+	}
+
+	o := &copyTestStruct{
+		Map:   map[string]string{expectedValueString: expectedValueString},
+		Slice: []string{expectedValueString},
+		Pointer: &copyTestStruct{
+			Slice: []string{expectedValueString},
+		},
+		Function:  f,
+		Interface: &copyTestStringer{value: expectedValueString},
+	}
+
+	c, ok := DeepCopy(o).(*copyTestStruct)
+	if !ok {
+		t.Fatalf("DeepCopy should return value of the same type, got %T", c)
+	}
+
+	if diff := cmp.Diff(o.Map, c.Map); diff != "" {
+		t.Fatalf("copy should be equal to the original: %s", diff)
+	}
+
+	c.Map[expectedValueString] = "bar"
+	c.Slice[0] = "bar"
+	c.Pointer.Slice[0] = "bar"
+
+	if o.Map[expectedValueString] != expectedValueString {
+		t.Fatalf("modifying map in the copy should not modify the original")
+	}
+
+	if o.Slice[0] != expectedValueString || o.Pointer.Slice[0] != expectedValueString {
+		t.Fatalf("modifying slices in the copy should not modify the original")
+	}
+
+	if c.Function() != expectedValueString {
+		t.Fatalf("functions should be preserved in the copy")
+	}
+
+	if c.Interface != o.Interface {
+		t.Fatalf("values stored in interfaces should be shared with the copy")
+	}
+}
+
+func TestDeepCopyNil(t *testing.T) {
+	t.Parallel()
+
+	if DeepCopy(nil) != nil {
+		t.Fatalf("copying nil should return nil")
+	}
+
+	var m map[string]string
+
+	if c := DeepCopy(m).(map[string]string); c != nil {
+		t.Fatalf("copying nil map should return nil map, got: %v", c)
+	}
+}
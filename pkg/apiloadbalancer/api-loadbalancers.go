@@ -110,6 +110,11 @@ type APILoadBalancers struct {
 	// State stores state of the created containers. After deployment, it is up to the user to export
 	// the state and restore it on consecutive runs.
 	State container.ContainersState `json:"state,omitempty"`
+
+	// AllowRemoveAll allows removing all created containers, when list of load balancers is empty. Without it,
+	// deployment fails in such case, to prevent accidental removal, e.g. caused by invalid
+	// configuration.
+	AllowRemoveAll bool `json:"allowRemoveAll,omitempty"`
 }
 
 // apiLoadBalancers is validated and executable version of APILoadBalancers.
@@ -138,8 +143,9 @@ func (a *APILoadBalancers) New() (types.Resource, error) {
 	}
 
 	cc := &container.Containers{
-		PreviousState: a.State,
-		DesiredState:  make(container.ContainersState),
+		PreviousState:  a.State,
+		DesiredState:   make(container.ContainersState),
+		AllowRemoveAll: a.AllowRemoveAll,
 	}
 
 	for i, lb := range a.APILoadBalancers {
@@ -0,0 +1,159 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/flexkube/libflexkube/internal/util"
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+)
+
+// findExisting finds existing container created outside of the deployment with the name of the
+// container and updates the status of the container with it. Exactly one such container must exist.
+func (m *hostConfiguredContainer) findExisting() error {
+	return m.withForwardedRuntime(func() error {
+		r := m.container.Runtime()
+
+		l, ok := r.(runtime.Lister)
+		if !ok {
+			return fmt.Errorf("container runtime does not support listing containers")
+		}
+
+		instances, err := l.List(m.container.Config().Name)
+		if err != nil {
+			return fmt.Errorf("failed listing containers: %w", err)
+		}
+
+		switch len(instances) {
+		case 0:
+			return fmt.Errorf("no existing container found")
+		case 1:
+		default:
+			// Picking one of the containers and removing others could be disruptive, so let user decide.
+			return fmt.Errorf("found %d existing containers, expected exactly one", len(instances))
+		}
+
+		s, err := r.Status(instances[0].ID)
+		if err != nil {
+			return fmt.Errorf("failed getting status of container %s: %w", instances[0].ID, err)
+		}
+
+		m.container.SetStatus(s)
+
+		return nil
+	})
+}
+
+// adoptCandidate returns container from the desired state with status of the existing container
+// found on the host and with current content of configuration files.
+func (c *containers) adoptCandidate(n string) (*hostConfiguredContainer, error) {
+	d := c.desiredState[n]
+
+	a := *d
+	a.container = &container{
+		base: base{
+			config:        d.container.Config(),
+			runtime:       d.container.Runtime(),
+			runtimeConfig: d.container.RuntimeConfig(),
+		},
+	}
+
+	if err := a.findExisting(); err != nil {
+		return nil, err
+	}
+
+	if err := a.ConfigurationStatus(); err != nil {
+		return nil, fmt.Errorf("failed reading configuration files: %w", err)
+	}
+
+	equivalent, err := a.equivalent(d.container.Config())
+	if err != nil {
+		return nil, fmt.Errorf("failed comparing configuration: %w", err)
+	}
+
+	if !equivalent {
+		return nil, fmt.Errorf("existing container %s runs with configuration different from desired one",
+			a.container.Status().ID)
+	}
+
+	return &a, nil
+}
+
+// adoptNames returns names of containers, which should be adopted. If no names are given,
+// all containers in scope from the desired state, which are not in the current state are returned.
+func (c *containers) adoptNames(names []string) ([]string, error) {
+	if len(names) == 0 {
+		for n := range c.desiredState {
+			if _, ok := c.currentState[n]; !ok && c.inScope(n) {
+				names = append(names, n)
+			}
+		}
+
+		sort.Strings(names)
+
+		return names, nil
+	}
+
+	for _, n := range names {
+		if _, ok := c.desiredState[n]; !ok {
+			return nil, fmt.Errorf("container %q does not exist in the desired state", n)
+		}
+
+		if r, ok := c.currentState[n]; ok && r.container.Status().ID != "" {
+			return nil, fmt.Errorf("container %q is already managed", n)
+		}
+	}
+
+	return names, nil
+}
+
+// Adopt imports containers with given names, which has been created outside of the deployment,
+// e.g. manually, into the current state, so they are managed from now on without being re-created.
+// If no names are given, all containers from the desired state missing in the current state are
+// adopted.
+//
+// Container is adopted only if exactly one container with the desired name exists on the desired
+// host and container runtime reports, that it runs with configuration equivalent to the desired
+// one. Content of configuration files is read from the host, so changes to them will be visible
+// in the difference between current and desired state and applied by Deploy() without re-creating
+// the container. If any of the containers can't be adopted, error is returned and current state
+// is left untouched.
+//
+// CheckCurrentState() must be called before calling Adopt().
+func (c *containers) Adopt(names []string) error {
+	if c.currentState == nil {
+		return fmt.Errorf("can't adopt containers without knowing current state of the containers")
+	}
+
+	names, err := c.adoptNames(names)
+	if err != nil {
+		return err
+	}
+
+	var errors util.ValidateError
+
+	adopted := containersState{}
+
+	for _, n := range names {
+		a, err := c.adoptCandidate(n)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("failed adopting container %q on host %s: %w", n, c.desiredState[n].host.ID(), err))
+
+			continue
+		}
+
+		adopted[n] = a
+	}
+
+	if err := errors.Return(); err != nil {
+		return err
+	}
+
+	for _, n := range names {
+		c.logf(n, "Adopting existing container %s as container '%s'", adopted[n].container.Status().ID, n)
+
+		c.currentState[n] = adopted[n]
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+// adoptTestRuntime is a fake runtime, which implements both Lister and ConfigComparer interfaces.
+type adoptTestRuntime struct {
+	runtime.FakeLister
+
+	equivalent bool
+}
+
+func (r adoptTestRuntime) Equivalent(id string, config *types.ContainerConfig) (bool, error) {
+	return r.equivalent, nil
+}
+
+func adoptTestContainer(ids []string, equivalent bool) *hostConfiguredContainer {
+	r := adoptTestRuntime{
+		FakeLister: runtime.FakeLister{
+			Fake: runtime.Fake{
+				CreateF: func(config *types.ContainerConfig) (string, error) {
+					return "config", nil
+				},
+				StatusF: func(id string) (types.ContainerStatus, error) {
+					return types.ContainerStatus{
+						ID:     id,
+						Status: "running",
+					}, nil
+				},
+				DeleteF: func(id string) error {
+					return nil
+				},
+			},
+			ListF: func(name string) ([]types.ContainerInstance, error) {
+				instances := []types.ContainerInstance{}
+
+				for _, id := range ids {
+					instances = append(instances, types.ContainerInstance{ID: id})
+				}
+
+				return instances, nil
+			},
+		},
+		equivalent: equivalent,
+	}
+
+	return &hostConfiguredContainer{
+		host: host.Host{
+			DirectConfig: &direct.Config{},
+		},
+		container: &container{
+			base: base{
+				config: types.ContainerConfig{
+					Name:  foo,
+					Image: foo,
+				},
+				runtime: r,
+				runtimeConfig: &runtime.FakeConfig{
+					Runtime: r,
+				},
+			},
+		},
+	}
+}
+
+// Adopt() tests.
+func TestAdopt(t *testing.T) {
+	c := &containers{
+		currentState: containersState{},
+		desiredState: containersState{
+			foo: adoptTestContainer([]string{bar}, true),
+		},
+	}
+
+	if err := c.Adopt(nil); err != nil {
+		t.Fatalf("Adopting should succeed, got: %v", err)
+	}
+
+	r, ok := c.currentState[foo]
+	if !ok {
+		t.Fatalf("Adopted container should be added to the current state")
+	}
+
+	if id := r.container.Status().ID; id != bar {
+		t.Fatalf("Expected adopted container to have ID %q, got %q", bar, id)
+	}
+
+	if c.desiredState[foo].container.Status().ID != "" {
+		t.Fatalf("Adopting should not modify desired state")
+	}
+}
+
+func TestAdoptNotEquivalent(t *testing.T) {
+	c := &containers{
+		currentState: containersState{},
+		desiredState: containersState{
+			foo: adoptTestContainer([]string{bar}, true),
+			bar: adoptTestContainer([]string{foo}, false),
+		},
+	}
+
+	if err := c.Adopt(nil); err == nil {
+		t.Fatalf("Adopting container running with different configuration should fail")
+	}
+
+	if len(c.currentState) != 0 {
+		t.Fatalf("Current state should be left untouched, when adopting fails")
+	}
+}
+
+func TestAdoptMultipleContainers(t *testing.T) {
+	c := &containers{
+		currentState: containersState{},
+		desiredState: containersState{
+			foo: adoptTestContainer([]string{foo, bar}, true),
+		},
+	}
+
+	if err := c.Adopt(nil); err == nil {
+		t.Fatalf("Adopting should fail, when multiple containers are found")
+	}
+}
+
+func TestAdoptNotFound(t *testing.T) {
+	c := &containers{
+		currentState: containersState{},
+		desiredState: containersState{
+			foo: adoptTestContainer(nil, true),
+		},
+	}
+
+	if err := c.Adopt([]string{foo}); err == nil {
+		t.Fatalf("Adopting should fail, when no container is found")
+	}
+}
+
+func TestAdoptAlreadyManaged(t *testing.T) {
+	current := adoptTestContainer(nil, true)
+	current.container.Status().ID = foo
+
+	c := &containers{
+		currentState: containersState{
+			foo: current,
+		},
+		desiredState: containersState{
+			foo: adoptTestContainer([]string{bar}, true),
+		},
+	}
+
+	if err := c.Adopt([]string{foo}); err == nil {
+		t.Fatalf("Adopting already managed container should fail")
+	}
+
+	if err := c.Adopt(nil); err != nil {
+		t.Fatalf("Adopting without names should skip managed containers, got: %v", err)
+	}
+
+	if id := c.currentState[foo].container.Status().ID; id != foo {
+		t.Fatalf("Managed container should not be replaced, got ID %q", id)
+	}
+}
+
+func TestAdoptNotDesired(t *testing.T) {
+	c := &containers{
+		currentState: containersState{},
+		desiredState: containersState{},
+	}
+
+	if err := c.Adopt([]string{foo}); err == nil {
+		t.Fatalf("Adopting container not present in desired state should fail")
+	}
+}
+
+func TestAdoptNoCurrentState(t *testing.T) {
+	c := &containers{}
+
+	if err := c.Adopt(nil); err == nil {
+		t.Fatalf("Adopting without current state should fail")
+	}
+}
@@ -0,0 +1,96 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/flexkube/libflexkube/internal/util"
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+)
+
+// checkImage checks, if image of the container is present on the host or if it can be pulled
+// for given architecture. If container runtime does not support checking images, check is skipped.
+func (m *hostConfiguredContainer) checkImage(architecture string) error {
+	return m.withForwardedRuntime(func() error {
+		c, ok := m.container.Runtime().(runtime.ImageChecker)
+		if !ok {
+			return nil
+		}
+
+		return c.CheckImage(m.container.Config().Image, architecture)
+	})
+}
+
+// platformArchitecture returns architecture part of given platform in 'os/arch[/variant]'
+// format. If platform is empty, empty string is returned.
+func platformArchitecture(platform string) string {
+	p := strings.Split(platform, "/")
+	if len(p) < 2 {
+		return ""
+	}
+
+	return p[1]
+}
+
+// checkImagesAvailability checks images of all desired containers in scope of the deployment, before
+// any change is applied, so deployment does not fail half-way because of a missing image or an image
+// not supporting the architecture of the host. Architecture of each host is discovered once and
+// each image is checked only once per host and architecture. If container has platform specified,
+// architecture of the platform is used instead of the architecture of the host. All failures are
+// aggregated.
+func (c *containers) checkImagesAvailability() error {
+	var errors util.ValidateError
+
+	names := []string{}
+
+	for n := range c.desiredState {
+		if c.inScope(n) {
+			names = append(names, n)
+		}
+	}
+
+	sort.Strings(names)
+
+	architectures := map[string]string{}
+	checked := map[string]struct{}{}
+
+	for _, n := range names {
+		d := c.desiredState[n]
+		h := d.host.ID()
+
+		a, ok := architectures[h]
+		if pa := platformArchitecture(d.container.Config().Platform); pa != "" {
+			a, ok = pa, true
+		}
+
+		if !ok {
+			var err error
+
+			if a, err = d.host.Architecture(); err != nil {
+				errors = append(errors, fmt.Errorf("host of container %q: %w", n, err))
+
+				continue
+			}
+
+			c.logf(n, "Host %s has architecture %s", h, a)
+
+			architectures[h] = a
+		}
+
+		image := d.container.Config().Image
+		k := h + "/" + a + "/" + image
+
+		if _, ok := checked[k]; ok {
+			continue
+		}
+
+		checked[k] = struct{}{}
+
+		if err := d.checkImage(a); err != nil {
+			errors = append(errors, fmt.Errorf("image %q of container %q: %w", image, n, err))
+		}
+	}
+
+	return errors.Return()
+}
@@ -0,0 +1,129 @@
+package container
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+func checkImagesTestContainer(rc runtime.Config, image string) *hostConfiguredContainer {
+	return &hostConfiguredContainer{
+		host: host.Host{
+			DirectConfig: &direct.Config{},
+		},
+		container: &container{
+			base: base{
+				config: types.ContainerConfig{
+					Image: image,
+				},
+				runtimeConfig: rc,
+			},
+		},
+	}
+}
+
+// checkImagesAvailability() tests.
+func TestCheckImagesAvailability(t *testing.T) {
+	checked := map[string]int{}
+
+	rc := &runtime.FakeConfig{
+		Runtime: &runtime.FakeImageChecker{
+			CheckImageF: func(image, architecture string) error {
+				if architecture == "" {
+					t.Errorf("Discovered host architecture should be passed to the runtime")
+				}
+
+				checked[image]++
+
+				if image == bar {
+					return fmt.Errorf("image not found")
+				}
+
+				return nil
+			},
+		},
+	}
+
+	c := &containers{
+		desiredState: containersState{
+			foo:   checkImagesTestContainer(rc, foo),
+			"baz": checkImagesTestContainer(rc, foo),
+			bar:   checkImagesTestContainer(rc, bar),
+		},
+	}
+
+	if err := c.checkImagesAvailability(); err == nil {
+		t.Fatalf("Checking images should fail, when one of images is not available")
+	}
+
+	if checked[foo] != 1 {
+		t.Fatalf("Image used by multiple containers on the same host should be checked once, got %d", checked[foo])
+	}
+
+	if checked[bar] != 1 {
+		t.Fatalf("Image %q should be checked", bar)
+	}
+}
+
+func TestCheckImagesAvailabilityPlatform(t *testing.T) {
+	architectures := []string{}
+
+	rc := &runtime.FakeConfig{
+		Runtime: &runtime.FakeImageChecker{
+			CheckImageF: func(image, architecture string) error {
+				architectures = append(architectures, architecture)
+
+				return nil
+			},
+		},
+	}
+
+	d := checkImagesTestContainer(rc, foo)
+	d.container.(*container).config.Platform = "linux/arm64"
+
+	c := &containers{
+		desiredState: containersState{
+			foo: d,
+		},
+	}
+
+	if err := c.checkImagesAvailability(); err != nil {
+		t.Fatalf("Checking images should succeed, got: %v", err)
+	}
+
+	if len(architectures) != 1 || architectures[0] != "arm64" {
+		t.Fatalf("Architecture of the container platform should be used, got: %v", architectures)
+	}
+}
+
+// platformArchitecture() tests.
+func TestPlatformArchitecture(t *testing.T) {
+	cases := map[string]string{
+		"":             "",
+		"linux/arm64":  "arm64",
+		"linux/arm/v7": "arm",
+		"linux/amd64/": "amd64",
+	}
+
+	for platform, expected := range cases {
+		if a := platformArchitecture(platform); a != expected {
+			t.Fatalf("Expected architecture %q for platform %q, got %q", expected, platform, a)
+		}
+	}
+}
+
+func TestCheckImagesAvailabilityNotSupported(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: checkImagesTestContainer(&runtime.FakeConfig{Runtime: &runtime.Fake{}}, foo),
+		},
+	}
+
+	if err := c.checkImagesAvailability(); err != nil {
+		t.Fatalf("Checking images should be skipped, when runtime does not support it, got: %v", err)
+	}
+}
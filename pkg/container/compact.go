@@ -0,0 +1,134 @@
+package container
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// configFileReferencePrefix is a prefix of references to configuration files content,
+// which are stored in compact state instead of the content.
+const configFileReferencePrefix = "sha256:"
+
+// ConfigFileReference returns reference to given configuration file content. Reference is
+// stored in compact state instead of the content itself.
+func ConfigFileReference(content string) string {
+	return fmt.Sprintf("%s%x", configFileReferencePrefix, sha256.Sum256([]byte(content)))
+}
+
+// isConfigFileReference checks, if given configuration file content is a reference.
+func isConfigFileReference(content string) bool {
+	return strings.HasPrefix(content, configFileReferencePrefix) && len(content) == len(configFileReferencePrefix)+2*sha256.Size
+}
+
+// sameConfigFileContent checks, if desired configuration file content is the same as
+// current content. Current content may be a reference, if state has been stored in compact
+// form.
+func sameConfigFileContent(desired, current string) bool {
+	if desired == current {
+		return true
+	}
+
+	return isConfigFileReference(current) && ConfigFileReference(desired) == current
+}
+
+// SameConfigFiles checks, if desired configuration files are the same as current ones. Content
+// of current configuration files may be references, if state has been stored in compact form.
+func SameConfigFiles(desired, current map[string]string) bool {
+	if len(desired) != len(current) {
+		return false
+	}
+
+	for p, content := range desired {
+		c, ok := current[p]
+		if !ok || !sameConfigFileContent(content, c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Compact returns copy of the containers state, where content of configuration files
+// is replaced with references. Referenced content is returned as a second value, indexed by
+// the reference, so it can be stored separately.
+func (s ContainersState) Compact() (ContainersState, map[string]string) {
+	cs := ContainersState{}
+	contents := map[string]string{}
+
+	for n, hcc := range s {
+		if hcc == nil {
+			cs[n] = nil
+
+			continue
+		}
+
+		h := *hcc
+		h.ConfigFiles = map[string]string{}
+
+		for p, content := range hcc.ConfigFiles {
+			r := content
+
+			if !isConfigFileReference(content) {
+				r = ConfigFileReference(content)
+				contents[r] = content
+			}
+
+			h.ConfigFiles[p] = r
+		}
+
+		cs[n] = &h
+	}
+
+	return cs, contents
+}
+
+// Resolve returns copy of the containers state, where references to configuration files content
+// are replaced with the content from given map, as returned by Compact(). If referenced content
+// is missing, error is returned.
+func (s ContainersState) Resolve(contents map[string]string) (ContainersState, error) {
+	cs := ContainersState{}
+
+	for n, hcc := range s {
+		if hcc == nil {
+			cs[n] = nil
+
+			continue
+		}
+
+		h := *hcc
+		h.ConfigFiles = map[string]string{}
+
+		for p, content := range hcc.ConfigFiles {
+			if isConfigFileReference(content) {
+				c, ok := contents[content]
+				if !ok {
+					return nil, fmt.Errorf("content of configuration file %q of container %q is missing", p, n)
+				}
+
+				content = c
+			}
+
+			h.ConfigFiles[p] = content
+		}
+
+		cs[n] = &h
+	}
+
+	return cs, nil
+}
+
+// CompactExport returns exported previous state of the containers, where content of configuration
+// files is replaced with references, which makes the state smaller and free from secrets. Content
+// of configuration files is returned as a second value, indexed by the reference.
+//
+// Compact state can be used directly as previous state, as the configuration files content is
+// read from the hosts when checking current state and references are taken into account while
+// detecting configuration drift.
+func (c *containers) CompactExport() (*Containers, map[string]string) {
+	s, contents := c.previousState.Export().Compact()
+
+	return &Containers{
+		PreviousState: s,
+	}, contents
+}
@@ -0,0 +1,102 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestContainersStateCompactResolve(t *testing.T) {
+	s := ContainersState{
+		"foo": &HostConfiguredContainer{
+			ConfigFiles: map[string]string{
+				"/etc/foo": "foo",
+				"/etc/bar": "bar",
+			},
+		},
+	}
+
+	cs, contents := s.Compact()
+
+	r := ConfigFileReference("foo")
+
+	if cs["foo"].ConfigFiles["/etc/foo"] != r {
+		t.Fatalf("Content of configuration file should be replaced with reference %q, got %q", r, cs["foo"].ConfigFiles["/etc/foo"])
+	}
+
+	if contents[r] != "foo" {
+		t.Fatalf("Referenced content should be returned, got: %v", contents)
+	}
+
+	if s["foo"].ConfigFiles["/etc/foo"] != "foo" {
+		t.Fatalf("Compacting should not modify original state")
+	}
+
+	rs, err := cs.Resolve(contents)
+	if err != nil {
+		t.Fatalf("Resolving compact state should succeed, got: %v", err)
+	}
+
+	if diff := cmp.Diff(s, rs); diff != "" {
+		t.Fatalf("Resolved state should be the same as original: %s", diff)
+	}
+}
+
+func TestContainersStateResolveMissingContent(t *testing.T) {
+	s := ContainersState{
+		"foo": &HostConfiguredContainer{
+			ConfigFiles: map[string]string{
+				"/etc/foo": "foo",
+			},
+		},
+	}
+
+	cs, _ := s.Compact()
+
+	if _, err := cs.Resolve(map[string]string{}); err == nil {
+		t.Fatalf("Resolving state with missing content should fail")
+	}
+}
+
+func TestChangedConfigFilesReference(t *testing.T) {
+	d := hostConfiguredContainer{
+		configFiles: map[string]string{
+			"/etc/foo": "foo",
+			"/etc/bar": "baz",
+		},
+	}
+
+	c := hostConfiguredContainer{
+		configFiles: map[string]string{
+			"/etc/foo": ConfigFileReference("foo"),
+			"/etc/bar": ConfigFileReference("bar"),
+		},
+	}
+
+	if diff := cmp.Diff([]string{"/etc/bar"}, changedConfigFiles(d, c)); diff != "" {
+		t.Fatalf("Only files with changed content should be returned: %s", diff)
+	}
+}
+
+// SameConfigFiles() tests.
+func TestSameConfigFiles(t *testing.T) {
+	d := map[string]string{
+		"/etc/foo": "foo",
+	}
+
+	if !SameConfigFiles(d, map[string]string{"/etc/foo": ConfigFileReference("foo")}) {
+		t.Fatalf("Reference to the same content should be treated as the same file")
+	}
+
+	if SameConfigFiles(d, map[string]string{"/etc/foo": ConfigFileReference("bar")}) {
+		t.Fatalf("Reference to different content should be treated as different file")
+	}
+
+	if SameConfigFiles(d, map[string]string{"/etc/bar": "foo"}) {
+		t.Fatalf("Files with different paths should be treated as different")
+	}
+
+	if !SameConfigFiles(map[string]string{}, nil) {
+		t.Fatalf("Nil and empty configuration files should be treated as the same")
+	}
+}
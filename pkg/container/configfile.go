@@ -0,0 +1,177 @@
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/flexkube/libflexkube/internal/util"
+)
+
+// ConfigFileType defines the format of configuration file content. If configuration file has
+// type declared, it's content will be validated before writing it to the host, so malformed files
+// are detected before container using them fails to start.
+type ConfigFileType string
+
+const (
+	// ConfigFileTypeYAML declares, that configuration file must be a valid YAML document.
+	ConfigFileTypeYAML ConfigFileType = "yaml"
+
+	// ConfigFileTypeJSON declares, that configuration file must be a valid JSON document.
+	ConfigFileTypeJSON ConfigFileType = "json"
+
+	// ConfigFileTypeKubeconfig declares, that configuration file must be a YAML document
+	// with kind 'Config'.
+	ConfigFileTypeKubeconfig ConfigFileType = "kubeconfig"
+)
+
+// Validate checks, if configuration file type is supported.
+func (t ConfigFileType) Validate() error {
+	switch t {
+	case ConfigFileTypeYAML, ConfigFileTypeJSON, ConfigFileTypeKubeconfig:
+		return nil
+	default:
+		return fmt.Errorf("unsupported configuration file type %q", t)
+	}
+}
+
+// ValidateContent checks, if given content is valid for configuration file type.
+func (t ConfigFileType) ValidateContent(content string) error {
+	var i interface{}
+
+	switch t {
+	case ConfigFileTypeYAML:
+		if err := yaml.Unmarshal([]byte(content), &i); err != nil {
+			return fmt.Errorf("failed parsing YAML: %w", err)
+		}
+	case ConfigFileTypeJSON:
+		if err := json.Unmarshal([]byte(content), &i); err != nil {
+			return fmt.Errorf("failed parsing JSON: %w", err)
+		}
+	case ConfigFileTypeKubeconfig:
+		return validateKubeconfig(content)
+	default:
+		return t.Validate()
+	}
+
+	return nil
+}
+
+// validateKubeconfig checks, if given content is parseable kubeconfig file.
+func validateKubeconfig(content string) error {
+	k := struct {
+		Kind     string        `json:"kind"`
+		Clusters []interface{} `json:"clusters"`
+	}{}
+
+	if err := yaml.Unmarshal([]byte(content), &k); err != nil {
+		return fmt.Errorf("failed parsing kubeconfig: %w", err)
+	}
+
+	if k.Kind != "Config" {
+		return fmt.Errorf("kubeconfig must have kind 'Config', got %q", k.Kind)
+	}
+
+	if len(k.Clusters) == 0 {
+		return fmt.Errorf("kubeconfig must define at least one cluster")
+	}
+
+	return nil
+}
+
+// configFilePermissions stores permissions and ownership of configuration file.
+type configFilePermissions struct {
+	mode  int64
+	user  string
+	group string
+}
+
+// desiredConfigFilePermissions returns permissions, which configuration files of the
+// container should have. If user or group is not set, files are owned by root.
+func (m *hostConfiguredContainer) desiredConfigFilePermissions() configFilePermissions {
+	return configFilePermissions{
+		mode:  configFileMode,
+		user:  util.PickString(m.container.Config().User, "0"),
+		group: util.PickString(m.container.Config().Group, "0"),
+	}
+}
+
+// isNumeric checks, if given string is a numeric ID.
+func isNumeric(s string) bool {
+	_, err := strconv.Atoi(s)
+
+	return err == nil
+}
+
+// diff returns human readable difference between given current and desired permissions.
+// Runtime reports numeric owners, so owners configured by name are not compared.
+// If permissions are the same, empty string is returned.
+func (p configFilePermissions) diff(desired configFilePermissions) string {
+	d := []string{}
+
+	if p.mode&0o7777 != desired.mode {
+		d = append(d, fmt.Sprintf("mode %04o, desired %04o", p.mode&0o7777, desired.mode))
+	}
+
+	if p.user != "" && isNumeric(desired.user) && p.user != desired.user {
+		d = append(d, fmt.Sprintf("user %s, desired %s", p.user, desired.user))
+	}
+
+	if p.group != "" && isNumeric(desired.group) && p.group != desired.group {
+		d = append(d, fmt.Sprintf("group %s, desired %s", p.group, desired.group))
+	}
+
+	return strings.Join(d, ", ")
+}
+
+// permissionsDrift returns human readable permissions differences of configuration files
+// of given current container, compared to given desired container, indexed by file path.
+// Only files with the same content are taken into account, as other files will be written
+// anyway.
+func permissionsDrift(d hostConfiguredContainer, c hostConfiguredContainer) map[string]string {
+	drift := map[string]string{}
+	desired := d.desiredConfigFilePermissions()
+
+	for p, content := range d.configFiles {
+		cp, ok := c.configFilePermissions[p]
+		if !ok || !sameConfigFileContent(content, c.configFiles[p]) || d.keepsExisting(p, c) {
+			continue
+		}
+
+		if diff := cp.diff(desired); diff != "" {
+			drift[p] = diff
+		}
+	}
+
+	return drift
+}
+
+// deletedFileMounts returns desired configuration files, which are mounted into the container
+// as files, but are missing on the host, for example because they were removed outside of the
+// deployment. Container still sees the removed file, so after writing the file again, container
+// must be restarted to mount the new file. Files in mounted directories are not affected.
+func deletedFileMounts(d hostConfiguredContainer, c hostConfiguredContainer) []string {
+	files := []string{}
+
+	for _, m := range d.container.Config().Mounts {
+		if strings.HasSuffix(m.Source, "/") {
+			continue
+		}
+
+		if _, desired := d.configFiles[m.Source]; !desired {
+			continue
+		}
+
+		if _, exists := c.configFiles[m.Source]; !exists {
+			files = append(files, m.Source)
+		}
+	}
+
+	sort.Strings(files)
+
+	return files
+}
@@ -0,0 +1,114 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+// ValidateContent() tests.
+func TestConfigFileTypeValidateContent(t *testing.T) {
+	cases := map[string]struct {
+		Type    ConfigFileType
+		Content string
+		Error   bool
+	}{
+		"valid YAML": {
+			Type:    ConfigFileTypeYAML,
+			Content: "foo: bar\n",
+		},
+		"malformed YAML": {
+			Type:    ConfigFileTypeYAML,
+			Content: "foo: [bar\n",
+			Error:   true,
+		},
+		"valid JSON": {
+			Type:    ConfigFileTypeJSON,
+			Content: `{"foo": "bar"}`,
+		},
+		"malformed JSON": {
+			Type:    ConfigFileTypeJSON,
+			Content: `{"foo": `,
+			Error:   true,
+		},
+		"valid kubeconfig": {
+			Type:    ConfigFileTypeKubeconfig,
+			Content: "apiVersion: v1\nkind: Config\nclusters:\n- name: static\n",
+		},
+		"kubeconfig with wrong kind": {
+			Type:    ConfigFileTypeKubeconfig,
+			Content: "apiVersion: v1\nkind: Pod\nclusters:\n- name: static\n",
+			Error:   true,
+		},
+		"kubeconfig without clusters": {
+			Type:    ConfigFileTypeKubeconfig,
+			Content: "apiVersion: v1\nkind: Config\n",
+			Error:   true,
+		},
+		"unsupported type": {
+			Type:    ConfigFileType("foo"),
+			Content: "foo",
+			Error:   true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			err := c.Type.ValidateContent(c.Content)
+			if !c.Error && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if c.Error && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+// permissionsDrift() tests.
+func TestPermissionsDrift(t *testing.T) {
+	d := hostConfiguredContainer{
+		container: &container{
+			base: base{
+				config: types.ContainerConfig{
+					User: "1000",
+				},
+			},
+		},
+		configFiles: map[string]string{
+			"/foo": "foo",
+			"/bar": "bar",
+			"/baz": "baz",
+		},
+	}
+
+	c := hostConfiguredContainer{
+		configFiles: map[string]string{
+			"/foo": "foo",
+			"/bar": "bar",
+			"/baz": "old",
+		},
+		configFilePermissions: map[string]configFilePermissions{
+			"/foo": {mode: 0o644, user: "1000", group: "0"},
+			"/bar": {mode: 0o600, user: "1000", group: "0"},
+			"/baz": {mode: 0o644, user: "0", group: "0"},
+		},
+	}
+
+	drift := permissionsDrift(d, c)
+
+	if _, ok := drift["/foo"]; !ok || len(drift) != 1 {
+		t.Fatalf("Expected only permissions drift of file with matching content and wrong mode, got: %v", drift)
+	}
+}
+
+func TestConfigFilePermissionsDiffNamedOwner(t *testing.T) {
+	p := configFilePermissions{mode: 0o600, user: "1000", group: "1000"}
+
+	if d := p.diff(configFilePermissions{mode: 0o600, user: "etcd", group: "etcd"}); d != "" {
+		t.Fatalf("Owners configured by name should not be compared, got: %s", d)
+	}
+}
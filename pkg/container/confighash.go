@@ -0,0 +1,33 @@
+package container
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+// configHash returns a hash of given container configuration, which is passed to the container
+// runtime when creating the container. Labels and resource limits are not included, as they can be
+// updated without re-creating the container.
+func configHash(config types.ContainerConfig) string {
+	config.Labels = nil
+	config.SetResourceLimits(types.ResourceLimits{})
+
+	// Marshaling configuration never fails, as it consists only of serializable fields.
+	b, _ := json.Marshal(config)
+
+	h := sha256.Sum256(b)
+
+	return hex.EncodeToString(h[:])
+}
+
+// configHashMismatch checks, if container runtime reports configuration hash of given container
+// different than the hash of given configuration. If container runtime does not report the hash,
+// e.g. for containers created without it, false is returned.
+func configHashMismatch(m *hostConfiguredContainer, config types.ContainerConfig) bool {
+	h := m.container.Status().ConfigHash
+
+	return h != "" && h != configHash(config)
+}
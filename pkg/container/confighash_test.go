@@ -0,0 +1,96 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+// configHash() tests.
+func TestConfigHash(t *testing.T) {
+	config := types.ContainerConfig{
+		Name:  foo,
+		Image: foo,
+	}
+
+	if configHash(config) != configHash(config) {
+		t.Fatalf("Hash of the same configuration should be stable")
+	}
+
+	withLabels := config
+	withLabels.Labels = map[string]string{foo: bar}
+
+	if configHash(config) != configHash(withLabels) {
+		t.Fatalf("Labels should not be included in the hash")
+	}
+
+	withLimits := config
+	withLimits.Memory = 1024
+	withLimits.CpusetCpus = "0"
+
+	if configHash(config) != configHash(withLimits) {
+		t.Fatalf("Resource limits should not be included in the hash")
+	}
+
+	withImage := config
+	withImage.Image = bar
+
+	if configHash(config) == configHash(withImage) {
+		t.Fatalf("Changing image should change the hash")
+	}
+}
+
+func TestConfigHashIgnoresConfigHash(t *testing.T) {
+	config := types.ContainerConfig{
+		Name: foo,
+	}
+
+	withHash := config
+	withHash.ConfigHash = bar
+
+	if configHash(config) != configHash(withHash) {
+		t.Fatalf("Configuration hash field should not be included in the hash")
+	}
+}
+
+// configHashMismatch() tests.
+func TestConfigHashMismatch(t *testing.T) {
+	config := types.ContainerConfig{
+		Name: foo,
+	}
+
+	cases := map[string]struct {
+		hash     string
+		mismatch bool
+	}{
+		"not reported": {},
+		"matching": {
+			hash: configHash(config),
+		},
+		"different": {
+			hash:     bar,
+			mismatch: true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			m := &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						status: types.ContainerStatus{
+							ID:         foo,
+							ConfigHash: c.hash,
+						},
+					},
+				},
+			}
+
+			if mismatch := configHashMismatch(m, config); mismatch != c.mismatch {
+				t.Fatalf("Expected mismatch to be %t, got %t", c.mismatch, mismatch)
+			}
+		})
+	}
+}
@@ -3,12 +3,41 @@ package container
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/flexkube/libflexkube/pkg/container/runtime"
 	"github.com/flexkube/libflexkube/pkg/container/runtime/docker"
 	"github.com/flexkube/libflexkube/pkg/container/types"
 )
 
+const (
+	// minOOMScoreAdj is the lowest OOM score adjustment accepted by the kernel.
+	minOOMScoreAdj = -1000
+
+	// maxOOMScoreAdj is the highest OOM score adjustment accepted by the kernel.
+	maxOOMScoreAdj = 1000
+
+	// maxAnnotationNameLength is a maximum length of annotation key name part.
+	maxAnnotationNameLength = 63
+
+	// maxAnnotationPrefixLength is a maximum length of annotation key prefix part.
+	maxAnnotationPrefixLength = 253
+)
+
+var (
+	// annotationNameRegexp matches valid name part of annotation key.
+	annotationNameRegexp = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+
+	// annotationPrefixRegexp matches valid prefix part of annotation key, which is a DNS subdomain.
+	annotationPrefixRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+	// platformRegexp matches valid image platform in 'os/arch[/variant]' format.
+	platformRegexp = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9_]+(/[a-z0-9]+)?$`)
+)
+
 // Interface represents container capabilities, which may or may not exist.
 type Interface interface {
 	// Create creates the container.
@@ -20,15 +49,21 @@ type Interface interface {
 	// UpdateStatus updates container status.
 	UpdateStatus() error
 
+	// Exists checks, if the container exists in the runtime and returns the reason.
+	Exists() (types.Existence, error)
+
 	// Start starts the container.
 	Start() error
 
 	// Stop stops the container.
 	Stop() error
 
-	// Delete removes the container.
+	// Delete removes the container. Named volumes used by the container are preserved.
 	Delete() error
 
+	// RemoveVolumes removes named volumes used by the container.
+	RemoveVolumes() error
+
 	// Status returns container status.
 	Status() *types.ContainerStatus
 
@@ -54,6 +89,9 @@ type InstanceInterface interface {
 	// Status returns container status read from the configured container runtime.
 	Status() (types.ContainerStatus, error)
 
+	// Exists checks, if the container exists in the configured container runtime.
+	Exists() (types.Existence, error)
+
 	// Read reads content of the given file paths in the container.
 	Read(srcPath []string) ([]*types.File, error)
 
@@ -91,6 +129,34 @@ type Container struct {
 type RuntimeConfig struct {
 	// Docker stores Docker runtime configuration.
 	Docker *docker.Config `json:"docker,omitempty"`
+
+	// Custom allows to use custom container runtime implementation, for example a fake
+	// runtime in tests. If set, it takes precedence over other runtimes.
+	//
+	// Due to it's nature, it can only be set programmatically.
+	Custom runtime.Config `json:"-"`
+}
+
+// config returns configured container runtime configuration.
+func (r RuntimeConfig) config() runtime.Config {
+	if r.Custom != nil {
+		return r.Custom
+	}
+
+	return r.Docker
+}
+
+// exportRuntimeConfig converts given runtime configuration into RuntimeConfig.
+func exportRuntimeConfig(c runtime.Config) RuntimeConfig {
+	if d, ok := c.(*docker.Config); ok {
+		return RuntimeConfig{
+			Docker: d,
+		}
+	}
+
+	return RuntimeConfig{
+		Custom: c,
+	}
 }
 
 // container represents validated version of Container object, which contains all requires
@@ -127,7 +193,7 @@ func (c *Container) New() (Interface, error) {
 	nc := &container{
 		base{
 			config:        c.Config,
-			runtimeConfig: c.Runtime.Docker,
+			runtimeConfig: c.Runtime.config(),
 		},
 	}
 
@@ -135,6 +201,10 @@ func (c *Container) New() (Interface, error) {
 		nc.base.status = *c.Status
 	}
 
+	// Store image in canonical form, so different spellings of the same image
+	// do not cause the container to be re-created.
+	nc.base.config.Image, _ = types.NormalizeImage(c.Config.Image)
+
 	if err := nc.selectRuntime(); err != nil {
 		return nil, fmt.Errorf("unable to determine container runtime: %w", err)
 	}
@@ -152,14 +222,237 @@ func (c *Container) Validate() error {
 		return fmt.Errorf("image must be set")
 	}
 
-	if c.Runtime.Docker == nil {
+	if _, err := types.NormalizeImage(c.Config.Image); err != nil {
+		return fmt.Errorf("invalid image: %w", err)
+	}
+
+	if c.Runtime.Docker == nil && c.Runtime.Custom == nil {
 		return fmt.Errorf("docker runtime must be set")
 	}
 
+	if err := validateVolumes(c.Config.Volumes); err != nil {
+		return fmt.Errorf("invalid volumes: %w", err)
+	}
+
+	if err := validateCpuset(c.Config.CpusetCpus); err != nil {
+		return fmt.Errorf("invalid cpusetCpus: %w", err)
+	}
+
+	if err := validateCpuset(c.Config.CpusetMems); err != nil {
+		return fmt.Errorf("invalid cpusetMems: %w", err)
+	}
+
+	if c.Config.OOMScoreAdj < minOOMScoreAdj || c.Config.OOMScoreAdj > maxOOMScoreAdj {
+		return fmt.Errorf("oomScoreAdj must be between %d and %d, got %d", minOOMScoreAdj, maxOOMScoreAdj, c.Config.OOMScoreAdj)
+	}
+
+	if err := validateCgroupParent(c.Config.CgroupParent); err != nil {
+		return fmt.Errorf("invalid cgroupParent: %w", err)
+	}
+
+	if c.Config.Platform != "" && !platformRegexp.MatchString(c.Config.Platform) {
+		return fmt.Errorf("platform must be in 'os/arch[/variant]' format, got %q", c.Config.Platform)
+	}
+
+	if c.Config.ShmSize < 0 {
+		return fmt.Errorf("shmSize can't be negative, got %d", c.Config.ShmSize)
+	}
+
+	if c.Config.Memory < 0 {
+		return fmt.Errorf("memory can't be negative, got %d", c.Config.Memory)
+	}
+
+	if c.Config.NanoCPUs < 0 {
+		return fmt.Errorf("nanoCPUs can't be negative, got %d", c.Config.NanoCPUs)
+	}
+
+	if c.Config.StopTimeout < 0 {
+		return fmt.Errorf("stopTimeout can't be negative, got %d", c.Config.StopTimeout)
+	}
+
+	if err := validateHealthCheck(c.Config.HealthCheck); err != nil {
+		return fmt.Errorf("invalid healthCheck: %w", err)
+	}
+
+	if err := validateRestartLimits(c.Config); err != nil {
+		return err
+	}
+
+	if err := validateAnnotations(c.Config.Annotations); err != nil {
+		return fmt.Errorf("invalid annotations: %w", err)
+	}
+
 	// TODO check runtime configurations here
 	return nil
 }
 
+// validateVolumes validates, that all volumes have name and absolute target path set.
+func validateVolumes(volumes []types.VolumeMount) error {
+	for i, v := range volumes {
+		if v.Name == "" {
+			return fmt.Errorf("volume %d must have name set", i)
+		}
+
+		if !strings.HasPrefix(v.Target, "/") {
+			return fmt.Errorf("volume %q must have absolute target path, got %q", v.Name, v.Target)
+		}
+	}
+
+	return nil
+}
+
+// validateCpuset validates, that given string is a valid cpuset list, which is a comma
+// separated list of numbers or ranges of numbers, like '0-2,4'. Empty string is valid.
+func validateCpuset(cpuset string) error {
+	if cpuset == "" {
+		return nil
+	}
+
+	for _, e := range strings.Split(cpuset, ",") {
+		r := strings.SplitN(e, "-", 2)
+
+		start, err := strconv.ParseUint(r[0], 10, 32)
+		if err != nil {
+			return fmt.Errorf("malformed element %q: %w", e, err)
+		}
+
+		if len(r) == 1 {
+			continue
+		}
+
+		end, err := strconv.ParseUint(r[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("malformed range end in element %q: %w", e, err)
+		}
+
+		if start > end {
+			return fmt.Errorf("range %q has start greater than end", e)
+		}
+	}
+
+	return nil
+}
+
+// validateAnnotations validates, that all annotation keys are in '[prefix/]name' format.
+func validateAnnotations(annotations map[string]string) error {
+	for k := range annotations {
+		if err := validateAnnotationKey(k); err != nil {
+			return fmt.Errorf("key %q: %w", k, err)
+		}
+	}
+
+	return nil
+}
+
+// validateAnnotationKey validates single annotation key, which consists of optional DNS subdomain
+// prefix and a name, separated by '/'.
+func validateAnnotationKey(k string) error {
+	name := k
+
+	if i := strings.LastIndex(k, "/"); i != -1 {
+		prefix := k[:i]
+		name = k[i+1:]
+
+		if len(prefix) > maxAnnotationPrefixLength || !annotationPrefixRegexp.MatchString(prefix) {
+			return fmt.Errorf("prefix must be a valid DNS subdomain of at most %d characters", maxAnnotationPrefixLength)
+		}
+	}
+
+	if len(name) > maxAnnotationNameLength || !annotationNameRegexp.MatchString(name) {
+		return fmt.Errorf("name must be at most %d alphanumeric characters, '-', '_' or '.', "+
+			"starting and ending with alphanumeric character", maxAnnotationNameLength)
+	}
+
+	return nil
+}
+
+// validateCgroupParent validates, that given cgroup parent is either systemd slice name
+// or an absolute cgroup path.
+func validateCgroupParent(p string) error {
+	if p == "" {
+		return nil
+	}
+
+	if strings.ContainsAny(p, " \t\n") {
+		return fmt.Errorf("must not contain whitespace characters, got %q", p)
+	}
+
+	if strings.HasSuffix(p, ".slice") {
+		if strings.Contains(p, "/") {
+			return fmt.Errorf("slice name must not contain '/', got %q", p)
+		}
+
+		return nil
+	}
+
+	if !strings.HasPrefix(p, "/") {
+		return fmt.Errorf("must be either slice name or absolute path, got %q", p)
+	}
+
+	for _, e := range strings.Split(p, "/") {
+		if e == ".." || e == "." {
+			return fmt.Errorf("must not contain relative path elements, got %q", p)
+		}
+	}
+
+	return nil
+}
+
+// validateRestartLimits validates restart limits and backoff of the container.
+//
+// Setting restart limit is accepted regardless of the restart policy used by the runtime,
+// even though it switches Docker containers from 'unless-stopped' to 'on-failure' policy,
+// as documented on types.ContainerConfig.RestartMaxRetries.
+func validateRestartLimits(c types.ContainerConfig) error {
+	if c.RestartMaxRetries < 0 {
+		return fmt.Errorf("restartMaxRetries can't be negative, got %d", c.RestartMaxRetries)
+	}
+
+	if _, err := parseTimeout(c.RestartBackoff); err != nil {
+		return fmt.Errorf("invalid restartBackoff %q: %w", c.RestartBackoff, err)
+	}
+
+	return nil
+}
+
+// validateHealthCheck validates given optional runtime health check configuration.
+func validateHealthCheck(hc *types.HealthCheck) error {
+	if hc == nil {
+		return nil
+	}
+
+	if len(hc.Command) == 0 {
+		return fmt.Errorf("command must be set")
+	}
+
+	durations := map[string]string{
+		"interval":    hc.Interval,
+		"timeout":     hc.Timeout,
+		"startPeriod": hc.StartPeriod,
+	}
+
+	for n, v := range durations {
+		if v == "" {
+			continue
+		}
+
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("failed parsing %s %q: %w", n, v, err)
+		}
+
+		if d < 0 {
+			return fmt.Errorf("%s can't be negative, got %s", n, d)
+		}
+	}
+
+	if hc.Retries < 0 {
+		return fmt.Errorf("retries can't be negative, got %d", hc.Retries)
+	}
+
+	return nil
+}
+
 // selectRuntime returns container runtime configured for container.
 //
 // It returns error if container runtime configuration is invalid.
@@ -177,6 +470,12 @@ func (c *container) selectRuntime() error {
 
 // Create creates container container from it's definition.
 func (c *container) Create() (InstanceInterface, error) {
+	for _, v := range c.config.Volumes {
+		if err := c.runtime.CreateVolume(v.Name); err != nil {
+			return nil, fmt.Errorf("creating volume failed: %w", err)
+		}
+	}
+
 	id, err := c.runtime.Create(&c.config)
 	if err != nil {
 		return nil, fmt.Errorf("creating container failed: %w", err)
@@ -230,6 +529,21 @@ func (c *container) UpdateStatus() error {
 	return nil
 }
 
+// Exists checks, if the container exists. Containers without ID are reported as never
+// created without querying the runtime.
+func (c *container) Exists() (types.Existence, error) {
+	if !c.status.Exists() {
+		return types.Existence{Reason: types.ExistenceNotCreated}, nil
+	}
+
+	ci, err := c.FromStatus()
+	if err != nil {
+		return types.Existence{Reason: types.ExistenceUnknown}, fmt.Errorf("failed creating container instance: %w", err)
+	}
+
+	return ci.Exists()
+}
+
 func (c *container) Status() *types.ContainerStatus {
 	return &c.status
 }
@@ -290,11 +604,27 @@ func (c *container) Delete() error {
 	return nil
 }
 
+// RemoveVolumes removes named volumes used by the container.
+func (c *container) RemoveVolumes() error {
+	for _, v := range c.config.Volumes {
+		if err := c.runtime.RemoveVolume(v.Name); err != nil {
+			return fmt.Errorf("removing volume failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // ReadState reads state of the container from container runtime and returns it to the user.
 func (c *containerInstance) Status() (types.ContainerStatus, error) {
 	return c.runtime.Status(c.status.ID)
 }
 
+// Exists checks, if the container exists in the runtime.
+func (c *containerInstance) Exists() (types.Existence, error) {
+	return c.runtime.Exists(c.status.ID)
+}
+
 // Read reads given path from the container and returns reader with TAR format with file content.
 func (c *containerInstance) Read(srcPath []string) ([]*types.File, error) {
 	return c.runtime.Read(c.status.ID, srcPath)
@@ -2,6 +2,7 @@ package container
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -33,6 +34,27 @@ func TestNewGoodConfiguration(t *testing.T) {
 	}
 }
 
+func TestNewNormalizeImage(t *testing.T) {
+	c := &Container{
+		Runtime: RuntimeConfig{
+			Docker: &docker.Config{},
+		},
+		Config: types.ContainerConfig{
+			Name:  "foo",
+			Image: "nonexistent",
+		},
+	}
+
+	nc, err := c.New()
+	if err != nil {
+		t.Fatalf("Creating container with good configuration should pass, got: %v", err)
+	}
+
+	if i := nc.Config().Image; i != "docker.io/library/nonexistent:latest" {
+		t.Fatalf("Image should be stored in canonical form, got %q", i)
+	}
+}
+
 // Validate() tests.
 func TestValidateNoName(t *testing.T) {
 	c := &Container{
@@ -81,6 +103,454 @@ func TestValidateRequireImage(t *testing.T) {
 	}
 }
 
+func TestValidateCpuset(t *testing.T) {
+	cases := map[string]bool{
+		"":        false,
+		"0":       false,
+		"0-2,4":   false,
+		"1,3,5-7": false,
+		"a":       true,
+		"0-":      true,
+		"-1":      true,
+		"3-1":     true,
+		"0,,1":    true,
+		"0-1-2":   true,
+	}
+
+	for cpuset, expectError := range cases {
+		cpuset, expectError := cpuset, expectError
+
+		t.Run(cpuset, func(t *testing.T) {
+			c := &Container{
+				Runtime: RuntimeConfig{
+					Docker: &docker.Config{},
+				},
+				Config: types.ContainerConfig{
+					Name:       "foo",
+					Image:      "nonexistent",
+					CpusetCpus: cpuset,
+					CpusetMems: cpuset,
+				},
+			}
+
+			err := c.Validate()
+			if !expectError && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if expectError && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestValidateShmSize(t *testing.T) {
+	cases := map[int64]bool{
+		0:         false,
+		268435456: false,
+		-1:        true,
+	}
+
+	for size, expectError := range cases {
+		size, expectError := size, expectError
+
+		t.Run(fmt.Sprintf("%d", size), func(t *testing.T) {
+			c := &Container{
+				Runtime: RuntimeConfig{
+					Docker: &docker.Config{},
+				},
+				Config: types.ContainerConfig{
+					Name:    "foo",
+					Image:   "nonexistent",
+					ShmSize: size,
+				},
+			}
+
+			err := c.Validate()
+			if !expectError && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if expectError && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestValidateResourceLimits(t *testing.T) {
+	cases := map[string]struct {
+		memory   int64
+		nanoCPUs int64
+		err      bool
+	}{
+		"no limits":         {},
+		"valid limits":      {memory: 536870912, nanoCPUs: 1500000000},
+		"negative memory":   {memory: -1, err: true},
+		"negative nanoCPUs": {nanoCPUs: -1, err: true},
+	}
+
+	for n, tc := range cases {
+		tc := tc
+
+		t.Run(n, func(t *testing.T) {
+			c := &Container{
+				Runtime: RuntimeConfig{
+					Docker: &docker.Config{},
+				},
+				Config: types.ContainerConfig{
+					Name:     "foo",
+					Image:    "nonexistent",
+					Memory:   tc.memory,
+					NanoCPUs: tc.nanoCPUs,
+				},
+			}
+
+			err := c.Validate()
+			if !tc.err && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if tc.err && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestValidatePlatform(t *testing.T) {
+	cases := map[string]bool{
+		"":              false,
+		"linux/amd64":   false,
+		"linux/arm/v7":  false,
+		"linux":         true,
+		"linux/":        true,
+		"/amd64":        true,
+		"Linux/amd64":   true,
+		"linux/arm/v7/": true,
+	}
+
+	for p, expectError := range cases {
+		p, expectError := p, expectError
+
+		t.Run(p, func(t *testing.T) {
+			c := &Container{
+				Runtime: RuntimeConfig{
+					Docker: &docker.Config{},
+				},
+				Config: types.ContainerConfig{
+					Name:     "foo",
+					Image:    "nonexistent",
+					Platform: p,
+				},
+			}
+
+			err := c.Validate()
+			if !expectError && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if expectError && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestValidateImage(t *testing.T) {
+	cases := map[string]bool{
+		"nginx":                       false,
+		"quay.io/coreos/etcd:v3.4.10": false,
+		"localhost:5000/foo@sha256:0123456789abcdef0123456789abcdef": false,
+		"Nginx":            true,
+		"nginx:":           true,
+		"nginx@sha256:foo": true,
+		"foo//bar":         true,
+	}
+
+	for i, expectError := range cases {
+		i, expectError := i, expectError
+
+		t.Run(i, func(t *testing.T) {
+			c := &Container{
+				Runtime: RuntimeConfig{
+					Docker: &docker.Config{},
+				},
+				Config: types.ContainerConfig{
+					Name:  "foo",
+					Image: i,
+				},
+			}
+
+			err := c.Validate()
+			if !expectError && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if expectError && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestValidateCgroupParent(t *testing.T) {
+	cases := map[string]bool{
+		"":                  false,
+		"system.slice":      false,
+		"/flexkube":         false,
+		"/system.slice/foo": false,
+		"flexkube":          true,
+		"foo/bar.slice":     true,
+		"/foo/../bar":       true,
+		"/foo bar":          true,
+	}
+
+	for p, expectError := range cases {
+		p, expectError := p, expectError
+
+		t.Run(p, func(t *testing.T) {
+			c := &Container{
+				Runtime: RuntimeConfig{
+					Docker: &docker.Config{},
+				},
+				Config: types.ContainerConfig{
+					Name:         "foo",
+					Image:        "nonexistent",
+					CgroupParent: p,
+				},
+			}
+
+			err := c.Validate()
+			if !expectError && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if expectError && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestValidateRestartLimits(t *testing.T) {
+	cases := map[string]struct {
+		maxRetries int
+		backoff    string
+		err        bool
+	}{
+		"not set":             {},
+		"valid":               {maxRetries: 3, backoff: "10s"},
+		"negative maxRetries": {maxRetries: -1, err: true},
+		"malformed backoff":   {backoff: "doh", err: true},
+		"negative backoff":    {backoff: "-1s", err: true},
+	}
+
+	for n, tc := range cases {
+		tc := tc
+
+		t.Run(n, func(t *testing.T) {
+			c := &Container{
+				Runtime: RuntimeConfig{
+					Docker: &docker.Config{},
+				},
+				Config: types.ContainerConfig{
+					Name:              "foo",
+					Image:             "nonexistent",
+					RestartMaxRetries: tc.maxRetries,
+					RestartBackoff:    tc.backoff,
+				},
+			}
+
+			err := c.Validate()
+			if !tc.err && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if tc.err && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestValidateAnnotations(t *testing.T) {
+	cases := map[string]bool{
+		"foo":                   false,
+		"foo.bar_baz-1":         false,
+		"example.com/foo":       false,
+		"-foo":                  true,
+		"foo/":                  true,
+		"/foo":                  true,
+		"Example.com/foo":       true,
+		"example..com/foo":      true,
+		"foo bar":               true,
+		strings.Repeat("a", 64): true,
+	}
+
+	for k, expectError := range cases {
+		k, expectError := k, expectError
+
+		t.Run(k, func(t *testing.T) {
+			c := &Container{
+				Runtime: RuntimeConfig{
+					Docker: &docker.Config{},
+				},
+				Config: types.ContainerConfig{
+					Name:  "foo",
+					Image: "nonexistent",
+					Annotations: map[string]string{
+						k: "bar",
+					},
+				},
+			}
+
+			err := c.Validate()
+			if !expectError && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if expectError && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestValidateHealthCheck(t *testing.T) {
+	cases := map[string]struct {
+		healthCheck *types.HealthCheck
+		err         bool
+	}{
+		"not set": {},
+		"valid": {
+			healthCheck: &types.HealthCheck{
+				Command:     []string{"/bin/health"},
+				Interval:    "10s",
+				Timeout:     "5s",
+				StartPeriod: "1m",
+				Retries:     3,
+			},
+		},
+		"no command": {
+			healthCheck: &types.HealthCheck{},
+			err:         true,
+		},
+		"bad interval": {
+			healthCheck: &types.HealthCheck{
+				Command:  []string{"/bin/health"},
+				Interval: "doh",
+			},
+			err: true,
+		},
+		"negative timeout": {
+			healthCheck: &types.HealthCheck{
+				Command: []string{"/bin/health"},
+				Timeout: "-5s",
+			},
+			err: true,
+		},
+		"negative retries": {
+			healthCheck: &types.HealthCheck{
+				Command: []string{"/bin/health"},
+				Retries: -1,
+			},
+			err: true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			err := validateHealthCheck(c.healthCheck)
+
+			if c.err && err == nil {
+				t.Fatalf("Expected error")
+			}
+
+			if !c.err && err != nil {
+				t.Fatalf("Didn't expect error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateVolumes(t *testing.T) {
+	cases := map[string]struct {
+		volume      types.VolumeMount
+		expectError bool
+	}{
+		"valid":         {types.VolumeMount{Name: "foo", Target: "/data"}, false},
+		"no name":       {types.VolumeMount{Target: "/data"}, true},
+		"no target":     {types.VolumeMount{Name: "foo"}, true},
+		"relative path": {types.VolumeMount{Name: "foo", Target: "data"}, true},
+	}
+
+	for n, tc := range cases {
+		tc := tc
+
+		t.Run(n, func(t *testing.T) {
+			c := &Container{
+				Runtime: RuntimeConfig{
+					Docker: &docker.Config{},
+				},
+				Config: types.ContainerConfig{
+					Name:    "foo",
+					Image:   "nonexistent",
+					Volumes: []types.VolumeMount{tc.volume},
+				},
+			}
+
+			err := c.Validate()
+			if !tc.expectError && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestValidateOOMScoreAdj(t *testing.T) {
+	cases := map[int]bool{
+		-1001: true,
+		-1000: false,
+		0:     false,
+		1000:  false,
+		1001:  true,
+	}
+
+	for oomScoreAdj, expectError := range cases {
+		oomScoreAdj, expectError := oomScoreAdj, expectError
+
+		t.Run(fmt.Sprintf("%d", oomScoreAdj), func(t *testing.T) {
+			c := &Container{
+				Runtime: RuntimeConfig{
+					Docker: &docker.Config{},
+				},
+				Config: types.ContainerConfig{
+					Name:        "foo",
+					Image:       "nonexistent",
+					OOMScoreAdj: oomScoreAdj,
+				},
+			}
+
+			err := c.Validate()
+			if !expectError && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if expectError && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
 // selectRuntime() tests.
 func TestSelectDockerRuntime(t *testing.T) {
 	c := &container{
@@ -3,14 +3,22 @@
 package container
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"sigs.k8s.io/yaml"
 
 	"github.com/flexkube/libflexkube/internal/util"
 	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
 )
 
 // ContainersInterface represents capabilities of containers struct.
@@ -23,11 +31,38 @@ type ContainersInterface interface {
 	// correct actions.
 	CheckCurrentState() error
 
+	// CheckCurrentStateOf checks and updates the state of a single container from the state,
+	// leaving state of all other containers untouched. It returns error if given container
+	// does not exist in the state.
+	CheckCurrentStateOf(name string) error
+
 	// Deploy creates configured containers.
 	//
 	// CheckCurrentState() must be called before calling Deploy(), otherwise error will be returned.
 	Deploy() error
 
+	// Plan returns changes, which Deploy() would make to the containers, without modifying the
+	// containers.
+	//
+	// CheckCurrentState() must be called before calling Plan(), otherwise error will be returned.
+	Plan() (*Plan, error)
+
+	// DeployWithPlan creates configured containers, if changes Deploy() would make are the same
+	// as changes in given plan. If plan is nil, it behaves the same as Deploy().
+	//
+	// CheckCurrentState() must be called before calling DeployWithPlan().
+	DeployWithPlan(p *Plan) error
+
+	// SavePlan writes changes, which Deploy() would make to the containers, to given file,
+	// without modifying the containers.
+	SavePlan(path string) error
+
+	// ApplyPlan deploys the containers, if changes Deploy() would make are the same as changes
+	// in the plan saved to given file. Otherwise, error is returned, unless force is true.
+	//
+	// CheckCurrentState() must be called before calling ApplyPlan().
+	ApplyPlan(path string, force bool) error
+
 	// StateToYaml converts resource's containers state into YAML format and returns it to the user,
 	// so it can be persisted, e.g. to the file.
 	StateToYaml() ([]byte, error)
@@ -36,6 +71,10 @@ type ContainersInterface interface {
 	// serialized and persisted.
 	ToExported() *Containers
 
+	// CompactExport converts previous state of the containers into exported one, with content
+	// of configuration files replaced with references. Referenced content is returned separately.
+	CompactExport() (*Containers, map[string]string)
+
 	// DesiredState returns desired state of configured containers.
 	//
 	// Desired state differs from
@@ -48,6 +87,27 @@ type ContainersInterface interface {
 	// Having those fields modified allows to minimize the difference when comparing previous state
 	// and desired state.
 	DesiredState() ContainersState
+
+	// HighRestartCounts returns restart counts of containers from the current state, which
+	// has been restarted by the runtime at least given number of times.
+	HighRestartCounts(threshold int) map[string]int
+
+	// UnhealthyContainers returns health status of containers from the current state, which
+	// container runtime reports as not healthy.
+	UnhealthyContainers() map[string]string
+
+	// FollowLogs streams logs of containers with given names from the current state to given
+	// writer, with each line prefixed with the container name, until given context is cancelled
+	// or all containers stop.
+	FollowLogs(ctx context.Context, names []string, lines int, w io.Writer) error
+
+	// RotateTransportCredentials updates transport configuration of containers in the current state
+	// from the desired state, when only credentials has changed, without re-creating the containers.
+	RotateTransportCredentials() error
+
+	// Adopt imports existing containers with given names, created outside of the deployment,
+	// into the current state, so they are managed without being re-created.
+	Adopt(names []string) error
 }
 
 // Containers allow to orchestrate and update multiple containers spread
@@ -59,6 +119,161 @@ type Containers struct {
 
 	// DesiredState is a user-defined desired containers configuration.
 	DesiredState ContainersState `json:"desiredState,omitempty"`
+
+	// Events is an optional channel, where Deploy() will send progress events while processing
+	// the containers. Sending never blocks the deployment, so the channel should be buffered and
+	// drained by the caller, otherwise events will be dropped. Number of dropped events is logged
+	// as a warning at the end of the deployment.
+	//
+	// Due to it's nature, it can only be set programmatically.
+	Events chan<- ProgressEvent `json:"-"`
+
+	// MaxConcurrency defines, how many container operations can be executed at the same time
+	// across all hosts. If not set, operations are executed one by one. Failure of one operation does
+	// not stop remaining operations in the same deployment phase and all errors are returned together.
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+
+	// MaxPerHostConcurrency defines, how many container operations can be executed at the same time
+	// on a single host. If both MaxConcurrency and MaxPerHostConcurrency are set, the more restrictive
+	// limit applies. If not set, operations on each host are executed one by one.
+	MaxPerHostConcurrency int `json:"maxPerHostConcurrency,omitempty"`
+
+	// Drain is an optional function, which will be called before removing the container,
+	// which is no longer desired, e.g. to deregister it from the load balancer and let
+	// existing connections finish.
+	//
+	// Due to it's nature, it can only be set programmatically.
+	Drain func(name string) error `json:"-"`
+
+	// RemoveVolumes controls, if named volumes of containers, which are no longer desired, should
+	// be removed together with the containers. Volumes are never removed when container is recreated.
+	RemoveVolumes bool `json:"removeVolumes,omitempty"`
+
+	// AllowDataLoss allows moving containers with named volumes to a different host. As data
+	// is not moved together with the container, volumes on the old host will be orphaned.
+	AllowDataLoss bool `json:"allowDataLoss,omitempty"`
+
+	// AllowRemoveAll allows Deploy to remove all containers from the previous state, when desired
+	// state is empty. Without it, Deploy fails in such case, to prevent accidental removal of all
+	// containers, e.g. caused by loading invalid or incomplete configuration.
+	//
+	// It also allows both previous and desired state to be empty, e.g. when all containers has
+	// already been removed, in which case Deploy does nothing.
+	AllowRemoveAll bool `json:"allowRemoveAll,omitempty"`
+
+	// OperationTimeout limits, how long a single operation on the container, like creating,
+	// starting, stopping, removing or configuring can take. If operation does not finish in
+	// time, error is returned. If empty, operations are not time limited.
+	//
+	// Example value: '5m'.
+	OperationTimeout string `json:"operationTimeout,omitempty"`
+
+	// PhaseTimeout limits, how long each deployment phase, like checking existing containers,
+	// creating new containers or updating existing containers can take. If phase does not finish
+	// in time, error identifying the phase and containers, which are still being processed, is
+	// returned. If empty, phases are not time limited.
+	//
+	// Example value: '30m'.
+	PhaseTimeout string `json:"phaseTimeout,omitempty"`
+
+	// ImageVerifier is an optional verifier, which will be used to verify container images,
+	// for example their signatures, before creating containers. If verification fails,
+	// container is not created and deployment fails.
+	//
+	// Due to it's nature, it can only be set programmatically.
+	ImageVerifier ImageVerifier `json:"-"`
+
+	// MaxRestartCount defines, how many times container can be restarted by the runtime,
+	// before it is considered unhealthy. Unhealthy containers are recreated during deployment.
+	// If not set, restart count is ignored.
+	MaxRestartCount int `json:"maxRestartCount,omitempty"`
+
+	// Session is an optional session, which allows sharing host connections between
+	// multiple Containers instances. If not set, each operation connects to the host
+	// separately.
+	//
+	// Due to it's nature, it can only be set programmatically.
+	Session *Session `json:"-"`
+
+	// Mutator is an optional function, which will be called for each desired container before
+	// deployment. It allows to customize generated container configuration in ways, which are
+	// not covered by typed fields, for example to set niche runtime options. Mutations are
+	// visible in the desired state, so they are included in the diff. If mutator returns an
+	// error, deployment is aborted.
+	//
+	// Mutator may be called multiple times for the same container, so it must be idempotent.
+	//
+	// Due to it's nature, it can only be set programmatically.
+	Mutator func(name string, hcc *HostConfiguredContainer) error `json:"-"`
+
+	// HostFilter optionally restricts deployment to containers placed on matching hosts.
+	// Containers on other hosts are left untouched and their state is not changed. If not
+	// set, containers on all hosts are deployed.
+	HostFilter *HostFilter `json:"hostFilter,omitempty"`
+
+	// WaitForHealthyTimeout limits, how long container creation waits for containers listed
+	// in it's WaitForHealthy field to become healthy. If empty, 5 minutes timeout is used.
+	//
+	// Example value: '10m'.
+	WaitForHealthyTimeout string `json:"waitForHealthyTimeout,omitempty"`
+
+	// StartSettlePeriod is a time, for which container creation waits after starting new
+	// container, to verify that container is still running. If container exits during this
+	// period, for example because of invalid configuration, creation fails with an error
+	// containing exit code and last lines of container logs. If empty, no verification is
+	// performed.
+	//
+	// Example value: '5s'.
+	StartSettlePeriod string `json:"startSettlePeriod,omitempty"`
+
+	// AdoptEquivalentContainers enables checking live configuration of existing containers, which
+	// recorded configuration differs from the desired one. If container runtime reports, that
+	// container runs with configuration equivalent to the desired one, ignoring fields normalized
+	// or defaulted by the runtime, container is considered up to date and it won't be recreated.
+	// This allows adopting containers created by other tools without recreating them.
+	AdoptEquivalentContainers bool `json:"adoptEquivalentContainers,omitempty"`
+
+	// FailOnUnexpectedDrift makes Deploy fail, if containers has been changed outside of
+	// the deployment since the previous state was recorded, for example if container has
+	// been stopped or it's configuration files has been modified. Such changes may be manual
+	// intervention, like an emergency fix, which should not be silently reverted.
+	FailOnUnexpectedDrift bool `json:"failOnUnexpectedDrift,omitempty"`
+
+	// AcknowledgeDrift allows Deploy to proceed, even if changes made outside of the deployment
+	// has been detected and FailOnUnexpectedDrift is enabled. Detected changes will be reverted.
+	AcknowledgeDrift bool `json:"acknowledgeDrift,omitempty"`
+
+	// CheckImages enables checking, that images of all desired containers are present on the
+	// hosts or can be pulled from the registry for hosts platforms, before any change is applied.
+	// Architecture of each host is discovered by connecting to it, which allows to detect images
+	// not supporting some hosts in clusters with mixed architectures. Check is skipped for container
+	// runtimes, which do not support it.
+	CheckImages bool `json:"checkImages,omitempty"`
+
+	// ImmutableFiles is a list of paths of configuration files, which are written only once. If file
+	// already exists on the host, it is never overwritten, even if desired content differs. This allows
+	// managing write-once files, like generated secrets, which must not be replaced on later deployments.
+	//
+	// Example value: '[]string{"/etc/kubernetes/bootstrap-token"}'.
+	ImmutableFiles []string `json:"immutableFiles,omitempty"`
+
+	// Tracer is an optional tracer, which allows deployment to participate in distributed
+	// tracing. If not set, no spans are created.
+	//
+	// Due to it's nature, it can only be set programmatically.
+	Tracer Tracer `json:"-"`
+
+	// TraceContext is an optional context holding parent span of the deployment span, e.g.
+	// span of the orchestration system calling Deploy, which allows to correlate traces.
+	//
+	// Due to it's nature, it can only be set programmatically.
+	TraceContext context.Context `json:"-"`
+
+	// Logger is an optional logger, which receives messages logged during deployment. If not
+	// set, messages are written to standard output.
+	//
+	// Due to it's nature, it can only be set programmatically.
+	Logger Logger `json:"-"`
 }
 
 // containers is a validated version of the Containers, which allows user to perform operations on them
@@ -72,6 +287,107 @@ type containers struct {
 
 	// resiredState is a user-defined desired containers configuration after validation.
 	desiredState containersState
+
+	// events is an optional channel, where progress events will be sent.
+	events chan<- ProgressEvent
+
+	// eventHosts maps names of the containers to IDs of their hosts, which are included in the events.
+	eventHosts map[string]string
+
+	// droppedEvents is a number of progress events dropped, because events channel was full.
+	droppedEvents int
+
+	// eventsLock protects droppedEvents from concurrent modifications.
+	eventsLock sync.Mutex
+
+	// maxConcurrency is a maximum number of container operations executed at the same time.
+	maxConcurrency int
+
+	// maxPerHostConcurrency is a maximum number of container operations executed at the same time
+	// on a single host.
+	maxPerHostConcurrency int
+
+	// drain is an optional function called before removing not desired container.
+	drain func(name string) error
+
+	// removeVolumes controls, if volumes of not desired containers should be removed.
+	removeVolumes bool
+
+	// allowDataLoss controls, if containers with volumes can be moved between hosts.
+	allowDataLoss bool
+
+	// allowRemoveAll controls, if all containers can be removed, when desired state is empty.
+	allowRemoveAll bool
+
+	// operationTimeout is a maximum duration of a single container operation.
+	operationTimeout time.Duration
+
+	// phaseTimeout is a maximum duration of a single deployment phase.
+	phaseTimeout time.Duration
+
+	// progress tracks containers processed in the current deployment phase.
+	progress *phaseProgress
+
+	// imageVerifier is an optional verifier of container images.
+	imageVerifier ImageVerifier
+
+	// maxRestartCount is a number of restarts, after which container is considered unhealthy.
+	maxRestartCount int
+
+	// session is an optional session shared with other containers instances.
+	session *Session
+
+	// mutator is an optional function customizing desired containers.
+	mutator func(name string, hcc *HostConfiguredContainer) error
+
+	// hostFilter optionally restricts deployment to matching hosts.
+	hostFilter *HostFilter
+
+	// waitForHealthyTimeout is a maximum time of waiting for containers to become healthy.
+	waitForHealthyTimeout time.Duration
+
+	// startSettlePeriod is a time after starting new container, after which container must be running.
+	startSettlePeriod time.Duration
+
+	// immutableFiles is a list of paths of configuration files, which are never overwritten.
+	immutableFiles []string
+
+	// adoptEquivalentContainers controls, if live configuration of existing containers should be compared
+	// with desired configuration.
+	adoptEquivalentContainers bool
+
+	// failOnUnexpectedDrift controls, if deployment should fail, when external changes are detected.
+	failOnUnexpectedDrift bool
+
+	// acknowledgeDrift allows deployment to proceed despite detected external changes.
+	acknowledgeDrift bool
+
+	// checkImages controls, if images availability should be checked before deployment.
+	checkImages bool
+
+	// tracer is an optional tracer used for creating spans.
+	tracer Tracer
+
+	// traceContext is an optional context holding parent span of the deployment.
+	traceContext context.Context
+
+	// logger is an optional logger receiving messages logged during deployment.
+	logger Logger
+
+	// spanContext holds current span of the deployment.
+	spanContext context.Context
+
+	// created is a set of containers, which has been created during the deployment.
+	created map[string]struct{}
+
+	// drift is a list of external changes detected while checking current state.
+	drift []string
+
+	// hostVerifier verifies connectivity with the host. If nil, host.Host.Verify is used.
+	hostVerifier func(h host.Host) error
+
+	// lock protects current state from concurrent modifications.
+	lock sync.Mutex
 }
 
 // New validates Containers configuration and returns container object, which can be
@@ -83,11 +399,56 @@ func (c *Containers) New() (ContainersInterface, error) {
 
 	// Validate already checks for errors, so we can skip checking here.
 	previousState, _ := c.PreviousState.New()
-	desiredState, _ := c.DesiredState.New()
+	mutatedDesiredState, _ := c.mutatedDesiredState()
+	desiredState, _ := mutatedDesiredState.New()
+	operationTimeout, _ := parseTimeout(c.OperationTimeout)
+	phaseTimeout, _ := parseTimeout(c.PhaseTimeout)
+	waitForHealthyTimeout, _ := parseTimeout(c.WaitForHealthyTimeout)
+	startSettlePeriod, _ := parseTimeout(c.StartSettlePeriod)
+
+	ps := previousState.(containersState)
+	ds := desiredState.(containersState)
+
+	if c.Session != nil {
+		ps.withSession(c.Session)
+		ds.withSession(c.Session)
+	}
+
+	if c.Logger != nil {
+		ps.withLogger(c.Logger)
+		ds.withLogger(c.Logger)
+	}
+
+	ds.withImmutableFiles(c.ImmutableFiles)
 
 	return &containers{
-		previousState: previousState.(containersState),
-		desiredState:  desiredState.(containersState),
+		previousState:         ps,
+		desiredState:          ds,
+		events:                c.Events,
+		maxConcurrency:        c.MaxConcurrency,
+		maxPerHostConcurrency: c.MaxPerHostConcurrency,
+		drain:                 c.Drain,
+		removeVolumes:         c.RemoveVolumes,
+		allowDataLoss:         c.AllowDataLoss,
+		allowRemoveAll:        c.AllowRemoveAll,
+		operationTimeout:      operationTimeout,
+		phaseTimeout:          phaseTimeout,
+		imageVerifier:         c.ImageVerifier,
+		maxRestartCount:       c.MaxRestartCount,
+		session:               c.Session,
+		mutator:               c.Mutator,
+		hostFilter:            c.HostFilter,
+		waitForHealthyTimeout: waitForHealthyTimeout,
+		startSettlePeriod:     startSettlePeriod,
+		failOnUnexpectedDrift: c.FailOnUnexpectedDrift,
+		acknowledgeDrift:      c.AcknowledgeDrift,
+		checkImages:           c.CheckImages,
+		tracer:                c.Tracer,
+		traceContext:          c.TraceContext,
+		logger:                c.Logger,
+		immutableFiles:        c.ImmutableFiles,
+
+		adoptEquivalentContainers: c.AdoptEquivalentContainers,
 	}, nil
 }
 
@@ -101,7 +462,7 @@ func (c *Containers) Validate() error {
 		return errors.Return()
 	}
 
-	if (c.PreviousState == nil && c.DesiredState == nil) || (len(c.PreviousState) == 0 && len(c.DesiredState) == 0) {
+	if len(c.PreviousState) == 0 && len(c.DesiredState) == 0 && !c.AllowRemoveAll {
 		errors = append(errors, fmt.Errorf("either current state or desired state must be defined"))
 	}
 
@@ -109,13 +470,114 @@ func (c *Containers) Validate() error {
 		errors = append(errors, fmt.Errorf("validating previous state failed: %w", err))
 	}
 
-	if _, err := c.DesiredState.New(); err != nil {
+	ds, err := c.mutatedDesiredState()
+	if err != nil {
+		errors = append(errors, fmt.Errorf("mutating desired state failed: %w", err))
+	} else if _, err := ds.New(); err != nil {
 		errors = append(errors, fmt.Errorf("validating desired state failed: %w", err))
+	} else if err := validateWaitForHealthy(ds); err != nil {
+		errors = append(errors, fmt.Errorf("validating containers to wait for failed: %w", err))
+	}
+
+	if c.MaxConcurrency < 0 {
+		errors = append(errors, fmt.Errorf("max concurrency can't be negative"))
+	}
+
+	if c.MaxPerHostConcurrency < 0 {
+		errors = append(errors, fmt.Errorf("max per host concurrency can't be negative"))
+	}
+
+	if c.MaxRestartCount < 0 {
+		errors = append(errors, fmt.Errorf("max restart count can't be negative"))
+	}
+
+	if _, err := parseTimeout(c.OperationTimeout); err != nil {
+		errors = append(errors, fmt.Errorf("invalid operation timeout: %w", err))
+	}
+
+	if _, err := parseTimeout(c.PhaseTimeout); err != nil {
+		errors = append(errors, fmt.Errorf("invalid phase timeout: %w", err))
+	}
+
+	if _, err := parseTimeout(c.WaitForHealthyTimeout); err != nil {
+		errors = append(errors, fmt.Errorf("invalid wait for healthy timeout: %w", err))
+	}
+
+	if _, err := parseTimeout(c.StartSettlePeriod); err != nil {
+		errors = append(errors, fmt.Errorf("invalid start settle period: %w", err))
+	}
+
+	if c.HostFilter != nil {
+		if err := c.HostFilter.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("validating host filter failed: %w", err))
+		}
+	}
+
+	if err := validateImmutableFiles(c.ImmutableFiles); err != nil {
+		errors = append(errors, fmt.Errorf("invalid immutable files: %w", err))
 	}
 
 	return errors.Return()
 }
 
+// mutatedDesiredState returns copy of the desired state with configured mutator applied
+// to every container and with networking of containers in pods configured. If mutator is not
+// configured and there are no pods, desired state is returned as is.
+func (c *Containers) mutatedDesiredState() (ContainersState, error) {
+	if c.Mutator == nil {
+		return withPods(c.DesiredState)
+	}
+
+	ds := c.DesiredState.DeepCopy()
+
+	names := []string{}
+
+	for n := range ds {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	for _, n := range names {
+		if err := c.Mutator(n, ds[n]); err != nil {
+			return nil, fmt.Errorf("failed mutating container %q: %w", n, err)
+		}
+	}
+
+	return withPods(ds)
+}
+
+// parseTimeout returns parsed timeout, which must be positive. If timeout is not set, zero is returned.
+func parseTimeout(t string) (time.Duration, error) {
+	if t == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(t)
+	if err != nil {
+		return 0, err
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive, got %s", d)
+	}
+
+	return d, nil
+}
+
+// DeepCopy returns a copy of Containers, which does not share any maps or slices with
+// the original struct, so it can be safely modified. Events channel, functions and session
+// are shared, as well as interfaces like Logger, Tracer, TraceContext and ImageVerifier, so
+// the copy reports to the same destinations as the original.
+func (c *Containers) DeepCopy() *Containers {
+	n := util.DeepCopy(c).(*Containers)
+
+	// Session must be shared, as it's whole purpose is sharing connections.
+	n.Session = c.Session
+
+	return n
+}
+
 // CheckCurrentState iterates over containers defined in the state, checks if they exist, are
 // running etc and writes to containers current state. This allows then to compare current state
 // of the containers with desired state, using Containers() method, to check if there are any
@@ -142,25 +604,30 @@ func (c *Containers) CheckCurrentState() error {
 //
 // CheckCurrentState() must be called before calling Deploy(), otherwise error will be returned.
 func (c *Containers) Deploy() error {
+	return c.DeployWithPlan(nil)
+}
+
+// DeployWithPlan creates configured containers, if changes, which deployment would make are the
+// same as in given plan, computed earlier using Plan(). If plan is nil, changes are not verified.
+func (c *Containers) DeployWithPlan(p *Plan) error {
 	containers, err := c.New()
 	if err != nil {
 		return err
 	}
 
-	// TODO Deploy shouldn't refresh the state. However, due to how we handle exported/unexported
-	// structs to enforce validation of objects, we lose current state, as we want it to be computed.
-	// On the other hand, maybe it's a good thing to call it once we execute. This way we could compare
-	// the plan user agreed to execute with plan calculated right before the execution and fail early if they
-	// differ.
-	// This is similar to what terraform is doing and may cause planning to run several times, so it may require
-	// some optimization.
-	// Alternatively we can have serializable plan and a knob in execute command to control whether we should
-	// make additional validation or not.
-	if err := containers.CheckCurrentState(); err != nil {
+	// Deploy refreshes the state, as due to how we handle exported/unexported structs to enforce
+	// validation of objects, we lose current state. This also allows to compare the plan user agreed
+	// to execute with plan calculated right before the execution and fail early if they differ.
+	//
+	// If state of some containers is unknown, Deploy decides, if it can proceed, e.g. when
+	// host filter excludes unreachable hosts.
+	var unknown *UnknownStateError
+
+	if err := containers.CheckCurrentState(); err != nil && !errors.As(err, &unknown) {
 		return err
 	}
 
-	if err := containers.Deploy(); err != nil {
+	if err := containers.DeployWithPlan(p); err != nil {
 		return err
 	}
 
@@ -170,36 +637,124 @@ func (c *Containers) Deploy() error {
 }
 
 // CheckCurrentState copies previous state to current state, to mark, that it has been called at least once
-// and then updates state of all containers.
+// and then updates state of all containers. Changes made outside of the deployment since previous state
+// has been recorded are detected as well.
 func (c *containers) CheckCurrentState() error {
+	if c.currentState != nil {
+		return c.currentState.CheckState()
+	}
+
+	// Previous state gets updated together with the current state, so keep a copy of it
+	// for detecting external changes.
+	previous := c.previousState.Export().DeepCopy()
+
+	// We just assign the pointer, but it's fine, since we don't need previous
+	// state anyway.
+	c.currentState = c.previousState
+
+	// If only state of some containers is unknown, keep results for the remaining ones.
+	var unknown *UnknownStateError
+
+	err := c.currentState.CheckState()
+	if err != nil && !errors.As(err, &unknown) {
+		return err
+	}
+
+	c.drift = externalDrift(previous, c.currentState)
+
+	if c.adoptEquivalentContainers {
+		if err := c.adoptEquivalent(); err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+// checkUnknownState returns an error, if state of any container in scope of the deployment
+// is unknown, as it is not safe to modify such containers. Containers on unreachable hosts can
+// be excluded from the deployment using host filter.
+func (c *containers) checkUnknownState() error {
+	unknown := []string{}
+
+	for n, r := range c.currentState {
+		if r.container.Status().Status == StatusUnknown && c.inScope(n) {
+			unknown = append(unknown, n)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+
+	return fmt.Errorf("refusing to deploy, as state of containers %s is unknown, exclude their hosts "+
+		"using host filter to deploy to remaining hosts", strings.Join(unknown, ", "))
+}
+
+// CheckCurrentStateOf checks the state of single container from the current state.
+func (c *containers) CheckCurrentStateOf(n string) error {
 	if c.currentState == nil {
-		// We just assign the pointer, but it's fine, since we don't need previous
-		// state anyway.
-		// TODO we could keep previous state to inform user, that some external changes happened since last run
 		c.currentState = c.previousState
 	}
 
-	return c.currentState.CheckState()
+	r, ok := c.current(n)
+	if !ok {
+		return fmt.Errorf("container %q does not exist in the state", n)
+	}
+
+	return containersState{n: r}.CheckState()
 }
 
-// filesToUpdate returns list of files, which needs to be updated, based on the current state of the container.
-// If the file is missing or it's content is not the same as desired content, it will be added to the list.
-func filesToUpdate(d hostConfiguredContainer, c *hostConfiguredContainer) []string {
+// filesToUpdate returns list of files of given desired container, which needs to be updated, based on
+// the current state of the container. If the file is missing, it's content is not the same as desired
+// content or it has wrong permissions, it will be added to the list.
+func (c *containers) filesToUpdate(n string) []string {
+	d := c.desiredState[n]
+	r, _ := c.current(n)
+
 	// If current state does not exist, just return all files.
-	if c == nil {
+	if r == nil {
 		return util.KeysStringMap(d.configFiles)
 	}
 
+	for _, p := range d.keptImmutableFiles(r) {
+		if !sameConfigFileContent(d.configFiles[p], r.configFiles[p]) {
+			c.logf(n, "Skipping update of configuration file '%s', as it is immutable and already exists on the host", p)
+		}
+	}
+
+	files := changedConfigFiles(*d, *r)
+
+	for _, p := range files {
+		c.logf(n, "Detected configuration drift for file '%s'\n  current: \n%+v\n  desired: \n%+v", p, r.configFiles[p], d.configFiles[p])
+	}
+
+	drift := permissionsDrift(*d, *r)
+
+	for _, p := range util.KeysStringMap(drift) {
+		c.logf(n, "Detected permissions drift for file '%s': %s", p, drift[p])
+
+		files = append(files, p)
+	}
+
+	return files
+}
+
+// changedConfigFiles returns list of desired configuration files, which are missing or
+// have different content in the current state. Immutable files, which exist in the current
+// state are never considered changed.
+func changedConfigFiles(d hostConfiguredContainer, c hostConfiguredContainer) []string {
 	files := []string{}
 
 	// Loop over desired config files and check if they exist.
 	for p, content := range d.configFiles {
-		if currentContent, exists := c.configFiles[p]; !exists || content != currentContent {
-			// TODO convert all prints to logging, so we can add more verbose information too
-			fmt.Printf("Detected configuration drift for file '%s'\n", p)
-			fmt.Printf("  current: \n%+v\n", currentContent)
-			fmt.Printf("  desired: \n%+v\n", content)
+		if d.keepsExisting(p, c) {
+			continue
+		}
 
+		if currentContent, exists := c.configFiles[p]; !exists || !sameConfigFileContent(content, currentContent) {
 			files = append(files, p)
 		}
 	}
@@ -216,59 +771,86 @@ func (c *containers) ensureConfigured(n string) error {
 		return nil
 	}
 
-	r := c.currentState[n]
+	r, _ := c.current(n)
 
-	f := filesToUpdate(*d, r)
+	f := c.filesToUpdate(n)
 	if len(f) == 0 {
 		return nil
 	}
 
-	err := d.Configure(f)
+	kept := d.keptImmutableFiles(r)
 
-	if err != nil && reflect.DeepEqual(f, filesToUpdate(*d, r)) {
-		return err
+	err := c.withTimeout(n, "configuring", func() error {
+		return d.Configure(f)
+	})
+
+	if err != nil && reflect.DeepEqual(f, c.filesToUpdate(n)) {
+		return c.notifyResult(ProgressEventConfigured, n, err)
 	}
 
 	// If current state does not exist, simply replace it with desired state.
 	if r == nil {
-		c.currentState[n] = d
+		c.setCurrent(n, d)
 		r = d
 	}
 
-	// Update current state config files map.
-	r.configFiles = d.configFiles
+	// Update current state config files map. Files are written with desired permissions.
+	// Content of immutable files, which has not been written, stays the same.
+	r.configFiles = withKeptFiles(d.configFiles, r.configFiles, kept)
+	r.configFileTypes = d.configFileTypes
+	r.configFilePermissions = nil
 
-	return err
+	return c.notifyResult(ProgressEventConfigured, n, err)
 }
 
 // ensureRunning makes sure that given container is running.
-func ensureRunning(c *hostConfiguredContainer) error {
-	if c == nil {
+func (c *containers) ensureRunning(n string, r *hostConfiguredContainer) error {
+	if r == nil {
 		return fmt.Errorf("can't start non-existing container")
 	}
 
-	if c.container.Status().Running() {
+	s := r.container.Status()
+	if s.Running() {
 		return nil
 	}
 
-	return c.Start()
+	config := r.container.Config()
+
+	if err := checkRestartLimit(config, *s); err != nil {
+		return err
+	}
+
+	if b := restartBackoff(config, *s); b > 0 {
+		c.logf(n, "Waiting %s before starting container '%s', which has been restarted %d times", b, config.Name, s.RestartCount)
+		time.Sleep(b)
+	}
+
+	return r.Start()
 }
 
 func (c *containers) ensureExists(n string) error {
-	r := c.currentState[n]
+	r, _ := c.current(n)
 	if r != nil && r.container.Status().Exists() {
 		return nil
 	}
 
-	fmt.Printf("Creating new container '%s'\n", n)
+	if err := c.waitForHealthy(n); err != nil {
+		return c.notifyResult(ProgressEventCreated, n, err)
+	}
+
+	c.logf(n, "Creating new container '%s'", n)
+
+	if err := c.verifyImage(n); err != nil {
+		return c.notifyResult(ProgressEventCreated, n, err)
+	}
 
 	d := c.desiredState[n]
 
-	err := c.desiredState.CreateAndStart(n)
+	err := c.createAndStart(n)
 
 	// Container creation failed and it does not exist, meaning state is clean.
 	if err != nil && !d.container.Status().Exists() {
-		return err
+		return c.notifyResult(ProgressEventCreated, n, err)
 	}
 
 	// Even if CreateAndStart failed, update current state. This makes the process more robust,
@@ -278,20 +860,20 @@ func (c *containers) ensureExists(n string) error {
 
 	// If current state does not exist, simply replace it with desired state.
 	if r == nil {
-		c.currentState[n] = d
+		c.setCurrent(n, d)
 		r = d
 	}
 
 	// After new container is created, add it to current state, so it can be returned to the user.
 	*r.container.Status() = *d.container.Status()
 
-	return err
+	return c.notifyResult(ProgressEventCreated, n, err)
 }
 
 // isUpdatable determines if given container can be updated.
 func (c *containers) isUpdatable(n string) error {
 	// Container which currently does not exist can't be updated, only created.
-	if _, ok := c.currentState[n]; !ok {
+	if _, ok := c.current(n); !ok {
 		return fmt.Errorf("can't update non-existing container '%s'", n)
 	}
 
@@ -311,24 +893,87 @@ func (c *containers) diffHost(n string) (string, error) {
 		return "", fmt.Errorf("can't diff container: %w", err)
 	}
 
-	return cmp.Diff(c.currentState[n].host, c.desiredState[n].host), nil
+	r, _ := c.current(n)
+
+	return hostDiff(r.host, c.desiredState[n].host), nil
 }
 
 // recreate is a helper, which removes container from current state and creates new one from
 // desired state.
 func (c *containers) recreate(n string) error {
-	if err := c.currentState.RemoveContainer(n); err != nil {
+	// Verify image before removing old container, so it keeps running if verification fails.
+	if err := c.verifyImage(n); err != nil {
+		return err
+	}
+
+	if err := c.waitForHealthy(n); err != nil {
+		return err
+	}
+
+	if err := c.removeContainer(n); err != nil {
 		return fmt.Errorf("failed removing old container: %w", err)
 	}
 
-	return c.desiredState.CreateAndStart(n)
+	return c.createAndStart(n)
+}
+
+// verifyImage verifies image of given desired container using configured image verifier.
+// Reference to the verified image returned by the verifier is used for creating the container.
+func (c *containers) verifyImage(n string) error {
+	if c.imageVerifier == nil {
+		return nil
+	}
+
+	d, ok := c.desiredState[n]
+	if !ok {
+		return fmt.Errorf("can't verify image of non-existing container")
+	}
+
+	image := d.container.Config().Image
+
+	pinned, err := c.imageVerifier.Verify(image)
+	if err != nil {
+		return fmt.Errorf("failed verifying image %q: %w", image, err)
+	}
+
+	d.pinnedImage = pinned
+
+	return nil
+}
+
+// checkDataLoss returns error, if given container has named volumes and it would be moved
+// to a different host, which would orphan the data stored in the volumes.
+func (c *containers) checkDataLoss(n string) error {
+	if c.allowDataLoss {
+		return nil
+	}
+
+	r, _ := c.current(n)
+
+	volumes := r.container.Config().Volumes
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	currentHost := r.host.ID()
+	desiredHost := c.desiredState[n].host.ID()
+
+	if currentHost == desiredHost {
+		return nil
+	}
+
+	return fmt.Errorf("moving container from host %q to host %q would orphan data in %d volume(s), "+
+		"set AllowDataLoss to proceed", currentHost, desiredHost, len(volumes))
 }
 
 // ensureHost makes sure container is running on the right host.
 //
 // If host configuration changes, existing container will be removed and new one will be created.
+// If container has named volumes and it would be moved to a different host, error is returned,
+// unless data loss is explicitly allowed.
 //
-// TODO This might be an overkill. e.g. changing SSH key for deployment will re-create all containers.
+// TODO This might be an overkill. e.g. changing SSH key for deployment will re-create all containers,
+// unless RotateTransportCredentials() is called before the deployment.
 func (c *containers) ensureHost(n string) error {
 	diff, err := c.diffHost(n)
 	if err != nil {
@@ -336,19 +981,26 @@ func (c *containers) ensureHost(n string) error {
 	}
 
 	if diff == "" {
+		// Pre-deploy commands are not included in the diff, so make sure they are up to date.
+		r, _ := c.current(n)
+		r.host.PreDeployCommands = c.desiredState[n].host.PreDeployCommands
+
 		return nil
 	}
 
-	fmt.Printf("Detected host configuration drift '%s'\n", n)
-	fmt.Printf("  Diff: %v\n", util.ColorizeDiff(diff))
+	if err := c.checkDataLoss(n); err != nil {
+		return err
+	}
+
+	c.logf(n, "Detected host configuration drift '%s'\n  Diff: %v", n, util.ColorizeDiff(diff))
 
 	// recreate is 2 step process, it removes old container and creates new one.
 	// If process fails in the middle, we still want to save the progress.
 	defer func() {
-		c.currentState[n] = c.desiredState[n]
+		c.setCurrent(n, c.desiredState[n])
 	}()
 
-	return c.recreate(n)
+	return c.notifyResult(ProgressEventRecreated, n, c.recreate(n))
 }
 
 // diffContainer compares container fields of the container and returns it's diff.
@@ -359,15 +1011,92 @@ func (c *containers) diffContainer(n string) (string, error) {
 		return "", fmt.Errorf("can't diff container: %w", err)
 	}
 
-	cd := cmp.Diff(c.currentState[n].container.Config(), c.desiredState[n].container.Config())
-	rcd := cmp.Diff(c.currentState[n].container.RuntimeConfig(), c.desiredState[n].container.RuntimeConfig())
+	r, _ := c.current(n)
+	d := c.desiredState[n].container.Config()
+
+	cd := cmp.Diff(r.container.Config(), d)
+
+	// If container runtime reports configuration hash, compare it with the hash of desired
+	// configuration instead of comparing all fields, as it also detects changes made outside
+	// of the deployment. Labels and resource limits are not included in the hash, so they are
+	// compared separately.
+	if h := r.container.Status().ConfigHash; h != "" {
+		cd = cmp.Diff(r.container.Config().Labels, d.Labels) +
+			cmp.Diff(r.container.Config().ResourceLimits(), d.ResourceLimits())
+
+		if configHashMismatch(r, d) {
+			cd = fmt.Sprintf("configuration hash of the container %q does not match desired configuration %q\n%s",
+				h, configHash(d), cmp.Diff(r.container.Config(), d))
+		}
+	}
+
+	rcd := cmp.Diff(r.container.RuntimeConfig(), c.desiredState[n].container.RuntimeConfig())
 
 	return cd + rcd, nil
 }
 
+// onlyLabelsDiffer checks, if given container configurations differ only by labels.
+func onlyLabelsDiffer(a, b types.ContainerConfig) bool {
+	if (len(a.Labels) == 0 && len(b.Labels) == 0) || reflect.DeepEqual(a.Labels, b.Labels) {
+		return false
+	}
+
+	a.Labels = nil
+	b.Labels = nil
+
+	return cmp.Equal(a, b)
+}
+
+// labelsOnlyChange checks, if only labels of given container has changed, so they can be
+// updated without recreating the container.
+func (c *containers) labelsOnlyChange(n string) bool {
+	r, _ := c.current(n)
+	d := c.desiredState[n]
+
+	if cmp.Diff(r.container.RuntimeConfig(), d.container.RuntimeConfig()) != "" {
+		return false
+	}
+
+	return onlyLabelsDiffer(r.container.Config(), d.container.Config())
+}
+
+// labelsUpdatable checks, if given container can have it's labels updated in place.
+func (c *containers) labelsUpdatable(n string) bool {
+	r, _ := c.current(n)
+
+	return c.desiredState[n].inPlaceAllowed() && c.labelsOnlyChange(n) && r.supportsLabelsUpdate()
+}
+
+// updatableInPlace checks, if pending configuration changes of given container can be applied
+// without recreating the container.
+func (c *containers) updatableInPlace(n string) bool {
+	return c.labelsUpdatable(n) || c.resourcesUpdatable(n)
+}
+
+// updateLabels updates labels of existing container in place and persists the change
+// in the current state.
+func (c *containers) updateLabels(n string) error {
+	r, _ := c.current(n)
+	d := c.desiredState[n]
+
+	if err := c.withTimeout(n, "updating labels of", func() error {
+		return r.updateLabels(d.container.Config().Labels)
+	}); err != nil {
+		return fmt.Errorf("failed updating labels: %w", err)
+	}
+
+	*d.container.Status() = *r.container.Status()
+
+	c.setCurrent(n, d)
+
+	return nil
+}
+
 // ensureContainer makes sure container configuration is up to date.
 //
 // If container configuration changes, existing container will be removed and new one will be created.
+// If only labels or only resource limits changes, container update strategy allows it and container
+// runtime supports it, they are updated in place.
 func (c *containers) ensureContainer(n string) error {
 	diff, err := c.diffContainer(n)
 	if err != nil {
@@ -378,16 +1107,36 @@ func (c *containers) ensureContainer(n string) error {
 		return nil
 	}
 
-	fmt.Printf("Detected container configuration drift '%s'\n", n)
-	fmt.Printf("  Diff: %v\n", util.ColorizeDiff(diff))
+	switch {
+	case c.labelsOnlyChange(n):
+		c.logf(n, "Detected container labels drift '%s'\n  Diff: %v", n, util.ColorizeDiff(diff))
+
+		if c.labelsUpdatable(n) {
+			return c.notifyResult(ProgressEventLabelsUpdated, n, c.updateLabels(n))
+		}
+
+		c.logf(n, "  %s, container will be recreated", c.inPlaceRefusal(n, "labels"))
+	case c.resourcesOnlyChange(n):
+		c.logf(n, "Detected container resource limits drift '%s'\n  Diff: %v", n, util.ColorizeDiff(diff))
+
+		if c.resourcesUpdatable(n) {
+			c.logf(n, "  Resource limits will be updated live, without recreating the container")
+
+			return c.notifyResult(ProgressEventResourcesUpdated, n, c.updateResources(n))
+		}
+
+		c.logf(n, "  %s, container will be recreated", c.inPlaceRefusal(n, "resource limits"))
+	default:
+		c.logf(n, "Detected container configuration drift '%s'\n  Diff: %v", n, util.ColorizeDiff(diff))
+	}
 
 	// Reconfiguring container is 2 step process. If we fail in the middle, we still want to
 	// return updated state to the user.
 	defer func() {
-		c.currentState[n] = c.desiredState[n]
+		c.setCurrent(n, c.desiredState[n])
 	}()
 
-	return c.recreate(n)
+	return c.notifyResult(ProgressEventRecreated, n, c.recreate(n))
 }
 
 // hasUpdates return bool if there are any pending configuration changes to the container.
@@ -397,7 +1146,7 @@ func (c *containers) hasUpdates(n string) (bool, error) {
 		return false, fmt.Errorf("failed to check host diff: %w", err)
 	}
 
-	f := filesToUpdate(*c.desiredState[n], c.currentState[n])
+	f := c.filesToUpdate(n)
 
 	diffContainer, err := c.diffContainer(n)
 	if err != nil {
@@ -425,12 +1174,14 @@ func (c *containers) ensureCurrentContainer(n string, r hostConfiguredContainer)
 
 	// Container is gone, remove it from current state, so it will be scheduled for recreation.
 	if !exists {
-		delete(c.currentState, n)
+		c.deleteCurrent(n)
 	}
 
 	// If container exist, is desired or has no pending updates, make sure it's running.
-	if exists && isDesired && !hasUpdates {
-		return r, ensureRunning(&r)
+	if exists && isDesired && !hasUpdates && !r.container.Status().Running() {
+		return r, c.notifyResult(ProgressEventStarted, n, c.withTimeout(n, "starting", func() error {
+			return c.ensureRunning(n, &r)
+		}))
 	}
 
 	return r, nil
@@ -456,6 +1207,18 @@ func (c *containers) ensureUpToDate(i string) error {
 		return fmt.Errorf("failed updating host configuration of container %s: %w", i, err)
 	}
 
+	// This must be checked before configuration files gets updated.
+	restart, err := c.needsRestart(i)
+	if err != nil {
+		return fmt.Errorf("failed checking if container %s needs restart: %w", i, err)
+	}
+
+	// This must be checked before configuration files gets updated as well.
+	recreate, err := c.needsRecreate(i)
+	if err != nil {
+		return fmt.Errorf("failed checking if container %s needs to be recreated: %w", i, err)
+	}
+
 	if err := c.ensureConfigured(i); err != nil {
 		return fmt.Errorf("failed updating configuration for container %s: %w", i, err)
 	}
@@ -464,64 +1227,377 @@ func (c *containers) ensureUpToDate(i string) error {
 		return fmt.Errorf("failed updating container %s: %w", i, err)
 	}
 
+	if recreate {
+		defer func() {
+			c.setCurrent(i, c.desiredState[i])
+		}()
+
+		return c.notifyResult(ProgressEventRecreated, i, c.recreate(i))
+	}
+
+	if !restart {
+		return nil
+	}
+
+	if err := c.notifyResult(ProgressEventRestarted, i, c.restart(i)); err != nil {
+		return fmt.Errorf("failed restarting container %s: %w", i, err)
+	}
+
 	return nil
 }
 
-// updateExistingContainer handles updating existing containers. It either removes them
-// if they are not needed anymore or makes sure that their configuration is up to date.
+// needsRestart checks, if given container should be restarted to apply changes in it's configuration
+// files. Restart is not needed, if container configuration changes, as container will be recreated
+// anyway, unless only labels or resource limits changes and they can be updated in place.
+//
+// Container is also restarted regardless of restartOnConfigChange, if configuration files mounted
+// into it as files has been removed from the host, as otherwise it would not see the files written
+// again.
+func (c *containers) needsRestart(n string) (bool, error) {
+	d := c.desiredState[n]
+	r, ok := c.current(n)
+
+	if !ok || len(changedConfigFiles(*d, *r)) == 0 {
+		return false, nil
+	}
+
+	deleted := deletedFileMounts(*d, *r)
+
+	if !d.restartOnConfigChange && len(deleted) == 0 {
+		return false, nil
+	}
+
+	for _, p := range deleted {
+		c.logf(n, "Configuration file '%s' mounted into container '%s' has been removed from the host", p, n)
+	}
+
+	diff, err := c.diffContainer(n)
+	if err != nil {
+		return false, fmt.Errorf("failed to check container diff: %w", err)
+	}
+
+	return diff == "" || c.updatableInPlace(n), nil
+}
+
+// needsRecreate checks, if given container should be recreated to apply changes in it's
+// environment files, as they are only read when container is created. If container configuration
+// changes, it will be recreated anyway, unless only labels or resource limits changes and they can
+// be updated in place.
+func (c *containers) needsRecreate(n string) (bool, error) {
+	d := c.desiredState[n]
+	r, ok := c.current(n)
+
+	if !ok {
+		return false, nil
+	}
+
+	changed := changedEnvFiles(*d, *r)
+	podRecreated := c.podRecreated(n)
+
+	if len(changed) == 0 && !podRecreated {
+		return false, nil
+	}
+
+	diff, err := c.diffContainer(n)
+	if err != nil {
+		return false, fmt.Errorf("failed to check container diff: %w", err)
+	}
+
+	if diff != "" && !c.updatableInPlace(n) {
+		return false, nil
+	}
+
+	for _, p := range changed {
+		c.logf(n, "Environment file '%s' of container '%s' has changed, container will be recreated", p, n)
+	}
+
+	if podRecreated {
+		c.logf(n, "Pod container '%s' of container '%s' has been recreated, container will be recreated", d.pod, n)
+	}
+
+	return true, nil
+}
+
+// restart stops given container, if it's running and starts it again.
+func (c *containers) restart(n string) error {
+	r, _ := c.current(n)
+
+	c.logf(n, "Restarting container '%s' to apply configuration changes", n)
+
+	if r.container.Status().Running() {
+		if err := c.withTimeout(n, "stopping", r.Stop); err != nil {
+			return fmt.Errorf("failed stopping container: %w", err)
+		}
+	}
+
+	return c.withTimeout(n, "starting", r.Start)
+}
+
+// updateExistingContainer handles updating existing containers. It makes sure that
+// configuration of desired containers is up to date and then removes containers, which
+// are not needed anymore.
 func (c *containers) updateExistingContainers() error {
-	for i := range c.currentState {
-		if _, exists := c.desiredState[i]; !exists {
-			if err := c.currentState.RemoveContainer(i); err != nil {
-				return fmt.Errorf("failed removing old container: %w", err)
-			}
+	if err := c.scheduler().run(c.scopedTasks(c.currentState), c.progress.track(c.traced(c.updateExistingContainer))); err != nil {
+		return err
+	}
+
+	return c.removeOldContainers()
+}
+
+// updateExistingContainer makes sure that configuration of given container is up to date.
+// Containers, which are not needed anymore are skipped, as they are handled by removeOldContainers.
+func (c *containers) updateExistingContainer(i string) error {
+	if _, exists := c.desiredState[i]; !exists {
+		return nil
+	}
 
+	if err := c.ensureUpToDate(i); err != nil {
+		return fmt.Errorf("failed ensuring, that container %s is up to date: %w", i, err)
+	}
+
+	return nil
+}
+
+// removeOldContainers removes containers, which are no longer desired, one by one. Each
+// container is drained before removal and after each removal, health of the remaining
+// containers is verified, so scaling down never removes more than one replica at a time.
+func (c *containers) removeOldContainers() error {
+	for _, t := range c.scopedTasks(c.currentState) {
+		if _, exists := c.desiredState[t.name]; exists {
+			continue
+		}
+
+		if err := c.notifyResult(ProgressEventRemoved, t.name, c.progress.track(c.traced(c.drainAndRemove))(t.name)); err != nil {
+			return fmt.Errorf("failed removing old container %s: %w", t.name, err)
+		}
+
+		if err := c.checkHealth(); err != nil {
+			return fmt.Errorf("remaining containers are not healthy after removing container %s: %w", t.name, err)
+		}
+	}
+
+	return nil
+}
+
+// drainAndRemove calls configured drain function for given container and then removes it.
+// If configured, named volumes of the container are removed as well.
+func (c *containers) drainAndRemove(n string) error {
+	if c.drain != nil {
+		if err := c.drain(n); err != nil {
+			return fmt.Errorf("failed draining container: %w", err)
+		}
+	}
+
+	r, _ := c.current(n)
+
+	if err := c.removeContainer(n); err != nil {
+		return err
+	}
+
+	if !c.removeVolumes || r == nil {
+		return nil
+	}
+
+	if err := c.withTimeout(n, "removing volumes of", r.removeVolumes); err != nil {
+		return fmt.Errorf("failed removing volumes: %w", err)
+	}
+
+	return nil
+}
+
+// checkHealth runs health check hooks of all desired containers, which exist in the current state.
+func (c *containers) checkHealth() error {
+	for _, t := range c.tasks(c.currentState) {
+		d, ok := c.desiredState[t.name]
+		if !ok || d.hooks == nil || d.hooks.HealthCheck == nil {
 			continue
 		}
 
-		if err := c.ensureUpToDate(i); err != nil {
-			return fmt.Errorf("failed ensuring, that container %s is up to date: %w", i, err)
+		if err := (*d.hooks.HealthCheck)(); err != nil {
+			return fmt.Errorf("container %s is not healthy: %w", t.name, err)
 		}
 	}
 
 	return nil
 }
 
+// removeContainer stops and removes given container and removes it from the current state.
+func (c *containers) removeContainer(n string) error {
+	r, ok := c.current(n)
+	if !ok {
+		return fmt.Errorf("can't remove non-existing container")
+	}
+
+	if err := c.withTimeout(n, "removing", r.remove); err != nil {
+		return err
+	}
+
+	c.deleteCurrent(n)
+
+	return nil
+}
+
+// ensureCurrent makes sure, that given container from current state is in a good shape
+// and updates it's current state.
+func (c *containers) ensureCurrent(n string) error {
+	r, _ := c.current(n)
+
+	d, err := c.ensureCurrentContainer(n, *r)
+
+	c.setCurrent(n, &d)
+
+	if err != nil {
+		return fmt.Errorf("failed to handle existing container %s: %w", n, err)
+	}
+
+	if !c.restartCountExceeded(n) {
+		return nil
+	}
+
+	c.logf(n, "Container '%s' has been restarted %d times, recreating", n, d.container.Status().RestartCount)
+
+	// Recreating is 2 step process, so if we fail in the middle, we still want to save the progress.
+	defer func() {
+		c.setCurrent(n, c.desiredState[n])
+	}()
+
+	return c.notifyResult(ProgressEventRecreated, n, c.recreate(n))
+}
+
+// restartCountExceeded checks, if given existing and desired container has been restarted
+// more times than allowed.
+func (c *containers) restartCountExceeded(n string) bool {
+	if c.maxRestartCount == 0 {
+		return false
+	}
+
+	if _, ok := c.desiredState[n]; !ok {
+		return false
+	}
+
+	r, ok := c.current(n)
+	if !ok || !r.container.Status().Exists() {
+		return false
+	}
+
+	return r.container.Status().RestartCount > c.maxRestartCount
+}
+
+// HighRestartCounts returns restart counts of containers from the current state, which has
+// been restarted at least given number of times.
+func (c *containers) HighRestartCounts(threshold int) map[string]int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	counts := map[string]int{}
+
+	for n, r := range c.currentState {
+		if rc := r.container.Status().RestartCount; rc > 0 && rc >= threshold {
+			counts[n] = rc
+		}
+	}
+
+	return counts
+}
+
+// UnhealthyContainers returns health status of containers from the current state, which
+// container runtime reports as not healthy, using runtime health checks.
+func (c *containers) UnhealthyContainers() map[string]string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	health := map[string]string{}
+
+	for n, r := range c.currentState {
+		if s := r.container.Status(); s.Unhealthy() {
+			health[n] = s.Health
+		}
+	}
+
+	return health
+}
+
+// warnDebugCommands prints a warning for each desired container, which has debug command
+// configured, so it is not forgotten.
+func (c *containers) warnDebugCommands() {
+	dc := c.desiredState.debugCommands()
+
+	for _, n := range util.KeysStringMap(dc) {
+		c.warnf(n, "Container '%s' will run debug command '%s' instead of it's regular command", n, dc[n])
+	}
+}
+
 // Deploy checks for containers configuration drifts and tries to reach desired state.
 //
 // TODO we should break down this function into smaller functions
-// TODO add planning, so it is possible to inspect what will be done
 // TODO currently we only compare previous configuration with new configuration.
 // We should also read runtime parameters and confirm that everything is according
 // to the spec.
-func (c *containers) Deploy() error {
+func (c *containers) Deploy() (err error) {
 	if c.currentState == nil {
 		return fmt.Errorf("can't execute without knowing current state of the containers")
 	}
 
-	fmt.Println("Checking for stopped and missing containers")
+	end := c.startSpan(spanDeploy, nil)
 
-	for n, r := range c.currentState {
-		d, err := c.ensureCurrentContainer(n, *r)
+	defer func() {
+		end(err)
+	}()
 
-		c.currentState[n] = &d
+	if len(c.desiredState) == 0 && len(c.currentState) > 0 && !c.allowRemoveAll {
+		return fmt.Errorf("refusing to remove all %d containers, as desired state is empty, which may be caused "+
+			"by invalid configuration, allow removing all containers to proceed", len(c.currentState))
+	}
 
-		if err != nil {
-			return fmt.Errorf("failed to handle existing container %s: %w", n, err)
-		}
+	if err := c.checkUnknownState(); err != nil {
+		return err
+	}
+
+	c.recordEventHosts()
+
+	defer c.warnDroppedEvents()
+
+	c.warnDebugCommands()
+
+	if err := c.checkDrift(); err != nil {
+		return err
 	}
 
-	fmt.Println("Configuring and creating new containers")
+	if c.checkImages {
+		c.logf("", "Checking container images")
 
-	for i := range c.desiredState {
-		if err := c.ensureNewContainer(i); err != nil {
-			return fmt.Errorf("failed creating new container %s: %w", i, err)
+		if err := c.checkImagesAvailability(); err != nil {
+			return fmt.Errorf("checking container images failed: %w", err)
 		}
 	}
 
-	fmt.Println("Updating existing containers")
+	if c.hostFilter != nil {
+		c.logf("", "Deploying only to hosts: %s", strings.Join(c.hostsInScope(), ", "))
+	}
+
+	if err := c.runPreDeployCommands(); err != nil {
+		return err
+	}
+
+	c.logf("", "Checking for stopped and missing containers")
+
+	if err := c.withPhaseTimeout(phaseCheck, func() error {
+		return c.scheduler().run(c.scopedTasks(c.currentState), c.progress.track(c.traced(c.ensureCurrent)))
+	}); err != nil {
+		return err
+	}
+
+	c.logf("", "Configuring and creating new containers")
+
+	if err := c.withPhaseTimeout(phaseCreate, func() error {
+		return c.scheduler().run(c.scopedTasks(c.desiredState), c.progress.track(c.traced(c.ensureNewContainer)))
+	}); err != nil {
+		return err
+	}
+
+	c.logf("", "Updating existing containers")
 
-	return c.updateExistingContainers()
+	return c.withPhaseTimeout(phaseUpdate, c.updateExistingContainers)
 }
 
 // FromYaml allows to load containers configuration and state from YAML format.
@@ -552,23 +1628,62 @@ func (c *containers) StateToYaml() ([]byte, error) {
 // ToExported converts containers struct to exported Containers.
 func (c *containers) ToExported() *Containers {
 	return &Containers{
-		PreviousState: c.previousState.Export(),
-		DesiredState:  c.desiredState.Export(),
+		PreviousState:         c.previousState.Export(),
+		DesiredState:          c.desiredState.Export(),
+		Events:                c.events,
+		MaxConcurrency:        c.maxConcurrency,
+		MaxPerHostConcurrency: c.maxPerHostConcurrency,
+		Drain:                 c.drain,
+		RemoveVolumes:         c.removeVolumes,
+		AllowDataLoss:         c.allowDataLoss,
+		AllowRemoveAll:        c.allowRemoveAll,
+		OperationTimeout:      exportedTimeout(c.operationTimeout),
+		PhaseTimeout:          exportedTimeout(c.phaseTimeout),
+		ImageVerifier:         c.imageVerifier,
+		MaxRestartCount:       c.maxRestartCount,
+		Session:               c.session,
+		Mutator:               c.mutator,
+		HostFilter:            c.hostFilter,
+		WaitForHealthyTimeout: exportedTimeout(c.waitForHealthyTimeout),
+		StartSettlePeriod:     exportedTimeout(c.startSettlePeriod),
+		FailOnUnexpectedDrift: c.failOnUnexpectedDrift,
+		AcknowledgeDrift:      c.acknowledgeDrift,
+		CheckImages:           c.checkImages,
+		Tracer:                c.tracer,
+		TraceContext:          c.traceContext,
+		Logger:                c.logger,
+		ImmutableFiles:        c.immutableFiles,
+
+		AdoptEquivalentContainers: c.adoptEquivalentContainers,
 	}
 }
 
+// exportedTimeout returns given timeout in the exported format.
+func exportedTimeout(t time.Duration) string {
+	if t == 0 {
+		return ""
+	}
+
+	return t.String()
+}
+
 // DesiredState returns desired state enhanced with current state, to highlight
-// important configuration changes from user perspective.
+// important configuration changes from user perspective. Containers out of scope
+// of the host filter are returned as they are in the previous state.
 func (c *containers) DesiredState() ContainersState {
 	d := c.desiredState.Export()
 
 	for h := range d {
 		// If container already exist, append it's ID to desired state to reduce the diff.
 		id := ""
+		restartCount := 0
+		hash := ""
 
 		cs, ok := c.previousState[h]
 		if ok && cs.container.Status().ID != "" {
 			id = cs.container.Status().ID
+			restartCount = cs.container.Status().RestartCount
+			hash = cs.container.Status().ConfigHash
 		}
 
 		// Make sure, that desired state has correct status. Container should always be running
@@ -576,12 +1691,14 @@ func (c *containers) DesiredState() ContainersState {
 		// to the container, it will get new ID anyway, but user does not care about this change,
 		// so we can hide it this way from the diff.
 		d[h].Container.Status = &types.ContainerStatus{
-			Status: "running",
-			ID:     id,
+			Status:       "running",
+			ID:           id,
+			RestartCount: restartCount,
+			ConfigHash:   hash,
 		}
 	}
 
-	return d
+	return c.withOutOfScopeUnchanged(d)
 }
 
 // Containers implement types.Resource interface.
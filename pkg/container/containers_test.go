@@ -13,6 +13,7 @@ import (
 	"github.com/flexkube/libflexkube/pkg/container/types"
 	"github.com/flexkube/libflexkube/pkg/host"
 	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+	"github.com/flexkube/libflexkube/pkg/host/transport/ssh"
 )
 
 const (
@@ -124,7 +125,14 @@ func TestFilesToUpdateEmpty(t *testing.T) {
 		},
 	}
 
-	if v := filesToUpdate(d, nil); !reflect.DeepEqual(expected, v) {
+	c := &containers{
+		desiredState: containersState{
+			foo: &d,
+		},
+		currentState: containersState{},
+	}
+
+	if v := c.filesToUpdate(foo); !reflect.DeepEqual(expected, v) {
 		t.Fatalf("Expected %v, got %v", expected, d)
 	}
 }
@@ -157,6 +165,121 @@ func TestValidateNoContainers(t *testing.T) {
 	}
 }
 
+func TestValidateNoContainersAllowRemoveAll(t *testing.T) {
+	cc := &Containers{
+		AllowRemoveAll: true,
+	}
+
+	if err := cc.Validate(); err != nil {
+		t.Fatalf("Containers object without any containers should be valid, when removing all is allowed, got: %v", err)
+	}
+}
+
+func TestValidateNegativeConcurrency(t *testing.T) {
+	cc := &Containers{
+		PreviousState: ContainersState{
+			foo: &HostConfiguredContainer{
+				Host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				Container: Container{
+					Runtime: RuntimeConfig{
+						Docker: &docker.Config{},
+					},
+					Config: types.ContainerConfig{
+						Name:  foo,
+						Image: "busybox:latest",
+					},
+				},
+			},
+		},
+		MaxConcurrency:        -1,
+		MaxPerHostConcurrency: -1,
+	}
+
+	if err := cc.Validate(); err == nil {
+		t.Fatalf("Validating containers with negative concurrency should fail")
+	}
+}
+
+func TestValidateBadOperationTimeout(t *testing.T) {
+	cc := &Containers{
+		PreviousState: ContainersState{
+			foo: &HostConfiguredContainer{
+				Host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				Container: Container{
+					Runtime: RuntimeConfig{
+						Docker: &docker.Config{},
+					},
+					Config: types.ContainerConfig{
+						Name:  foo,
+						Image: "busybox:latest",
+					},
+				},
+			},
+		},
+		OperationTimeout: "foo",
+	}
+
+	if err := cc.Validate(); err == nil {
+		t.Fatalf("Validating containers with malformed operation timeout should fail")
+	}
+}
+
+func TestValidateBadPhaseTimeout(t *testing.T) {
+	cc := &Containers{
+		PreviousState: ContainersState{
+			foo: &HostConfiguredContainer{
+				Host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				Container: Container{
+					Runtime: RuntimeConfig{
+						Docker: &docker.Config{},
+					},
+					Config: types.ContainerConfig{
+						Name:  foo,
+						Image: "busybox:latest",
+					},
+				},
+			},
+		},
+		PhaseTimeout: "-1m",
+	}
+
+	if err := cc.Validate(); err == nil {
+		t.Fatalf("Validating containers with negative phase timeout should fail")
+	}
+}
+
+func TestValidateBadWaitForHealthyTimeout(t *testing.T) {
+	cc := &Containers{
+		PreviousState: ContainersState{
+			foo: &HostConfiguredContainer{
+				Host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				Container: Container{
+					Runtime: RuntimeConfig{
+						Docker: &docker.Config{},
+					},
+					Config: types.ContainerConfig{
+						Name:  foo,
+						Image: "busybox:latest",
+					},
+				},
+			},
+		},
+		WaitForHealthyTimeout: "doh",
+	}
+
+	if err := cc.Validate(); err == nil {
+		t.Fatalf("Validating containers with bad wait for healthy timeout should fail")
+	}
+}
+
 func TestValidateBadDesiredContainers(t *testing.T) {
 	cc := &Containers{
 		DesiredState: ContainersState{
@@ -365,6 +488,88 @@ func TestDiffContainer(t *testing.T) {
 	}
 }
 
+func diffContainerHashTestContainers(currentConfig, desiredConfig types.ContainerConfig, hash string) *containers {
+	return &containers{
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						config: desiredConfig,
+					},
+				},
+			},
+		},
+		currentState: containersState{
+			foo: &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						config: currentConfig,
+						status: types.ContainerStatus{
+							ID:         foo,
+							ConfigHash: hash,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDiffContainerConfigHashMismatch(t *testing.T) {
+	config := types.ContainerConfig{
+		Name: foo,
+	}
+
+	diff, err := diffContainerHashTestContainers(config, config, bar).diffContainer(foo)
+	if err != nil {
+		t.Fatalf("Updatable container should return diff, got: %v", err)
+	}
+
+	if diff == "" {
+		t.Fatalf("Container modified outside of the deployment should return diff")
+	}
+}
+
+func TestDiffContainerConfigHashMatching(t *testing.T) {
+	current := types.ContainerConfig{
+		Name: foo,
+		Env:  []string{},
+	}
+
+	desired := types.ContainerConfig{
+		Name: foo,
+	}
+
+	diff, err := diffContainerHashTestContainers(current, desired, configHash(desired)).diffContainer(foo)
+	if err != nil {
+		t.Fatalf("Updatable container should return diff, got: %v", err)
+	}
+
+	if diff != "" {
+		t.Fatalf("Container with matching configuration hash shouldn't return diff, got: %s", diff)
+	}
+}
+
+func TestDiffContainerConfigHashLabels(t *testing.T) {
+	desired := types.ContainerConfig{
+		Name:   foo,
+		Labels: map[string]string{foo: bar},
+	}
+
+	current := types.ContainerConfig{
+		Name: foo,
+	}
+
+	diff, err := diffContainerHashTestContainers(current, desired, configHash(desired)).diffContainer(foo)
+	if err != nil {
+		t.Fatalf("Updatable container should return diff, got: %v", err)
+	}
+
+	if diff == "" {
+		t.Fatalf("Container with changed labels should return diff")
+	}
+}
+
 func TestDiffContainerRuntimeConfig(t *testing.T) {
 	c := &containers{
 		desiredState: containersState{
@@ -401,13 +606,48 @@ func TestDiffContainerRuntimeConfig(t *testing.T) {
 	}
 }
 
+func TestDiffContainerInit(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						config: types.ContainerConfig{
+							Init:       true,
+							StopSignal: "SIGTERM",
+						},
+					},
+				},
+			},
+		},
+		currentState: containersState{
+			foo: &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						config: types.ContainerConfig{},
+					},
+				},
+			},
+		},
+	}
+
+	diff, err := c.diffContainer(foo)
+	if err != nil {
+		t.Fatalf("Updatable container should return diff, got: %v", err)
+	}
+
+	if diff == "" {
+		t.Fatalf("Changing init process or stop signal should be detected as configuration drift")
+	}
+}
+
 // ensureRunning() tests.
 func TestEnsureRunningNonExistent(t *testing.T) {
 	c := &containers{
 		currentState: containersState{},
 	}
 
-	if err := ensureRunning(c.currentState[bar]); err == nil {
+	if err := c.ensureRunning(bar, c.currentState[bar]); err == nil {
 		t.Fatalf("Ensuring that non existing container is running should fail")
 	}
 }
@@ -438,11 +678,41 @@ func TestEnsureRunning(t *testing.T) {
 		},
 	}
 
-	if err := ensureRunning(c.currentState[foo]); err != nil {
+	if err := c.ensureRunning(foo, c.currentState[foo]); err != nil {
 		t.Fatalf("Ensuring that running container is running should succeed, got: %v", err)
 	}
 }
 
+func TestEnsureRunningRestartLimitReached(t *testing.T) {
+	r := &hostConfiguredContainer{
+		container: &container{
+			base: base{
+				config: types.ContainerConfig{
+					RestartMaxRetries: 3,
+				},
+				status: types.ContainerStatus{
+					ID:           "existing",
+					Status:       "exited",
+					RestartCount: 3,
+				},
+				runtime: runtime.Fake{
+					StartF: func(id string) error {
+						t.Errorf("Container which reached restart limit should not be started")
+
+						return nil
+					},
+				},
+			},
+		},
+	}
+
+	c := &containers{}
+
+	if err := c.ensureRunning(foo, r); err == nil {
+		t.Fatalf("Ensuring that container which reached restart limit is running should fail")
+	}
+}
+
 // ensureExists() tests.
 func TestEnsureExistsAlreadyExists(t *testing.T) {
 	c := &containers{
@@ -588,6 +858,74 @@ func TestEnsureExist(t *testing.T) {
 	}
 }
 
+// checkDataLoss() tests.
+func TestCheckDataLoss(t *testing.T) {
+	newContainers := func(address string, volumes []types.VolumeMount, allowDataLoss bool) *containers {
+		return &containers{
+			allowDataLoss: allowDataLoss,
+			desiredState: containersState{
+				foo: &hostConfiguredContainer{
+					host: host.Host{
+						SSHConfig: &ssh.Config{
+							Address: address,
+							Port:    22,
+						},
+					},
+				},
+			},
+			currentState: containersState{
+				foo: &hostConfiguredContainer{
+					host: host.Host{
+						SSHConfig: &ssh.Config{
+							Address: "foo",
+							Port:    22,
+						},
+					},
+					container: &container{
+						base: base{
+							config: types.ContainerConfig{
+								Volumes: volumes,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	volumes := []types.VolumeMount{
+		{
+			Name:   "foo-data",
+			Target: "/data",
+		},
+	}
+
+	cases := map[string]struct {
+		containers  *containers
+		expectError bool
+	}{
+		"move with volumes":         {newContainers("bar", volumes, false), true},
+		"move with volumes allowed": {newContainers("bar", volumes, true), false},
+		"move without volumes":      {newContainers("bar", nil, false), false},
+		"same host with volumes":    {newContainers("foo", volumes, false), false},
+	}
+
+	for n, tc := range cases {
+		tc := tc
+
+		t.Run(n, func(t *testing.T) {
+			err := tc.containers.checkDataLoss(foo)
+			if !tc.expectError && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
 // ensureHost() tests.
 func TestEnsureHostNoDiff(t *testing.T) {
 	c := &containers{
@@ -944,34 +1282,143 @@ func TestEnsureContainer(t *testing.T) { //nolint:funlen
 	}
 }
 
-// recreate() tests.
-func TestRecreateNonExistent(t *testing.T) {
-	c := &containers{}
-	if err := c.recreate(foo); err == nil {
-		t.Fatalf("Recreating on empty containers should fail")
-	}
-}
+func TestEnsureContainerLabelsOnly(t *testing.T) {
+	updated := false
 
-// Deploy() tests.
-func TestDeployNoCurrentState(t *testing.T) {
-	c := &containers{}
-	if err := c.Deploy(); err == nil {
-		t.Fatalf("Execute without current state should fail")
+	rc := &runtime.FakeConfig{
+		Runtime: &runtime.FakeLabelsUpdater{
+			UpdateLabelsF: func(id string, labels map[string]string) error {
+				updated = true
+
+				return nil
+			},
+		},
 	}
-}
 
-// hasUpdates() tests.
-func TestHasUpdatesHost(t *testing.T) {
 	c := &containers{
 		desiredState: containersState{
 			foo: &hostConfiguredContainer{
+				host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				updateStrategy: UpdateStrategyInPlaceIfPossible,
 				container: &container{
 					base: base{
-						config: types.ContainerConfig{},
+						config: types.ContainerConfig{
+							Image:  foo,
+							Labels: map[string]string{foo: bar},
+						},
+						runtimeConfig: rc,
 					},
 				},
-				host: host.Host{
-					DirectConfig: &direct.Config{},
+			},
+		},
+		currentState: containersState{
+			foo: &hostConfiguredContainer{
+				host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				container: &container{
+					base: base{
+						status: types.ContainerStatus{
+							ID: foo,
+						},
+						config: types.ContainerConfig{
+							Image: foo,
+						},
+						runtimeConfig: rc,
+					},
+				},
+			},
+		},
+	}
+
+	if err := c.ensureContainer(foo); err != nil {
+		t.Fatalf("Ensuring that container labels are up to date should succeed, got: %v", err)
+	}
+
+	if !updated {
+		t.Fatalf("Labels should be updated in place")
+	}
+
+	if c.currentState[foo].container.Status().ID != foo {
+		t.Fatalf("Updating labels should not recreate the container")
+	}
+
+	if c.currentState[foo].container.Config().Labels[foo] != bar {
+		t.Fatalf("Updated labels should be persisted in current state")
+	}
+}
+
+// recreate() tests.
+func TestRecreateNonExistent(t *testing.T) {
+	c := &containers{}
+	if err := c.recreate(foo); err == nil {
+		t.Fatalf("Recreating on empty containers should fail")
+	}
+}
+
+// Deploy() tests.
+func TestDeployNoCurrentState(t *testing.T) {
+	c := &containers{}
+	if err := c.Deploy(); err == nil {
+		t.Fatalf("Execute without current state should fail")
+	}
+}
+
+func TestDeployRemoveAllNotAllowed(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{},
+		currentState: containersState{
+			foo: &hostConfiguredContainer{},
+		},
+	}
+
+	if err := c.Deploy(); err == nil {
+		t.Fatalf("Deploy removing all containers should fail, when it's not allowed")
+	}
+}
+
+// checkUnknownState() tests.
+func TestCheckUnknownState(t *testing.T) {
+	unknown := testHostFilterContainer(sshTestHost(bar))
+	unknown.container.SetStatus(types.ContainerStatus{
+		ID:     bar,
+		Status: StatusUnknown,
+	})
+
+	c := &containers{
+		currentState: containersState{
+			foo: testHostFilterContainer(sshTestHost(foo)),
+			bar: unknown,
+		},
+	}
+
+	if err := c.checkUnknownState(); err == nil {
+		t.Fatalf("Checking should fail, when state of container in scope is unknown")
+	}
+
+	c.hostFilter = &HostFilter{
+		Hosts: []string{foo},
+	}
+
+	if err := c.checkUnknownState(); err != nil {
+		t.Fatalf("Containers with unknown state out of scope should be ignored, got: %v", err)
+	}
+}
+
+// hasUpdates() tests.
+func TestHasUpdatesHost(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						config: types.ContainerConfig{},
+					},
+				},
+				host: host.Host{
+					DirectConfig: &direct.Config{},
 				},
 			},
 		},
@@ -1610,3 +2057,487 @@ func TestEnsureCurrentContainerNonExisting(t *testing.T) {
 		t.Fatalf("ensuring removed container should remove it from current state to trigger creation")
 	}
 }
+
+// CheckCurrentStateOf() tests.
+func TestContainersCheckCurrentStateOfNonExistent(t *testing.T) {
+	c := GetContainers(t)
+
+	if err := c.CheckCurrentStateOf(bar); err == nil {
+		t.Fatalf("checking state of container, which does not exist in the state should fail")
+	}
+}
+
+// removeOldContainers() tests.
+func removableContainer(id string) *hostConfiguredContainer {
+	return &hostConfiguredContainer{
+		hooks: &Hooks{},
+		host: host.Host{
+			DirectConfig: &direct.Config{},
+		},
+		container: &container{
+			base: base{
+				status: types.ContainerStatus{
+					Status: "running",
+					ID:     id,
+				},
+				runtimeConfig: &runtime.FakeConfig{
+					Runtime: &runtime.Fake{
+						DeleteF: func(id string) error {
+							return nil
+						},
+						StatusF: func(id string) (types.ContainerStatus, error) {
+							return types.ContainerStatus{
+								Status: "running",
+								ID:     id,
+							}, nil
+						},
+						StopF: func(id string) error {
+							return nil
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRemoveOldContainersDrainAndCheckHealth(t *testing.T) {
+	removed := []string{}
+	healthChecks := 0
+
+	healthCheck := Hook(func() error {
+		healthChecks++
+
+		return nil
+	})
+
+	desired := removableContainer("baz")
+	desired.hooks.HealthCheck = &healthCheck
+
+	c := &containers{
+		desiredState: containersState{
+			"baz": desired,
+		},
+		currentState: containersState{
+			foo:   removableContainer(foo),
+			bar:   removableContainer(bar),
+			"baz": removableContainer("baz"),
+		},
+		drain: func(name string) error {
+			removed = append(removed, name)
+
+			return nil
+		},
+	}
+
+	if err := c.removeOldContainers(); err != nil {
+		t.Fatalf("Removing old containers should succeed, got: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{bar, foo}, removed); diff != "" {
+		t.Fatalf("Containers should be drained one by one in order: %s", diff)
+	}
+
+	if healthChecks != 2 {
+		t.Fatalf("Health of remaining containers should be checked after each removal, got %d checks", healthChecks)
+	}
+
+	if len(c.currentState) != 1 {
+		t.Fatalf("Only desired container should remain in current state, got: %v", c.currentState)
+	}
+}
+
+func TestRemoveOldContainersStopOnUnhealthy(t *testing.T) {
+	healthCheck := Hook(func() error {
+		return fmt.Errorf("not healthy")
+	})
+
+	desired := removableContainer("baz")
+	desired.hooks.HealthCheck = &healthCheck
+
+	c := &containers{
+		desiredState: containersState{
+			"baz": desired,
+		},
+		currentState: containersState{
+			foo:   removableContainer(foo),
+			bar:   removableContainer(bar),
+			"baz": removableContainer("baz"),
+		},
+	}
+
+	if err := c.removeOldContainers(); err == nil {
+		t.Fatalf("Removing old containers should fail when remaining containers are not healthy")
+	}
+
+	if _, ok := c.currentState[foo]; !ok {
+		t.Fatalf("No more containers should be removed after failed health check")
+	}
+}
+
+func TestRemoveOldContainersDrainFail(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{},
+		currentState: containersState{
+			foo: removableContainer(foo),
+		},
+		drain: func(name string) error {
+			return fmt.Errorf("drain failed")
+		},
+	}
+
+	if err := c.removeOldContainers(); err == nil {
+		t.Fatalf("Removing old containers should fail when draining fails")
+	}
+
+	if _, ok := c.currentState[foo]; !ok {
+		t.Fatalf("Container should not be removed when draining fails")
+	}
+}
+
+func TestDiffContainerHostnameAndWorkingDir(t *testing.T) {
+	cases := map[string]types.ContainerConfig{
+		"hostname": {
+			Hostname: foo,
+		},
+		"working directory": {
+			WorkingDir: "/foo",
+		},
+	}
+
+	for n, config := range cases {
+		config := config
+
+		t.Run(n, func(t *testing.T) {
+			c := &containers{
+				desiredState: containersState{
+					foo: &hostConfiguredContainer{
+						container: &container{
+							base: base{
+								config: config,
+							},
+						},
+					},
+				},
+				currentState: containersState{
+					foo: &hostConfiguredContainer{
+						container: &container{
+							base: base{
+								config: types.ContainerConfig{},
+							},
+						},
+					},
+				},
+			}
+
+			diff, err := c.diffContainer(foo)
+			if err != nil {
+				t.Fatalf("Updatable container should return diff, got: %v", err)
+			}
+
+			if diff == "" {
+				t.Fatalf("Changing %s should be detected as configuration drift", n)
+			}
+		})
+	}
+}
+
+func TestDiffContainerOOMScoreAdj(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						config: types.ContainerConfig{
+							OOMScoreAdj: -997,
+						},
+					},
+				},
+			},
+		},
+		currentState: containersState{
+			foo: &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						config: types.ContainerConfig{},
+					},
+				},
+			},
+		},
+	}
+
+	diff, err := c.diffContainer(foo)
+	if err != nil {
+		t.Fatalf("Updatable container should return diff, got: %v", err)
+	}
+
+	if diff == "" {
+		t.Fatalf("Changing OOM score adjustment should be detected as configuration drift")
+	}
+}
+
+// needsRestart() tests.
+func TestNeedsRestart(t *testing.T) {
+	cases := map[string]struct {
+		restartOnConfigChange bool
+		currentConfig         types.ContainerConfig
+		currentFiles          map[string]string
+		mounts                []types.Mount
+		expected              bool
+	}{
+		"changed configuration file": {
+			restartOnConfigChange: true,
+			currentFiles:          map[string]string{"/foo": "foo"},
+			expected:              true,
+		},
+		"restart disabled": {
+			restartOnConfigChange: false,
+			currentFiles:          map[string]string{"/foo": "foo"},
+			expected:              false,
+		},
+		"unchanged configuration files": {
+			restartOnConfigChange: true,
+			currentFiles:          map[string]string{"/foo": "bar"},
+			expected:              false,
+		},
+		"container will be recreated": {
+			restartOnConfigChange: true,
+			currentConfig:         types.ContainerConfig{Image: foo},
+			currentFiles:          map[string]string{"/foo": "foo"},
+			expected:              false,
+		},
+		"mounted file removed from the host": {
+			restartOnConfigChange: false,
+			currentFiles:          map[string]string{},
+			mounts:                []types.Mount{{Source: "/foo", Target: "/foo"}},
+			expected:              true,
+		},
+		"file in mounted directory removed from the host": {
+			restartOnConfigChange: false,
+			currentFiles:          map[string]string{},
+			mounts:                []types.Mount{{Source: "/", Target: "/foo"}},
+			expected:              false,
+		},
+	}
+
+	for n, testCase := range cases {
+		testCase := testCase
+
+		t.Run(n, func(t *testing.T) {
+			currentConfig := testCase.currentConfig
+			currentConfig.Mounts = testCase.mounts
+
+			c := &containers{
+				desiredState: containersState{
+					foo: &hostConfiguredContainer{
+						container: &container{
+							base: base{
+								config: types.ContainerConfig{
+									Mounts: testCase.mounts,
+								},
+							},
+						},
+						configFiles: map[string]string{
+							"/foo": "bar",
+						},
+						restartOnConfigChange: testCase.restartOnConfigChange,
+					},
+				},
+				currentState: containersState{
+					foo: &hostConfiguredContainer{
+						container: &container{
+							base: base{
+								config: currentConfig,
+							},
+						},
+						configFiles: testCase.currentFiles,
+					},
+				},
+			}
+
+			r, err := c.needsRestart(foo)
+			if err != nil {
+				t.Fatalf("Checking if container needs restart should succeed, got: %v", err)
+			}
+
+			if r != testCase.expected {
+				t.Fatalf("Expected restart to be %t, got %t", testCase.expected, r)
+			}
+		})
+	}
+}
+
+// HighRestartCounts() tests.
+func TestHighRestartCounts(t *testing.T) {
+	c := &containers{
+		currentState: containersState{
+			foo: &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						status: types.ContainerStatus{
+							ID:           foo,
+							RestartCount: 5,
+						},
+					},
+				},
+			},
+			bar: &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						status: types.ContainerStatus{
+							ID:           bar,
+							RestartCount: 1,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	e := map[string]int{foo: 5}
+
+	if diff := cmp.Diff(e, c.HighRestartCounts(3)); diff != "" {
+		t.Fatalf("Unexpected restart counts: %s", diff)
+	}
+}
+
+// UnhealthyContainers() tests.
+func TestUnhealthyContainers(t *testing.T) {
+	c := &containers{
+		currentState: containersState{
+			foo: &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						status: types.ContainerStatus{
+							ID:     foo,
+							Health: "unhealthy",
+						},
+					},
+				},
+			},
+			bar: &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						status: types.ContainerStatus{
+							ID:     bar,
+							Health: types.HealthHealthy,
+						},
+					},
+				},
+			},
+			"baz": &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						status: types.ContainerStatus{
+							ID: "baz",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	e := map[string]string{foo: "unhealthy"}
+
+	if diff := cmp.Diff(e, c.UnhealthyContainers()); diff != "" {
+		t.Fatalf("Unexpected unhealthy containers: %s", diff)
+	}
+}
+
+// restartCountExceeded() tests.
+func TestRestartCountExceeded(t *testing.T) {
+	c := &containers{
+		maxRestartCount: 3,
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{},
+		},
+		currentState: containersState{
+			foo: &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						status: types.ContainerStatus{
+							ID:           foo,
+							RestartCount: 4,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if !c.restartCountExceeded(foo) {
+		t.Fatalf("Container restarted more times than allowed should be unhealthy")
+	}
+
+	c.maxRestartCount = 0
+
+	if c.restartCountExceeded(foo) {
+		t.Fatalf("Restart count should be ignored, when max restart count is not set")
+	}
+}
+
+// Mutator tests.
+func testContainersWithMutator(m func(string, *HostConfiguredContainer) error) *Containers {
+	return &Containers{
+		DesiredState: ContainersState{
+			foo: &HostConfiguredContainer{
+				Host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				Container: Container{
+					Runtime: RuntimeConfig{
+						Docker: &docker.Config{},
+					},
+					Config: types.ContainerConfig{
+						Name:  foo,
+						Image: "busybox:latest",
+					},
+				},
+			},
+		},
+		Mutator: m,
+	}
+}
+
+func TestContainersMutator(t *testing.T) {
+	cc := testContainersWithMutator(func(name string, hcc *HostConfiguredContainer) error {
+		hcc.Container.Config.Privileged = true
+
+		return nil
+	})
+
+	c, err := cc.New()
+	if err != nil {
+		t.Fatalf("Creating containers with mutator should succeed, got: %v", err)
+	}
+
+	if !c.DesiredState()[foo].Container.Config.Privileged {
+		t.Fatalf("Mutation should be reflected in desired state")
+	}
+
+	if cc.DesiredState[foo].Container.Config.Privileged {
+		t.Fatalf("Mutator should not modify user-defined desired state")
+	}
+}
+
+func TestContainersMutatorFail(t *testing.T) {
+	cc := testContainersWithMutator(func(name string, hcc *HostConfiguredContainer) error {
+		return fmt.Errorf("expected")
+	})
+
+	if _, err := cc.New(); err == nil {
+		t.Fatalf("Error returned by mutator should be propagated")
+	}
+}
+
+func TestContainersMutatorInvalid(t *testing.T) {
+	cc := testContainersWithMutator(func(name string, hcc *HostConfiguredContainer) error {
+		hcc.Container.Config.Image = ""
+
+		return nil
+	})
+
+	if err := cc.Validate(); err == nil {
+		t.Fatalf("Mutated containers should be validated")
+	}
+}
@@ -2,8 +2,10 @@ package container
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
-	"github.com/flexkube/libflexkube/pkg/container/runtime/docker"
+	"github.com/flexkube/libflexkube/internal/util"
 	"github.com/flexkube/libflexkube/pkg/container/types"
 )
 
@@ -11,8 +13,44 @@ const (
 	// StatusMissing is a value, which is set to ContainerStatus.Status field,
 	// if stored container ID is not found.
 	StatusMissing = "gone"
+
+	// StatusUnknown is a value, which is set to ContainerStatus.Status field, if state of
+	// the container could not be checked, for example because it's host is unreachable.
+	StatusUnknown = "unknown"
 )
 
+// UnknownStateError is returned, when state of some containers could not be checked, for
+// example because their hosts are unreachable. State of remaining containers is still
+// updated, so it can be inspected and acted upon.
+type UnknownStateError struct {
+	// Errors maps names of containers, which state could not be checked, to errors
+	// encountered while checking it.
+	Errors map[string]error
+
+	// Hosts is a sorted list of IDs of hosts, where containers with unknown state are placed.
+	Hosts []string
+}
+
+// Error implements error interface.
+func (e *UnknownStateError) Error() string {
+	names := []string{}
+
+	for n := range e.Errors {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	errs := []string{}
+
+	for _, n := range names {
+		errs = append(errs, e.Errors[n].Error())
+	}
+
+	return fmt.Sprintf("failed checking state of %d container(s) on host(s) %s: %s",
+		len(names), strings.Join(e.Hosts, ", "), strings.Join(errs, ", "))
+}
+
 // ContainersStateInterface represents 'constainersState' capabilities.
 type ContainersStateInterface interface {
 	// CheckState updates the state of all previously configured containers
@@ -56,25 +94,74 @@ func (s ContainersState) New() (ContainersStateInterface, error) {
 }
 
 // CheckState updates the state of all previously configured containers
-// and their configuration on the host.
+// and their configuration on the host. If there are multiple containers created
+// for the same container, for example after interrupted deployment, only the
+// newest one is kept.
+//
+// If state of some containers could not be checked, for example because their hosts are
+// unreachable, state of remaining containers is still updated, failed containers get
+// StatusUnknown status and UnknownStateError is returned.
 func (s containersState) CheckState() error {
-	for _, hcc := range s {
-		if err := hcc.Status(); err != nil {
-			return err
-		}
+	errs := map[string]error{}
+	hosts := map[string]struct{}{}
 
-		if hcc.container.Status().ID == "" {
-			hcc.container.SetStatus(types.ContainerStatus{
-				Status: StatusMissing,
-			})
-		}
+	for n, hcc := range s {
+		if err := hcc.checkState(n); err != nil {
+			// Keep the ID, so container is not considered missing, as it may still be running.
+			hcc.container.Status().Status = StatusUnknown
 
-		if err := hcc.ConfigurationStatus(); err != nil {
-			return err
+			errs[n] = fmt.Errorf("container %s on host %s: %w", n, hcc.host.ID(), err)
+			hosts[hcc.host.ID()] = struct{}{}
 		}
 	}
 
-	return nil
+	if len(errs) == 0 {
+		return nil
+	}
+
+	e := &UnknownStateError{
+		Errors: errs,
+	}
+
+	for h := range hosts {
+		e.Hosts = append(e.Hosts, h)
+	}
+
+	sort.Strings(e.Hosts)
+
+	return e
+}
+
+// checkState updates the state of the container and it's configuration on the host.
+func (m *hostConfiguredContainer) checkState(n string) error {
+	e, err := m.Exists()
+	if err != nil {
+		// Don't treat failed query as missing container, as it would trigger
+		// recreation of the container, which may still be running.
+		return fmt.Errorf("can't determine if container exists (%s): %w", e.Reason, err)
+	}
+
+	if e.Reason == types.ExistenceRemoved {
+		m.logf(n, "Container '%s' has been removed outside of the deployment", n)
+
+		m.container.Status().ID = ""
+	}
+
+	if err := m.reconcileDuplicates(n); err != nil {
+		return fmt.Errorf("failed reconciling duplicates: %w", err)
+	}
+
+	if err := m.Status(); err != nil {
+		return err
+	}
+
+	if m.container.Status().ID == "" {
+		m.container.SetStatus(types.ContainerStatus{
+			Status: StatusMissing,
+		})
+	}
+
+	return m.ConfigurationStatus()
 }
 
 // RemoveContainer removes the container by ID.
@@ -83,17 +170,8 @@ func (s containersState) RemoveContainer(containerName string) error {
 		return fmt.Errorf("can't remove non-existing container")
 	}
 
-	status := s[containerName].container.Status()
-	if status.Running() || status.Restarting() {
-		if err := s[containerName].Stop(); err != nil {
-			return fmt.Errorf("failed stopping container: %w", err)
-		}
-	}
-
-	if s[containerName].container.Status().Exists() {
-		if err := s[containerName].Delete(); err != nil {
-			return fmt.Errorf("failed removing container: %w", err)
-		}
+	if err := s[containerName].remove(); err != nil {
+		return err
 	}
 
 	delete(s, containerName)
@@ -125,13 +203,18 @@ func (s containersState) Export() ContainersState {
 	for i, m := range s {
 		h := &HostConfiguredContainer{
 			Container: Container{
-				Config: m.container.Config(),
-				Runtime: RuntimeConfig{
-					Docker: m.container.RuntimeConfig().(*docker.Config),
-				},
+				Config:  m.container.Config(),
+				Runtime: exportRuntimeConfig(m.container.RuntimeConfig()),
 			},
-			Host:        m.host,
-			ConfigFiles: m.configFiles,
+			Host:            m.host,
+			ConfigFiles:     m.configFiles,
+			ConfigFileTypes: m.configFileTypes,
+
+			RestartOnConfigChange: m.restartOnConfigChange,
+			VerifyConfigFiles:     m.verifyConfigFiles,
+			UpdateStrategy:        m.updateStrategy,
+			WaitForHealthy:        m.waitForHealthy,
+			Pod:                   m.pod,
 		}
 
 		if s := m.container.Status(); s.ID != "" && s.Status != "" {
@@ -147,3 +230,37 @@ func (s containersState) Export() ContainersState {
 
 	return cs
 }
+
+// debugCommands returns map of container names and their debug commands, for containers
+// which have debug command configured.
+func (s containersState) debugCommands() map[string]string {
+	r := map[string]string{}
+
+	for n, hcc := range s {
+		if dc := hcc.container.Config().DebugCommand; len(dc) > 0 {
+			r[n] = strings.Join(dc, " ")
+		}
+	}
+
+	return r
+}
+
+// DeepCopy returns a copy of the containers state, which does not share any maps or slices
+// with the original state, so it can be safely modified. Hooks functions are shared.
+func (s ContainersState) DeepCopy() ContainersState {
+	return util.DeepCopy(s).(ContainersState)
+}
+
+// withSession configures given session for all containers in the state.
+func (s containersState) withSession(session *Session) {
+	for _, m := range s {
+		m.session = session
+	}
+}
+
+// withLogger configures given logger for all containers in the state.
+func (s containersState) withLogger(l Logger) {
+	for _, m := range s {
+		m.logger = l
+	}
+}
@@ -1,6 +1,7 @@
 package container
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -273,3 +274,182 @@ func TestCreateAndStartFailOnMissingContainer(t *testing.T) {
 		t.Fatalf("creating and starting non existing container should give error")
 	}
 }
+
+// debugCommands() tests.
+func TestContainersStateDebugCommands(t *testing.T) {
+	s := containersState{
+		foo: &hostConfiguredContainer{
+			container: &container{
+				base: base{
+					config: types.ContainerConfig{
+						DebugCommand: []string{"sleep", "infinity"},
+					},
+				},
+			},
+		},
+		bar: &hostConfiguredContainer{
+			container: &container{
+				base: base{
+					config: types.ContainerConfig{},
+				},
+			},
+		},
+	}
+
+	e := map[string]string{
+		foo: "sleep infinity",
+	}
+
+	if diff := cmp.Diff(e, s.debugCommands()); diff != "" {
+		t.Fatalf("Unexpected debug commands: %s", diff)
+	}
+}
+
+// DeepCopy() tests.
+func TestContainersStateDeepCopy(t *testing.T) {
+	s := ContainersState{
+		foo: &HostConfiguredContainer{
+			Host: host.Host{
+				DirectConfig: &direct.Config{},
+			},
+			ConfigFiles: map[string]string{
+				"/foo": foo,
+			},
+			Container: Container{
+				Config: types.ContainerConfig{
+					Name: foo,
+					Args: []string{foo},
+				},
+				Runtime: RuntimeConfig{
+					Docker: &docker.Config{},
+				},
+			},
+		},
+	}
+
+	c := s.DeepCopy()
+
+	if diff := cmp.Diff(s, c); diff != "" {
+		t.Fatalf("copy should be equal to the original: %s", diff)
+	}
+
+	c[foo].ConfigFiles["/foo"] = bar
+	c[foo].Container.Config.Args[0] = bar
+	c[bar] = c[foo]
+
+	if s[foo].ConfigFiles["/foo"] != foo {
+		t.Fatalf("modifying config files in the copy should not modify the original")
+	}
+
+	if s[foo].Container.Config.Args[0] != foo {
+		t.Fatalf("modifying args in the copy should not modify the original")
+	}
+
+	if _, ok := s[bar]; ok {
+		t.Fatalf("adding container to the copy should not modify the original")
+	}
+}
+
+func TestContainersStateCheckStateExistenceUnknown(t *testing.T) {
+	c := containersState{
+		foo: &hostConfiguredContainer{
+			host: host.Host{
+				DirectConfig: &direct.Config{},
+			},
+			container: &container{
+				base: base{
+					runtimeConfig: &runtime.FakeConfig{
+						Runtime: &runtime.Fake{
+							ExistsF: func(id string) (types.Existence, error) {
+								return types.Existence{Reason: types.ExistenceUnknown}, fmt.Errorf("connection refused")
+							},
+						},
+					},
+					status: types.ContainerStatus{
+						ID:     foo,
+						Status: "running",
+					},
+				},
+			},
+		},
+	}
+
+	if err := c.CheckState(); err == nil {
+		t.Fatalf("Checking state should fail, when existence of the container can't be determined")
+	}
+
+	if s := c[foo].container.Status(); s.ID != foo || s.Status == StatusMissing {
+		t.Fatalf("Container should not be marked as missing, when existence can't be determined, got: %+v", s)
+	}
+}
+
+func TestContainersStateCheckStatePartial(t *testing.T) {
+	c := containersState{
+		foo: &hostConfiguredContainer{
+			host: host.Host{
+				DirectConfig: &direct.Config{},
+			},
+			container: &container{
+				base: base{
+					runtimeConfig: &runtime.FakeConfig{
+						Runtime: &runtime.Fake{
+							StatusF: func(id string) (types.ContainerStatus, error) {
+								return types.ContainerStatus{}, fmt.Errorf("connection refused")
+							},
+						},
+					},
+					status: types.ContainerStatus{
+						ID:     foo,
+						Status: "running",
+					},
+				},
+			},
+		},
+		bar: &hostConfiguredContainer{
+			host: host.Host{
+				DirectConfig: &direct.Config{},
+			},
+			container: &container{
+				base: base{
+					runtimeConfig: &runtime.FakeConfig{
+						Runtime: &runtime.Fake{
+							StatusF: func(id string) (types.ContainerStatus, error) {
+								return types.ContainerStatus{
+									ID:     bar,
+									Status: "exited",
+								}, nil
+							},
+						},
+					},
+					status: types.ContainerStatus{
+						ID:     bar,
+						Status: "running",
+					},
+				},
+			},
+		},
+	}
+
+	err := c.CheckState()
+
+	var unknown *UnknownStateError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("Checking state should return UnknownStateError, got: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"direct"}, unknown.Hosts); diff != "" {
+		t.Fatalf("Unexpected hosts: %s", diff)
+	}
+
+	if _, ok := unknown.Errors[foo]; !ok || len(unknown.Errors) != 1 {
+		t.Fatalf("Only failing container should be reported, got: %v", unknown.Errors)
+	}
+
+	if s := c[foo].container.Status(); s.ID != foo || s.Status != StatusUnknown {
+		t.Fatalf("Failing container should keep it's ID and have status %q, got: %+v", StatusUnknown, s)
+	}
+
+	if s := c[bar].container.Status(); s.Status != "exited" {
+		t.Fatalf("State of reachable container should be updated, got: %+v", s)
+	}
+}
@@ -0,0 +1,46 @@
+package containertest
+
+import (
+	"github.com/flexkube/libflexkube/pkg/container"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+// Image is an image used by containers created by helpers in this package.
+const Image = "busybox:latest"
+
+// HostConfiguredContainer returns valid HostConfiguredContainer with given name, which
+// uses given fake runtime. Direct transport is used, as it does not require any connection.
+func HostConfiguredContainer(r *Runtime, name string) *container.HostConfiguredContainer {
+	return &container.HostConfiguredContainer{
+		Host: host.Host{
+			DirectConfig: &direct.Config{},
+		},
+		Container: container.Container{
+			Runtime: container.RuntimeConfig{
+				Custom: &RuntimeConfig{
+					Runtime: r,
+				},
+			},
+			Config: types.ContainerConfig{
+				Name:  name,
+				Image: Image,
+			},
+		},
+	}
+}
+
+// Containers returns Containers with desired state populated with containers with given
+// names, all using given fake runtime.
+func Containers(r *Runtime, names ...string) *container.Containers {
+	cs := container.ContainersState{}
+
+	for _, n := range names {
+		cs[n] = HostConfiguredContainer(r, n)
+	}
+
+	return &container.Containers{
+		DesiredState: cs,
+	}
+}
@@ -0,0 +1,261 @@
+package containertest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/flexkube/libflexkube/pkg/container"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host/transport"
+)
+
+func TestDeploy(t *testing.T) {
+	r := NewRuntime()
+
+	c := Containers(r, "foo", "bar")
+	c.DesiredState["foo"].ConfigFiles = map[string]string{
+		"/etc/foo": "foo",
+	}
+
+	if err := c.Deploy(); err != nil {
+		t.Fatalf("Deploying with fake runtime should succeed, got: %v", err)
+	}
+
+	running := 0
+
+	for _, fc := range r.Containers {
+		if fc.Running {
+			running++
+		}
+	}
+
+	if running != 2 {
+		t.Fatalf("Expected 2 running containers, got %d: %+v", running, r.Containers)
+	}
+
+	if s := c.PreviousState["foo"].Container.Status; s == nil || s.Status != StatusRunning {
+		t.Fatalf("Container should be running according to the state, got: %+v", s)
+	}
+
+	if f, ok := r.Files["/mnt/host/etc/foo"]; !ok || f.Content != "foo" {
+		t.Fatalf("Configuration file should be written, got: %+v", f)
+	}
+}
+
+func TestDeployRestartStopped(t *testing.T) {
+	r := NewRuntime()
+
+	c := Containers(r, "foo")
+
+	if err := c.Deploy(); err != nil {
+		t.Fatalf("Deploying with fake runtime should succeed, got: %v", err)
+	}
+
+	for _, fc := range r.Containers {
+		fc.Running = false
+	}
+
+	if err := c.Deploy(); err != nil {
+		t.Fatalf("Deploying again should succeed, got: %v", err)
+	}
+
+	if s := c.PreviousState["foo"].Container.Status; s == nil || s.Status != StatusRunning {
+		t.Fatalf("Stopped container should be started, got: %+v", s)
+	}
+}
+
+func TestDeployFailCreate(t *testing.T) {
+	r := NewRuntime()
+	r.Errors["Create"] = fmt.Errorf("create failed")
+
+	if err := Containers(r, "foo").Deploy(); err == nil {
+		t.Fatalf("Deploy should fail when runtime fails to create containers")
+	}
+}
+
+func TestRuntimeStatusMissing(t *testing.T) {
+	r := NewRuntime()
+
+	s, err := r.Status("foo")
+	if err != nil {
+		t.Fatalf("Checking status of missing container should succeed, got: %v", err)
+	}
+
+	if s.Exists() {
+		t.Fatalf("Missing container should not exist, got: %+v", s)
+	}
+}
+
+func TestRuntimeAddContainer(t *testing.T) {
+	r := NewRuntime()
+
+	id := r.AddContainer(types.ContainerConfig{Name: "foo"}, true)
+
+	s, err := r.Status(id)
+	if err != nil {
+		t.Fatalf("Checking status should succeed, got: %v", err)
+	}
+
+	if s.Status != StatusRunning {
+		t.Fatalf("Added container should be running, got: %+v", s)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var tr transport.Interface = &Transport{}
+
+	c, err := tr.Connect()
+	if err != nil {
+		t.Fatalf("Connecting should succeed, got: %v", err)
+	}
+
+	a, err := c.ForwardUnixSocket("/foo")
+	if err != nil {
+		t.Fatalf("Forwarding should succeed, got: %v", err)
+	}
+
+	if a != "/foo" {
+		t.Fatalf("Forwarded address should be returned unmodified, got: %q", a)
+	}
+}
+
+func TestTransportConnectFail(t *testing.T) {
+	tr := &Transport{
+		ConnectErr: fmt.Errorf("failed"),
+	}
+
+	if _, err := tr.Connect(); err == nil {
+		t.Fatalf("Connecting should fail")
+	}
+}
+
+func TestDeployNoChanges(t *testing.T) {
+	r := NewRuntime()
+
+	c := Containers(r, "foo")
+
+	if err := c.Deploy(); err != nil {
+		t.Fatalf("Deploying with fake runtime should succeed, got: %v", err)
+	}
+
+	if err := c.Deploy(); err != nil {
+		t.Fatalf("Deploying again should succeed, got: %v", err)
+	}
+
+	if len(r.Containers) != 1 {
+		t.Fatalf("Deploying without changes should not create new containers, got: %+v", r.Containers)
+	}
+}
+
+func TestDeployVolumes(t *testing.T) {
+	r := NewRuntime()
+
+	c := Containers(r, "foo")
+	c.DesiredState["foo"].Container.Config.Volumes = []types.VolumeMount{
+		{
+			Name:   "foo-data",
+			Target: "/data",
+		},
+	}
+
+	if err := c.Deploy(); err != nil {
+		t.Fatalf("Deploying with fake runtime should succeed, got: %v", err)
+	}
+
+	if _, ok := r.Volumes["foo-data"]; !ok {
+		t.Fatalf("Volume should be created, got: %+v", r.Volumes)
+	}
+
+	c.DesiredState["foo"].Container.Config.Args = []string{"--foo"}
+
+	if err := c.Deploy(); err != nil {
+		t.Fatalf("Recreating container should succeed, got: %v", err)
+	}
+
+	if _, ok := r.Volumes["foo-data"]; !ok {
+		t.Fatalf("Volume should be preserved when container is recreated, got: %+v", r.Volumes)
+	}
+
+	c.DesiredState = container.ContainersState{}
+	c.RemoveVolumes = true
+
+	if err := c.Deploy(); err == nil {
+		t.Fatalf("Removing all containers should fail, when it's not allowed")
+	}
+
+	c.AllowRemoveAll = true
+
+	if err := c.Deploy(); err != nil {
+		t.Fatalf("Removing container should succeed, got: %v", err)
+	}
+
+	if _, ok := r.Volumes["foo-data"]; ok {
+		t.Fatalf("Volume should be removed together with container, got: %+v", r.Volumes)
+	}
+}
+
+func TestDeployCompactState(t *testing.T) {
+	r := NewRuntime()
+
+	c := Containers(r, "foo")
+	c.DesiredState["foo"].ConfigFiles = map[string]string{
+		"/etc/foo": "foo",
+	}
+
+	if err := c.Deploy(); err != nil {
+		t.Fatalf("Deploying with fake runtime should succeed, got: %v", err)
+	}
+
+	co, err := c.New()
+	if err != nil {
+		t.Fatalf("Creating containers should succeed, got: %v", err)
+	}
+
+	id := c.PreviousState["foo"].Container.Status.ID
+
+	compact, contents := co.CompactExport()
+
+	if content := compact.PreviousState["foo"].ConfigFiles["/etc/foo"]; content == "foo" {
+		t.Fatalf("Compact state should not contain content of configuration files")
+	}
+
+	if len(contents) != 1 {
+		t.Fatalf("Expected content of one configuration file, got: %v", contents)
+	}
+
+	compact.DesiredState = c.DesiredState
+
+	if err := compact.Deploy(); err != nil {
+		t.Fatalf("Deploying with compact state should succeed, got: %v", err)
+	}
+
+	if newID := compact.PreviousState["foo"].Container.Status.ID; newID != id {
+		t.Fatalf("Deploying with compact state should not recreate container, expected ID %q, got %q", id, newID)
+	}
+}
+
+func TestDeployCompactStateFailOnUnexpectedDrift(t *testing.T) {
+	r := NewRuntime()
+
+	c := Containers(r, "foo")
+	c.DesiredState["foo"].ConfigFiles = map[string]string{
+		"/etc/foo": "foo",
+	}
+
+	if err := c.Deploy(); err != nil {
+		t.Fatalf("Deploying with fake runtime should succeed, got: %v", err)
+	}
+
+	co, err := c.New()
+	if err != nil {
+		t.Fatalf("Creating containers should succeed, got: %v", err)
+	}
+
+	compact, _ := co.CompactExport()
+	compact.DesiredState = c.DesiredState
+	compact.FailOnUnexpectedDrift = true
+
+	if err := compact.Deploy(); err != nil {
+		t.Fatalf("Configuration files referenced in compact state should not be reported as modified, got: %v", err)
+	}
+}
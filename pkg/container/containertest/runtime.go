@@ -0,0 +1,328 @@
+// Package containertest provides fake container runtime and transport implementations
+// together with helpers, which allow testing containers reconciliation logic without
+// real container runtime or remote hosts.
+package containertest
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+const (
+	// StatusRunning is a status reported for running containers.
+	StatusRunning = "running"
+
+	// StatusExited is a status reported for stopped containers.
+	StatusExited = "exited"
+)
+
+// Container is a container stored in the fake runtime.
+type Container struct {
+	// Config is a configuration, which container has been created with.
+	Config types.ContainerConfig
+
+	// Running controls, if container is running.
+	Running bool
+}
+
+// Runtime is an in-memory implementation of runtime.Runtime. It keeps track of created
+// containers and of files written using it, so it behaves like a real runtime on
+// a single host.
+//
+// Containers, files and errors can be modified directly to program the responses.
+type Runtime struct {
+	// Containers stores containers known to the runtime, indexed by their IDs.
+	Containers map[string]*Container
+
+	// Files stores files written to the host, indexed by their paths. Directories
+	// have paths with trailing slash.
+	Files map[string]*types.File
+
+	// Volumes stores names of named volumes existing in the runtime.
+	Volumes map[string]struct{}
+
+	// Errors allows to make runtime methods fail. Key is a method name, e.g. "Create".
+	Errors map[string]error
+
+	lock   sync.Mutex
+	lastID int
+}
+
+// NewRuntime returns new, empty fake runtime.
+func NewRuntime() *Runtime {
+	return &Runtime{
+		Containers: map[string]*Container{},
+		Files:      map[string]*types.File{},
+		Volumes:    map[string]struct{}{},
+		Errors:     map[string]error{},
+	}
+}
+
+// AddContainer adds container with given configuration to the runtime, as it would
+// exist before and returns it's ID.
+func (r *Runtime) AddContainer(config types.ContainerConfig, running bool) string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.add(config, running)
+}
+
+func (r *Runtime) add(config types.ContainerConfig, running bool) string {
+	r.lastID++
+
+	id := fmt.Sprintf("fake-%d", r.lastID)
+
+	r.Containers[id] = &Container{
+		Config:  config,
+		Running: running,
+	}
+
+	return id
+}
+
+// container returns container with given ID, unless given method is programmed to fail.
+func (r *Runtime) container(method, id string) (*Container, error) {
+	if err := r.Errors[method]; err != nil {
+		return nil, err
+	}
+
+	c, ok := r.Containers[id]
+	if !ok {
+		return nil, fmt.Errorf("container %q does not exist", id)
+	}
+
+	return c, nil
+}
+
+// Create implements runtime.Runtime interface.
+func (r *Runtime) Create(config *types.ContainerConfig) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.Errors["Create"]; err != nil {
+		return "", err
+	}
+
+	return r.add(*config, false), nil
+}
+
+// Delete implements runtime.Runtime interface.
+func (r *Runtime) Delete(id string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, err := r.container("Delete", id); err != nil {
+		return err
+	}
+
+	delete(r.Containers, id)
+
+	return nil
+}
+
+// Start implements runtime.Runtime interface.
+func (r *Runtime) Start(id string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	c, err := r.container("Start", id)
+	if err != nil {
+		return err
+	}
+
+	c.Running = true
+
+	return nil
+}
+
+// Stop implements runtime.Runtime interface.
+func (r *Runtime) Stop(id string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	c, err := r.container("Stop", id)
+	if err != nil {
+		return err
+	}
+
+	c.Running = false
+
+	return nil
+}
+
+// Status implements runtime.Runtime interface. Like Docker runtime, it returns
+// status with empty ID, if container does not exist.
+func (r *Runtime) Status(id string) (types.ContainerStatus, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.Errors["Status"]; err != nil {
+		return types.ContainerStatus{}, err
+	}
+
+	c, ok := r.Containers[id]
+	if !ok {
+		return types.ContainerStatus{}, nil
+	}
+
+	s := types.ContainerStatus{
+		ID:     id,
+		Status: StatusExited,
+	}
+
+	if c.Running {
+		s.Status = StatusRunning
+	}
+
+	return s, nil
+}
+
+// Exists implements runtime.Runtime interface.
+func (r *Runtime) Exists(id string) (types.Existence, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.Errors["Exists"]; err != nil {
+		return types.Existence{Reason: types.ExistenceUnknown}, err
+	}
+
+	if _, ok := r.Containers[id]; !ok {
+		return types.Existence{Reason: types.ExistenceRemoved}, nil
+	}
+
+	return types.Existence{Exists: true, Reason: types.ExistenceFound}, nil
+}
+
+// Copy implements runtime.Runtime interface.
+func (r *Runtime) Copy(id string, files []*types.File) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, err := r.container("Copy", id); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		nf := *f
+		r.Files[f.Path] = &nf
+	}
+
+	return nil
+}
+
+// Read implements runtime.Runtime interface. Files, which does not exist are skipped.
+func (r *Runtime) Read(id string, srcPath []string) ([]*types.File, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, err := r.container("Read", id); err != nil {
+		return nil, err
+	}
+
+	files := []*types.File{}
+
+	for _, p := range srcPath {
+		if f, ok := r.Files[p]; ok {
+			nf := *f
+			files = append(files, &nf)
+		}
+	}
+
+	return files, nil
+}
+
+// Stat implements runtime.Runtime interface. Files, which does not exist are skipped.
+func (r *Runtime) Stat(id string, paths []string) (map[string]os.FileMode, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, err := r.container("Stat", id); err != nil {
+		return nil, err
+	}
+
+	modes := map[string]os.FileMode{}
+
+	for _, p := range paths {
+		if f, ok := r.Files[p]; ok {
+			modes[p] = os.FileMode(f.Mode)
+		}
+
+		if f, ok := r.Files[fmt.Sprintf("%s/", strings.TrimSuffix(p, "/"))]; ok {
+			modes[p] = os.FileMode(f.Mode) | os.ModeDir
+		}
+	}
+
+	return modes, nil
+}
+
+// CreateVolume implements runtime.Runtime interface.
+func (r *Runtime) CreateVolume(name string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.Errors["CreateVolume"]; err != nil {
+		return err
+	}
+
+	r.Volumes[name] = struct{}{}
+
+	return nil
+}
+
+// RemoveVolume implements runtime.Runtime interface.
+func (r *Runtime) RemoveVolume(name string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.Errors["RemoveVolume"]; err != nil {
+		return err
+	}
+
+	delete(r.Volumes, name)
+
+	return nil
+}
+
+// RuntimeConfig implements runtime.Config interface and always returns configured
+// fake runtime.
+type RuntimeConfig struct {
+	// Runtime is a runtime, which will be returned by New().
+	Runtime *Runtime
+
+	// Address is an address of the runtime. It is not used for anything.
+	Address string
+}
+
+// GetAddress implements runtime.Config interface.
+func (c *RuntimeConfig) GetAddress() string {
+	return c.Address
+}
+
+// SetAddress implements runtime.Config interface.
+func (c *RuntimeConfig) SetAddress(a string) {
+	c.Address = a
+}
+
+// Equal allows comparing runtime configurations using go-cmp, as fake runtime
+// contains unexported fields. Configurations are equal, if they use the same runtime.
+func (c *RuntimeConfig) Equal(o *RuntimeConfig) bool {
+	if c == nil || o == nil {
+		return c == o
+	}
+
+	return c.Runtime == o.Runtime
+}
+
+// New implements runtime.Config interface.
+func (c *RuntimeConfig) New() (runtime.Runtime, error) {
+	if c.Runtime == nil {
+		return nil, fmt.Errorf("no runtime defined")
+	}
+
+	return c.Runtime, nil
+}
@@ -0,0 +1,47 @@
+package containertest
+
+import (
+	"github.com/flexkube/libflexkube/pkg/host/transport"
+)
+
+// Transport is a fake implementation of transport.Interface and transport.Connected
+// interfaces. By default, it returns given addresses unmodified, like direct transport.
+type Transport struct {
+	// ConnectErr, if set, will be returned by Connect().
+	ConnectErr error
+
+	// ForwardErr, if set, will be returned by forwarding methods.
+	ForwardErr error
+
+	// Forwarded records all forwarded addresses.
+	Forwarded []string
+}
+
+// Connect implements transport.Interface.
+func (t *Transport) Connect() (transport.Connected, error) {
+	if t.ConnectErr != nil {
+		return nil, t.ConnectErr
+	}
+
+	return t, nil
+}
+
+// ForwardUnixSocket implements transport.Connected.
+func (t *Transport) ForwardUnixSocket(path string) (string, error) {
+	return t.forward(path)
+}
+
+// ForwardTCP implements transport.Connected.
+func (t *Transport) ForwardTCP(address string) (string, error) {
+	return t.forward(address)
+}
+
+func (t *Transport) forward(a string) (string, error) {
+	if t.ForwardErr != nil {
+		return "", t.ForwardErr
+	}
+
+	t.Forwarded = append(t.Forwarded, a)
+
+	return a, nil
+}
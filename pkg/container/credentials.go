@@ -0,0 +1,104 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/internal/util"
+	"github.com/flexkube/libflexkube/pkg/host"
+)
+
+// verifyHost checks connectivity with given host using configured host verifier.
+func (c *containers) verifyHost(h host.Host) error {
+	if c.hostVerifier != nil {
+		return c.hostVerifier(h)
+	}
+
+	return h.Verify()
+}
+
+// credentialsChanged returns names of containers in scope, which are placed on the same host in
+// both current and desired state, but with different transport configuration, e.g. with new
+// SSH private key.
+func (c *containers) credentialsChanged() []string {
+	names := []string{}
+
+	for n, r := range c.currentState {
+		d, ok := c.desiredState[n]
+		if !ok || !c.inScope(n) {
+			continue
+		}
+
+		if r.host.ID() != d.host.ID() || hostDiff(r.host, d.host) == "" {
+			continue
+		}
+
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// hostVerified checks, if given host configuration is already in given list of verified hosts.
+func hostVerified(verified []host.Host, h host.Host) bool {
+	for _, v := range verified {
+		if cmp.Equal(v, h) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RotateTransportCredentials updates transport configuration of containers in the current state
+// with the configuration from the desired state, when both point to the same host and only
+// connection parameters differ, e.g. when SSH private key or password has been changed. This allows
+// to rotate credentials without re-creating the containers, as containers themselves are not touched.
+//
+// Before any container is updated, connectivity with each affected host is verified using new
+// configuration. If connecting to any of the hosts fails, error is returned and current state
+// is left untouched, so old credentials are kept.
+//
+// CheckCurrentState() must be called before calling RotateTransportCredentials().
+func (c *containers) RotateTransportCredentials() error {
+	if c.currentState == nil {
+		return fmt.Errorf("can't rotate credentials without knowing current state of the containers")
+	}
+
+	names := c.credentialsChanged()
+
+	var errors util.ValidateError
+
+	verified := []host.Host{}
+
+	for _, n := range names {
+		h := c.desiredState[n].host
+
+		// Multiple containers may share the same host configuration, so verify it only once.
+		if hostVerified(verified, h) {
+			continue
+		}
+
+		verified = append(verified, h)
+
+		if err := c.verifyHost(h); err != nil {
+			errors = append(errors, fmt.Errorf("failed verifying new credentials for host %s: %w", h.ID(), err))
+		}
+	}
+
+	if err := errors.Return(); err != nil {
+		return err
+	}
+
+	for _, n := range names {
+		c.logf(n, "Rotating transport credentials of container '%s' on host %s", n, c.desiredState[n].host.ID())
+
+		c.currentState[n].host = c.desiredState[n].host
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package container
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/ssh"
+)
+
+func credentialsTestHost(address, password string) host.Host {
+	return host.Host{
+		SSHConfig: &ssh.Config{
+			Address:  address,
+			Port:     22,
+			Password: password,
+		},
+	}
+}
+
+// RotateTransportCredentials() tests.
+func TestRotateTransportCredentials(t *testing.T) {
+	verified := 0
+
+	c := &containers{
+		currentState: containersState{
+			foo: testHostFilterContainer(credentialsTestHost(foo, foo)),
+			bar: testHostFilterContainer(credentialsTestHost(foo, foo)),
+		},
+		desiredState: containersState{
+			foo: testHostFilterContainer(credentialsTestHost(foo, bar)),
+			bar: testHostFilterContainer(credentialsTestHost(foo, bar)),
+		},
+		hostVerifier: func(h host.Host) error {
+			verified++
+
+			return nil
+		},
+	}
+
+	if err := c.RotateTransportCredentials(); err != nil {
+		t.Fatalf("Rotating credentials should succeed, got: %v", err)
+	}
+
+	if verified != 1 {
+		t.Fatalf("Same host configuration should be verified once, got %d verifications", verified)
+	}
+
+	for _, n := range []string{foo, bar} {
+		if p := c.currentState[n].host.SSHConfig.Password; p != bar {
+			t.Fatalf("Container %q should have new credentials, got password %q", n, p)
+		}
+	}
+}
+
+func TestRotateTransportCredentialsVerifyFail(t *testing.T) {
+	c := &containers{
+		currentState: containersState{
+			foo: testHostFilterContainer(credentialsTestHost(foo, foo)),
+			bar: testHostFilterContainer(credentialsTestHost(bar, foo)),
+		},
+		desiredState: containersState{
+			foo: testHostFilterContainer(credentialsTestHost(foo, bar)),
+			bar: testHostFilterContainer(credentialsTestHost(bar, bar)),
+		},
+		hostVerifier: func(h host.Host) error {
+			if h.SSHConfig.Address == bar {
+				return fmt.Errorf("authentication failed")
+			}
+
+			return nil
+		},
+	}
+
+	if err := c.RotateTransportCredentials(); err == nil {
+		t.Fatalf("Rotating credentials should fail, when connecting with new credentials fails")
+	}
+
+	for _, n := range []string{foo, bar} {
+		if p := c.currentState[n].host.SSHConfig.Password; p != foo {
+			t.Fatalf("Container %q should keep old credentials, got password %q", n, p)
+		}
+	}
+}
+
+func TestRotateTransportCredentialsHostChanged(t *testing.T) {
+	c := &containers{
+		currentState: containersState{
+			foo: testHostFilterContainer(credentialsTestHost(foo, foo)),
+		},
+		desiredState: containersState{
+			foo: testHostFilterContainer(credentialsTestHost(bar, bar)),
+		},
+		hostVerifier: func(h host.Host) error {
+			t.Errorf("Moved container should not be verified")
+
+			return nil
+		},
+	}
+
+	if err := c.RotateTransportCredentials(); err != nil {
+		t.Fatalf("Rotating credentials should succeed, got: %v", err)
+	}
+
+	if a := c.currentState[foo].host.SSHConfig.Address; a != foo {
+		t.Fatalf("Container moved to different host should not be updated, got address %q", a)
+	}
+}
+
+func TestRotateTransportCredentialsNoCurrentState(t *testing.T) {
+	c := &containers{}
+
+	if err := c.RotateTransportCredentials(); err == nil {
+		t.Fatalf("Rotating credentials without current state should fail")
+	}
+}
@@ -0,0 +1,114 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/yaml"
+)
+
+// stateFromYaml parses given YAML-serialized Containers and returns containers state from it.
+// If previous state is empty, desired state is returned.
+func stateFromYaml(c []byte) (ContainersState, error) {
+	containers := &Containers{}
+	if err := yaml.Unmarshal(c, containers); err != nil {
+		return nil, fmt.Errorf("failed to parse input yaml: %w", err)
+	}
+
+	if len(containers.PreviousState) > 0 {
+		return containers.PreviousState, nil
+	}
+
+	return containers.DesiredState, nil
+}
+
+// DiffStates compares two YAML-serialized Containers, for example previous state returned by
+// StateToYaml() and proposed desired state, and returns human readable, per-container difference
+// between them. This function does not talk to any host.
+//
+// If given input has no previous state set, it's desired state will be used for comparison.
+//
+// If there are no differences, empty string is returned.
+func DiffStates(a, b []byte) (string, error) {
+	as, err := stateFromYaml(a)
+	if err != nil {
+		return "", fmt.Errorf("failed parsing first state: %w", err)
+	}
+
+	bs, err := stateFromYaml(b)
+	if err != nil {
+		return "", fmt.Errorf("failed parsing second state: %w", err)
+	}
+
+	names := map[string]struct{}{}
+
+	for n := range as {
+		names[n] = struct{}{}
+	}
+
+	for n := range bs {
+		names[n] = struct{}{}
+	}
+
+	sorted := []string{}
+
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+
+	sort.Strings(sorted)
+
+	var diff strings.Builder
+
+	for _, n := range sorted {
+		ac, aok := as[n]
+		bc, bok := bs[n]
+
+		switch {
+		case !aok:
+			fmt.Fprintf(&diff, "Container '%s' added\n", n)
+		case !bok:
+			fmt.Fprintf(&diff, "Container '%s' removed\n", n)
+		default:
+			d := diffHostConfiguredContainers(*ac, *bc)
+
+			switch {
+			case d == "":
+			case labelsOnlyDiff(*ac, *bc):
+				fmt.Fprintf(&diff, "Container '%s' labels changed:\n%s", n, d)
+			case resourceLimitsOnlyDiff(*ac, *bc):
+				fmt.Fprintf(&diff, "Container '%s' resource limits changed:\n%s", n, d)
+			default:
+				fmt.Fprintf(&diff, "Container '%s' changed:\n%s", n, d)
+			}
+		}
+	}
+
+	return diff.String(), nil
+}
+
+// diffHostConfiguredContainers compares two containers, ignoring their status, as it is
+// not part of the configuration.
+func diffHostConfiguredContainers(a, b HostConfiguredContainer) string {
+	a.Container.Status = nil
+	b.Container.Status = nil
+
+	if len(a.ConfigFiles) == 0 {
+		a.ConfigFiles = nil
+	}
+
+	if len(b.ConfigFiles) == 0 {
+		b.ConfigFiles = nil
+	}
+
+	return cmp.Diff(a, b)
+}
+
+// labelsOnlyDiff checks, if two containers are identical, except their labels.
+func labelsOnlyDiff(a, b HostConfiguredContainer) bool {
+	b.Container.Config.Labels = a.Container.Config.Labels
+
+	return diffHostConfiguredContainers(a, b) == ""
+}
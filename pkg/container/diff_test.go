@@ -0,0 +1,118 @@
+package container
+
+import (
+	"strings"
+	"testing"
+)
+
+const diffStatesBase = `
+previousState:
+  foo:
+    host:
+      direct: {}
+    container:
+      runtime:
+        docker: {}
+      config:
+        name: foo
+        image: busybox
+      status:
+        id: foo
+        status: running
+    configFiles:
+      /etc/foo: foo
+  bar:
+    host:
+      direct: {}
+    container:
+      runtime:
+        docker: {}
+      config:
+        name: bar
+        image: busybox
+`
+
+const diffStatesDesired = `
+desiredState:
+  foo:
+    host:
+      direct: {}
+    container:
+      runtime:
+        docker: {}
+      config:
+        name: foo
+        image: busybox
+    configFiles:
+      /etc/foo: bar
+  baz:
+    host:
+      direct: {}
+    container:
+      runtime:
+        docker: {}
+      config:
+        name: baz
+        image: busybox
+`
+
+// DiffStates() tests.
+func TestDiffStatesNoDiff(t *testing.T) {
+	d, err := DiffStates([]byte(diffStatesBase), []byte(diffStatesBase))
+	if err != nil {
+		t.Fatalf("Diffing valid states should work, got: %v", err)
+	}
+
+	if d != "" {
+		t.Fatalf("Diffing the same state should return no difference, got: %s", d)
+	}
+}
+
+func TestDiffStates(t *testing.T) {
+	d, err := DiffStates([]byte(diffStatesBase), []byte(diffStatesDesired))
+	if err != nil {
+		t.Fatalf("Diffing valid states should work, got: %v", err)
+	}
+
+	for _, e := range []string{"Container 'bar' removed", "Container 'baz' added", "Container 'foo' changed", "/etc/foo"} {
+		if !strings.Contains(d, e) {
+			t.Errorf("Expected diff to contain %q, got: %s", e, d)
+		}
+	}
+}
+
+func TestDiffStatesBadYAML(t *testing.T) {
+	if _, err := DiffStates([]byte("foo"), []byte(diffStatesBase)); err == nil {
+		t.Fatalf("Diffing malformed state should fail")
+	}
+
+	if _, err := DiffStates([]byte(diffStatesBase), []byte("foo")); err == nil {
+		t.Fatalf("Diffing malformed state should fail")
+	}
+}
+
+func TestDiffStatesLabelsOnly(t *testing.T) {
+	desired := `
+desiredState:
+  bar:
+    host:
+      direct: {}
+    container:
+      runtime:
+        docker: {}
+      config:
+        name: bar
+        image: busybox
+        labels:
+          foo: bar
+`
+
+	d, err := DiffStates([]byte(diffStatesBase), []byte(desired))
+	if err != nil {
+		t.Fatalf("Diffing valid states should work, got: %v", err)
+	}
+
+	if !strings.Contains(d, "Container 'bar' labels changed") {
+		t.Fatalf("Expected diff to classify labels only change, got: %s", d)
+	}
+}
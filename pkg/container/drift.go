@@ -0,0 +1,66 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// externalDrift compares given previous state of the containers with their current state and
+// returns sorted list of changes, which has been made outside of the deployment, like stopped
+// or removed containers and modified or removed configuration files.
+func externalDrift(previous ContainersState, current containersState) []string {
+	drift := []string{}
+
+	for n, p := range previous {
+		// State of containers, which couldn't be checked is unknown, so drift can't be determined.
+		r, ok := current[n]
+		if !ok || r.container.Status().Status == StatusUnknown {
+			continue
+		}
+
+		if p.Container.Status != nil && p.Container.Status.Running() && !r.container.Status().Running() {
+			drift = append(drift, fmt.Sprintf("container '%s' is not running anymore (status: %s)", n, r.container.Status().Status))
+		}
+
+		if s := p.Container.Status; s != nil && s.ID != "" && r.container.Status().ID != "" && s.ID != r.container.Status().ID {
+			drift = append(drift, fmt.Sprintf("container '%s' has been replaced", n))
+		}
+
+		if configHashMismatch(r, p.Container.Config) {
+			drift = append(drift, fmt.Sprintf("configuration of container '%s' has been modified", n))
+		}
+
+		for f, content := range p.ConfigFiles {
+			c, ok := r.configFiles[f]
+
+			switch {
+			case !ok:
+				drift = append(drift, fmt.Sprintf("configuration file '%s' of container '%s' has been removed", f, n))
+			case !sameConfigFileContent(c, content):
+				drift = append(drift, fmt.Sprintf("configuration file '%s' of container '%s' has been modified", f, n))
+			}
+		}
+	}
+
+	sort.Strings(drift)
+
+	return drift
+}
+
+// checkDrift returns an error, if unexpected external drift has been detected and deployment
+// is configured to fail on it, unless drift has been acknowledged.
+func (c *containers) checkDrift() error {
+	if len(c.drift) == 0 {
+		return nil
+	}
+
+	c.logf("", "Detected changes made outside of the deployment:\n  - %s", strings.Join(c.drift, "\n  - "))
+
+	if !c.failOnUnexpectedDrift || c.acknowledgeDrift {
+		return nil
+	}
+
+	return fmt.Errorf("refusing to deploy, as containers has been changed outside of the deployment, "+
+		"which may be a manual intervention, acknowledge the changes to proceed: %s", strings.Join(c.drift, ", "))
+}
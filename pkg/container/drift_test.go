@@ -0,0 +1,229 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+func driftTestContainer(status types.ContainerStatus, configFiles map[string]string) *hostConfiguredContainer {
+	return &hostConfiguredContainer{
+		configFiles: configFiles,
+		container: &container{
+			base: base{
+				status: status,
+			},
+		},
+	}
+}
+
+// externalDrift() tests.
+func TestExternalDrift(t *testing.T) {
+	previous := ContainersState{
+		foo: {
+			Container: Container{
+				Status: &types.ContainerStatus{
+					ID:     foo,
+					Status: "running",
+				},
+			},
+			ConfigFiles: map[string]string{
+				"/foo": foo,
+				"/bar": bar,
+			},
+		},
+		bar: {
+			Container: Container{
+				Status: &types.ContainerStatus{
+					ID:     bar,
+					Status: "running",
+				},
+			},
+		},
+	}
+
+	current := containersState{
+		foo: driftTestContainer(types.ContainerStatus{ID: foo, Status: "exited"}, map[string]string{
+			"/foo": bar,
+		}),
+		bar: driftTestContainer(types.ContainerStatus{ID: foo, Status: "running"}, nil),
+	}
+
+	expected := []string{
+		"configuration file '/bar' of container 'foo' has been removed",
+		"configuration file '/foo' of container 'foo' has been modified",
+		"container 'bar' has been replaced",
+		"container 'foo' is not running anymore (status: exited)",
+	}
+
+	if diff := cmp.Diff(expected, externalDrift(previous, current)); diff != "" {
+		t.Fatalf("Unexpected drift: %s", diff)
+	}
+}
+
+func TestExternalDriftNoChanges(t *testing.T) {
+	previous := ContainersState{
+		foo: {
+			Container: Container{
+				Status: &types.ContainerStatus{
+					ID:     foo,
+					Status: "running",
+				},
+			},
+			ConfigFiles: map[string]string{
+				"/foo": foo,
+			},
+		},
+	}
+
+	current := containersState{
+		foo: driftTestContainer(types.ContainerStatus{ID: foo, Status: "running"}, map[string]string{
+			"/foo": foo,
+		}),
+	}
+
+	if d := externalDrift(previous, current); len(d) != 0 {
+		t.Fatalf("No drift should be detected, got: %v", d)
+	}
+}
+
+func TestExternalDriftConfigFileReference(t *testing.T) {
+	previous := ContainersState{
+		foo: {
+			ConfigFiles: map[string]string{
+				"/foo": ConfigFileReference(foo),
+				"/bar": ConfigFileReference(bar),
+			},
+		},
+	}
+
+	current := containersState{
+		foo: driftTestContainer(types.ContainerStatus{ID: foo, Status: "running"}, map[string]string{
+			"/foo": foo,
+			"/bar": foo,
+		}),
+	}
+
+	expected := []string{
+		"configuration file '/bar' of container 'foo' has been modified",
+	}
+
+	if diff := cmp.Diff(expected, externalDrift(previous, current)); diff != "" {
+		t.Fatalf("Unexpected drift: %s", diff)
+	}
+}
+
+func TestExternalDriftUnknownState(t *testing.T) {
+	previous := ContainersState{
+		foo: {
+			Container: Container{
+				Status: &types.ContainerStatus{
+					ID:     foo,
+					Status: "running",
+				},
+			},
+			ConfigFiles: map[string]string{
+				"/foo": foo,
+			},
+		},
+	}
+
+	current := containersState{
+		foo: driftTestContainer(types.ContainerStatus{ID: foo, Status: StatusUnknown}, nil),
+	}
+
+	if d := externalDrift(previous, current); len(d) != 0 {
+		t.Fatalf("No drift should be reported for containers with unknown state, got: %v", d)
+	}
+}
+
+func TestExternalDriftConfigHash(t *testing.T) {
+	previous := ContainersState{
+		foo: {
+			Container: Container{
+				Config: types.ContainerConfig{
+					Name: foo,
+				},
+				Status: &types.ContainerStatus{
+					ID:     foo,
+					Status: "running",
+				},
+			},
+		},
+	}
+
+	current := containersState{
+		foo: driftTestContainer(types.ContainerStatus{ID: foo, Status: "running", ConfigHash: bar}, nil),
+	}
+
+	expected := []string{
+		"configuration of container 'foo' has been modified",
+	}
+
+	if diff := cmp.Diff(expected, externalDrift(previous, current)); diff != "" {
+		t.Fatalf("Unexpected drift: %s", diff)
+	}
+}
+
+// checkDrift() tests.
+func TestCheckDrift(t *testing.T) {
+	cases := map[string]struct {
+		containers *containers
+		err        bool
+	}{
+		"no drift": {
+			containers: &containers{
+				failOnUnexpectedDrift: true,
+			},
+		},
+		"drift not failing": {
+			containers: &containers{
+				drift: []string{foo},
+			},
+		},
+		"drift failing": {
+			containers: &containers{
+				drift:                 []string{foo},
+				failOnUnexpectedDrift: true,
+			},
+			err: true,
+		},
+		"drift acknowledged": {
+			containers: &containers{
+				drift:                 []string{foo},
+				failOnUnexpectedDrift: true,
+				acknowledgeDrift:      true,
+			},
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			err := c.containers.checkDrift()
+
+			if c.err && err == nil {
+				t.Fatalf("Expected error")
+			}
+
+			if !c.err && err != nil {
+				t.Fatalf("Didn't expect error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestDeployFailOnUnexpectedDrift(t *testing.T) {
+	c := &containers{
+		currentState:          containersState{},
+		drift:                 []string{foo},
+		failOnUnexpectedDrift: true,
+	}
+
+	if err := c.Deploy(); err == nil {
+		t.Fatalf("Deploy should fail, when unexpected drift is detected")
+	}
+}
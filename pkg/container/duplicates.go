@@ -0,0 +1,78 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+// newestFirst sorts given container instances from the newest to the oldest. Instances
+// created at the same time are sorted by ID, so the order is deterministic.
+func newestFirst(instances []types.ContainerInstance) {
+	sort.Slice(instances, func(i, j int) bool {
+		if !instances[i].Created.Equal(instances[j].Created) {
+			return instances[i].Created.After(instances[j].Created)
+		}
+
+		return instances[i].ID < instances[j].ID
+	})
+}
+
+// reconcileDuplicates finds all containers created for the container and if there is more
+// than one, keeps only the newest one and removes all others. This cleans up leftovers of
+// interrupted deployments. If the newest container is not the one tracked in the state,
+// it becomes the tracked one.
+//
+// If container runtime does not support listing containers, nothing is done.
+func (m *hostConfiguredContainer) reconcileDuplicates(n string) error {
+	return m.withForwardedRuntime(func() error {
+		r := m.container.Runtime()
+
+		l, ok := r.(runtime.Lister)
+		if !ok {
+			return nil
+		}
+
+		instances, err := l.List(m.container.Config().Name)
+		if err != nil {
+			return fmt.Errorf("failed listing containers: %w", err)
+		}
+
+		if len(instances) == 0 || (len(instances) == 1 && instances[0].ID == m.container.Status().ID) {
+			return nil
+		}
+
+		newestFirst(instances)
+
+		keep := instances[0]
+
+		for _, i := range instances[1:] {
+			m.logf(n, "Removing duplicate container %s of '%s', keeping the newest container %s", i.ID, n, keep.ID)
+
+			if err := r.Stop(i.ID); err != nil {
+				return fmt.Errorf("failed stopping duplicate container %s: %w", i.ID, err)
+			}
+
+			if err := r.Delete(i.ID); err != nil {
+				return fmt.Errorf("failed removing duplicate container %s: %w", i.ID, err)
+			}
+		}
+
+		if keep.ID == m.container.Status().ID {
+			return nil
+		}
+
+		m.logf(n, "Using the newest container %s as container '%s'", keep.ID, n)
+
+		s, err := r.Status(keep.ID)
+		if err != nil {
+			return fmt.Errorf("failed getting status of container %s: %w", keep.ID, err)
+		}
+
+		m.container.SetStatus(s)
+
+		return nil
+	})
+}
@@ -0,0 +1,147 @@
+package container
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+func duplicatesTestContainer(id string, r runtime.Runtime) *hostConfiguredContainer {
+	return &hostConfiguredContainer{
+		host: host.Host{
+			DirectConfig: &direct.Config{},
+		},
+		container: &container{
+			base: base{
+				config: types.ContainerConfig{
+					Name: foo,
+				},
+				status: types.ContainerStatus{
+					ID:     id,
+					Status: "running",
+				},
+				runtimeConfig: &runtime.FakeConfig{
+					Runtime: r,
+				},
+			},
+		},
+	}
+}
+
+// newestFirst() tests.
+func TestNewestFirst(t *testing.T) {
+	now := time.Now()
+
+	instances := []types.ContainerInstance{
+		{ID: "b", Created: now.Add(-time.Hour)},
+		{ID: "c", Created: now},
+		{ID: "a", Created: now.Add(-time.Hour)},
+	}
+
+	newestFirst(instances)
+
+	ids := []string{}
+
+	for _, i := range instances {
+		ids = append(ids, i.ID)
+	}
+
+	if diff := cmp.Diff([]string{"c", "a", "b"}, ids); diff != "" {
+		t.Fatalf("Unexpected order of containers: %s", diff)
+	}
+}
+
+// reconcileDuplicates() tests.
+func TestReconcileDuplicatesKeepNewest(t *testing.T) {
+	now := time.Now()
+
+	removed := []string{}
+
+	r := &runtime.FakeLister{
+		Fake: runtime.Fake{
+			StopF: func(id string) error {
+				return nil
+			},
+			DeleteF: func(id string) error {
+				removed = append(removed, id)
+
+				return nil
+			},
+			StatusF: func(id string) (types.ContainerStatus, error) {
+				return types.ContainerStatus{
+					ID:     id,
+					Status: "running",
+				}, nil
+			},
+		},
+		ListF: func(name string) ([]types.ContainerInstance, error) {
+			if name != foo {
+				return nil, fmt.Errorf("unexpected name %q", name)
+			}
+
+			return []types.ContainerInstance{
+				{ID: foo, Created: now.Add(-time.Hour)},
+				{ID: bar, Created: now},
+			}, nil
+		},
+	}
+
+	hcc := duplicatesTestContainer(foo, r)
+
+	if err := hcc.reconcileDuplicates(foo); err != nil {
+		t.Fatalf("Reconciling duplicates should succeed, got: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{foo}, removed); diff != "" {
+		t.Fatalf("Only older container should be removed: %s", diff)
+	}
+
+	if id := hcc.container.Status().ID; id != bar {
+		t.Fatalf("Newest container should become tracked container, got ID %q", id)
+	}
+}
+
+func TestReconcileDuplicatesNoDuplicates(t *testing.T) {
+	r := &runtime.FakeLister{
+		ListF: func(name string) ([]types.ContainerInstance, error) {
+			return []types.ContainerInstance{
+				{ID: foo, Created: time.Now()},
+			}, nil
+		},
+	}
+
+	hcc := duplicatesTestContainer(foo, r)
+
+	if err := hcc.reconcileDuplicates(foo); err != nil {
+		t.Fatalf("Reconciling container without duplicates should succeed, got: %v", err)
+	}
+
+	if id := hcc.container.Status().ID; id != foo {
+		t.Fatalf("Tracked container should not change, got ID %q", id)
+	}
+}
+
+func TestReconcileDuplicatesListFail(t *testing.T) {
+	r := &runtime.FakeLister{
+		ListF: func(name string) ([]types.ContainerInstance, error) {
+			return nil, fmt.Errorf("list failed")
+		},
+	}
+
+	if err := duplicatesTestContainer(foo, r).reconcileDuplicates(foo); err == nil {
+		t.Fatalf("Reconciling duplicates should fail, when listing containers fails")
+	}
+}
+
+func TestReconcileDuplicatesNotSupported(t *testing.T) {
+	if err := duplicatesTestContainer(foo, &runtime.Fake{}).reconcileDuplicates(foo); err != nil {
+		t.Fatalf("Reconciling duplicates should be skipped, when runtime does not support listing, got: %v", err)
+	}
+}
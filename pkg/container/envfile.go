@@ -0,0 +1,111 @@
+package container
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// parseEnvFile parses given content of environment file into list of environment
+// variables in 'KEY=value' format. Empty lines and lines starting with '#' are ignored.
+func parseEnvFile(content string) ([]string, error) {
+	env := []string{}
+
+	for i, l := range strings.Split(content, "\n") {
+		l = strings.TrimSpace(l)
+
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+
+		kv := strings.SplitN(l, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("line %d: expected 'KEY=value' format", i+1)
+		}
+
+		if kv[0] == "" || strings.ContainsAny(kv[0], " \t") {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", i+1, kv[0])
+		}
+
+		env = append(env, l)
+	}
+
+	return env, nil
+}
+
+// validateEnvFiles validates given list of environment files paths.
+func validateEnvFiles(paths []string) error {
+	for i, p := range paths {
+		if !path.IsAbs(p) {
+			return fmt.Errorf("path of environment file at index %d must be absolute, got %q", i, p)
+		}
+	}
+
+	return nil
+}
+
+// readEnvFiles reads environment files of the container from the host using configuration
+// container and returns parsed environment variables in the order of the files. Configuration
+// container must be created before calling this function.
+func (m *hostConfiguredContainer) readEnvFiles() ([]string, error) {
+	paths := m.container.Config().EnvFiles
+
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	files := []string{}
+
+	for _, p := range paths {
+		files = append(files, path.Join(ConfigMountpoint, p))
+	}
+
+	f, err := m.configContainer.Read(files)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading environment files: %w", err)
+	}
+
+	contents := map[string]string{}
+
+	for _, f := range f {
+		contents[f.Path] = f.Content
+	}
+
+	env := []string{}
+
+	for i, p := range paths {
+		content, ok := contents[files[i]]
+		if !ok {
+			return nil, fmt.Errorf("environment file %q does not exist on the host", p)
+		}
+
+		e, err := parseEnvFile(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing environment file %q: %w", p, err)
+		}
+
+		env = append(env, e...)
+	}
+
+	return env, nil
+}
+
+// changedEnvFiles returns list of environment files of desired container, which are managed
+// as configuration files and which content differs from the current state.
+func changedEnvFiles(d hostConfiguredContainer, c hostConfiguredContainer) []string {
+	changed := map[string]struct{}{}
+
+	for _, p := range changedConfigFiles(d, c) {
+		changed[p] = struct{}{}
+	}
+
+	files := []string{}
+
+	for _, p := range d.container.Config().EnvFiles {
+		if _, ok := changed[p]; ok {
+			files = append(files, p)
+		}
+	}
+
+	return files
+}
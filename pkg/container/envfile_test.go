@@ -0,0 +1,125 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+// parseEnvFile() tests.
+func TestParseEnvFile(t *testing.T) {
+	content := `# Comment.
+FOO=bar
+
+  BAR=baz=qux
+EMPTY=
+`
+
+	env, err := parseEnvFile(content)
+	if err != nil {
+		t.Fatalf("Parsing valid environment file should succeed, got: %v", err)
+	}
+
+	expected := []string{"FOO=bar", "BAR=baz=qux", "EMPTY="}
+
+	if diff := cmp.Diff(expected, env); diff != "" {
+		t.Fatalf("Unexpected environment variables: %s", diff)
+	}
+}
+
+func TestParseEnvFileInvalid(t *testing.T) {
+	cases := map[string]string{
+		"no separator":        "FOO",
+		"empty name":          "=foo",
+		"whitespace in name":  "FOO BAR=baz",
+		"invalid second line": "FOO=bar\nBAR",
+	}
+
+	for n, content := range cases {
+		content := content
+
+		t.Run(n, func(t *testing.T) {
+			if _, err := parseEnvFile(content); err == nil {
+				t.Fatalf("Parsing invalid environment file should fail")
+			}
+		})
+	}
+}
+
+// needsRecreate() tests.
+func TestNeedsRecreate(t *testing.T) {
+	cases := map[string]struct {
+		currentConfig types.ContainerConfig
+		currentFiles  map[string]string
+		envFiles      []string
+		expected      bool
+	}{
+		"changed environment file": {
+			currentFiles: map[string]string{"/foo": "FOO=foo"},
+			envFiles:     []string{"/foo"},
+			expected:     true,
+		},
+		"changed configuration file": {
+			currentFiles: map[string]string{"/foo": "FOO=foo"},
+			expected:     false,
+		},
+		"unchanged environment file": {
+			currentFiles: map[string]string{"/foo": "FOO=bar"},
+			envFiles:     []string{"/foo"},
+			expected:     false,
+		},
+		"container will be recreated": {
+			currentConfig: types.ContainerConfig{Image: foo},
+			currentFiles:  map[string]string{"/foo": "FOO=foo"},
+			envFiles:      []string{"/foo"},
+			expected:      false,
+		},
+	}
+
+	for n, testCase := range cases {
+		testCase := testCase
+
+		t.Run(n, func(t *testing.T) {
+			currentConfig := testCase.currentConfig
+			currentConfig.EnvFiles = testCase.envFiles
+
+			c := &containers{
+				desiredState: containersState{
+					foo: &hostConfiguredContainer{
+						container: &container{
+							base: base{
+								config: types.ContainerConfig{
+									EnvFiles: testCase.envFiles,
+								},
+							},
+						},
+						configFiles: map[string]string{
+							"/foo": "FOO=bar",
+						},
+					},
+				},
+				currentState: containersState{
+					foo: &hostConfiguredContainer{
+						container: &container{
+							base: base{
+								config: currentConfig,
+							},
+						},
+						configFiles: testCase.currentFiles,
+					},
+				},
+			}
+
+			r, err := c.needsRecreate(foo)
+			if err != nil {
+				t.Fatalf("Checking if container needs to be recreated should succeed, got: %v", err)
+			}
+
+			if r != testCase.expected {
+				t.Fatalf("Expected recreate to be %t, got %t", testCase.expected, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+// equivalent checks, if container runs with configuration equivalent to the given one, using
+// container runtime. If container runtime does not support comparing configuration, false
+// is returned.
+func (m *hostConfiguredContainer) equivalent(config types.ContainerConfig) (bool, error) {
+	equivalent := false
+
+	err := m.withForwardedRuntime(func() error {
+		c, ok := m.container.Runtime().(runtime.ConfigComparer)
+		if !ok {
+			return nil
+		}
+
+		var err error
+
+		equivalent, err = c.Equivalent(m.container.Status().ID, &config)
+
+		return err
+	})
+
+	return equivalent, err
+}
+
+// adoptEquivalent replaces recorded configuration of existing containers in the current state
+// with desired configuration, if recorded configuration differs, but container runtime reports,
+// that containers run with configuration equivalent to the desired one. This avoids recreating
+// containers, which has been created by other tool or which state has been recorded differently.
+func (c *containers) adoptEquivalent() error {
+	names := []string{}
+
+	for n := range c.currentState {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	for _, n := range names {
+		r := c.currentState[n]
+
+		d, ok := c.desiredState[n]
+		// Containers with configuration hash are compared using the hash.
+		if !ok || !r.container.Status().Exists() || r.container.Status().Status == StatusUnknown ||
+			r.container.Status().ConfigHash != "" {
+			continue
+		}
+
+		config := d.container.Config()
+
+		if cmp.Equal(r.container.Config(), config) ||
+			cmp.Diff(r.container.RuntimeConfig(), d.container.RuntimeConfig()) != "" ||
+			hostDiff(r.host, d.host) != "" {
+			continue
+		}
+
+		equivalent, err := r.equivalent(config)
+		if err != nil {
+			return fmt.Errorf("failed comparing configuration of container %q: %w", n, err)
+		}
+
+		if !equivalent {
+			continue
+		}
+
+		c.logf(n, "Container '%s' runs with configuration equivalent to desired one, it won't be recreated", n)
+
+		r.container = &container{
+			base: base{
+				config:        config,
+				runtime:       r.container.Runtime(),
+				runtimeConfig: r.container.RuntimeConfig(),
+				status:        *r.container.Status(),
+			},
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/runtime/docker"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+func equivalentTestContainers(equivalent bool) *containers {
+	rc := &runtime.FakeConfig{
+		Runtime: &runtime.FakeConfigComparer{
+			EquivalentF: func(id string, config *types.ContainerConfig) (bool, error) {
+				return equivalent, nil
+			},
+		},
+	}
+
+	return &containers{
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{
+				host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				container: &container{
+					base: base{
+						config: types.ContainerConfig{
+							Image: bar,
+						},
+						runtimeConfig: rc,
+					},
+				},
+			},
+		},
+		currentState: containersState{
+			foo: &hostConfiguredContainer{
+				host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				container: &container{
+					base: base{
+						config: types.ContainerConfig{
+							Image: foo,
+						},
+						runtimeConfig: rc,
+						status: types.ContainerStatus{
+							ID:     foo,
+							Status: "running",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// adoptEquivalent() tests.
+func TestAdoptEquivalent(t *testing.T) {
+	c := equivalentTestContainers(true)
+
+	if err := c.adoptEquivalent(); err != nil {
+		t.Fatalf("Adopting equivalent containers should succeed, got: %v", err)
+	}
+
+	if d, err := c.diffContainer(foo); err != nil || d != "" {
+		t.Fatalf("Equivalent container should not have any diff, got: %q, %v", d, err)
+	}
+
+	if c.currentState[foo].container.Status().ID != foo {
+		t.Fatalf("Status of adopted container should be preserved")
+	}
+}
+
+func TestAdoptEquivalentNotEquivalent(t *testing.T) {
+	c := equivalentTestContainers(false)
+
+	if err := c.adoptEquivalent(); err != nil {
+		t.Fatalf("Adopting equivalent containers should succeed, got: %v", err)
+	}
+
+	if c.currentState[foo].container.Config().Image != foo {
+		t.Fatalf("Configuration of not equivalent container should not be changed")
+	}
+}
+
+func TestContainersNewAdoptEquivalentContainers(t *testing.T) {
+	cc := &Containers{
+		DesiredState: ContainersState{
+			foo: &HostConfiguredContainer{
+				Host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				Container: Container{
+					Runtime: RuntimeConfig{
+						Docker: &docker.Config{},
+					},
+					Config: types.ContainerConfig{
+						Name:  foo,
+						Image: foo,
+					},
+				},
+			},
+		},
+		AdoptEquivalentContainers: true,
+	}
+
+	c, err := cc.New()
+	if err != nil {
+		t.Fatalf("Creating containers should succeed, got: %v", err)
+	}
+
+	if !c.(*containers).adoptEquivalentContainers {
+		t.Fatalf("Adopting equivalent containers should be enabled")
+	}
+}
@@ -0,0 +1,121 @@
+package container
+
+// ProgressEventType describes, what kind of action has been performed on the container.
+type ProgressEventType string
+
+const (
+	// ProgressEventStarted is sent, when existing, stopped container has been started.
+	ProgressEventStarted ProgressEventType = "started"
+
+	// ProgressEventConfigured is sent, when configuration files of the container has been updated.
+	ProgressEventConfigured ProgressEventType = "configured"
+
+	// ProgressEventCreated is sent, when new container has been created and started.
+	ProgressEventCreated ProgressEventType = "created"
+
+	// ProgressEventRecreated is sent, when container has been removed and created again,
+	// for example because of configuration drift.
+	ProgressEventRecreated ProgressEventType = "recreated"
+
+	// ProgressEventLabelsUpdated is sent, when labels of the container has been updated
+	// in place, without recreating the container.
+	ProgressEventLabelsUpdated ProgressEventType = "labelsUpdated"
+
+	// ProgressEventResourcesUpdated is sent, when resource limits of the container has been
+	// updated in place, without recreating the container.
+	ProgressEventResourcesUpdated ProgressEventType = "resourcesUpdated"
+
+	// ProgressEventRestarted is sent, when container has been restarted to apply changes
+	// to it's configuration files.
+	ProgressEventRestarted ProgressEventType = "restarted"
+
+	// ProgressEventRemoved is sent, when container, which is no longer desired, has been removed.
+	ProgressEventRemoved ProgressEventType = "removed"
+
+	// ProgressEventFailed is sent, when processing the container failed.
+	ProgressEventFailed ProgressEventType = "failed"
+)
+
+// ProgressEvent is a typed event sent by Deploy() while processing containers. It can be
+// used for example for driving progress bars.
+type ProgressEvent struct {
+	// Type is a type of the event.
+	Type ProgressEventType
+
+	// Container is a name of the container, which this event is about.
+	Container string
+
+	// Error holds the error, which occurred while processing the container. It is only
+	// set for ProgressEventFailed events.
+	Error error
+
+	// Host is an ID of the host, where the container is placed, as returned by host.Host.ID().
+	// If container is moved to a different host, it is ID of the new host. It allows routing
+	// events from different hosts to separate outputs.
+	Host string
+}
+
+// notify sends progress event to the configured events channel.
+//
+// Sending never blocks the deployment. If there is no space left in the channel buffer,
+// event is dropped and counted, so it can be reported at the end of the deployment. It's up
+// to the caller to drain the channel.
+func (c *containers) notify(t ProgressEventType, n string, err error) {
+	if c.events == nil {
+		return
+	}
+
+	select {
+	case c.events <- ProgressEvent{Type: t, Container: n, Error: err, Host: c.eventHosts[n]}:
+	default:
+		c.eventsLock.Lock()
+		c.droppedEvents++
+		c.eventsLock.Unlock()
+	}
+}
+
+// warnDroppedEvents logs a warning, if any progress events has been dropped during the
+// deployment, so consumers like HostSinks can be trusted to be complete otherwise.
+func (c *containers) warnDroppedEvents() {
+	c.eventsLock.Lock()
+	dropped := c.droppedEvents
+	c.droppedEvents = 0
+	c.eventsLock.Unlock()
+
+	if dropped == 0 {
+		return
+	}
+
+	c.warnf("", "%d progress events has been dropped, as events channel was full, consider increasing "+
+		"it's buffer size", dropped)
+}
+
+// recordEventHosts records IDs of hosts of all containers, so they can be included in the events
+// even after the container has been removed from the state. Host from the desired state takes
+// precedence, as container is moved there during the deployment.
+func (c *containers) recordEventHosts() {
+	c.eventHosts = map[string]string{}
+
+	for n, r := range c.currentState {
+		c.eventHosts[n] = r.host.ID()
+	}
+
+	for n, d := range c.desiredState {
+		c.eventHosts[n] = d.host.ID()
+	}
+}
+
+// notifyResult sends either given event or failure event, depending if
+// given error is nil. Given error is always returned, so it can be used
+// as a return statement.
+func (c *containers) notifyResult(t ProgressEventType, n string, err error) error {
+	if err != nil {
+		c.notify(ProgressEventFailed, n, err)
+
+		return err
+	}
+
+	c.notify(t, n, nil)
+
+	return nil
+}
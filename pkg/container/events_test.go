@@ -0,0 +1,173 @@
+package container
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+// notify() tests.
+func TestNotifyNoChannel(t *testing.T) {
+	c := &containers{}
+
+	c.notify(ProgressEventCreated, foo, nil)
+}
+
+func TestNotifyFullChannel(t *testing.T) {
+	events := make(chan ProgressEvent, 1)
+
+	c := &containers{
+		events: events,
+	}
+
+	c.notify(ProgressEventCreated, foo, nil)
+	c.notify(ProgressEventRemoved, bar, nil)
+
+	if l := len(events); l != 1 {
+		t.Fatalf("Expected 1 event in the channel, got %d", l)
+	}
+
+	if e := <-events; e.Type != ProgressEventCreated || e.Container != foo {
+		t.Fatalf("Expected first event to be kept, got: %+v", e)
+	}
+
+	if c.droppedEvents != 1 {
+		t.Fatalf("Expected dropped event to be counted, got %d", c.droppedEvents)
+	}
+}
+
+// warnDroppedEvents() tests.
+func TestWarnDroppedEvents(t *testing.T) {
+	l := &fakeLogger{}
+
+	c := &containers{
+		logger:        l,
+		droppedEvents: 2,
+	}
+
+	c.warnDroppedEvents()
+
+	if len(l.entries) != 1 || l.entries[0].Level != LogLevelWarning {
+		t.Fatalf("Expected warning about dropped events, got: %+v", l.entries)
+	}
+
+	if c.droppedEvents != 0 {
+		t.Fatalf("Dropped events counter should be reset after reporting")
+	}
+
+	c.warnDroppedEvents()
+
+	if len(l.entries) != 1 {
+		t.Fatalf("Nothing should be logged when no events has been dropped, got: %+v", l.entries)
+	}
+}
+
+func TestNotifyHost(t *testing.T) {
+	events := make(chan ProgressEvent, 1)
+
+	c := &containers{
+		events:     events,
+		eventHosts: map[string]string{foo: bar},
+	}
+
+	c.notify(ProgressEventCreated, foo, nil)
+
+	if e := <-events; e.Host != bar {
+		t.Fatalf("Expected event to have host %q, got: %+v", bar, e)
+	}
+}
+
+// recordEventHosts() tests.
+func TestRecordEventHostsDesiredTakesPrecedence(t *testing.T) {
+	c := &containers{
+		currentState: containersState{
+			foo: testHostFilterContainer(sshTestHost("1.1.1.1")),
+			bar: testHostFilterContainer(sshTestHost("2.2.2.2")),
+		},
+		desiredState: containersState{
+			foo: testHostFilterContainer(sshTestHost("3.3.3.3")),
+		},
+	}
+
+	c.recordEventHosts()
+
+	expected := map[string]string{
+		foo: "ssh://3.3.3.3:22",
+		bar: "ssh://2.2.2.2:22",
+	}
+
+	if diff := cmp.Diff(expected, c.eventHosts); diff != "" {
+		t.Fatalf("Unexpected event hosts: %s", diff)
+	}
+}
+
+// notifyResult() tests.
+func TestNotifyResultFailed(t *testing.T) {
+	events := make(chan ProgressEvent, 1)
+
+	c := &containers{
+		events: events,
+	}
+
+	if err := c.notifyResult(ProgressEventCreated, foo, fmt.Errorf("failed")); err == nil {
+		t.Fatalf("notifyResult should return given error")
+	}
+
+	if e := <-events; e.Type != ProgressEventFailed || e.Error == nil {
+		t.Fatalf("Expected failure event with error, got: %+v", e)
+	}
+}
+
+func TestEnsureExistSendsCreatedEvent(t *testing.T) {
+	events := make(chan ProgressEvent, 1)
+
+	c := &containers{
+		events:       events,
+		currentState: containersState{},
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{
+				hooks: &Hooks{},
+				host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				container: &container{
+					base: base{
+						config: types.ContainerConfig{},
+						runtimeConfig: &runtime.FakeConfig{
+							Runtime: &runtime.Fake{
+								CreateF: func(config *types.ContainerConfig) (string, error) {
+									return foo, nil
+								},
+								StatusF: func(id string) (types.ContainerStatus, error) {
+									return types.ContainerStatus{
+										ID: bar,
+									}, nil
+								},
+								DeleteF: func(id string) error {
+									return nil
+								},
+								StartF: func(id string) error {
+									return nil
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if err := c.ensureExists(foo); err != nil {
+		t.Fatalf("Ensuring that new container exists should succeed, got: %v", err)
+	}
+
+	if e := <-events; e.Type != ProgressEventCreated || e.Container != foo {
+		t.Fatalf("Expected created event for container %q, got: %+v", foo, e)
+	}
+}
@@ -0,0 +1,124 @@
+package container
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/flexkube/libflexkube/internal/util"
+)
+
+// defaultHealthCheckTimeout is a default timeout for a single health check request.
+const defaultHealthCheckTimeout = "10s"
+
+// HTTPHealthCheck checks health of the container by sending HTTP GET request to given URL.
+// Container is considered healthy, if the response has 2xx status code.
+//
+// For HTTPS URLs, server certificate is verified using given CA certificate or using system
+// trust store, if no CA certificate is given.
+type HTTPHealthCheck struct {
+	// URL is an URL, which will be requested. Only HTTP and HTTPS URLs are supported.
+	//
+	// Example value: 'https://192.168.1.2:6443/readyz'.
+	URL string `json:"url"`
+
+	// CACertificate is a X.509 CA certificate, PEM encoded, which will be used to verify
+	// server certificate.
+	//
+	// This field is optional.
+	CACertificate string `json:"caCertificate,omitempty"`
+
+	// InsecureSkipVerify disables verification of server certificate. It should only be
+	// used for testing.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// Timeout is a timeout for a single request. If empty, 10 seconds is used.
+	//
+	// Example value: '5s'.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Validate validates health check configuration.
+func (h *HTTPHealthCheck) Validate() error {
+	var errors util.ValidateError
+
+	u, err := url.Parse(h.URL)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("failed parsing URL: %w", err))
+	}
+
+	if err == nil && u.Scheme != "http" && u.Scheme != "https" {
+		errors = append(errors, fmt.Errorf("URL must use http or https scheme, got %q", h.URL))
+	}
+
+	if h.CACertificate != "" && h.InsecureSkipVerify {
+		errors = append(errors, fmt.Errorf("CA certificate can't be used together with skipping verification"))
+	}
+
+	if h.CACertificate != "" {
+		if ok := x509.NewCertPool().AppendCertsFromPEM([]byte(h.CACertificate)); !ok {
+			errors = append(errors, fmt.Errorf("failed parsing CA certificate"))
+		}
+	}
+
+	if _, err := time.ParseDuration(util.PickString(h.Timeout, defaultHealthCheckTimeout)); err != nil {
+		errors = append(errors, fmt.Errorf("failed parsing timeout: %w", err))
+	}
+
+	return errors.Return()
+}
+
+// client returns HTTP client configured according to the health check.
+func (h *HTTPHealthCheck) client() *http.Client {
+	// Errors are checked in Validate().
+	timeout, _ := time.ParseDuration(util.PickString(h.Timeout, defaultHealthCheckTimeout))
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: h.InsecureSkipVerify, //nolint:gosec // Explicitly requested by the user.
+	}
+
+	if h.CACertificate != "" {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM([]byte(h.CACertificate))
+		tlsConfig.RootCAs = pool
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	}
+}
+
+// Check performs the health check and returns error, if the endpoint is not healthy.
+func (h *HTTPHealthCheck) Check() error {
+	if err := h.Validate(); err != nil {
+		return fmt.Errorf("invalid health check configuration: %w", err)
+	}
+
+	resp, err := h.client().Get(h.URL)
+	if err != nil {
+		return fmt.Errorf("failed requesting %q: %w", h.URL, err)
+	}
+
+	if err := resp.Body.Close(); err != nil {
+		return fmt.Errorf("failed closing response body: %w", err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("endpoint %q returned unexpected status code %d", h.URL, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Hook returns health check as a Hook, which can be used as a HealthCheck hook.
+func (h *HTTPHealthCheck) Hook() *Hook {
+	f := Hook(h.Check)
+
+	return &f
+}
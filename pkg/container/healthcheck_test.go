@@ -0,0 +1,86 @@
+package container
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Validate() tests.
+func TestHTTPHealthCheckValidate(t *testing.T) {
+	cases := map[string]struct {
+		healthCheck HTTPHealthCheck
+		expectError bool
+	}{
+		"valid":              {HTTPHealthCheck{URL: "https://localhost:6443/readyz"}, false},
+		"bad scheme":         {HTTPHealthCheck{URL: "tcp://localhost:6443"}, true},
+		"bad CA":             {HTTPHealthCheck{URL: "https://localhost", CACertificate: "foo"}, true},
+		"bad timeout":        {HTTPHealthCheck{URL: "https://localhost", Timeout: "foo"}, true},
+		"CA with insecure":   {HTTPHealthCheck{URL: "https://localhost", CACertificate: "foo", InsecureSkipVerify: true}, true},
+		"insecure allowed":   {HTTPHealthCheck{URL: "https://localhost", InsecureSkipVerify: true}, false},
+		"valid with timeout": {HTTPHealthCheck{URL: "http://localhost", Timeout: "1s"}, false},
+	}
+
+	for n, tc := range cases {
+		tc := tc
+
+		t.Run(n, func(t *testing.T) {
+			err := tc.healthCheck.Validate()
+			if !tc.expectError && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+// Check() tests.
+func TestHTTPHealthCheckVerifyCA(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+
+	ca := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: s.Certificate().Raw,
+	}))
+
+	h := &HTTPHealthCheck{
+		URL:           s.URL,
+		CACertificate: ca,
+	}
+
+	if err := h.Check(); err != nil {
+		t.Fatalf("Health check with valid CA certificate should succeed, got: %v", err)
+	}
+
+	h.CACertificate = ""
+
+	if err := h.Check(); err == nil {
+		t.Fatalf("Health check should fail, when server certificate is not trusted")
+	}
+
+	h.InsecureSkipVerify = true
+
+	if err := h.Check(); err != nil {
+		t.Fatalf("Health check with skipped verification should succeed, got: %v", err)
+	}
+}
+
+func TestHTTPHealthCheckBadStatus(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	h := &HTTPHealthCheck{
+		URL: s.URL,
+	}
+
+	if err := h.Check(); err == nil {
+		t.Fatalf("Health check should fail, when endpoint returns error status code")
+	}
+}
@@ -1,10 +1,14 @@
 package container
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"os"
 	"path"
+	"sort"
+	"strings"
 
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
 	"github.com/flexkube/libflexkube/pkg/container/types"
 	"github.com/flexkube/libflexkube/pkg/host"
 )
@@ -56,14 +60,24 @@ const (
 	// configFileMode is default configuration file permissions.
 	configFileMode = 0o600
 
+	// tempConfigFileSuffix is a suffix of temporary files used for writing configuration files atomically.
+	tempConfigFileSuffix = ".flexkube-tmp"
+
 	// mountpointDirMode is default host mountpoint directory permission.
 	mountpointDirMode = 0o700
+
+	// configDirMode is default permission of directories created for configuration files.
+	configDirMode = 0o755
 )
 
 // Hooks defines type of hooks HostConfiguredContainer supports.
 type Hooks struct {
 	// PostStart hook will be executed after container is started.
 	PostStart *Hook
+
+	// HealthCheck hook should return an error, if the container is not healthy. It is used to verify,
+	// that remaining containers are healthy, while removing containers which are no longer desired.
+	HealthCheck *Hook
 }
 
 // Hook is an action, which may be called before or after certain container operation, like starting or creating.
@@ -81,6 +95,42 @@ type HostConfiguredContainer struct {
 	// on the host, where the container will be created.
 	ConfigFiles map[string]string `json:"configFiles,omitempty"`
 
+	// ConfigFileTypes allows to declare content type of configuration files, using
+	// file path as a key. Content of files with declared type is validated before
+	// writing them to the host.
+	ConfigFileTypes map[string]ConfigFileType `json:"configFileTypes,omitempty"`
+
+	// RestartOnConfigChange controls, if container should be restarted when it's configuration
+	// files change. This should be used for processes, which only read their configuration
+	// on start.
+	RestartOnConfigChange bool `json:"restartOnConfigChange,omitempty"`
+
+	// VerifyConfigFiles controls, if configuration files should be read back from the host after
+	// writing them and compared with the intended content, to detect corruption caused by transport
+	// or filesystem issues. If content differs, deployment fails. As it requires reading all written
+	// files again, it makes configuring files slower.
+	VerifyConfigFiles bool `json:"verifyConfigFiles,omitempty"`
+
+	// UpdateStrategy controls, how changes to the container configuration are applied. With
+	// 'Recreate' strategy, container is always recreated. With 'InPlaceIfPossible' strategy, changes
+	// to labels or resource limits are applied to the existing container, if container runtime
+	// supports it, which avoids restarting the container process. Defaults to 'Recreate'.
+	UpdateStrategy UpdateStrategy `json:"updateStrategy,omitempty"`
+
+	// WaitForHealthy is a list of names of other containers from the same containers group,
+	// which must be running and healthy before this container is created or recreated. Health
+	// is verified using HealthCheck hook of those containers. Containers without health check
+	// are considered healthy, once they are running.
+	WaitForHealthy []string `json:"waitForHealthy,omitempty"`
+
+	// Pod is a name of other container from the same containers group running on the same host,
+	// which acts as a pod container. This container will join network namespace of the pod container,
+	// so they can communicate using localhost, which allows running sidecar containers. Pod container
+	// is created before this container and if it gets recreated, this container is recreated as well.
+	//
+	// Containers in a pod can't expose ports and can't have network mode set.
+	Pod string `json:"pod,omitempty"`
+
 	// Hooks holds all hooks, which will be triggered after certain container actions.
 	//
 	// Due to it's nature, it can only be set programmatically.
@@ -93,8 +143,40 @@ type hostConfiguredContainer struct {
 	container       Interface
 	host            host.Host
 	configFiles     map[string]string
+	configFileTypes map[string]ConfigFileType
 	configContainer InstanceInterface
 	hooks           *Hooks
+
+	// configFilePermissions stores permissions of configuration files read from the host.
+	configFilePermissions map[string]configFilePermissions
+
+	// session is an optional session, which allows to share host connections.
+	session *Session
+
+	// logger is an optional logger for messages about the container. If nil, messages are
+	// written to standard output.
+	logger Logger
+
+	// pinnedImage is a reference to the image returned by image verifier, which is used instead
+	// of configured image when creating the container.
+	pinnedImage string
+
+	restartOnConfigChange bool
+
+	// verifyConfigFiles controls, if written configuration files are read back and verified.
+	verifyConfigFiles bool
+
+	// updateStrategy controls, how changes to the container configuration are applied.
+	updateStrategy UpdateStrategy
+
+	// immutableFiles is a set of configuration files paths, which are not written, if they already exist.
+	immutableFiles map[string]struct{}
+
+	// waitForHealthy is a list of containers, which must be healthy before this container is started.
+	waitForHealthy []string
+
+	// pod is a name of container, which network namespace this container joins.
+	pod string
 }
 
 // New validates HostConfiguredContainer struct and return the interface implementation, which
@@ -107,10 +189,17 @@ func (m *HostConfiguredContainer) New() (HostConfiguredContainerInterface, error
 	c, _ := m.Container.New()
 
 	hcc := &hostConfiguredContainer{
-		container:   c,
-		host:        m.Host,
-		configFiles: m.ConfigFiles,
-		hooks:       m.Hooks,
+		container:       c,
+		host:            m.Host,
+		configFiles:     m.ConfigFiles,
+		configFileTypes: m.ConfigFileTypes,
+		hooks:           m.Hooks,
+
+		restartOnConfigChange: m.RestartOnConfigChange,
+		verifyConfigFiles:     m.VerifyConfigFiles,
+		updateStrategy:        m.UpdateStrategy,
+		waitForHealthy:        m.WaitForHealthy,
+		pod:                   m.Pod,
 	}
 
 	if hcc.hooks == nil {
@@ -130,14 +219,46 @@ func (m *HostConfiguredContainer) Validate() error {
 		return fmt.Errorf("failed to validate host configuration: %w", err)
 	}
 
+	for p, t := range m.ConfigFileTypes {
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("failed to validate type of configuration file %q: %w", p, err)
+		}
+	}
+
+	if err := validateEnvFiles(m.Container.Config.EnvFiles); err != nil {
+		return fmt.Errorf("invalid envFiles: %w", err)
+	}
+
+	if err := m.UpdateStrategy.Validate(); err != nil {
+		return fmt.Errorf("invalid updateStrategy: %w", err)
+	}
+
+	for i, w := range m.WaitForHealthy {
+		if w == "" {
+			return fmt.Errorf("name of container to wait for at index %d is empty", i)
+		}
+	}
+
 	return nil
 }
 
 // connectAndForward instantiates new host object, connects to it and then
-// forwards given UNIX socket using this connection.
+// forwards given UNIX socket using this connection. If session is configured,
+// forwarding is reused between operations.
 //
 // It returns address of local UNIX socket, where user can connect.
 func (m *hostConfiguredContainer) connectAndForward(a string) (string, error) {
+	if m.session != nil {
+		return m.session.forward(m.host, a, func() (string, error) {
+			return m.connect(a)
+		})
+	}
+
+	return m.connect(a)
+}
+
+// connect connects to the host and forwards given UNIX socket using this connection.
+func (m *hostConfiguredContainer) connect(a string) (string, error) {
 	h, err := m.host.New()
 	if err != nil {
 		return "", err
@@ -194,6 +315,16 @@ func (m *hostConfiguredContainer) withForwardedRuntime(action func() error) erro
 	return action()
 }
 
+// image returns image, which should be used for creating containers. If image has been pinned
+// by image verifier, pinned image is returned, so exactly verified image is used.
+func (m *hostConfiguredContainer) image() string {
+	if m.pinnedImage != "" {
+		return m.pinnedImage
+	}
+
+	return m.container.Config().Image
+}
+
 // createConfigurationContainer creates container used for reading and updating configuration and
 // stores saves it reference.
 func (m *hostConfiguredContainer) createConfigurationContainer() error {
@@ -201,7 +332,7 @@ func (m *hostConfiguredContainer) createConfigurationContainer() error {
 		base: base{
 			config: types.ContainerConfig{
 				Name:  fmt.Sprintf("%s-config", m.container.Config().Name),
-				Image: m.container.Config().Image,
+				Image: m.image(),
 				Mounts: []types.Mount{
 					{
 						Source: "/",
@@ -240,8 +371,8 @@ func (m *hostConfiguredContainer) removeConfigurationContainer() error {
 	return m.configContainer.Delete()
 }
 
-// updateConfigurationStatus overrides configFiles field with current content of configuration files.
-// If configuration file is missing, the entry is removed from the map.
+// updateConfigurationStatus overrides configFiles field with current content of configuration files
+// and stores their permissions. If configuration file is missing, the entry is removed from the map.
 func (m *hostConfiguredContainer) updateConfigurationStatus() error {
 	// If there is no config files configured, don't do anything.
 	if len(m.configFiles) == 0 {
@@ -267,9 +398,19 @@ func (m *hostConfiguredContainer) updateConfigurationStatus() error {
 	}
 
 	m.configFiles = map[string]string{}
+	m.configFilePermissions = map[string]configFilePermissions{}
 
 	for _, f := range f {
 		m.configFiles[paths[f.Path]] = f.Content
+
+		// Mode is not reported by all runtimes, so only track permissions, if it's known.
+		if f.Mode != 0 {
+			m.configFilePermissions[paths[f.Path]] = configFilePermissions{
+				mode:  f.Mode,
+				user:  f.User,
+				group: f.Group,
+			}
+		}
 	}
 
 	return nil
@@ -288,7 +429,7 @@ func (m *hostConfiguredContainer) withConfigurationContainer(action func() error
 
 	defer func() {
 		if err := m.removeConfigurationContainer(); err != nil {
-			fmt.Printf("Removing configuration container failed: %v", err)
+			m.warnf(m.container.Config().Name, "Removing configuration container failed: %v", err)
 		}
 	}()
 
@@ -317,17 +458,158 @@ func (m *hostConfiguredContainer) ConfigurationStatus() error {
 // user can override ConfigImage field in the configuration, to specify different image which should be
 // pulled and used for configuration management.
 func (m *hostConfiguredContainer) Configure(paths []string) error {
+	if err := m.validateConfigFiles(paths); err != nil {
+		return fmt.Errorf("refusing to write malformed configuration files: %w", err)
+	}
+
 	return m.withForwardedRuntime(func() error {
 		return m.withConfigurationContainer(func() error {
-			return m.copyConfigFiles(paths)
+			if err := m.createConfigDirs(paths); err != nil {
+				return fmt.Errorf("failed creating directories for configuration files: %w", err)
+			}
+
+			if err := m.copyConfigFiles(paths); err != nil {
+				return err
+			}
+
+			if !m.verifyConfigFiles {
+				return nil
+			}
+
+			return m.verifyWrittenConfigFiles(paths)
 		})
 	})
 }
 
+// validateConfigFiles validates content of given configuration files, which have type declared.
+func (m *hostConfiguredContainer) validateConfigFiles(paths []string) error {
+	for _, p := range paths {
+		t, ok := m.configFileTypes[p]
+		if !ok {
+			continue
+		}
+
+		if err := t.ValidateContent(m.configFiles[p]); err != nil {
+			return fmt.Errorf("configuration file %q is not valid %s: %w", p, t, err)
+		}
+	}
+
+	return nil
+}
+
+// configDirs returns sorted list of all parent directories of given configuration files,
+// so parent directories always come before their children.
+func configDirs(paths []string) []string {
+	dirs := map[string]struct{}{}
+
+	for _, p := range paths {
+		for d := path.Dir(p); d != "/" && d != "."; d = path.Dir(d) {
+			dirs[d] = struct{}{}
+		}
+	}
+
+	r := []string{}
+
+	for d := range dirs {
+		r = append(r, d)
+	}
+
+	sort.Strings(r)
+
+	return r
+}
+
+// createConfigDirs makes sure, that parent directories of given configuration files exist
+// on the host. Missing directories are created with configDirMode permissions and are owned
+// by the user and group of the container, same as configuration files. If any of the parent
+// paths exists, but it's not a directory, error is returned, as files can't be written there.
+//
+// This function requires functional config container.
+func (m *hostConfiguredContainer) createConfigDirs(paths []string) error {
+	dirs := configDirs(paths)
+
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	cpaths := []string{}
+
+	for _, d := range dirs {
+		cpaths = append(cpaths, path.Join(ConfigMountpoint, d))
+	}
+
+	rc, err := m.configContainer.Stat(cpaths)
+	if err != nil {
+		return fmt.Errorf("failed checking if directories exist: %w", err)
+	}
+
+	files := []*types.File{}
+
+	for i, d := range dirs {
+		fm, exists := rc[cpaths[i]]
+
+		if exists && !fm.IsDir() {
+			return fmt.Errorf("path %s exists, but it is not a directory", d)
+		}
+
+		if !exists {
+			files = append(files, &types.File{
+				Path:  fmt.Sprintf("%s/", cpaths[i]),
+				Mode:  configDirMode,
+				User:  m.container.Config().User,
+				Group: m.container.Config().Group,
+			})
+		}
+	}
+
+	// If all directories exist, don't call the runtime again.
+	if len(files) == 0 {
+		return nil
+	}
+
+	return m.configContainer.Copy(files)
+}
+
+// tempConfigFilePath returns path of the temporary file, which is used for writing given
+// configuration file atomically. Temporary file is placed in the same directory as the
+// destination file, so both are on the same filesystem and rename is atomic.
+func tempConfigFilePath(p string) string {
+	dir, file := path.Split(p)
+
+	return path.Join(dir, fmt.Sprintf(".%s%s", file, tempConfigFileSuffix))
+}
+
 // copyConfigFiles takes list of configuration files which should be created in the container
 // and creates them in batch. This function requires functional config container.
+//
+// If container runtime supports renaming files, files are first written to the temporary
+// location and then renamed into place, so partially written files are never observed.
+// If files can't be renamed in the configuration container, e.g. because the image has
+// no shell, files are written directly.
 func (m *hostConfiguredContainer) copyConfigFiles(paths []string) error {
+	renamer, atomic := m.container.Runtime().(runtime.FileRenamer)
+
+	id := ""
+
+	if atomic {
+		s, err := m.configContainer.Status()
+		if err != nil {
+			return fmt.Errorf("getting config container status failed: %w", err)
+		}
+
+		id = s.ID
+
+		if atomic, err = renamer.CanRename(id); err != nil {
+			return fmt.Errorf("checking if configuration files can be renamed failed: %w", err)
+		}
+
+		if !atomic {
+			m.logf(m.container.Config().Name, "Image %q can't rename files, writing configuration files directly", m.image())
+		}
+	}
+
 	files := []*types.File{}
+	renames := map[string]string{}
 
 	for _, p := range paths {
 		content, exists := m.configFiles[p]
@@ -335,8 +617,16 @@ func (m *hostConfiguredContainer) copyConfigFiles(paths []string) error {
 			return fmt.Errorf("can't configure file which do not exist: %s", p)
 		}
 
+		dst := path.Join(ConfigMountpoint, p)
+		src := dst
+
+		if atomic {
+			src = tempConfigFilePath(dst)
+			renames[src] = dst
+		}
+
 		files = append(files, &types.File{
-			Path:    path.Join(ConfigMountpoint, p),
+			Path:    src,
 			Content: content,
 			Mode:    configFileMode,
 			User:    m.container.Config().User,
@@ -348,6 +638,56 @@ func (m *hostConfiguredContainer) copyConfigFiles(paths []string) error {
 		return err
 	}
 
+	if !atomic {
+		return nil
+	}
+
+	warnings, err := renamer.Rename(id, renames)
+	if err != nil {
+		return fmt.Errorf("moving configuration files into place failed: %w", err)
+	}
+
+	for _, w := range warnings {
+		m.warnf(m.container.Config().Name, "Moving configuration files into place: %s", w)
+	}
+
+	return nil
+}
+
+// verifyWrittenConfigFiles reads given configuration files back from the host and checks, that
+// their content matches the intended content, by comparing SHA-256 hashes. This function requires
+// functional config container.
+func (m *hostConfiguredContainer) verifyWrittenConfigFiles(paths []string) error {
+	cpaths := []string{}
+
+	for _, p := range paths {
+		cpaths = append(cpaths, path.Join(ConfigMountpoint, p))
+	}
+
+	files, err := m.configContainer.Read(cpaths)
+	if err != nil {
+		return fmt.Errorf("failed reading back configuration files: %w", err)
+	}
+
+	written := map[string][sha256.Size]byte{}
+
+	for _, f := range files {
+		written[f.Path] = sha256.Sum256([]byte(f.Content))
+	}
+
+	corrupted := []string{}
+
+	for i, p := range paths {
+		if h, ok := written[cpaths[i]]; !ok || h != sha256.Sum256([]byte(m.configFiles[p])) {
+			corrupted = append(corrupted, p)
+		}
+	}
+
+	if len(corrupted) > 0 {
+		return fmt.Errorf("content of configuration files %s read back from the host does not match "+
+			"written content", strings.Join(corrupted, ", "))
+	}
+
 	return nil
 }
 
@@ -431,7 +771,28 @@ func (m *hostConfiguredContainer) Create() error {
 				return fmt.Errorf("failed creating missing mountpoints: %w", err)
 			}
 
-			i, err := m.container.Create()
+			env, err := m.readEnvFiles()
+			if err != nil {
+				return err
+			}
+
+			config := m.container.Config()
+			config.ConfigHash = configHash(config)
+			config.Env = append(env, config.Env...)
+
+			config.Image = m.image()
+
+			// Create container with environment variables from environment files included
+			// and verified image, while keeping the configuration of the container as it
+			// was defined.
+			c := &container{
+				base: base{
+					config:  config,
+					runtime: m.container.Runtime(),
+				},
+			}
+
+			i, err := c.Create()
 			if err != nil {
 				return fmt.Errorf("failed creating container: %w", err)
 			}
@@ -458,6 +819,25 @@ func (m *hostConfiguredContainer) Status() error {
 	return m.withForwardedRuntime(m.container.UpdateStatus)
 }
 
+// Exists checks, if the container exists on the host and returns the reason.
+func (m *hostConfiguredContainer) Exists() (types.Existence, error) {
+	if !m.container.Status().Exists() {
+		return m.container.Exists()
+	}
+
+	var e types.Existence
+
+	err := m.withForwardedRuntime(func() error {
+		var err error
+
+		e, err = m.container.Exists()
+
+		return err
+	})
+
+	return e, err
+}
+
 // Start starts created container.
 func (m *hostConfiguredContainer) Start() error {
 	return withHook(nil, func() error {
@@ -475,6 +855,88 @@ func (m *hostConfiguredContainer) Delete() error {
 	return m.withForwardedRuntime(m.container.Delete)
 }
 
+// supportsLabelsUpdate checks, if container runtime of the container is able to update
+// labels of existing container without recreating it.
+func (m *hostConfiguredContainer) supportsLabelsUpdate() bool {
+	r, err := m.container.RuntimeConfig().New()
+	if err != nil {
+		return false
+	}
+
+	_, ok := r.(runtime.LabelsUpdater)
+
+	return ok
+}
+
+// updateLabels replaces labels of existing container with given ones.
+func (m *hostConfiguredContainer) updateLabels(labels map[string]string) error {
+	return m.withForwardedRuntime(func() error {
+		u, ok := m.container.Runtime().(runtime.LabelsUpdater)
+		if !ok {
+			return fmt.Errorf("container runtime does not support updating labels")
+		}
+
+		return u.UpdateLabels(m.container.Status().ID, labels)
+	})
+}
+
+// supportsResourcesUpdate checks, if container runtime of the container is able to update
+// resource limits of existing container without recreating it.
+func (m *hostConfiguredContainer) supportsResourcesUpdate() bool {
+	r, err := m.container.RuntimeConfig().New()
+	if err != nil {
+		return false
+	}
+
+	_, ok := r.(runtime.ResourcesUpdater)
+
+	return ok
+}
+
+// updateResources sets resource limits of existing container to given ones.
+func (m *hostConfiguredContainer) updateResources(limits types.ResourceLimits) error {
+	return m.withForwardedRuntime(func() error {
+		u, ok := m.container.Runtime().(runtime.ResourcesUpdater)
+		if !ok {
+			return fmt.Errorf("container runtime does not support updating resource limits")
+		}
+
+		warnings, err := u.UpdateResources(m.container.Status().ID, limits)
+		if err != nil {
+			return err
+		}
+
+		for _, w := range warnings {
+			m.warnf(m.container.Config().Name, "Updating resources of container: %s", w)
+		}
+
+		return nil
+	})
+}
+
+// removeVolumes removes named volumes used by the container.
+func (m *hostConfiguredContainer) removeVolumes() error {
+	return m.withForwardedRuntime(m.container.RemoveVolumes)
+}
+
+// remove stops the container if it's running and removes it, if it exists.
+func (m *hostConfiguredContainer) remove() error {
+	status := m.container.Status()
+	if status.Running() || status.Restarting() {
+		if err := m.Stop(); err != nil {
+			return fmt.Errorf("failed stopping container: %w", err)
+		}
+	}
+
+	if m.container.Status().Exists() {
+		if err := m.Delete(); err != nil {
+			return fmt.Errorf("failed removing container: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // withHook wraps given action function with pre and post functionality.
 //
 // This allows to inject custom actions before and after hostConfiguredContainer operations.
@@ -5,11 +5,13 @@ import (
 	"net"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 
 	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/runtime/docker"
 	"github.com/flexkube/libflexkube/pkg/container/types"
 	"github.com/flexkube/libflexkube/pkg/host"
 	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
@@ -757,6 +759,53 @@ func TestHostConfiguredContainerCreate(t *testing.T) {
 	}
 }
 
+func TestHostConfiguredContainerCreatePinnedImage(t *testing.T) {
+	images := []string{}
+
+	h := &hostConfiguredContainer{
+		host: host.Host{
+			DirectConfig: &direct.Config{},
+		},
+		pinnedImage: "foo@sha256:bar",
+		container: &container{
+			base{
+				runtimeConfig: &runtime.FakeConfig{
+					Runtime: &runtime.Fake{
+						CreateF: func(config *types.ContainerConfig) (string, error) {
+							images = append(images, config.Image)
+
+							return foo, nil
+						},
+						DeleteF: func(id string) error {
+							return nil
+						},
+						StatusF: func(id string) (types.ContainerStatus, error) {
+							return types.ContainerStatus{
+								ID: "bar",
+							}, nil
+						},
+					},
+				},
+				config: types.ContainerConfig{
+					Image: foo,
+				},
+			},
+		},
+	}
+
+	if err := h.Create(); err != nil {
+		t.Fatalf("create should succeed, got: %v", err)
+	}
+
+	if len(images) == 0 || images[len(images)-1] != "foo@sha256:bar" {
+		t.Fatalf("container should be created from pinned image, got: %v", images)
+	}
+
+	if i := h.container.Config().Image; i != foo {
+		t.Fatalf("configured image should not be modified, got %q", i)
+	}
+}
+
 // updateConfigurationStatus() tests.
 func TestHostConfiguredContainerUpdateConfigurationStatusNoAction(t *testing.T) {
 	h := &hostConfiguredContainer{
@@ -894,3 +943,447 @@ func TestHostConfiguredContainerUpdateConfigurationStatusReadRuntimeError(t *tes
 		t.Fatalf("Updating configuration status should return error when runtime read fails")
 	}
 }
+
+// Validate() tests.
+func TestHostConfiguredContainerValidateConfigFileTypes(t *testing.T) {
+	h := &HostConfiguredContainer{
+		Host: host.Host{
+			DirectConfig: &direct.Config{},
+		},
+		Container: Container{
+			Runtime: RuntimeConfig{
+				Docker: &docker.Config{},
+			},
+			Config: types.ContainerConfig{
+				Name:  "foo",
+				Image: "busybox:latest",
+			},
+		},
+		ConfigFileTypes: map[string]ConfigFileType{
+			"/foo": ConfigFileType("bar"),
+		},
+	}
+
+	if err := h.Validate(); err == nil {
+		t.Fatalf("validating configuration file with unsupported type should fail")
+	}
+}
+
+func TestHostConfiguredContainerValidateRelativeEnvFile(t *testing.T) {
+	h := &HostConfiguredContainer{
+		Host: host.Host{
+			DirectConfig: &direct.Config{},
+		},
+		Container: Container{
+			Runtime: RuntimeConfig{
+				Docker: &docker.Config{},
+			},
+			Config: types.ContainerConfig{
+				Name:     "foo",
+				Image:    "busybox:latest",
+				EnvFiles: []string{"foo.env"},
+			},
+		},
+	}
+
+	if err := h.Validate(); err == nil {
+		t.Fatalf("validating container with relative environment file path should fail")
+	}
+}
+
+// Configure() tests.
+func TestHostConfiguredContainerConfigureMalformedFile(t *testing.T) {
+	h := &hostConfiguredContainer{
+		configFiles: map[string]string{
+			"/foo": "foo: [bar",
+		},
+		configFileTypes: map[string]ConfigFileType{
+			"/foo": ConfigFileTypeYAML,
+		},
+	}
+
+	// Validation must happen before connecting to the host, so nothing else must be set.
+	if err := h.Configure([]string{"/foo"}); err == nil {
+		t.Fatalf("configuring malformed YAML file should fail")
+	}
+}
+
+// configDirs() tests.
+func TestConfigDirs(t *testing.T) {
+	expected := []string{"/etc", "/etc/foo", "/etc/foo/bar", "/var", "/var/lib"}
+
+	dirs := configDirs([]string{"/etc/foo/bar/baz", "/etc/foo/qux", "/var/lib/foo", "/foo"})
+
+	if diff := cmp.Diff(expected, dirs); diff != "" {
+		t.Fatalf("Unexpected directories: %s", diff)
+	}
+}
+
+// createConfigDirs() tests.
+func TestCreateConfigDirs(t *testing.T) {
+	var created []*types.File
+
+	r := &runtime.Fake{
+		StatF: func(id string, paths []string) (map[string]os.FileMode, error) {
+			return map[string]os.FileMode{
+				path.Join(ConfigMountpoint, "/etc"): os.ModeDir,
+			}, nil
+		},
+		CopyF: func(id string, files []*types.File) error {
+			created = files
+
+			return nil
+		},
+	}
+
+	h := &hostConfiguredContainer{
+		configContainer: &containerInstance{
+			base: base{
+				runtime: r,
+			},
+		},
+		container: &container{
+			base: base{
+				config: types.ContainerConfig{
+					User:  foo,
+					Group: bar,
+				},
+			},
+		},
+	}
+
+	if err := h.createConfigDirs([]string{"/etc/foo/bar"}); err != nil {
+		t.Fatalf("Creating directories should succeed, got: %v", err)
+	}
+
+	expected := []*types.File{
+		{
+			Path:  path.Join(ConfigMountpoint, "/etc/foo") + "/",
+			Mode:  configDirMode,
+			User:  foo,
+			Group: bar,
+		},
+	}
+
+	if diff := cmp.Diff(expected, created); diff != "" {
+		t.Fatalf("Only missing directories should be created: %s", diff)
+	}
+}
+
+func TestCreateConfigDirsNotDirectory(t *testing.T) {
+	r := &runtime.Fake{
+		StatF: func(id string, paths []string) (map[string]os.FileMode, error) {
+			return map[string]os.FileMode{
+				path.Join(ConfigMountpoint, "/etc"): 0o644,
+			}, nil
+		},
+		CopyF: func(id string, files []*types.File) error {
+			t.Fatalf("Nothing should be created, when parent path is a file")
+
+			return nil
+		},
+	}
+
+	h := &hostConfiguredContainer{
+		configContainer: &containerInstance{
+			base: base{
+				runtime: r,
+			},
+		},
+		container: &container{},
+	}
+
+	if err := h.createConfigDirs([]string{"/etc/foo"}); err == nil {
+		t.Fatalf("Creating directories should fail, when parent path is a file")
+	}
+}
+
+func TestCreateConfigDirsStatFail(t *testing.T) {
+	h := &hostConfiguredContainer{
+		configContainer: &containerInstance{
+			base: base{
+				runtime: &runtime.Fake{
+					StatF: func(id string, paths []string) (map[string]os.FileMode, error) {
+						return nil, fmt.Errorf("stat failed")
+					},
+				},
+			},
+		},
+		container: &container{},
+	}
+
+	if err := h.createConfigDirs([]string{"/etc/foo"}); err == nil {
+		t.Fatalf("Creating directories should fail, when checking existing directories fails")
+	}
+}
+
+// copyConfigFiles() tests.
+func TestHostConfiguredContainerCopyConfigFilesNoRename(t *testing.T) {
+	var copied []*types.File
+
+	r := &runtime.Fake{
+		CopyF: func(id string, files []*types.File) error {
+			copied = files
+
+			return nil
+		},
+	}
+
+	h := &hostConfiguredContainer{
+		configFiles: map[string]string{
+			"/foo": foo,
+		},
+		configContainer: &containerInstance{
+			base: base{
+				runtime: r,
+			},
+		},
+		container: &container{
+			base: base{
+				runtime: r,
+			},
+		},
+	}
+
+	if err := h.copyConfigFiles([]string{"/foo"}); err != nil {
+		t.Fatalf("Copying configuration files should succeed, got: %v", err)
+	}
+
+	if len(copied) != 1 || copied[0].Path != path.Join(ConfigMountpoint, "/foo") {
+		t.Fatalf("File should be written directly to the destination when runtime does not support renaming, got: %+v", copied)
+	}
+}
+
+func TestHostConfiguredContainerCopyConfigFilesAtomic(t *testing.T) {
+	var copied []*types.File
+
+	renamed := map[string]string{}
+
+	r := &runtime.FakeFileRenamer{
+		Fake: runtime.Fake{
+			CopyF: func(id string, files []*types.File) error {
+				copied = files
+
+				return nil
+			},
+			StatusF: func(id string) (types.ContainerStatus, error) {
+				return types.ContainerStatus{
+					ID: id,
+				}, nil
+			},
+		},
+		RenameF: func(id string, paths map[string]string) ([]string, error) {
+			if id != foo {
+				t.Errorf("Files should be renamed in config container %q, got %q", foo, id)
+			}
+
+			renamed = paths
+
+			return []string{bar}, nil
+		},
+	}
+
+	l := &fakeLogger{}
+
+	h := &hostConfiguredContainer{
+		logger: l,
+		configFiles: map[string]string{
+			"/etc/foo": foo,
+		},
+		configContainer: &containerInstance{
+			base: base{
+				runtime: r,
+				status: types.ContainerStatus{
+					ID: foo,
+				},
+			},
+		},
+		container: &container{
+			base: base{
+				runtime: r,
+			},
+		},
+	}
+
+	if err := h.copyConfigFiles([]string{"/etc/foo"}); err != nil {
+		t.Fatalf("Copying configuration files should succeed, got: %v", err)
+	}
+
+	tmp := path.Join(ConfigMountpoint, "/etc/.foo"+tempConfigFileSuffix)
+
+	if len(copied) != 1 || copied[0].Path != tmp {
+		t.Fatalf("File should be written to temporary path %q, got: %+v", tmp, copied)
+	}
+
+	expected := map[string]string{
+		tmp: path.Join(ConfigMountpoint, "/etc/foo"),
+	}
+
+	if diff := cmp.Diff(expected, renamed); diff != "" {
+		t.Fatalf("Unexpected rename paths: %s", diff)
+	}
+
+	if len(l.entries) != 1 || l.entries[0].Level != LogLevelWarning {
+		t.Fatalf("Warning returned by runtime should be logged, got: %+v", l.entries)
+	}
+}
+
+func TestHostConfiguredContainerCopyConfigFilesNoShell(t *testing.T) {
+	var copied []*types.File
+
+	r := &runtime.FakeFileRenamer{
+		Fake: runtime.Fake{
+			CopyF: func(id string, files []*types.File) error {
+				copied = files
+
+				return nil
+			},
+			StatusF: func(id string) (types.ContainerStatus, error) {
+				return types.ContainerStatus{
+					ID: id,
+				}, nil
+			},
+		},
+		CanRenameF: func(id string) (bool, error) {
+			return false, nil
+		},
+		RenameF: func(id string, paths map[string]string) ([]string, error) {
+			t.Errorf("Files should not be renamed, when renaming is not possible")
+
+			return nil, nil
+		},
+	}
+
+	h := &hostConfiguredContainer{
+		logger: &fakeLogger{},
+		configFiles: map[string]string{
+			"/foo": foo,
+		},
+		configContainer: &containerInstance{
+			base: base{
+				runtime: r,
+				status: types.ContainerStatus{
+					ID: foo,
+				},
+			},
+		},
+		container: &container{
+			base: base{
+				runtime: r,
+			},
+		},
+	}
+
+	if err := h.copyConfigFiles([]string{"/foo"}); err != nil {
+		t.Fatalf("Copying configuration files should succeed, got: %v", err)
+	}
+
+	if len(copied) != 1 || copied[0].Path != path.Join(ConfigMountpoint, "/foo") {
+		t.Fatalf("File should be written directly to the destination when renaming is not possible, got: %+v", copied)
+	}
+}
+
+func TestHostConfiguredContainerCopyConfigFilesRenameFail(t *testing.T) {
+	r := &runtime.FakeFileRenamer{
+		Fake: runtime.Fake{
+			CopyF: func(id string, files []*types.File) error {
+				return nil
+			},
+			StatusF: func(id string) (types.ContainerStatus, error) {
+				return types.ContainerStatus{
+					ID: id,
+				}, nil
+			},
+		},
+		RenameF: func(id string, paths map[string]string) ([]string, error) {
+			return nil, fmt.Errorf("renaming failed")
+		},
+	}
+
+	h := &hostConfiguredContainer{
+		configFiles: map[string]string{
+			"/foo": foo,
+		},
+		configContainer: &containerInstance{
+			base: base{
+				runtime: r,
+			},
+		},
+		container: &container{
+			base: base{
+				runtime: r,
+			},
+		},
+	}
+
+	if err := h.copyConfigFiles([]string{"/foo"}); err == nil {
+		t.Fatalf("Copying configuration files should fail when renaming fails")
+	}
+}
+
+// verifyWrittenConfigFiles() tests.
+func verifyConfigFilesTestContainer(read map[string]string) *hostConfiguredContainer {
+	return &hostConfiguredContainer{
+		configFiles: map[string]string{
+			"/foo": foo,
+			"/bar": bar,
+		},
+		configContainer: &containerInstance{
+			base: base{
+				runtime: &runtime.Fake{
+					ReadF: func(id string, srcPath []string) ([]*types.File, error) {
+						files := []*types.File{}
+
+						for _, p := range srcPath {
+							if c, ok := read[p]; ok {
+								files = append(files, &types.File{
+									Path:    p,
+									Content: c,
+								})
+							}
+						}
+
+						return files, nil
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestHostConfiguredContainerVerifyWrittenConfigFiles(t *testing.T) {
+	h := verifyConfigFilesTestContainer(map[string]string{
+		path.Join(ConfigMountpoint, "/foo"): foo,
+		path.Join(ConfigMountpoint, "/bar"): bar,
+	})
+
+	if err := h.verifyWrittenConfigFiles([]string{"/foo", "/bar"}); err != nil {
+		t.Fatalf("Verifying correctly written files should succeed, got: %v", err)
+	}
+}
+
+func TestHostConfiguredContainerVerifyWrittenConfigFilesCorrupted(t *testing.T) {
+	h := verifyConfigFilesTestContainer(map[string]string{
+		path.Join(ConfigMountpoint, "/foo"): foo,
+		path.Join(ConfigMountpoint, "/bar"): "ba",
+	})
+
+	err := h.verifyWrittenConfigFiles([]string{"/foo", "/bar"})
+	if err == nil {
+		t.Fatalf("Verifying corrupted file should fail")
+	}
+
+	if !strings.Contains(err.Error(), "/bar") || strings.Contains(err.Error(), "/foo") {
+		t.Fatalf("Error should include only corrupted file, got: %v", err)
+	}
+}
+
+func TestHostConfiguredContainerVerifyWrittenConfigFilesMissing(t *testing.T) {
+	h := verifyConfigFilesTestContainer(map[string]string{
+		path.Join(ConfigMountpoint, "/foo"): foo,
+	})
+
+	if err := h.verifyWrittenConfigFiles([]string{"/foo", "/bar"}); err == nil {
+		t.Fatalf("Verifying missing file should fail")
+	}
+}
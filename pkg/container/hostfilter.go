@@ -0,0 +1,149 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/flexkube/libflexkube/internal/util"
+	"github.com/flexkube/libflexkube/pkg/host"
+)
+
+// HostFilter restricts deployment to containers running on matching hosts. It allows
+// staged rollouts, where only part of the hosts is updated at a time. Containers on
+// other hosts are left untouched.
+type HostFilter struct {
+	// Hosts is a list of hosts in scope of the deployment. Host matches, if either it's
+	// ID, like 'ssh://10.0.0.1:22' or 'direct', or it's SSH address, like '10.0.0.1', is
+	// on the list.
+	Hosts []string `json:"hosts,omitempty"`
+
+	// Match is an optional function, which can be used to select hosts using custom criteria,
+	// for example labels maintained outside of libflexkube. Host matches, if it is either on
+	// the Hosts list or Match returns true for it.
+	//
+	// Due to it's nature, it can only be set programmatically.
+	Match func(h host.Host) bool `json:"-"`
+}
+
+// Validate validates HostFilter struct.
+func (f *HostFilter) Validate() error {
+	var errors util.ValidateError
+
+	if len(f.Hosts) == 0 && f.Match == nil {
+		errors = append(errors, fmt.Errorf("either hosts or match function must be set"))
+	}
+
+	for i, h := range f.Hosts {
+		if h == "" {
+			errors = append(errors, fmt.Errorf("host %d is empty", i))
+		}
+	}
+
+	return errors.Return()
+}
+
+// matches checks, if given host is in scope of the filter. If filter is nil, all hosts match.
+func (f *HostFilter) matches(h host.Host) bool {
+	if f == nil {
+		return true
+	}
+
+	for _, a := range f.Hosts {
+		if a == h.ID() || (h.SSHConfig != nil && a == h.SSHConfig.Address) {
+			return true
+		}
+	}
+
+	return f.Match != nil && f.Match(h)
+}
+
+// inScope checks, if given container should be processed by the deployment. Container is in
+// scope only if all hosts it is placed on in desired, current and previous state match the
+// host filter, so containers moving from or to hosts out of scope are not touched.
+func (c *containers) inScope(n string) bool {
+	if c.hostFilter == nil {
+		return true
+	}
+
+	if d, ok := c.desiredState[n]; ok && !c.hostFilter.matches(d.host) {
+		return false
+	}
+
+	if r, ok := c.current(n); ok && !c.hostFilter.matches(r.host) {
+		return false
+	}
+
+	if p, ok := c.previousState[n]; ok && !c.hostFilter.matches(p.host) {
+		return false
+	}
+
+	return true
+}
+
+// scopedTasks returns tasks for containers from given state, which are in scope of the
+// host filter.
+func (c *containers) scopedTasks(s containersState) []task {
+	t := []task{}
+
+	for _, st := range c.tasks(s) {
+		if c.inScope(st.name) {
+			t = append(t, st)
+		}
+	}
+
+	return t
+}
+
+// hostsInScope returns sorted list of IDs of hosts, which will be touched by the deployment.
+func (c *containers) hostsInScope() []string {
+	hosts := map[string]struct{}{}
+
+	for _, s := range []containersState{c.desiredState, c.currentState} {
+		for _, t := range c.scopedTasks(s) {
+			hosts[t.host] = struct{}{}
+		}
+	}
+
+	r := []string{}
+
+	for h := range hosts {
+		r = append(r, h)
+	}
+
+	sort.Strings(r)
+
+	return r
+}
+
+// withOutOfScopeUnchanged replaces given desired configuration of containers, which are out
+// of scope of the host filter, with their previous state, as they won't be touched by the
+// deployment. Out of scope containers, which do not exist yet, are removed.
+func (c *containers) withOutOfScopeUnchanged(d ContainersState) ContainersState {
+	if c.hostFilter == nil {
+		return d
+	}
+
+	ps := c.previousState.Export()
+
+	for n := range d {
+		if c.inScope(n) {
+			continue
+		}
+
+		if p, ok := ps[n]; ok {
+			d[n] = p
+
+			continue
+		}
+
+		delete(d, n)
+	}
+
+	for n, p := range ps {
+		if _, ok := d[n]; !ok && !c.inScope(n) {
+			d[n] = p
+		}
+	}
+
+	return d
+}
@@ -0,0 +1,203 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+	"github.com/flexkube/libflexkube/pkg/host/transport/ssh"
+)
+
+func sshTestHost(a string) host.Host {
+	return host.Host{
+		SSHConfig: &ssh.Config{
+			Address: a,
+			Port:    22,
+		},
+	}
+}
+
+// Validate() tests.
+func TestHostFilterValidateEmpty(t *testing.T) {
+	f := &HostFilter{}
+
+	if err := f.Validate(); err == nil {
+		t.Fatalf("Validating empty host filter should fail")
+	}
+}
+
+func TestHostFilterValidateEmptyHost(t *testing.T) {
+	f := &HostFilter{
+		Hosts: []string{""},
+	}
+
+	if err := f.Validate(); err == nil {
+		t.Fatalf("Validating host filter with empty host should fail")
+	}
+}
+
+func TestHostFilterValidate(t *testing.T) {
+	f := &HostFilter{
+		Hosts: []string{foo},
+	}
+
+	if err := f.Validate(); err != nil {
+		t.Fatalf("Validating host filter should succeed, got: %v", err)
+	}
+}
+
+// matches() tests.
+func TestHostFilterMatches(t *testing.T) {
+	cases := map[string]struct {
+		filter   *HostFilter
+		host     host.Host
+		expected bool
+	}{
+		"nil filter": {
+			host: host.Host{
+				DirectConfig: &direct.Config{},
+			},
+			expected: true,
+		},
+		"by ID": {
+			filter: &HostFilter{
+				Hosts: []string{"ssh://foo:22"},
+			},
+			host:     sshTestHost(foo),
+			expected: true,
+		},
+		"by address": {
+			filter: &HostFilter{
+				Hosts: []string{foo},
+			},
+			host:     sshTestHost(foo),
+			expected: true,
+		},
+		"by match function": {
+			filter: &HostFilter{
+				Match: func(h host.Host) bool {
+					return h.SSHConfig != nil && h.SSHConfig.Address == bar
+				},
+			},
+			host:     sshTestHost(bar),
+			expected: true,
+		},
+		"no match": {
+			filter: &HostFilter{
+				Hosts: []string{foo},
+				Match: func(h host.Host) bool {
+					return false
+				},
+			},
+			host:     sshTestHost(bar),
+			expected: false,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			if m := c.filter.matches(c.host); m != c.expected {
+				t.Fatalf("Expected match %t, got %t", c.expected, m)
+			}
+		})
+	}
+}
+
+func testHostFilterContainer(h host.Host) *hostConfiguredContainer {
+	return &hostConfiguredContainer{
+		host: h,
+		container: &container{
+			base: base{
+				config: types.ContainerConfig{
+					Image: foo,
+				},
+			},
+		},
+	}
+}
+
+// scopedTasks() tests.
+func TestContainersScopedTasks(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: testHostFilterContainer(sshTestHost(foo)),
+			bar: testHostFilterContainer(sshTestHost(bar)),
+		},
+		hostFilter: &HostFilter{
+			Hosts: []string{foo},
+		},
+	}
+
+	expected := []task{
+		{
+			name: foo,
+			host: "ssh://foo:22",
+		},
+	}
+
+	if diff := cmp.Diff(expected, c.scopedTasks(c.desiredState), cmp.AllowUnexported(task{})); diff != "" {
+		t.Fatalf("Unexpected tasks: %s", diff)
+	}
+
+	if diff := cmp.Diff([]string{"ssh://foo:22"}, c.hostsInScope()); diff != "" {
+		t.Fatalf("Unexpected hosts in scope: %s", diff)
+	}
+}
+
+func TestContainersScopedTasksMovedContainer(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: testHostFilterContainer(sshTestHost(foo)),
+		},
+		currentState: containersState{
+			foo: testHostFilterContainer(sshTestHost(bar)),
+		},
+		hostFilter: &HostFilter{
+			Hosts: []string{foo},
+		},
+	}
+
+	if tasks := c.scopedTasks(c.desiredState); len(tasks) != 0 {
+		t.Fatalf("Container moved from host out of scope should not be processed, got: %+v", tasks)
+	}
+}
+
+// DesiredState() tests.
+func TestContainersDesiredStateOutOfScope(t *testing.T) {
+	previous := testHostFilterContainer(sshTestHost(bar))
+	desired := testHostFilterContainer(sshTestHost(bar))
+	desired.container.(*container).config.Image = bar
+
+	c := &containers{
+		previousState: containersState{
+			bar: previous,
+		},
+		desiredState: containersState{
+			foo:   testHostFilterContainer(sshTestHost(foo)),
+			bar:   desired,
+			"baz": testHostFilterContainer(sshTestHost("baz")),
+		},
+		hostFilter: &HostFilter{
+			Hosts: []string{foo},
+		},
+	}
+
+	d := c.DesiredState()
+
+	if _, ok := d[foo]; !ok {
+		t.Fatalf("Container in scope should be included in desired state")
+	}
+
+	if _, ok := d["baz"]; ok {
+		t.Fatalf("New container out of scope should not be included in desired state")
+	}
+
+	if i := d[bar].Container.Config.Image; i != foo {
+		t.Fatalf("Container out of scope should have previous configuration, got image %q", i)
+	}
+}
@@ -0,0 +1,131 @@
+package container
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// HostSinks writes progress events received from Deploy() to separate writers for each host,
+// which allows e.g. writing deployment summary of each host to a separate file or UI pane.
+//
+// HostSinks also implements Logger interface, so it can be set as a logger for the deployment
+// to route log entries to the writers of their hosts as well. Entries and events are never
+// written to the same writer concurrently.
+type HostSinks struct {
+	// Sink returns writer for the host with given ID, as returned by host.Host.ID(). If it is
+	// nil or it returns nil writer, Default writer is used.
+	Sink func(host string) io.Writer
+
+	// Default is a writer used for events from hosts without dedicated writer. If nil, such
+	// events are discarded.
+	Default io.Writer
+
+	// lock serializes writes of events and log entries.
+	lock sync.Mutex
+}
+
+// Log implements Logger interface. Entries are written to the writer of their host in the same
+// format as NewWriterLogger uses. Entries not referring to any host are written to Default
+// writer.
+func (s *HostSinks) Log(e LogEntry) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	writerLogger{w: s.writer(e.Host)}.Log(e)
+}
+
+// write writes given string to the writer of given host.
+func (s *HostSinks) write(host, str string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	_, err := io.WriteString(s.writer(host), str)
+
+	return err
+}
+
+// writer returns writer for the host with given ID.
+func (s *HostSinks) writer(host string) io.Writer {
+	if s.Sink != nil {
+		if w := s.Sink(host); w != nil {
+			return w
+		}
+	}
+
+	if s.Default != nil {
+		return s.Default
+	}
+
+	return ioutil.Discard
+}
+
+// formatEvent returns single line description of given event.
+func formatEvent(e ProgressEvent) string {
+	if e.Error != nil {
+		return fmt.Sprintf("Container '%s': %s: %v\n", e.Container, e.Type, e.Error)
+	}
+
+	return fmt.Sprintf("Container '%s': %s\n", e.Container, e.Type)
+}
+
+// formatSummary returns summary of the deployment on a single host based on given number of
+// events of each type.
+func formatSummary(counts map[ProgressEventType]int) string {
+	types := []string{}
+
+	for t := range counts {
+		types = append(types, string(t))
+	}
+
+	sort.Strings(types)
+
+	s := []string{}
+
+	for _, t := range types {
+		s = append(s, fmt.Sprintf("%d %s", counts[ProgressEventType(t)], t))
+	}
+
+	return fmt.Sprintf("Summary: %s\n", strings.Join(s, ", "))
+}
+
+// Consume writes events received from given channel to the writers of their hosts, until the
+// channel is closed. After that, summary of processed containers is written to the writer of
+// each host, which had any events. It is up to the caller to close the channel once Deploy()
+// returns.
+//
+// Errors returned by the writers are aggregated and returned once the channel is closed.
+func (s *HostSinks) Consume(events <-chan ProgressEvent) error {
+	counts := map[string]map[ProgressEventType]int{}
+	hosts := []string{}
+
+	var errs []string
+
+	for e := range events {
+		if _, ok := counts[e.Host]; !ok {
+			counts[e.Host] = map[ProgressEventType]int{}
+			hosts = append(hosts, e.Host)
+		}
+
+		counts[e.Host][e.Type]++
+
+		if err := s.write(e.Host, formatEvent(e)); err != nil {
+			errs = append(errs, fmt.Sprintf("writing event for host %q: %v", e.Host, err))
+		}
+	}
+
+	for _, h := range hosts {
+		if err := s.write(h, formatSummary(counts[h])); err != nil {
+			errs = append(errs, fmt.Sprintf("writing summary for host %q: %v", h, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed writing events: %s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// errWriter is an io.Writer, which always fails.
+type errWriter struct{}
+
+func (errWriter) Write(b []byte) (int, error) {
+	return 0, fmt.Errorf("write failed")
+}
+
+func hostSinksTestEvents(events ...ProgressEvent) <-chan ProgressEvent {
+	c := make(chan ProgressEvent, len(events))
+
+	for _, e := range events {
+		c <- e
+	}
+
+	close(c)
+
+	return c
+}
+
+// Consume() tests.
+func TestHostSinksConsume(t *testing.T) {
+	var fooBuf, defaultBuf bytes.Buffer
+
+	s := &HostSinks{
+		Sink: func(host string) io.Writer {
+			if host == foo {
+				return &fooBuf
+			}
+
+			return nil
+		},
+		Default: &defaultBuf,
+	}
+
+	events := hostSinksTestEvents(
+		ProgressEvent{Type: ProgressEventCreated, Container: "a", Host: foo},
+		ProgressEvent{Type: ProgressEventFailed, Container: "b", Host: foo, Error: fmt.Errorf("boom")},
+		ProgressEvent{Type: ProgressEventCreated, Container: "c", Host: foo},
+		ProgressEvent{Type: ProgressEventRemoved, Container: "d", Host: bar},
+	)
+
+	if err := s.Consume(events); err != nil {
+		t.Fatalf("Consuming events should succeed, got: %v", err)
+	}
+
+	expectedFoo := `Container 'a': created
+Container 'b': failed: boom
+Container 'c': created
+Summary: 2 created, 1 failed
+`
+
+	if fooBuf.String() != expectedFoo {
+		t.Fatalf("Expected host output %q, got %q", expectedFoo, fooBuf.String())
+	}
+
+	expectedDefault := "Container 'd': removed\nSummary: 1 removed\n"
+
+	if defaultBuf.String() != expectedDefault {
+		t.Fatalf("Expected default output %q, got %q", expectedDefault, defaultBuf.String())
+	}
+}
+
+func TestHostSinksConsumeNoWriters(t *testing.T) {
+	s := &HostSinks{}
+
+	if err := s.Consume(hostSinksTestEvents(ProgressEvent{Type: ProgressEventCreated, Container: foo})); err != nil {
+		t.Fatalf("Consuming events without writers should succeed, got: %v", err)
+	}
+}
+
+func TestHostSinksConsumeWriteError(t *testing.T) {
+	s := &HostSinks{
+		Default: errWriter{},
+	}
+
+	if err := s.Consume(hostSinksTestEvents(ProgressEvent{Type: ProgressEventCreated, Container: foo})); err == nil {
+		t.Fatalf("Consuming events should fail, when writer fails")
+	}
+}
+
+// Log() tests.
+func TestHostSinksLog(t *testing.T) {
+	var fooBuf, defaultBuf bytes.Buffer
+
+	s := &HostSinks{
+		Sink: func(host string) io.Writer {
+			if host == foo {
+				return &fooBuf
+			}
+
+			return nil
+		},
+		Default: &defaultBuf,
+	}
+
+	s.Log(LogEntry{Level: LogLevelWarning, Container: bar, Host: foo, Message: "drift"})
+	s.Log(LogEntry{Level: LogLevelInfo, Message: "Updating existing containers"})
+
+	if e := "WARNING: drift\n"; fooBuf.String() != e {
+		t.Fatalf("Expected host output %q, got %q", e, fooBuf.String())
+	}
+
+	if e := "Updating existing containers\n"; defaultBuf.String() != e {
+		t.Fatalf("Expected default output %q, got %q", e, defaultBuf.String())
+	}
+}
@@ -0,0 +1,50 @@
+package container
+
+import (
+	"strings"
+)
+
+// RewriteImage rewrites registry of given image reference using given mirrors map, where
+// key is a registry prefix to replace and value is a replacement, for example
+// 'k8s.gcr.io' -> 'registry.internal'. Prefixes are matched on path boundaries and the
+// longest matching prefix is used. If no prefix matches, image is returned unchanged.
+//
+// Images without registry in the reference, like 'busybox', are matched only by 'docker.io'
+// prefix, as this is where they are pulled from.
+func RewriteImage(image string, mirrors map[string]string) string {
+	ref := image
+
+	if !hasRegistry(image) {
+		ref = "docker.io/" + image
+	}
+
+	match := ""
+
+	for prefix := range mirrors {
+		p := strings.TrimSuffix(prefix, "/")
+
+		if strings.HasPrefix(ref, p+"/") && len(p) > len(match) {
+			match = p
+		}
+	}
+
+	if match == "" {
+		return image
+	}
+
+	return strings.TrimSuffix(mirrors[match], "/") + strings.TrimPrefix(ref, match)
+}
+
+// hasRegistry checks, if given image reference includes registry host, using the same
+// rules as Docker. First path component is considered a registry, if it contains a dot,
+// a port or if it's 'localhost'.
+func hasRegistry(image string) bool {
+	i := strings.Index(image, "/")
+	if i == -1 {
+		return false
+	}
+
+	host := image[:i]
+
+	return strings.ContainsAny(host, ".:") || host == "localhost"
+}
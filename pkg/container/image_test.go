@@ -0,0 +1,44 @@
+package container
+
+import (
+	"testing"
+)
+
+// RewriteImage() tests.
+func TestRewriteImage(t *testing.T) {
+	mirrors := map[string]string{
+		"k8s.gcr.io":         "registry.internal",
+		"quay.io/coreos":     "registry.internal/coreos-mirror/",
+		"quay.io":            "registry.internal/quay",
+		"docker.io":          "registry.internal/hub",
+		"registry.internal2": "foo",
+	}
+
+	cases := map[string]string{
+		"k8s.gcr.io/hyperkube:v1.18.3":   "registry.internal/hyperkube:v1.18.3",
+		"quay.io/coreos/etcd:v3.4.9":     "registry.internal/coreos-mirror/etcd:v3.4.9",
+		"quay.io/foo/bar:latest":         "registry.internal/quay/foo/bar:latest",
+		"busybox:latest":                 "registry.internal/hub/busybox:latest",
+		"library/busybox":                "registry.internal/hub/library/busybox",
+		"k8s.gcr.io.evil.com/foo:latest": "k8s.gcr.io.evil.com/foo:latest",
+		"registry.internal/foo:latest":   "registry.internal/foo:latest",
+	}
+
+	for image, expected := range cases {
+		image, expected := image, expected
+
+		t.Run(image, func(t *testing.T) {
+			if r := RewriteImage(image, mirrors); r != expected {
+				t.Fatalf("expected image %q to be rewritten to %q, got %q", image, expected, r)
+			}
+		})
+	}
+}
+
+func TestRewriteImageNoMirrors(t *testing.T) {
+	i := "busybox:latest"
+
+	if r := RewriteImage(i, nil); r != i {
+		t.Fatalf("image should not be changed when no mirrors are configured, got %q", r)
+	}
+}
@@ -0,0 +1,112 @@
+package container
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/flexkube/libflexkube/internal/util"
+)
+
+// defaultCosignBinary is a name of cosign binary used, when no binary path is configured.
+const defaultCosignBinary = "cosign"
+
+// ImageVerifier verifies container images before they are used for creating containers,
+// for example by checking their signatures.
+type ImageVerifier interface {
+	// Verify returns an error, if given image must not be used. Otherwise it returns reference
+	// to the verified image pinned to its digest, e.g. 'busybox@sha256:...', which is used for
+	// creating the container, so image can't be replaced between verification and creation.
+	// If returned reference is empty, configured image is used as is.
+	Verify(image string) (string, error)
+}
+
+// CosignVerifier is an ImageVerifier, which verifies image signatures using 'cosign' binary
+// available on the machine running libflexkube.
+type CosignVerifier struct {
+	// PublicKey is a path to the public key or a KMS URI, which will be used to verify
+	// image signatures.
+	//
+	// Example value: '/etc/flexkube/cosign.pub'.
+	PublicKey string
+
+	// Binary is a path to cosign binary. If empty, 'cosign' is looked up in PATH.
+	//
+	// This field is optional.
+	Binary string
+}
+
+// args returns cosign arguments for verifying given image.
+func (c *CosignVerifier) args(image string) []string {
+	return []string{"verify", "--key", c.PublicKey, "--output", "json", image}
+}
+
+// Verify verifies signature of given image using configured public key and returns
+// reference to the image pinned to the digest, which signature has been verified for.
+func (c *CosignVerifier) Verify(image string) (string, error) {
+	if c.PublicKey == "" {
+		return "", fmt.Errorf("public key must be set")
+	}
+
+	var stderr bytes.Buffer
+
+	cmd := exec.Command(util.PickString(c.Binary, defaultCosignBinary), c.args(image)...) //nolint:gosec
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("verifying signature failed: %w, output: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	digest, err := cosignDigest(out)
+	if err != nil {
+		return "", fmt.Errorf("failed reading verified image digest: %w", err)
+	}
+
+	return pinnedImage(image, digest), nil
+}
+
+// cosignPayload is a part of the signature payload printed by 'cosign verify' in JSON format.
+type cosignPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// cosignDigest returns image digest from given output of 'cosign verify'. All verified
+// signatures must refer to the same digest.
+func cosignDigest(out []byte) (string, error) {
+	payloads := []cosignPayload{}
+
+	if err := json.Unmarshal(out, &payloads); err != nil {
+		return "", fmt.Errorf("failed parsing cosign output: %w", err)
+	}
+
+	digest := ""
+
+	for _, p := range payloads {
+		d := p.Critical.Image.DockerManifestDigest
+
+		if d == "" || (digest != "" && d != digest) {
+			return "", fmt.Errorf("verified signatures refer to different or empty digests")
+		}
+
+		digest = d
+	}
+
+	if digest == "" {
+		return "", fmt.Errorf("no verified signatures found")
+	}
+
+	return digest, nil
+}
+
+// pinnedImage returns reference to given image pinned to given digest. If image is already
+// referenced by digest, the digest is replaced.
+func pinnedImage(image, digest string) string {
+	return strings.SplitN(image, "@", 2)[0] + "@" + digest
+}
@@ -0,0 +1,196 @@
+package container
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+func TestCosignVerifierArgs(t *testing.T) {
+	c := &CosignVerifier{
+		PublicKey: "/etc/cosign.pub",
+	}
+
+	expected := []string{"verify", "--key", "/etc/cosign.pub", "--output", "json", "busybox"}
+
+	if diff := cmp.Diff(expected, c.args("busybox")); diff != "" {
+		t.Fatalf("Unexpected args: %s", diff)
+	}
+}
+
+func TestCosignVerifierNoPublicKey(t *testing.T) {
+	c := &CosignVerifier{}
+
+	if _, err := c.Verify("busybox"); err == nil {
+		t.Fatalf("Verifying without public key should fail")
+	}
+}
+
+func TestCosignVerifierFail(t *testing.T) {
+	c := &CosignVerifier{
+		PublicKey: "/etc/cosign.pub",
+		Binary:    "false",
+	}
+
+	if _, err := c.Verify("busybox"); err == nil {
+		t.Fatalf("Verifying should fail, when cosign fails")
+	}
+}
+
+func TestCosignDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	other := "sha256:" + strings.Repeat("b", 64)
+
+	payload := func(d string) string {
+		return fmt.Sprintf(`{"critical":{"image":{"docker-manifest-digest":%q}}}`, d)
+	}
+
+	cases := map[string]struct {
+		out      string
+		expected string
+		err      bool
+	}{
+		"single signature": {
+			out:      "[" + payload(digest) + "]",
+			expected: digest,
+		},
+		"multiple signatures": {
+			out:      "[" + payload(digest) + "," + payload(digest) + "]",
+			expected: digest,
+		},
+		"different digests": {
+			out: "[" + payload(digest) + "," + payload(other) + "]",
+			err: true,
+		},
+		"empty digest": {
+			out: "[" + payload("") + "]",
+			err: true,
+		},
+		"no signatures": {
+			out: "[]",
+			err: true,
+		},
+		"malformed output": {
+			out: "foo",
+			err: true,
+		},
+	}
+
+	for n, testCase := range cases {
+		testCase := testCase
+
+		t.Run(n, func(t *testing.T) {
+			d, err := cosignDigest([]byte(testCase.out))
+			if testCase.err && err == nil {
+				t.Fatalf("Reading digest should fail")
+			}
+
+			if !testCase.err && err != nil {
+				t.Fatalf("Reading digest should succeed, got: %v", err)
+			}
+
+			if d != testCase.expected {
+				t.Fatalf("Expected digest %q, got %q", testCase.expected, d)
+			}
+		})
+	}
+}
+
+func TestPinnedImage(t *testing.T) {
+	cases := map[string]string{
+		"busybox":                 "busybox@sha256:foo",
+		"busybox:latest":          "busybox:latest@sha256:foo",
+		"busybox@sha256:bar":      "busybox@sha256:foo",
+		"busybox:latest@sha256:b": "busybox:latest@sha256:foo",
+	}
+
+	for image, expected := range cases {
+		if p := pinnedImage(image, "sha256:foo"); p != expected {
+			t.Fatalf("Expected image %q to be pinned as %q, got %q", image, expected, p)
+		}
+	}
+}
+
+// fakeImageVerifier is an ImageVerifier implemented by a function.
+type fakeImageVerifier func(image string) (string, error)
+
+// Verify implements ImageVerifier interface.
+func (f fakeImageVerifier) Verify(image string) (string, error) {
+	return f(image)
+}
+
+func TestEnsureExistsImageVerificationFail(t *testing.T) {
+	created := false
+
+	c := &containers{
+		currentState: containersState{},
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{
+				host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				container: &container{
+					base: base{
+						config: types.ContainerConfig{
+							Image: foo,
+						},
+						runtimeConfig: &runtime.FakeConfig{
+							Runtime: &runtime.Fake{
+								CreateF: func(config *types.ContainerConfig) (string, error) {
+									created = true
+
+									return foo, nil
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		imageVerifier: fakeImageVerifier(func(image string) (string, error) {
+			return "", fmt.Errorf("unsigned image %s", image)
+		}),
+	}
+
+	if err := c.ensureExists(foo); err == nil {
+		t.Fatalf("Ensuring that new container exists should fail, when image verification fails")
+	}
+
+	if created {
+		t.Fatalf("Container should not be created, when image verification fails")
+	}
+}
+
+func TestVerifyImagePinsImage(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{
+				container: &container{
+					base: base{
+						config: types.ContainerConfig{
+							Image: foo,
+						},
+					},
+				},
+			},
+		},
+		imageVerifier: fakeImageVerifier(func(image string) (string, error) {
+			return image + "@sha256:bar", nil
+		}),
+	}
+
+	if err := c.verifyImage(foo); err != nil {
+		t.Fatalf("Verifying image should succeed, got: %v", err)
+	}
+
+	if p := c.desiredState[foo].pinnedImage; p != "foo@sha256:bar" {
+		t.Fatalf("Verified image should be used for creating the container, got %q", p)
+	}
+}
@@ -0,0 +1,91 @@
+package container
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/flexkube/libflexkube/internal/util"
+)
+
+// validateImmutableFiles validates given list of immutable configuration files paths.
+func validateImmutableFiles(files []string) error {
+	var errors util.ValidateError
+
+	for _, f := range files {
+		if !path.IsAbs(f) {
+			errors = append(errors, fmt.Errorf("path %q must be absolute", f))
+		}
+	}
+
+	return errors.Return()
+}
+
+// withImmutableFiles configures given immutable configuration files for all containers in the state.
+func (s containersState) withImmutableFiles(files []string) {
+	if len(files) == 0 {
+		return
+	}
+
+	immutable := map[string]struct{}{}
+
+	for _, f := range files {
+		immutable[f] = struct{}{}
+	}
+
+	for _, m := range s {
+		m.immutableFiles = immutable
+	}
+}
+
+// keepsExisting checks, if given configuration file is immutable and it exists in given
+// current state of the container, so it must not be written again.
+func (m *hostConfiguredContainer) keepsExisting(p string, c hostConfiguredContainer) bool {
+	if _, immutable := m.immutableFiles[p]; !immutable {
+		return false
+	}
+
+	_, exists := c.configFiles[p]
+
+	return exists
+}
+
+// keptImmutableFiles returns sorted list of desired configuration files, which are immutable
+// and which exist in given current state of the container, so they won't be written.
+func (m *hostConfiguredContainer) keptImmutableFiles(c *hostConfiguredContainer) []string {
+	files := []string{}
+
+	if c == nil {
+		return files
+	}
+
+	for p := range m.configFiles {
+		if m.keepsExisting(p, *c) {
+			files = append(files, p)
+		}
+	}
+
+	sort.Strings(files)
+
+	return files
+}
+
+// withKeptFiles returns given desired configuration files, with content of given kept files
+// taken from given current configuration files.
+func withKeptFiles(desired, current map[string]string, kept []string) map[string]string {
+	if len(kept) == 0 {
+		return desired
+	}
+
+	files := map[string]string{}
+
+	for p, content := range desired {
+		files[p] = content
+	}
+
+	for _, p := range kept {
+		files[p] = current[p]
+	}
+
+	return files
+}
@@ -0,0 +1,106 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// validateImmutableFiles() tests.
+func TestValidateImmutableFilesRelative(t *testing.T) {
+	if err := validateImmutableFiles([]string{"/etc/foo", "etc/bar"}); err == nil {
+		t.Fatalf("Relative immutable file path should be rejected")
+	}
+}
+
+func immutableTestContainers(current map[string]string) (hostConfiguredContainer, hostConfiguredContainer) {
+	d := hostConfiguredContainer{
+		configFiles: map[string]string{
+			"/foo": foo,
+			"/bar": foo,
+		},
+	}
+
+	containersState{foo: &d}.withImmutableFiles([]string{"/foo"})
+
+	c := hostConfiguredContainer{
+		configFiles: current,
+	}
+
+	return d, c
+}
+
+func immutableTestFilesToUpdate(d, c hostConfiguredContainer) []string {
+	cs := &containers{
+		desiredState: containersState{
+			foo: &d,
+		},
+		currentState: containersState{
+			foo: &c,
+		},
+	}
+
+	return cs.filesToUpdate(foo)
+}
+
+// filesToUpdate() tests.
+func TestFilesToUpdateImmutableExisting(t *testing.T) {
+	d, c := immutableTestContainers(map[string]string{
+		"/foo": bar,
+		"/bar": bar,
+	})
+
+	if diff := cmp.Diff([]string{"/bar"}, immutableTestFilesToUpdate(d, c)); diff != "" {
+		t.Fatalf("Existing immutable file should not be updated: %s", diff)
+	}
+}
+
+func TestFilesToUpdateImmutableMissing(t *testing.T) {
+	d, c := immutableTestContainers(map[string]string{
+		"/bar": foo,
+	})
+
+	if diff := cmp.Diff([]string{"/foo"}, immutableTestFilesToUpdate(d, c)); diff != "" {
+		t.Fatalf("Missing immutable file should be written: %s", diff)
+	}
+}
+
+// keptImmutableFiles() tests.
+func TestKeptImmutableFiles(t *testing.T) {
+	d, c := immutableTestContainers(map[string]string{
+		"/foo": bar,
+	})
+
+	if diff := cmp.Diff([]string{"/foo"}, d.keptImmutableFiles(&c)); diff != "" {
+		t.Fatalf("Unexpected kept files: %s", diff)
+	}
+
+	if k := d.keptImmutableFiles(nil); len(k) != 0 {
+		t.Fatalf("No files should be kept without current state, got: %v", k)
+	}
+}
+
+// withKeptFiles() tests.
+func TestWithKeptFiles(t *testing.T) {
+	desired := map[string]string{
+		"/foo": foo,
+		"/bar": foo,
+	}
+
+	current := map[string]string{
+		"/foo": bar,
+	}
+
+	expected := map[string]string{
+		"/foo": bar,
+		"/bar": foo,
+	}
+
+	if diff := cmp.Diff(expected, withKeptFiles(desired, current, []string{"/foo"})); diff != "" {
+		t.Fatalf("Unexpected configuration files: %s", diff)
+	}
+
+	if desired["/foo"] != foo {
+		t.Fatalf("Desired configuration files should not be modified")
+	}
+}
@@ -0,0 +1,161 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// LogLevel is a severity of the message logged during deployment.
+type LogLevel string
+
+const (
+	// LogLevelInfo is used for messages describing regular progress of the deployment.
+	LogLevelInfo LogLevel = "info"
+
+	// LogLevelWarning is used for messages, which user should pay attention to.
+	LogLevelWarning LogLevel = "warning"
+)
+
+// LogEntry is a single message logged during deployment.
+type LogEntry struct {
+	// Level is a severity of the message.
+	Level LogLevel `json:"level"`
+
+	// Container is a name of the container, which message refers to. It is empty for messages
+	// referring to the whole deployment.
+	Container string `json:"container,omitempty"`
+
+	// Host is an ID of the host, which message refers to, as returned by host.Host.ID(). It is
+	// empty for messages, which does not refer to a single host. It allows routing messages from
+	// different hosts to separate outputs, like HostSinks does.
+	Host string `json:"host,omitempty"`
+
+	// Message is a human-readable message. It may span multiple lines, e.g. when it includes
+	// a diff of the configuration.
+	Message string `json:"message"`
+}
+
+// Logger receives messages logged during deployment, like detected configuration drifts or
+// containers being created. It allows to capture deployment output in a structured way, e.g. to
+// emit it as JSON or to inspect it in tests.
+type Logger interface {
+	// Log logs given entry.
+	Log(entry LogEntry)
+}
+
+// writerLogger is a Logger, which writes messages to a writer, one entry per line.
+type writerLogger struct {
+	w io.Writer
+}
+
+// NewWriterLogger returns a Logger, which writes messages to given writer, one entry per line.
+// Warnings are prefixed with "WARNING: ".
+func NewWriterLogger(w io.Writer) Logger {
+	return writerLogger{
+		w: w,
+	}
+}
+
+// Log implements Logger interface.
+//
+// Whole line is written using single write, so lines logged concurrently are not interleaved.
+func (l writerLogger) Log(e LogEntry) {
+	prefix := ""
+
+	if e.Level == LogLevelWarning {
+		prefix = "WARNING: "
+	}
+
+	fmt.Fprintln(l.w, prefix+e.Message)
+}
+
+// loggerOrDefault returns given logger or logger writing to standard output, if given
+// logger is nil.
+func loggerOrDefault(l Logger) Logger {
+	if l == nil {
+		return NewWriterLogger(os.Stdout)
+	}
+
+	return l
+}
+
+// logEntryf logs formatted message with given level about given container placed on given host
+// using given logger.
+func logEntryf(l Logger, level LogLevel, host, n string, format string, args ...interface{}) {
+	loggerOrDefault(l).Log(LogEntry{
+		Level:     level,
+		Container: n,
+		Host:      host,
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+// getLogger returns configured logger or logger writing to standard output, if logger
+// is not configured.
+func (c *containers) getLogger() Logger {
+	return loggerOrDefault(c.logger)
+}
+
+// logf logs formatted informational message about given container. For messages referring
+// to the whole deployment, container name should be empty.
+func (c *containers) logf(n string, format string, args ...interface{}) {
+	logEntryf(c.logger, LogLevelInfo, c.eventHosts[n], n, format, args...)
+}
+
+// warnf logs formatted warning about given container.
+func (c *containers) warnf(n string, format string, args ...interface{}) {
+	logEntryf(c.logger, LogLevelWarning, c.eventHosts[n], n, format, args...)
+}
+
+// hostLogf logs formatted informational message about given host.
+func (c *containers) hostLogf(host string, format string, args ...interface{}) {
+	logEntryf(c.logger, LogLevelInfo, host, "", format, args...)
+}
+
+// logf logs formatted informational message about given container using logger configured
+// for the container.
+func (m *hostConfiguredContainer) logf(n string, format string, args ...interface{}) {
+	logEntryf(m.logger, LogLevelInfo, m.host.ID(), n, format, args...)
+}
+
+// warnf logs formatted warning about given container using logger configured for the container.
+func (m *hostConfiguredContainer) warnf(n string, format string, args ...interface{}) {
+	logEntryf(m.logger, LogLevelWarning, m.host.ID(), n, format, args...)
+}
+
+// logWriter is an io.Writer, which logs each complete line written to it using given function,
+// so output of commands can be passed to the Logger.
+type logWriter struct {
+	log func(line string)
+	buf []byte
+}
+
+// Write implements io.Writer interface. Incomplete lines are buffered until newline
+// character is written or Flush is called.
+func (l *logWriter) Write(b []byte) (int, error) {
+	l.buf = append(l.buf, b...)
+
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i == -1 {
+			return len(b), nil
+		}
+
+		l.log(string(l.buf[:i]))
+
+		l.buf = l.buf[i+1:]
+	}
+}
+
+// Flush logs buffered incomplete line, if there is any.
+func (l *logWriter) Flush() {
+	if len(l.buf) == 0 {
+		return
+	}
+
+	l.log(string(l.buf))
+
+	l.buf = nil
+}
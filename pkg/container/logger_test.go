@@ -0,0 +1,163 @@
+package container
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeLogger struct {
+	lock    sync.Mutex
+	entries []LogEntry
+}
+
+func (f *fakeLogger) Log(e LogEntry) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.entries = append(f.entries, e)
+}
+
+// NewWriterLogger() tests.
+func TestWriterLogger(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+
+	l := NewWriterLogger(&b)
+
+	l.Log(LogEntry{
+		Level:     LogLevelInfo,
+		Container: foo,
+		Message:   "first\n  second",
+	})
+
+	l.Log(LogEntry{
+		Level:   LogLevelWarning,
+		Message: bar,
+	})
+
+	expected := "first\n  second\nWARNING: bar\n"
+
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Fatalf("Unexpected output: %s", diff)
+	}
+}
+
+// logf() tests.
+func TestLogf(t *testing.T) {
+	t.Parallel()
+
+	l := &fakeLogger{}
+
+	c := &containers{
+		logger: l,
+	}
+
+	c.logf(foo, "Creating new container '%s'", foo)
+	c.warnf("", "%s", bar)
+
+	expected := []LogEntry{
+		{
+			Level:     LogLevelInfo,
+			Container: foo,
+			Message:   "Creating new container 'foo'",
+		},
+		{
+			Level:   LogLevelWarning,
+			Message: bar,
+		},
+	}
+
+	if diff := cmp.Diff(expected, l.entries); diff != "" {
+		t.Fatalf("Unexpected log entries: %s", diff)
+	}
+}
+
+func TestLogfHost(t *testing.T) {
+	t.Parallel()
+
+	l := &fakeLogger{}
+
+	c := &containers{
+		logger:     l,
+		eventHosts: map[string]string{foo: bar},
+	}
+
+	c.logf(foo, "Creating new container '%s'", foo)
+
+	if h := l.entries[0].Host; h != bar {
+		t.Fatalf("Log entry should include host of the container %q, got %q", bar, h)
+	}
+}
+
+// Deploy() tests.
+func TestDeployLogger(t *testing.T) {
+	t.Parallel()
+
+	l := &fakeLogger{}
+
+	c := &containers{
+		currentState: containersState{},
+		desiredState: containersState{},
+		drift:        []string{foo},
+		logger:       l,
+	}
+
+	if err := c.Deploy(); err != nil {
+		t.Fatalf("Deploying should succeed, got: %v", err)
+	}
+
+	expected := []LogEntry{
+		{Level: LogLevelInfo, Message: "Detected changes made outside of the deployment:\n  - foo"},
+		{Level: LogLevelInfo, Message: "Checking for stopped and missing containers"},
+		{Level: LogLevelInfo, Message: "Configuring and creating new containers"},
+		{Level: LogLevelInfo, Message: "Updating existing containers"},
+	}
+
+	if diff := cmp.Diff(expected, l.entries); diff != "" {
+		t.Fatalf("Deployment messages should be logged using configured logger: %s", diff)
+	}
+}
+
+// New() tests.
+func TestContainersNewLogger(t *testing.T) {
+	t.Parallel()
+
+	l := &fakeLogger{}
+
+	c := GetContainers(t).ToExported()
+	c.Logger = l
+
+	cs, err := c.New()
+	if err != nil {
+		t.Fatalf("Initializing containers should succeed, got: %v", err)
+	}
+
+	if cs.ToExported().Logger != l {
+		t.Fatalf("Logger should be preserved")
+	}
+
+	for n, m := range cs.(*containers).desiredState {
+		if m.logger != l {
+			t.Fatalf("Logger should be configured for container %q", n)
+		}
+	}
+}
+
+// DeepCopy() tests.
+func TestContainersDeepCopySharesLogger(t *testing.T) {
+	t.Parallel()
+
+	l := &fakeLogger{}
+
+	c := &Containers{
+		Logger: l,
+	}
+
+	if c.DeepCopy().Logger != l {
+		t.Fatalf("Logger should be shared with the copy")
+	}
+}
@@ -0,0 +1,147 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/flexkube/libflexkube/internal/util"
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+)
+
+// prefixWriter is an io.Writer, which writes only complete lines to the underlying writer,
+// prefixing each line with configured prefix. Lock is shared between multiple writers
+// writing to the same underlying writer, so lines from different writers are not interleaved.
+type prefixWriter struct {
+	w      io.Writer
+	lock   *sync.Mutex
+	prefix string
+	buf    []byte
+}
+
+// Write implements io.Writer interface. Incomplete lines are buffered until newline
+// character is written or Flush is called.
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i == -1 {
+			return len(b), nil
+		}
+
+		if err := p.writeLine(p.buf[:i+1]); err != nil {
+			return 0, err
+		}
+
+		p.buf = p.buf[i+1:]
+	}
+}
+
+// Flush writes buffered incomplete line, if there is any.
+func (p *prefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+
+	line := p.buf
+	p.buf = nil
+
+	return p.writeLine(append(line, '\n'))
+}
+
+// writeLine writes given line with the prefix to the underlying writer.
+func (p *prefixWriter) writeLine(line []byte) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	_, err := fmt.Fprintf(p.w, "%s | %s", p.prefix, line)
+
+	return err
+}
+
+// followLogs streams logs of the container to given writer, until given context is cancelled
+// or container stops.
+func (m *hostConfiguredContainer) followLogs(ctx context.Context, lines int, w io.Writer) error {
+	return m.withForwardedRuntime(func() error {
+		r, ok := m.container.Runtime().(runtime.LogsFollower)
+		if !ok {
+			return fmt.Errorf("container runtime does not support following logs")
+		}
+
+		return r.FollowLogs(ctx, m.container.Status().ID, lines, w)
+	})
+}
+
+// FollowLogs streams logs of containers with given names from the current state to given
+// writer, starting from given number of last lines of each container. Each line is prefixed
+// with the name of the container it comes from. If no names are given, logs of all containers
+// from the current state are streamed.
+//
+// Logs are streamed until given context is cancelled or all containers stop. Streaming uses
+// the runtime of the containers, so it should not be called while Deploy is in progress on the
+// same object. To watch logs during the deployment, create separate object from the same state.
+func (c *containers) FollowLogs(ctx context.Context, names []string, lines int, w io.Writer) error {
+	if c.currentState == nil {
+		return fmt.Errorf("can't follow logs without knowing current state of the containers")
+	}
+
+	if len(names) == 0 {
+		for n := range c.currentState {
+			names = append(names, n)
+		}
+
+		sort.Strings(names)
+	}
+
+	for _, n := range names {
+		if _, ok := c.currentState[n]; !ok {
+			return fmt.Errorf("container %q does not exist in the current state", n)
+		}
+	}
+
+	var lock sync.Mutex
+
+	var wg sync.WaitGroup
+
+	errs := make([]error, len(names))
+
+	for i, n := range names {
+		wg.Add(1)
+
+		go func(i int, n string) {
+			defer wg.Done()
+
+			r := c.currentState[n]
+
+			pw := &prefixWriter{
+				w:      w,
+				lock:   &lock,
+				prefix: fmt.Sprintf("%s (%s)", n, r.host.ID()),
+			}
+
+			if err := r.followLogs(ctx, lines, pw); err != nil {
+				errs[i] = fmt.Errorf("following logs of container %q failed: %w", n, err)
+			}
+
+			if err := pw.Flush(); err != nil && errs[i] == nil {
+				errs[i] = fmt.Errorf("writing logs of container %q failed: %w", n, err)
+			}
+		}(i, n)
+	}
+
+	wg.Wait()
+
+	var errors util.ValidateError
+
+	for _, err := range errs {
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	return errors.Return()
+}
@@ -0,0 +1,175 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+// prefixWriter tests.
+func TestPrefixWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	p := &prefixWriter{
+		w:      &buf,
+		lock:   &sync.Mutex{},
+		prefix: foo,
+	}
+
+	for _, s := range []string{"bar\nba", "z\n", "qux"} {
+		if _, err := p.Write([]byte(s)); err != nil {
+			t.Fatalf("Writing should succeed, got: %v", err)
+		}
+	}
+
+	if err := p.Flush(); err != nil {
+		t.Fatalf("Flushing should succeed, got: %v", err)
+	}
+
+	expected := "foo | bar\nfoo | baz\nfoo | qux\n"
+
+	if buf.String() != expected {
+		t.Fatalf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+// FollowLogs() tests.
+func followLogsTestContainer(followLogs func(ctx context.Context, id string, lines int, w io.Writer) error) *hostConfiguredContainer {
+	return &hostConfiguredContainer{
+		host: host.Host{
+			DirectConfig: &direct.Config{},
+		},
+		container: &container{
+			base: base{
+				status: types.ContainerStatus{
+					ID: foo,
+				},
+				runtimeConfig: &runtime.FakeConfig{
+					Runtime: &runtime.FakeLogsFollower{
+						FollowLogsF: followLogs,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestFollowLogs(t *testing.T) {
+	write := func(line string) func(ctx context.Context, id string, lines int, w io.Writer) error {
+		return func(ctx context.Context, id string, lines int, w io.Writer) error {
+			if lines != 10 {
+				t.Errorf("Expected 10 lines, got %d", lines)
+			}
+
+			_, err := w.Write([]byte(line + "\n"))
+
+			return err
+		}
+	}
+
+	c := &containers{
+		currentState: containersState{
+			foo: followLogsTestContainer(write("foo output")),
+			bar: followLogsTestContainer(write("bar output")),
+		},
+	}
+
+	var buf bytes.Buffer
+
+	if err := c.FollowLogs(context.Background(), nil, 10, &buf); err != nil {
+		t.Fatalf("Following logs should succeed, got: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	sort.Strings(lines)
+
+	expected := []string{
+		"bar (direct) | bar output",
+		"foo (direct) | foo output",
+	}
+
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected lines %q, got %q", expected, lines)
+	}
+}
+
+func TestFollowLogsSelected(t *testing.T) {
+	c := &containers{
+		currentState: containersState{
+			foo: followLogsTestContainer(func(ctx context.Context, id string, lines int, w io.Writer) error {
+				return nil
+			}),
+			bar: followLogsTestContainer(func(ctx context.Context, id string, lines int, w io.Writer) error {
+				t.Errorf("Logs of not selected container should not be followed")
+
+				return nil
+			}),
+		},
+	}
+
+	if err := c.FollowLogs(context.Background(), []string{foo}, 0, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Following logs should succeed, got: %v", err)
+	}
+}
+
+func TestFollowLogsCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &containers{
+		currentState: containersState{
+			foo: followLogsTestContainer(func(ctx context.Context, id string, lines int, w io.Writer) error {
+				<-ctx.Done()
+
+				return nil
+			}),
+		},
+	}
+
+	cancel()
+
+	if err := c.FollowLogs(ctx, nil, 0, &bytes.Buffer{}); err != nil {
+		t.Fatalf("Following logs should stop cleanly on cancel, got: %v", err)
+	}
+}
+
+func TestFollowLogsFail(t *testing.T) {
+	c := &containers{
+		currentState: containersState{
+			foo: followLogsTestContainer(func(ctx context.Context, id string, lines int, w io.Writer) error {
+				return fmt.Errorf("runtime error")
+			}),
+		},
+	}
+
+	if err := c.FollowLogs(context.Background(), nil, 0, &bytes.Buffer{}); err == nil {
+		t.Fatalf("Following logs should fail, when runtime fails")
+	}
+}
+
+func TestFollowLogsNonExistent(t *testing.T) {
+	c := &containers{
+		currentState: containersState{},
+	}
+
+	if err := c.FollowLogs(context.Background(), []string{foo}, 0, &bytes.Buffer{}); err == nil {
+		t.Fatalf("Following logs of non existing container should fail")
+	}
+}
+
+func TestFollowLogsNoCurrentState(t *testing.T) {
+	c := &containers{}
+
+	if err := c.FollowLogs(context.Background(), nil, 0, &bytes.Buffer{}); err == nil {
+		t.Fatalf("Following logs without current state should fail")
+	}
+}
@@ -0,0 +1,407 @@
+package container
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/yaml"
+
+	"github.com/flexkube/libflexkube/internal/util"
+)
+
+// PlanVersion is a version of the plan format produced by Plan. Plans with different version
+// are rejected, as they may not describe changes in the same way.
+const PlanVersion = 3
+
+// planFileMode is a mode used for writing plan files.
+const planFileMode = 0o600
+
+// PlanAction describes an action, which will be performed on the container during deployment.
+type PlanAction string
+
+const (
+	// PlanActionCreate means, that container does not exist and will be created.
+	PlanActionCreate PlanAction = "create"
+
+	// PlanActionRecreate means, that existing container will be removed and created again.
+	PlanActionRecreate PlanAction = "recreate"
+
+	// PlanActionUpdate means, that container configuration will be updated in place.
+	PlanActionUpdate PlanAction = "update"
+
+	// PlanActionRestart means, that configuration files of the container will be updated
+	// and container will be restarted.
+	PlanActionRestart PlanAction = "restart"
+
+	// PlanActionConfigure means, that only configuration files of the container will be updated.
+	PlanActionConfigure PlanAction = "configure"
+
+	// PlanActionStart means, that stopped container will be started.
+	PlanActionStart PlanAction = "start"
+
+	// PlanActionRemove means, that container is no longer desired and will be removed.
+	PlanActionRemove PlanAction = "remove"
+)
+
+// PlanReason describes why the container will be changed.
+type PlanReason string
+
+const (
+	// PlanReasonHostDrift means, that host configuration of the container has changed.
+	PlanReasonHostDrift PlanReason = "hostDrift"
+
+	// PlanReasonContainerConfigDrift means, that configuration of the container has changed.
+	PlanReasonContainerConfigDrift PlanReason = "containerConfigDrift"
+
+	// PlanReasonConfigFileDrift means, that content or permissions of configuration files
+	// of the container has changed.
+	PlanReasonConfigFileDrift PlanReason = "configFileDrift"
+
+	// PlanReasonRestartCountExceeded means, that container has been restarted by the runtime
+	// more times than allowed.
+	PlanReasonRestartCountExceeded PlanReason = "restartCountExceeded"
+
+	// PlanReasonPodRecreated means, that pod container of the container will be created again.
+	PlanReasonPodRecreated PlanReason = "podRecreated"
+)
+
+// ConfigFileChange describes a change of a single configuration file of the container.
+//
+// Configuration files may contain secrets like private keys, so plan only holds references
+// to their content, in the same format as compact state, see ConfigFileReference.
+type ConfigFileChange struct {
+	// Path is a path of the configuration file on the host.
+	Path string `json:"path"`
+
+	// Current is a reference to current content of the file. It is empty, if the file
+	// does not exist.
+	Current string `json:"current,omitempty"`
+
+	// Desired is a reference to desired content of the file.
+	Desired string `json:"desired,omitempty"`
+
+	// Permissions describes the permissions drift of the file, if only permissions differ.
+	Permissions string `json:"permissions,omitempty"`
+}
+
+// PlannedChange describes a change, which will be made to a single container.
+type PlannedChange struct {
+	// Container is a name of the container.
+	Container string `json:"container"`
+
+	// Host is an ID of the host, where the container is or will be running.
+	Host string `json:"host"`
+
+	// Action is an action, which will be performed on the container.
+	Action PlanAction `json:"action"`
+
+	// Reason describes why existing container will be changed.
+	Reason PlanReason `json:"reason,omitempty"`
+
+	// ConfigHash is a hash of desired configuration of the container. It allows to detect
+	// configuration changes, which result in the same action.
+	ConfigHash string `json:"configHash,omitempty"`
+
+	// ConfigFiles is a list of configuration files of the container, which will be written,
+	// sorted by path.
+	ConfigFiles []ConfigFileChange `json:"configFiles,omitempty"`
+}
+
+// Plan is a serializable list of changes, which deployment will make to the containers.
+type Plan struct {
+	// Version is a version of the plan format.
+	Version int `json:"version"`
+
+	// Changes is a list of planned changes, sorted by container name.
+	Changes []PlannedChange `json:"changes"`
+}
+
+// Validate validates the plan.
+func (p *Plan) Validate() error {
+	if p.Version != PlanVersion {
+		return fmt.Errorf("plan has format version %d, while only version %d is supported, plan must be created again",
+			p.Version, PlanVersion)
+	}
+
+	return nil
+}
+
+// LoadPlan reads the plan from given file and validates it.
+func LoadPlan(path string) (*Plan, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading plan file: %w", err)
+	}
+
+	p := &Plan{}
+
+	if err := yaml.Unmarshal(b, p); err != nil {
+		return nil, fmt.Errorf("failed parsing plan: %w", err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("failed validating plan: %w", err)
+	}
+
+	return p, nil
+}
+
+// Plan computes changes, which deployment will make to the containers in scope of the
+// deployment, without modifying the containers or the state.
+func (c *containers) Plan() (*Plan, error) {
+	if c.currentState == nil {
+		return nil, fmt.Errorf("can't plan without knowing current state of the containers")
+	}
+
+	hosts := map[string]string{}
+
+	for _, s := range []containersState{c.currentState, c.desiredState} {
+		for _, t := range c.scopedTasks(s) {
+			hosts[t.name] = t.host
+		}
+	}
+
+	names := util.KeysStringMap(hosts)
+	changes := map[string]*PlannedChange{}
+
+	for _, n := range names {
+		action, reason, err := c.plannedAction(n)
+		if err != nil {
+			return nil, fmt.Errorf("failed planning changes for container %s: %w", n, err)
+		}
+
+		changes[n] = &PlannedChange{
+			Container: n,
+			Host:      hosts[n],
+			Action:    action,
+			Reason:    reason,
+		}
+	}
+
+	c.planPodRecreations(changes)
+
+	p := &Plan{
+		Version: PlanVersion,
+		Changes: []PlannedChange{},
+	}
+
+	for _, n := range names {
+		pc := changes[n]
+		if pc.Action == "" {
+			continue
+		}
+
+		if d, ok := c.desiredState[n]; ok {
+			pc.ConfigHash = configHash(d.container.Config())
+			pc.ConfigFiles = c.plannedConfigFiles(n)
+		}
+
+		p.Changes = append(p.Changes, *pc)
+	}
+
+	return p, nil
+}
+
+// plannedAction returns an action, which deployment will perform on given container and the
+// reason of it. If container is up to date, empty action is returned.
+func (c *containers) plannedAction(n string) (PlanAction, PlanReason, error) {
+	d, isDesired := c.desiredState[n]
+	r, isCurrent := c.current(n)
+
+	if !isDesired {
+		return PlanActionRemove, "", nil
+	}
+
+	if !isCurrent || !r.container.Status().Exists() {
+		return PlanActionCreate, "", nil
+	}
+
+	hostDiff, err := c.diffHost(n)
+	if err != nil {
+		return "", "", err
+	}
+
+	containerDiff, err := c.diffContainer(n)
+	if err != nil {
+		return "", "", err
+	}
+
+	filesChanged := len(changedConfigFiles(*d, *r)) > 0 || len(permissionsDrift(*d, *r)) > 0
+
+	switch {
+	case hostDiff != "":
+		return PlanActionRecreate, PlanReasonHostDrift, nil
+	case c.restartCountExceeded(n):
+		return PlanActionRecreate, PlanReasonRestartCountExceeded, nil
+	case containerDiff != "" && !c.updatableInPlace(n):
+		return PlanActionRecreate, PlanReasonContainerConfigDrift, nil
+	case len(changedEnvFiles(*d, *r)) > 0:
+		return PlanActionRecreate, PlanReasonConfigFileDrift, nil
+	case containerDiff != "":
+		return PlanActionUpdate, PlanReasonContainerConfigDrift, nil
+	case filesChanged && (d.restartOnConfigChange || len(deletedFileMounts(*d, *r)) > 0):
+		return PlanActionRestart, PlanReasonConfigFileDrift, nil
+	case filesChanged:
+		return PlanActionConfigure, PlanReasonConfigFileDrift, nil
+	case !r.container.Status().Running():
+		return PlanActionStart, "", nil
+	}
+
+	return "", "", nil
+}
+
+// plannedConfigFiles returns changes of configuration files of given desired container, which
+// will be written during deployment, sorted by path.
+func (c *containers) plannedConfigFiles(n string) []ConfigFileChange {
+	d := c.desiredState[n]
+	r, ok := c.current(n)
+
+	files := []ConfigFileChange{}
+
+	if !ok || !r.container.Status().Exists() {
+		for _, p := range util.KeysStringMap(d.configFiles) {
+			files = append(files, ConfigFileChange{
+				Path:    p,
+				Desired: ConfigFileReference(d.configFiles[p]),
+			})
+		}
+	} else {
+		for _, p := range changedConfigFiles(*d, *r) {
+			files = append(files, ConfigFileChange{
+				Path:    p,
+				Current: currentConfigFileReference(*r, p),
+				Desired: ConfigFileReference(d.configFiles[p]),
+			})
+		}
+
+		for p, drift := range permissionsDrift(*d, *r) {
+			files = append(files, ConfigFileChange{
+				Path:        p,
+				Permissions: drift,
+			})
+		}
+	}
+
+	if len(files) == 0 {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	return files
+}
+
+// currentConfigFileReference returns reference to current content of given configuration file
+// of given container or empty string, if file does not exist. Content stored in compact state
+// is already a reference, so it is returned as is.
+func currentConfigFileReference(r hostConfiguredContainer, p string) string {
+	content, ok := r.configFiles[p]
+
+	switch {
+	case !ok:
+		return ""
+	case isConfigFileReference(content):
+		return content
+	default:
+		return ConfigFileReference(content)
+	}
+}
+
+// planPodRecreations marks containers as recreated, if their pod container will be created
+// or recreated, as they must join the new network namespace.
+func (c *containers) planPodRecreations(changes map[string]*PlannedChange) {
+	for n, pc := range changes {
+		d, ok := c.desiredState[n]
+		if !ok || d.pod == "" || pc.Action == PlanActionCreate || pc.Action == PlanActionRecreate {
+			continue
+		}
+
+		if pod, ok := changes[d.pod]; ok && (pod.Action == PlanActionCreate || pod.Action == PlanActionRecreate) {
+			pc.Action = PlanActionRecreate
+			pc.Reason = PlanReasonPodRecreated
+		}
+	}
+}
+
+// SavePlan computes changes, which deployment will make to the containers and writes them
+// to given file in YAML format, so they can be verified by ApplyPlan.
+func (c *containers) SavePlan(path string) error {
+	p, err := c.Plan()
+	if err != nil {
+		return err
+	}
+
+	b, err := yaml.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed serializing plan: %w", err)
+	}
+
+	if err := ioutil.WriteFile(path, b, planFileMode); err != nil {
+		return fmt.Errorf("failed writing plan file: %w", err)
+	}
+
+	return nil
+}
+
+// planDiff returns differences between given plan and changes, which deployment would
+// make now.
+func (c *containers) planDiff(p *Plan) (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", fmt.Errorf("failed validating plan: %w", err)
+	}
+
+	current, err := c.Plan()
+	if err != nil {
+		return "", err
+	}
+
+	return cmp.Diff(p.Changes, current.Changes), nil
+}
+
+// DeployWithPlan deploys the containers, if changes, which deployment would make are the same
+// as in given plan. If plan is nil, it behaves the same as Deploy.
+func (c *containers) DeployWithPlan(p *Plan) error {
+	if p == nil {
+		return c.Deploy()
+	}
+
+	diff, err := c.planDiff(p)
+	if err != nil {
+		return err
+	}
+
+	if diff != "" {
+		return fmt.Errorf("refusing to deploy, as planned changes differ from given plan:\n%s", diff)
+	}
+
+	return c.Deploy()
+}
+
+// ApplyPlan reads the plan from given file, compares it with the changes, which deployment
+// would make now and deploys the containers, if they are the same. If force is true, differences
+// are logged, but deployment proceeds.
+func (c *containers) ApplyPlan(path string, force bool) error {
+	saved, err := LoadPlan(path)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		return c.DeployWithPlan(saved)
+	}
+
+	diff, err := c.planDiff(saved)
+	if err != nil {
+		return err
+	}
+
+	if diff != "" {
+		c.warnf("", "Planned changes differ from saved plan, deploying anyway\n  Diff: %v", util.ColorizeDiff(diff))
+	}
+
+	return c.Deploy()
+}
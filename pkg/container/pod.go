@@ -0,0 +1,123 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+)
+
+// podNetworkMode returns network mode, which makes container join network namespace of the
+// container with given name.
+func podNetworkMode(name string) string {
+	return fmt.Sprintf("container:%s", name)
+}
+
+// validatePods checks, that pod containers referenced by containers in given state exist in
+// the state, run on the same host and that they are not in a pod themselves.
+func validatePods(s ContainersState) error {
+	names := []string{}
+
+	for n := range s {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	for _, n := range names {
+		hcc := s[n]
+		if hcc.Pod == "" {
+			continue
+		}
+
+		p, ok := s[hcc.Pod]
+
+		switch {
+		case hcc.Pod == n:
+			return fmt.Errorf("container %q can't be in a pod of itself", n)
+		case !ok:
+			return fmt.Errorf("container %q is in a pod of non-existing container %q", n, hcc.Pod)
+		case p.Pod != "":
+			return fmt.Errorf("container %q is in a pod of container %q, which is in a pod itself", n, hcc.Pod)
+		case p.Host.ID() != hcc.Host.ID():
+			return fmt.Errorf("container %q must run on the same host as it's pod container %q", n, hcc.Pod)
+		case len(hcc.Container.Config.Ports) > 0:
+			return fmt.Errorf("container %q in a pod can't expose ports, they must be exposed by pod container", n)
+		}
+
+		nm := hcc.Container.Config.NetworkMode
+		if nm != "" && nm != podNetworkMode(p.Container.Config.Name) {
+			return fmt.Errorf("container %q in a pod can't have network mode set", n)
+		}
+	}
+
+	return nil
+}
+
+// withPods validates pods in given state and returns copy of it, where containers in pods
+// join network namespace of their pod containers. If there are no pods, state is returned as is.
+func withPods(s ContainersState) (ContainersState, error) {
+	if err := validatePods(s); err != nil {
+		return nil, err
+	}
+
+	hasPods := false
+
+	for _, hcc := range s {
+		if hcc.Pod != "" {
+			hasPods = true
+		}
+	}
+
+	if !hasPods {
+		return s, nil
+	}
+
+	ps := s.DeepCopy()
+
+	for _, hcc := range ps {
+		if hcc.Pod != "" {
+			hcc.Container.Config.NetworkMode = podNetworkMode(ps[hcc.Pod].Container.Config.Name)
+		}
+	}
+
+	return ps, nil
+}
+
+// dependencies returns names of containers, which must be running and healthy, before given
+// container is created, which includes pod container of the container.
+func (m *hostConfiguredContainer) dependencies() []string {
+	if m.pod == "" {
+		return m.waitForHealthy
+	}
+
+	return append([]string{m.pod}, m.waitForHealthy...)
+}
+
+// markCreated records, that given container has been created during the deployment.
+func (c *containers) markCreated(n string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.created == nil {
+		c.created = map[string]struct{}{}
+	}
+
+	c.created[n] = struct{}{}
+}
+
+// podRecreated returns true, if pod container of given container has been created during the
+// deployment, while the container itself has not, which means container is attached to
+// the network namespace, which no longer exists.
+func (c *containers) podRecreated(n string) bool {
+	d, ok := c.desiredState[n]
+	if !ok || d.pod == "" {
+		return false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, podCreated := c.created[d.pod]
+	_, created := c.created[n]
+
+	return podCreated && !created
+}
@@ -0,0 +1,187 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+	"github.com/flexkube/libflexkube/pkg/host/transport/ssh"
+)
+
+func podTestContainer(name, pod string) *HostConfiguredContainer {
+	return &HostConfiguredContainer{
+		Container: Container{
+			Config: types.ContainerConfig{
+				Name: name,
+			},
+		},
+		Host: host.Host{
+			DirectConfig: &direct.Config{},
+		},
+		Pod: pod,
+	}
+}
+
+// validatePods() tests.
+func TestValidatePods(t *testing.T) {
+	otherHost := podTestContainer(bar, "")
+	otherHost.Host = host.Host{
+		SSHConfig: &ssh.Config{
+			Address: foo,
+		},
+	}
+
+	withPorts := podTestContainer(foo, bar)
+	withPorts.Container.Config.Ports = []types.PortMap{{Port: 80}}
+
+	withNetworkMode := podTestContainer(foo, bar)
+	withNetworkMode.Container.Config.NetworkMode = "host"
+
+	withPodNetworkMode := podTestContainer(foo, bar)
+	withPodNetworkMode.Container.Config.NetworkMode = podNetworkMode(bar)
+
+	cases := map[string]struct {
+		state ContainersState
+		err   bool
+	}{
+		"valid": {
+			state: ContainersState{
+				foo: podTestContainer(foo, bar),
+				bar: podTestContainer(bar, ""),
+			},
+		},
+		"self": {
+			state: ContainersState{
+				foo: podTestContainer(foo, foo),
+			},
+			err: true,
+		},
+		"non existing": {
+			state: ContainersState{
+				foo: podTestContainer(foo, bar),
+			},
+			err: true,
+		},
+		"nested": {
+			state: ContainersState{
+				foo:   podTestContainer(foo, bar),
+				bar:   podTestContainer(bar, "baz"),
+				"baz": podTestContainer("baz", ""),
+			},
+			err: true,
+		},
+		"different host": {
+			state: ContainersState{
+				foo: podTestContainer(foo, bar),
+				bar: otherHost,
+			},
+			err: true,
+		},
+		"ports": {
+			state: ContainersState{
+				foo: withPorts,
+				bar: podTestContainer(bar, ""),
+			},
+			err: true,
+		},
+		"network mode": {
+			state: ContainersState{
+				foo: withNetworkMode,
+				bar: podTestContainer(bar, ""),
+			},
+			err: true,
+		},
+		"pod network mode": {
+			state: ContainersState{
+				foo: withPodNetworkMode,
+				bar: podTestContainer(bar, ""),
+			},
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			err := validatePods(c.state)
+
+			if c.err && err == nil {
+				t.Fatalf("Expected error")
+			}
+
+			if !c.err && err != nil {
+				t.Fatalf("Didn't expect error, got: %v", err)
+			}
+		})
+	}
+}
+
+// withPods() tests.
+func TestWithPods(t *testing.T) {
+	s := ContainersState{
+		foo: podTestContainer(foo, bar),
+		bar: podTestContainer("bar-runtime", ""),
+	}
+
+	ps, err := withPods(s)
+	if err != nil {
+		t.Fatalf("Configuring pods should succeed, got: %v", err)
+	}
+
+	if nm := ps[foo].Container.Config.NetworkMode; nm != "container:bar-runtime" {
+		t.Fatalf("Container in a pod should join network namespace of pod container, got network mode %q", nm)
+	}
+
+	if ps[bar].Container.Config.NetworkMode != "" {
+		t.Fatalf("Network mode of pod container should not be changed")
+	}
+
+	if s[foo].Container.Config.NetworkMode != "" {
+		t.Fatalf("Given state should not be modified")
+	}
+}
+
+// podRecreated() tests.
+func TestPodRecreated(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{
+				pod: bar,
+			},
+			bar: &hostConfiguredContainer{},
+		},
+	}
+
+	if c.podRecreated(foo) {
+		t.Fatalf("Pod container should not be considered recreated")
+	}
+
+	c.markCreated(bar)
+
+	if !c.podRecreated(foo) {
+		t.Fatalf("Container should be recreated, when pod container has been recreated")
+	}
+
+	c.markCreated(foo)
+
+	if c.podRecreated(foo) {
+		t.Fatalf("Container created after pod container should not be recreated again")
+	}
+}
+
+// waitDepth() tests.
+func TestWaitDepthPod(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{
+				pod: bar,
+			},
+			bar: &hostConfiguredContainer{},
+		},
+	}
+
+	if d := c.waitDepth(foo); d != 1 {
+		t.Fatalf("Container in a pod should be processed after pod container, got depth %d", d)
+	}
+}
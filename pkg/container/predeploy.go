@@ -0,0 +1,75 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/flexkube/libflexkube/pkg/host"
+)
+
+// hostDiff returns difference between given host configurations. Pre-deploy commands are
+// ignored, as changing them does not require moving containers to a different host.
+func hostDiff(current, desired host.Host) string {
+	return cmp.Diff(current, desired, cmpopts.IgnoreFields(host.Host{}, "PreDeployCommands"))
+}
+
+// preDeployHosts returns hosts with pre-deploy commands configured, on which containers in scope
+// of the deployment will be deployed, indexed by host ID.
+func (c *containers) preDeployHosts() map[string]host.Host {
+	hosts := map[string]host.Host{}
+
+	for n, d := range c.desiredState {
+		if len(d.host.PreDeployCommands) == 0 || !c.inScope(n) {
+			continue
+		}
+
+		hosts[d.host.ID()] = d.host
+	}
+
+	return hosts
+}
+
+// runPreDeployCommands runs pre-deploy commands configured on hosts of the containers in scope of
+// the deployment, before any container is touched. Output of the commands is logged line by line
+// with host ID prefix. If commands fail on any of the hosts, the deployment is aborted.
+func (c *containers) runPreDeployCommands() error {
+	hosts := c.preDeployHosts()
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	c.logf("", "Running pre-deploy commands")
+
+	ids := []string{}
+
+	for id := range hosts {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		h := hosts[id]
+
+		id := id
+
+		lw := &logWriter{
+			log: func(line string) {
+				c.hostLogf(id, "%s | %s", id, line)
+			},
+		}
+
+		err := h.RunPreDeployCommands(lw)
+
+		lw.Flush()
+
+		if err != nil {
+			return fmt.Errorf("failed running pre-deploy commands on host %s: %w", id, err)
+		}
+	}
+
+	return nil
+}
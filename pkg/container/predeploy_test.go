@@ -0,0 +1,141 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+func preDeployTestHost(commands ...string) host.Host {
+	return host.Host{
+		DirectConfig:      &direct.Config{},
+		PreDeployCommands: commands,
+	}
+}
+
+// hostDiff() tests.
+func TestHostDiffIgnorePreDeployCommands(t *testing.T) {
+	if d := hostDiff(preDeployTestHost(foo), preDeployTestHost(bar)); d != "" {
+		t.Fatalf("Changing pre-deploy commands should not produce host diff, got: %s", d)
+	}
+
+	if d := hostDiff(preDeployTestHost(), sshTestHost(foo)); d == "" {
+		t.Fatalf("Changing transport method should produce host diff")
+	}
+}
+
+// preDeployHosts() tests.
+func TestPreDeployHosts(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: testHostFilterContainer(preDeployTestHost("true")),
+			bar: testHostFilterContainer(sshTestHost(bar)),
+		},
+	}
+
+	hosts := c.preDeployHosts()
+
+	if _, ok := hosts["direct"]; !ok || len(hosts) != 1 {
+		t.Fatalf("Only hosts with pre-deploy commands should be returned, got: %+v", hosts)
+	}
+}
+
+func TestPreDeployHostsOutOfScope(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: testHostFilterContainer(preDeployTestHost("true")),
+		},
+		hostFilter: &HostFilter{
+			Hosts: []string{bar},
+		},
+	}
+
+	if hosts := c.preDeployHosts(); len(hosts) != 0 {
+		t.Fatalf("Hosts out of scope should not be returned, got: %+v", hosts)
+	}
+}
+
+// runPreDeployCommands() tests.
+func TestRunPreDeployCommands(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: testHostFilterContainer(preDeployTestHost("true")),
+		},
+	}
+
+	if err := c.runPreDeployCommands(); err != nil {
+		t.Fatalf("Running pre-deploy commands should succeed, got: %v", err)
+	}
+}
+
+func TestRunPreDeployCommandsLogger(t *testing.T) {
+	l := &fakeLogger{}
+
+	c := &containers{
+		desiredState: containersState{
+			foo: testHostFilterContainer(preDeployTestHost("echo foo", "printf bar")),
+		},
+		logger: l,
+	}
+
+	if err := c.runPreDeployCommands(); err != nil {
+		t.Fatalf("Running pre-deploy commands should succeed, got: %v", err)
+	}
+
+	expected := []string{
+		"Running pre-deploy commands",
+		"direct | $ echo foo",
+		"direct | foo",
+		"direct | $ printf bar",
+		"direct | bar",
+	}
+
+	messages := []string{}
+
+	for _, e := range l.entries {
+		messages = append(messages, e.Message)
+	}
+
+	if diff := cmp.Diff(expected, messages); diff != "" {
+		t.Fatalf("Output of pre-deploy commands should be logged using configured logger: %s", diff)
+	}
+
+	if h := l.entries[1].Host; h != "direct" {
+		t.Fatalf("Output of pre-deploy commands should be logged with host ID, got %q", h)
+	}
+}
+
+func TestRunPreDeployCommandsFail(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: testHostFilterContainer(preDeployTestHost("false")),
+		},
+	}
+
+	if err := c.runPreDeployCommands(); err == nil {
+		t.Fatalf("Running failing pre-deploy commands should fail")
+	}
+}
+
+// ensureHost() tests.
+func TestEnsureHostUpdatePreDeployCommands(t *testing.T) {
+	c := &containers{
+		currentState: containersState{
+			foo: testHostFilterContainer(preDeployTestHost(foo)),
+		},
+		desiredState: containersState{
+			foo: testHostFilterContainer(preDeployTestHost(bar)),
+		},
+	}
+
+	if err := c.ensureHost(foo); err != nil {
+		t.Fatalf("Ensuring host should succeed, got: %v", err)
+	}
+
+	if cmds := c.currentState[foo].host.PreDeployCommands; len(cmds) != 1 || cmds[0] != bar {
+		t.Fatalf("Pre-deploy commands in current state should be updated, got: %v", cmds)
+	}
+}
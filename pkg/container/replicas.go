@@ -0,0 +1,116 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/flexkube/libflexkube/pkg/host"
+)
+
+// ReplicaName returns name of the replica of the container with given name and index,
+// e.g. 'kube-apiserver-0'.
+func ReplicaName(name string, index int) string {
+	return fmt.Sprintf("%s-%d", name, index)
+}
+
+// replicaIndex returns index of the replica from given container name, if name
+// is a replica name of the container with given name.
+func replicaIndex(name, replica string) (int, bool) {
+	prefix := fmt.Sprintf("%s-", name)
+
+	if !strings.HasPrefix(replica, prefix) {
+		return 0, false
+	}
+
+	suffix := strings.TrimPrefix(replica, prefix)
+
+	i, err := strconv.Atoi(suffix)
+	if err != nil || i < 0 || strconv.Itoa(i) != suffix {
+		return 0, false
+	}
+
+	return i, true
+}
+
+// AssignReplicas assigns replicas of the container with given name to given hosts, one
+// replica per host, and returns hosts indexed by replica name created using ReplicaName.
+//
+// Assignment is deterministic and stable across runs, so the same replica always lands on the
+// same host and it's configuration, like certificates, does not change:
+//
+// - hosts, which already run a replica according to given previous state, keep it.
+//
+// - remaining hosts are sorted by their ID and get lowest unused replica indexes.
+//
+// This means, that adding or removing hosts never moves existing replicas to other hosts.
+func AssignReplicas(name string, hosts []host.Host, previous ContainersState) (map[string]host.Host, error) {
+	desired := map[string]host.Host{}
+
+	for _, h := range hosts {
+		h := h
+		id := h.ID()
+
+		if _, ok := desired[id]; ok {
+			return nil, fmt.Errorf("host %q is specified more than once", id)
+		}
+
+		desired[id] = h
+	}
+
+	r := map[string]host.Host{}
+	used := map[int]struct{}{}
+
+	previousNames := []string{}
+
+	for n := range previous {
+		previousNames = append(previousNames, n)
+	}
+
+	sort.Strings(previousNames)
+
+	for _, n := range previousNames {
+		i, ok := replicaIndex(name, n)
+		if !ok || previous[n] == nil {
+			continue
+		}
+
+		id := previous[n].Host.ID()
+
+		h, ok := desired[id]
+		if !ok {
+			continue
+		}
+
+		r[n] = h
+		used[i] = struct{}{}
+
+		delete(desired, id)
+	}
+
+	ids := []string{}
+
+	for id := range desired {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	i := 0
+
+	for _, id := range ids {
+		for {
+			if _, ok := used[i]; !ok {
+				break
+			}
+
+			i++
+		}
+
+		r[ReplicaName(name, i)] = desired[id]
+		used[i] = struct{}{}
+	}
+
+	return r, nil
+}
@@ -0,0 +1,112 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/pkg/host"
+)
+
+func replicaHostIDs(r map[string]host.Host) map[string]string {
+	ids := map[string]string{}
+
+	for n, h := range r {
+		h := h
+		ids[n] = h.ID()
+	}
+
+	return ids
+}
+
+// ReplicaName() tests.
+func TestReplicaName(t *testing.T) {
+	if n := ReplicaName(foo, 1); n != "foo-1" {
+		t.Fatalf("Unexpected replica name: %q", n)
+	}
+}
+
+// replicaIndex() tests.
+func TestReplicaIndex(t *testing.T) {
+	cases := map[string]struct {
+		replica string
+		index   int
+		ok      bool
+	}{
+		"valid":          {"foo-2", 2, true},
+		"other name":     {"bar-2", 0, false},
+		"no index":       {"foo", 0, false},
+		"not a number":   {"foo-bar", 0, false},
+		"leading zero":   {"foo-01", 0, false},
+		"negative index": {"foo--1", 0, false},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			i, ok := replicaIndex(foo, c.replica)
+			if i != c.index || ok != c.ok {
+				t.Fatalf("Expected index %d and %t, got %d and %t", c.index, c.ok, i, ok)
+			}
+		})
+	}
+}
+
+// AssignReplicas() tests.
+func TestAssignReplicasSortedByHost(t *testing.T) {
+	r, err := AssignReplicas(foo, []host.Host{sshTestHost("2.2.2.2"), sshTestHost("1.1.1.1")}, nil)
+	if err != nil {
+		t.Fatalf("Assigning replicas should succeed, got: %v", err)
+	}
+
+	expected := map[string]string{
+		"foo-0": "ssh://1.1.1.1:22",
+		"foo-1": "ssh://2.2.2.2:22",
+	}
+
+	if diff := cmp.Diff(expected, replicaHostIDs(r)); diff != "" {
+		t.Fatalf("Unexpected replica assignment: %s", diff)
+	}
+}
+
+func TestAssignReplicasKeepPrevious(t *testing.T) {
+	previous := ContainersState{
+		"foo-0": {
+			Host: sshTestHost("3.3.3.3"),
+		},
+		"foo-1": {
+			Host: sshTestHost("1.1.1.1"),
+		},
+		"foo-2": {
+			Host: sshTestHost("4.4.4.4"),
+		},
+	}
+
+	hosts := []host.Host{
+		sshTestHost("1.1.1.1"),
+		sshTestHost("2.2.2.2"),
+		sshTestHost("4.4.4.4"),
+	}
+
+	r, err := AssignReplicas(foo, hosts, previous)
+	if err != nil {
+		t.Fatalf("Assigning replicas should succeed, got: %v", err)
+	}
+
+	expected := map[string]string{
+		"foo-0": "ssh://2.2.2.2:22",
+		"foo-1": "ssh://1.1.1.1:22",
+		"foo-2": "ssh://4.4.4.4:22",
+	}
+
+	if diff := cmp.Diff(expected, replicaHostIDs(r)); diff != "" {
+		t.Fatalf("Unexpected replica assignment: %s", diff)
+	}
+}
+
+func TestAssignReplicasDuplicatedHost(t *testing.T) {
+	if _, err := AssignReplicas(foo, []host.Host{sshTestHost(foo), sshTestHost(foo)}, nil); err == nil {
+		t.Fatalf("Assigning replicas should fail when host is specified more than once")
+	}
+}
@@ -22,6 +22,11 @@ type Containers struct {
 
 	// Containers stores user-provider containers to create.
 	Containers container.ContainersState `json:"containers,omitempty"`
+
+	// AllowRemoveAll allows removing all created containers, when list of containers is empty. Without it,
+	// deployment fails in such case, to prevent accidental removal, e.g. caused by invalid
+	// configuration.
+	AllowRemoveAll bool `json:"allowRemoveAll,omitempty"`
 }
 
 // containers implements both container.ContainersInterface and types.Resource.
@@ -34,8 +39,9 @@ type containers struct {
 // This method will validate all the configuration provided.
 func (c *Containers) New() (types.Resource, error) {
 	co := container.Containers{
-		PreviousState: c.State,
-		DesiredState:  c.Containers,
+		PreviousState:  c.State,
+		DesiredState:   c.Containers,
+		AllowRemoveAll: c.AllowRemoveAll,
 	}
 
 	ci, err := co.New()
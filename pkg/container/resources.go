@@ -0,0 +1,86 @@
+package container
+
+import (
+	"fmt"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+// resourceLimitsChangeLive checks, if resource limits changed from current to desired ones in a
+// way, which can be applied to the running container. Removing a limit can't be applied, as container
+// runtimes treat unset limits in update request as unchanged.
+func resourceLimitsChangeLive(current, desired types.ResourceLimits) bool {
+	if current == desired {
+		return false
+	}
+
+	return !(current.Memory != 0 && desired.Memory == 0) &&
+		!(current.NanoCPUs != 0 && desired.NanoCPUs == 0) &&
+		!(current.CpusetCpus != "" && desired.CpusetCpus == "") &&
+		!(current.CpusetMems != "" && desired.CpusetMems == "")
+}
+
+// onlyResourceLimitsDiffer checks, if given container configurations differ only by resource
+// limits, which can be applied to the running container.
+func onlyResourceLimitsDiffer(current, desired types.ContainerConfig) bool {
+	if !resourceLimitsChangeLive(current.ResourceLimits(), desired.ResourceLimits()) {
+		return false
+	}
+
+	current.SetResourceLimits(desired.ResourceLimits())
+
+	return cmp.Equal(current, desired)
+}
+
+// resourceLimitsOnlyDiff checks, if two containers are identical, except their resource limits,
+// which can be applied without re-creating the container.
+func resourceLimitsOnlyDiff(a, b HostConfiguredContainer) bool {
+	if !resourceLimitsChangeLive(a.Container.Config.ResourceLimits(), b.Container.Config.ResourceLimits()) {
+		return false
+	}
+
+	b.Container.Config.SetResourceLimits(a.Container.Config.ResourceLimits())
+
+	return diffHostConfiguredContainers(a, b) == ""
+}
+
+// resourcesOnlyChange checks, if only resource limits of given container has changed, so they
+// can be updated without recreating the container.
+func (c *containers) resourcesOnlyChange(n string) bool {
+	r, _ := c.current(n)
+	d := c.desiredState[n]
+
+	if cmp.Diff(r.container.RuntimeConfig(), d.container.RuntimeConfig()) != "" {
+		return false
+	}
+
+	return onlyResourceLimitsDiffer(r.container.Config(), d.container.Config())
+}
+
+// resourcesUpdatable checks, if given container can have it's resource limits updated in place.
+func (c *containers) resourcesUpdatable(n string) bool {
+	r, _ := c.current(n)
+
+	return c.desiredState[n].inPlaceAllowed() && c.resourcesOnlyChange(n) && r.supportsResourcesUpdate()
+}
+
+// updateResources updates resource limits of existing container in place and persists the
+// change in the current state.
+func (c *containers) updateResources(n string) error {
+	r, _ := c.current(n)
+	d := c.desiredState[n]
+
+	if err := c.withTimeout(n, "updating resource limits of", func() error {
+		return r.updateResources(d.container.Config().ResourceLimits())
+	}); err != nil {
+		return fmt.Errorf("failed updating resource limits: %w", err)
+	}
+
+	*d.container.Status() = *r.container.Status()
+
+	c.setCurrent(n, d)
+
+	return nil
+}
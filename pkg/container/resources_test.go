@@ -0,0 +1,193 @@
+package container
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+// resourceLimitsChangeLive() tests.
+func TestResourceLimitsChangeLive(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		current types.ResourceLimits
+		desired types.ResourceLimits
+		live    bool
+	}{
+		"no change": {
+			current: types.ResourceLimits{Memory: 1024},
+			desired: types.ResourceLimits{Memory: 1024},
+		},
+		"limit added": {
+			desired: types.ResourceLimits{Memory: 1024},
+			live:    true,
+		},
+		"limit changed": {
+			current: types.ResourceLimits{NanoCPUs: 1000000000, CpusetCpus: "0"},
+			desired: types.ResourceLimits{NanoCPUs: 2000000000, CpusetCpus: "0-1"},
+			live:    true,
+		},
+		"memory limit removed": {
+			current: types.ResourceLimits{Memory: 1024},
+		},
+		"cpuset removed": {
+			current: types.ResourceLimits{CpusetMems: "0"},
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			t.Parallel()
+
+			if live := resourceLimitsChangeLive(c.current, c.desired); live != c.live {
+				t.Fatalf("Expected live change to be %t, got %t", c.live, live)
+			}
+		})
+	}
+}
+
+// onlyResourceLimitsDiffer() tests.
+func TestOnlyResourceLimitsDifferOtherChanges(t *testing.T) {
+	t.Parallel()
+
+	current := types.ContainerConfig{
+		Image: foo,
+	}
+
+	desired := types.ContainerConfig{
+		Image:  bar,
+		Memory: 1024,
+	}
+
+	if onlyResourceLimitsDiffer(current, desired) {
+		t.Fatalf("Changes to other fields than resource limits should require recreation")
+	}
+}
+
+func resourcesTestContainers(rc *runtime.FakeConfig) *containers {
+	return &containers{
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{
+				host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				updateStrategy: UpdateStrategyInPlaceIfPossible,
+				container: &container{
+					base: base{
+						config: types.ContainerConfig{
+							Image:    foo,
+							Memory:   2048,
+							NanoCPUs: 1000000000,
+						},
+						runtimeConfig: rc,
+					},
+				},
+			},
+		},
+		currentState: containersState{
+			foo: &hostConfiguredContainer{
+				host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				container: &container{
+					base: base{
+						status: types.ContainerStatus{
+							ID: foo,
+						},
+						config: types.ContainerConfig{
+							Image:  foo,
+							Memory: 1024,
+						},
+						runtimeConfig: rc,
+					},
+				},
+			},
+		},
+	}
+}
+
+// ensureContainer() tests.
+func TestEnsureContainerResourcesOnly(t *testing.T) {
+	t.Parallel()
+
+	var updated types.ResourceLimits
+
+	rc := &runtime.FakeConfig{
+		Runtime: &runtime.FakeResourcesUpdater{
+			UpdateResourcesF: func(id string, limits types.ResourceLimits) ([]string, error) {
+				updated = limits
+
+				return nil, nil
+			},
+		},
+	}
+
+	c := resourcesTestContainers(rc)
+
+	if err := c.ensureContainer(foo); err != nil {
+		t.Fatalf("Ensuring that container resource limits are up to date should succeed, got: %v", err)
+	}
+
+	if updated.Memory != 2048 || updated.NanoCPUs != 1000000000 {
+		t.Fatalf("Desired resource limits should be applied in place, got: %+v", updated)
+	}
+
+	if c.currentState[foo].container.Status().ID != foo {
+		t.Fatalf("Updating resource limits should not recreate the container")
+	}
+
+	if c.currentState[foo].container.Config().Memory != 2048 {
+		t.Fatalf("Updated resource limits should be persisted in current state")
+	}
+}
+
+func TestResourcesUpdatableUnsupportedRuntime(t *testing.T) {
+	t.Parallel()
+
+	c := resourcesTestContainers(&runtime.FakeConfig{
+		Runtime: &runtime.Fake{},
+	})
+
+	if !c.resourcesOnlyChange(foo) {
+		t.Fatalf("Only resource limits should be detected as changed")
+	}
+
+	if c.resourcesUpdatable(foo) {
+		t.Fatalf("Resource limits should not be updatable, when runtime does not support it")
+	}
+}
+
+// DiffStates() tests.
+func TestDiffStatesResourceLimitsOnly(t *testing.T) {
+	t.Parallel()
+
+	desired := `
+desiredState:
+  bar:
+    host:
+      direct: {}
+    container:
+      runtime:
+        docker: {}
+      config:
+        name: bar
+        image: busybox
+        memory: 1024
+`
+
+	d, err := DiffStates([]byte(diffStatesBase), []byte(desired))
+	if err != nil {
+		t.Fatalf("Diffing valid states should work, got: %v", err)
+	}
+
+	if !strings.Contains(d, "Container 'bar' resource limits changed") {
+		t.Fatalf("Expected diff to classify resource limits only change, got: %s", d)
+	}
+}
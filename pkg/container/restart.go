@@ -0,0 +1,44 @@
+package container
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+// maxRestartBackoff is a maximum delay before starting the container, which has been
+// restarted by the runtime.
+const maxRestartBackoff = 5 * time.Minute
+
+// checkRestartLimit returns error, if container with given configuration and status has been
+// restarted more times than configured limit allows.
+func checkRestartLimit(config types.ContainerConfig, status types.ContainerStatus) error {
+	if config.RestartMaxRetries == 0 || status.RestartCount < config.RestartMaxRetries {
+		return nil
+	}
+
+	return fmt.Errorf("container has been restarted %d times, reaching the limit of %d restarts, "+
+		"not starting it again", status.RestartCount, config.RestartMaxRetries)
+}
+
+// restartBackoff returns, how long to wait before starting the container with given configuration
+// and status. Configured backoff is doubled with each restart, up to maxRestartBackoff.
+func restartBackoff(config types.ContainerConfig, status types.ContainerStatus) time.Duration {
+	// Configuration is validated, so error can be ignored.
+	b, _ := parseTimeout(config.RestartBackoff)
+
+	if b == 0 || status.RestartCount == 0 {
+		return 0
+	}
+
+	for i := 1; i < status.RestartCount && b < maxRestartBackoff; i++ {
+		b *= 2
+	}
+
+	if b > maxRestartBackoff {
+		return maxRestartBackoff
+	}
+
+	return b
+}
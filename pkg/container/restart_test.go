@@ -0,0 +1,69 @@
+package container
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+// checkRestartLimit() tests.
+func TestCheckRestartLimit(t *testing.T) {
+	cases := map[string]struct {
+		maxRetries   int
+		restartCount int
+		err          bool
+	}{
+		"no limit":       {restartCount: 100},
+		"below limit":    {maxRetries: 3, restartCount: 2},
+		"limit reached":  {maxRetries: 3, restartCount: 3, err: true},
+		"limit exceeded": {maxRetries: 3, restartCount: 4, err: true},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			config := types.ContainerConfig{RestartMaxRetries: c.maxRetries}
+			status := types.ContainerStatus{RestartCount: c.restartCount}
+
+			err := checkRestartLimit(config, status)
+			if !c.err && err != nil {
+				t.Fatalf("Didn't expect error, got: %v", err)
+			}
+
+			if c.err && err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+	}
+}
+
+// restartBackoff() tests.
+func TestRestartBackoff(t *testing.T) {
+	cases := map[string]struct {
+		backoff      string
+		restartCount int
+		expected     time.Duration
+	}{
+		"not configured":   {restartCount: 5},
+		"never restarted":  {backoff: "10s"},
+		"first restart":    {backoff: "10s", restartCount: 1, expected: 10 * time.Second},
+		"third restart":    {backoff: "10s", restartCount: 3, expected: 40 * time.Second},
+		"capped":           {backoff: "10s", restartCount: 100, expected: maxRestartBackoff},
+		"capped initially": {backoff: "1h", restartCount: 1, expected: maxRestartBackoff},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			config := types.ContainerConfig{RestartBackoff: c.backoff}
+			status := types.ContainerStatus{RestartCount: c.restartCount}
+
+			if b := restartBackoff(config, status); b != c.expected {
+				t.Fatalf("Expected backoff %s, got %s", c.expected, b)
+			}
+		})
+	}
+}
@@ -6,19 +6,25 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	dockertypes "github.com/docker/docker/api/types"
 	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/mount"
 	networktypes "github.com/docker/docker/api/types/network"
+	registrytypes "github.com/docker/docker/api/types/registry"
+	volumetypes "github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 
 	"github.com/flexkube/libflexkube/internal/util"
@@ -28,8 +34,21 @@ import (
 )
 
 const (
-	// stopTimeout is how long we wait when gracefully stopping the container before force-killing it.
+	// stopTimeout is how long we wait when gracefully stopping the container before force-killing it,
+	// if container has no stop timeout configured.
 	stopTimeout = 30 * time.Second
+
+	// NameLabel is a label set on all created containers, which holds the name of the container.
+	// It allows finding all containers created for given name.
+	NameLabel = "io.flexkube.name"
+
+	// AnnotationLabelPrefix is a prefix of labels, which hold container annotations, as Docker
+	// does not support annotations natively. This allows distinguishing them from regular labels.
+	AnnotationLabelPrefix = "io.flexkube.annotation."
+
+	// ConfigHashLabel is a label holding hash of the configuration, which container has been
+	// created with.
+	ConfigHashLabel = "io.flexkube.config-hash"
 )
 
 // Config struct represents Docker container runtime configuration.
@@ -53,6 +72,15 @@ type dockerClient interface { //nolint:dupl
 	ContainerStatPath(ctx context.Context, container, path string) (dockertypes.ContainerPathStat, error)
 	ImageList(ctx context.Context, options dockertypes.ImageListOptions) ([]dockertypes.ImageSummary, error)
 	ImagePull(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error)
+	VolumeCreate(ctx context.Context, options volumetypes.VolumeCreateBody) (dockertypes.Volume, error)
+	VolumeRemove(ctx context.Context, volumeID string, force bool) error
+	ContainerWait(ctx context.Context, container string, condition containertypes.WaitCondition) (<-chan containertypes.ContainerWaitOKBody, <-chan error)
+	ContainerList(ctx context.Context, options dockertypes.ContainerListOptions) ([]dockertypes.Container, error)
+	DistributionInspect(ctx context.Context, image, encodedRegistryAuth string) (registrytypes.DistributionInspect, error)
+	ServerVersion(ctx context.Context) (dockertypes.Version, error)
+	ImageInspectWithRaw(ctx context.Context, image string) (dockertypes.ImageInspect, []byte, error)
+	ContainerLogs(ctx context.Context, container string, options dockertypes.ContainerLogsOptions) (io.ReadCloser, error)
+	ContainerUpdate(ctx context.Context, container string, updateConfig containertypes.UpdateConfig) (containertypes.ContainerUpdateOKBody, error)
 }
 
 // docker struct is a struct, which can be used to manage Docker containers.
@@ -101,8 +129,10 @@ func (c *Config) getDockerClient() (*client.Client, error) {
 	return client.NewClientWithOpts(opts...)
 }
 
-// pullImageIfNotPresent pulls image if it's not already present on the host.
-func (d *docker) pullImageIfNotPresent(image string) error {
+// pullImageIfNotPresent pulls image if it's not already present on the host. If platform is
+// specified and image present on the host is built for different platform, image is pulled
+// again for the requested platform.
+func (d *docker) pullImageIfNotPresent(image, platform string) error {
 	// Pull image to make sure it's available.
 	// TODO make it configurable?
 	id, err := d.imageID(image)
@@ -110,11 +140,24 @@ func (d *docker) pullImageIfNotPresent(image string) error {
 		return fmt.Errorf("failed checking for image presence: %w", err)
 	}
 
-	if id != "" {
+	if id == "" {
+		return d.pullImage(image, platform)
+	}
+
+	if platform == "" {
+		return nil
+	}
+
+	i, _, err := d.cli.ImageInspectWithRaw(d.ctx, id)
+	if err != nil {
+		return fmt.Errorf("inspecting image failed: %w", err)
+	}
+
+	if strings.HasPrefix(platform+"/", i.Os+"/"+i.Architecture+"/") {
 		return nil
 	}
 
-	return d.pullImage(image)
+	return d.pullImage(image, platform)
 }
 
 // buildPorts converts container PortMap type to Docker port maps.
@@ -144,7 +187,7 @@ func buildPorts(ports []types.PortMap) (nat.PortMap, nat.PortSet, error) {
 }
 
 // mounts converts container Mount to Docker mount type.
-func mounts(m []types.Mount) []mount.Mount {
+func mounts(m []types.Mount, v []types.VolumeMount) []mount.Mount {
 	mounts := []mount.Mount{}
 
 	for _, m := range m {
@@ -159,19 +202,202 @@ func mounts(m []types.Mount) []mount.Mount {
 		})
 	}
 
+	for _, v := range v {
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeVolume,
+			Source:   v.Name,
+			Target:   v.Target,
+			ReadOnly: v.ReadOnly,
+		})
+	}
+
 	return mounts
 }
 
+// command returns arguments and entrypoint, which should be used for the container.
+// If debug command is set, it overrides both entrypoint and arguments.
+func command(config *types.ContainerConfig) ([]string, []string) {
+	if len(config.DebugCommand) > 0 {
+		return nil, config.DebugCommand
+	}
+
+	return config.Args, config.Entrypoint
+}
+
+// hostname returns hostname, which should be used for the container. If hostname is not
+// set, container name is used. Containers using host network can't have hostname set, so
+// empty hostname is returned for them, even if hostname is configured.
+func hostname(config *types.ContainerConfig) string {
+	if containertypes.NetworkMode(config.NetworkMode).IsHost() {
+		return ""
+	}
+
+	return util.PickString(config.Hostname, config.Name)
+}
+
+// labels returns labels for the container with given configuration, including the label
+// holding the name of the container, labels holding container annotations and the label
+// holding configuration hash, if it's set.
+func labels(config *types.ContainerConfig) map[string]string {
+	l := map[string]string{}
+
+	for k, v := range config.Labels {
+		l[k] = v
+	}
+
+	for k, v := range config.Annotations {
+		l[AnnotationLabelPrefix+k] = v
+	}
+
+	l[NameLabel] = config.Name
+
+	if config.ConfigHash != "" {
+		l[ConfigHashLabel] = config.ConfigHash
+	}
+
+	return l
+}
+
+// restartPolicy returns Docker restart policy for the container with given configuration. Docker
+// only supports limiting number of restarts with 'on-failure' policy, so setting the limit also
+// means, that container exiting successfully is not restarted, unlike with default
+// 'unless-stopped' policy. Restart backoff is managed by Docker itself and it can't be configured.
+func restartPolicy(config *types.ContainerConfig) containertypes.RestartPolicy {
+	if config.RestartMaxRetries > 0 {
+		return containertypes.RestartPolicy{
+			Name:              "on-failure",
+			MaximumRetryCount: config.RestartMaxRetries,
+		}
+	}
+
+	return containertypes.RestartPolicy{
+		Name: "unless-stopped",
+	}
+}
+
+// Prefixes of runtime options keys, which select Docker configuration struct to modify.
+const (
+	runtimeOptionConfigPrefix     = "config."
+	runtimeOptionHostConfigPrefix = "hostConfig."
+)
+
+// setField sets field with given name of given struct to given JSON-encoded value. Struct is
+// converted to JSON object, field is replaced and then object is decoded back, so any field,
+// which can be represented in JSON can be set, including fields of embedded structs.
+func setField(target interface{}, field, value string) error {
+	if !json.Valid([]byte(value)) {
+		return fmt.Errorf("value %q is not valid JSON", value)
+	}
+
+	b, err := json.Marshal(target)
+	if err != nil {
+		return fmt.Errorf("failed encoding configuration: %w", err)
+	}
+
+	fields := map[string]json.RawMessage{}
+
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return fmt.Errorf("failed decoding configuration fields: %w", err)
+	}
+
+	fields[field] = json.RawMessage(value)
+
+	b, err = json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed encoding configuration fields: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(target); err != nil {
+		return fmt.Errorf("failed setting field %q: %w", field, err)
+	}
+
+	return nil
+}
+
+// healthConfig converts given health check into Docker health check configuration. Durations
+// are expected to be validated already, so parsing errors are ignored and runtime defaults are used.
+func healthConfig(hc *types.HealthCheck) *containertypes.HealthConfig {
+	if hc == nil {
+		return nil
+	}
+
+	interval, _ := time.ParseDuration(hc.Interval)
+	timeout, _ := time.ParseDuration(hc.Timeout)
+	startPeriod, _ := time.ParseDuration(hc.StartPeriod)
+
+	return &containertypes.HealthConfig{
+		Test:        append([]string{"CMD"}, hc.Command...),
+		Interval:    interval,
+		Timeout:     timeout,
+		StartPeriod: startPeriod,
+		Retries:     hc.Retries,
+	}
+}
+
+// applyRuntimeOptions sets given runtime options on Docker container and host configuration.
+// Options are applied in order of their keys, so the result is deterministic.
+func applyRuntimeOptions(options map[string]string, config *containertypes.Config, hostConfig *containertypes.HostConfig) error {
+	keys := []string{}
+
+	for k := range options {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		var err error
+
+		switch {
+		case strings.HasPrefix(k, runtimeOptionConfigPrefix):
+			err = setField(config, strings.TrimPrefix(k, runtimeOptionConfigPrefix), options[k])
+		case strings.HasPrefix(k, runtimeOptionHostConfigPrefix):
+			err = setField(hostConfig, strings.TrimPrefix(k, runtimeOptionHostConfigPrefix), options[k])
+		default:
+			err = fmt.Errorf("key must start with %q or %q", runtimeOptionConfigPrefix, runtimeOptionHostConfigPrefix)
+		}
+
+		if err != nil {
+			return fmt.Errorf("invalid option %q: %w", k, err)
+		}
+	}
+
+	return nil
+}
+
 // Start starts Docker container.
 func (d *docker) Create(config *types.ContainerConfig) (string, error) {
-	if err := d.pullImageIfNotPresent(config.Image); err != nil {
+	// Docker API version used does not allow selecting platform when creating the container,
+	// so platform is selected by pulling the image for it, as containers are created from
+	// local images.
+	if err := d.pullImageIfNotPresent(config.Image, config.Platform); err != nil {
 		return "", fmt.Errorf("failed pulling image: %w", err)
 	}
 
+	dockerConfig, hostConfig, err := containerConfigs(config)
+	if err != nil {
+		return "", err
+	}
+
+	// Create container.
+	c, err := d.cli.ContainerCreate(d.ctx, dockerConfig, hostConfig, &networktypes.NetworkingConfig{}, config.Name)
+	if err != nil {
+		return "", fmt.Errorf("creating container: %w", err)
+	}
+
+	return c.ID, nil
+}
+
+// containerConfigs builds Docker container configuration and host configuration from
+// given container configuration.
+func containerConfigs(config *types.ContainerConfig) (*containertypes.Config, *containertypes.HostConfig, error) {
 	// TODO That should be validated at ContainerConfig level!
 	portBindings, exposedPorts, err := buildPorts(config.Ports)
 	if err != nil {
-		return "", fmt.Errorf("failed building ports: %w", err)
+		return nil, nil, fmt.Errorf("failed building ports: %w", err)
 	}
 
 	u := config.User
@@ -179,33 +405,50 @@ func (d *docker) Create(config *types.ContainerConfig) (string, error) {
 		u = fmt.Sprintf("%s:%s", config.User, config.Group)
 	}
 
+	cmd, entrypoint := command(config)
+
 	// Just structs required for starting container.
 	dockerConfig := containertypes.Config{
 		Image:        config.Image,
-		Cmd:          config.Args,
-		Entrypoint:   config.Entrypoint,
+		Cmd:          cmd,
+		Entrypoint:   entrypoint,
+		Env:          config.Env,
 		ExposedPorts: exposedPorts,
 		User:         u,
+		StopSignal:   config.StopSignal,
+		Hostname:     hostname(config),
+		WorkingDir:   config.WorkingDir,
+		Labels:       labels(config),
 	}
+
+	if config.StopTimeout > 0 {
+		dockerConfig.StopTimeout = &config.StopTimeout
+	}
+
+	dockerConfig.Healthcheck = healthConfig(config.HealthCheck)
+
 	hostConfig := containertypes.HostConfig{
-		Mounts:       mounts(config.Mounts),
+		Mounts:       mounts(config.Mounts, config.Volumes),
 		PortBindings: portBindings,
 		Privileged:   config.Privileged,
 		NetworkMode:  containertypes.NetworkMode(config.NetworkMode),
 		PidMode:      containertypes.PidMode(config.PidMode),
 		IpcMode:      containertypes.IpcMode(config.IpcMode),
-		RestartPolicy: containertypes.RestartPolicy{
-			Name: "unless-stopped",
-		},
+		Init:         &config.Init,
+		OomScoreAdj:  config.OOMScoreAdj,
+		ShmSize:      config.ShmSize,
+		Resources:    resources(config.ResourceLimits()),
 	}
 
-	// Create container.
-	c, err := d.cli.ContainerCreate(d.ctx, &dockerConfig, &hostConfig, &networktypes.NetworkingConfig{}, config.Name)
-	if err != nil {
-		return "", fmt.Errorf("creating container: %w", err)
+	hostConfig.CgroupParent = config.CgroupParent
+
+	hostConfig.RestartPolicy = restartPolicy(config)
+
+	if err := applyRuntimeOptions(config.RuntimeOptions, &dockerConfig, &hostConfig); err != nil {
+		return nil, nil, fmt.Errorf("failed applying runtime options: %w", err)
 	}
 
-	return c.ID, nil
+	return &dockerConfig, &hostConfig, nil
 }
 
 // Start starts Docker container.
@@ -213,10 +456,20 @@ func (d *docker) Start(id string) error {
 	return d.cli.ContainerStart(d.ctx, id, dockertypes.ContainerStartOptions{})
 }
 
-// Stop stops Docker container.
+// Stop stops Docker container. If container has stop timeout configured, it is respected,
+// otherwise default stop timeout is used.
 func (d *docker) Stop(id string) error {
-	// TODO make timeout configurable?
 	timeout := stopTimeout
+
+	c, err := d.cli.ContainerInspect(d.ctx, id)
+	if err != nil {
+		return fmt.Errorf("inspecting container failed: %w", err)
+	}
+
+	if c.Config != nil && c.Config.StopTimeout != nil {
+		timeout = time.Duration(*c.Config.StopTimeout) * time.Second
+	}
+
 	return d.cli.ContainerStop(d.ctx, id, &timeout)
 }
 
@@ -239,10 +492,96 @@ func (d *docker) Status(id string) (types.ContainerStatus, error) {
 	}
 
 	s.Status = status.State.Status
+	s.RestartCount = status.RestartCount
+	s.ExitCode = status.State.ExitCode
+
+	if status.State.Health != nil {
+		s.Health = status.State.Health.Status
+	}
+
+	if status.Config != nil {
+		s.ConfigHash = status.Config.Labels[ConfigHashLabel]
+	}
 
 	return s, nil
 }
 
+// Exists checks, if container with given ID exists.
+func (d *docker) Exists(id string) (types.Existence, error) {
+	if _, err := d.cli.ContainerInspect(d.ctx, id); err != nil {
+		if client.IsErrNotFound(err) {
+			return types.Existence{Reason: types.ExistenceRemoved}, nil
+		}
+
+		return types.Existence{Reason: types.ExistenceUnknown}, fmt.Errorf("inspecting container failed: %w", err)
+	}
+
+	return types.Existence{Exists: true, Reason: types.ExistenceFound}, nil
+}
+
+// Logs returns given number of last lines of combined standard output and standard error
+// of the container.
+func (d *docker) Logs(id string, lines int) (string, error) {
+	out, err := d.cli.ContainerLogs(d.ctx, id, dockertypes.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(lines),
+	})
+	if err != nil {
+		return "", fmt.Errorf("reading container logs failed: %w", err)
+	}
+
+	var buf bytes.Buffer
+
+	// Containers are created without TTY, so logs are multiplexed.
+	if _, err := stdcopy.StdCopy(&buf, &buf, out); err != nil {
+		return "", fmt.Errorf("demultiplexing container logs failed: %w", err)
+	}
+
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("closing container logs failed: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// FollowLogs streams logs of the container with given ID to given writer, starting from
+// given number of last lines, until given context is cancelled or container stops.
+func (d *docker) FollowLogs(ctx context.Context, id string, lines int, w io.Writer) error {
+	out, err := d.cli.ContainerLogs(ctx, id, dockertypes.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Tail:       strconv.Itoa(lines),
+	})
+	if err != nil {
+		return fmt.Errorf("reading container logs failed: %w", err)
+	}
+
+	// Close the stream when context gets cancelled, so demultiplexing does not block.
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = out.Close()
+		case <-done:
+		}
+	}()
+
+	// Containers are created without TTY, so logs are multiplexed.
+	if _, err := stdcopy.StdCopy(w, w, out); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("demultiplexing container logs failed: %w", err)
+	}
+
+	if err := out.Close(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("closing container logs failed: %w", err)
+	}
+
+	return nil
+}
+
 // Delete removes the container.
 func (d *docker) Delete(id string) error {
 	return d.cli.ContainerRemove(d.ctx, id, dockertypes.ContainerRemoveOptions{})
@@ -260,6 +599,163 @@ func (d *docker) Copy(id string, files []*types.File) error {
 	return d.cli.CopyToContainer(d.ctx, id, "/", t, dockertypes.CopyToContainerOptions{})
 }
 
+// shellQuote quotes given string, so it can be safely used as a shell argument.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// renameCommand returns shell command, which renames given files. Paths are sorted to
+// make the command deterministic.
+func renameCommand(paths map[string]string) string {
+	c := []string{}
+
+	for _, src := range util.KeysStringMap(paths) {
+		c = append(c, fmt.Sprintf("mv -f %s %s", shellQuote(src), shellQuote(paths[src])))
+	}
+
+	return strings.Join(c, " && ")
+}
+
+// renameShell is a shell used for renaming files. It must be available in the image of the
+// container, in which files are renamed.
+const renameShell = "/bin/sh"
+
+// CanRename checks, if shell required for renaming files is available in the image of the
+// container with given ID. Images without shell, like distroless images, can't rename files.
+func (d *docker) CanRename(id string) (bool, error) {
+	s, err := d.Stat(id, []string{renameShell})
+	if err != nil {
+		return false, fmt.Errorf("checking for shell failed: %w", err)
+	}
+
+	_, ok := s[renameShell]
+
+	return ok, nil
+}
+
+// Rename renames files in the container with given ID. Key of the given map is a source path
+// and value is a destination path. As Docker does not allow renaming files directly, rename is
+// performed by short-lived container, which shares mounts with the given container and runs
+// 'mv' from the same image. Failure of removing the rename container is returned as a warning.
+func (d *docker) Rename(id string, paths map[string]string) (warnings []string, err error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	c, err := d.cli.ContainerInspect(d.ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("inspecting container failed: %w", err)
+	}
+
+	dockerConfig := containertypes.Config{
+		Image:      c.Config.Image,
+		Entrypoint: []string{renameShell, "-c"},
+		Cmd:        []string{renameCommand(paths)},
+	}
+
+	hostConfig := containertypes.HostConfig{
+		VolumesFrom: []string{id},
+	}
+
+	r, err := d.cli.ContainerCreate(d.ctx, &dockerConfig, &hostConfig, &networktypes.NetworkingConfig{}, "")
+	if err != nil {
+		return nil, fmt.Errorf("creating rename container failed: %w", err)
+	}
+
+	defer func() {
+		if err := d.cli.ContainerRemove(d.ctx, r.ID, dockertypes.ContainerRemoveOptions{Force: true}); err != nil {
+			warnings = append(warnings, fmt.Sprintf("removing rename container failed: %v", err))
+		}
+	}()
+
+	statusCh, errCh := d.cli.ContainerWait(d.ctx, r.ID, containertypes.WaitConditionNextExit)
+
+	if err := d.cli.ContainerStart(d.ctx, r.ID, dockertypes.ContainerStartOptions{}); err != nil {
+		return nil, fmt.Errorf("starting rename container failed: %w", err)
+	}
+
+	select {
+	case err := <-errCh:
+		return nil, fmt.Errorf("waiting for rename container failed: %w", err)
+	case s := <-statusCh:
+		if s.StatusCode != 0 {
+			return nil, fmt.Errorf("renaming files failed with exit code %d", s.StatusCode)
+		}
+	}
+
+	return nil, nil
+}
+
+// resources converts given resource limits to Docker resources.
+func resources(limits types.ResourceLimits) containertypes.Resources {
+	return containertypes.Resources{
+		Memory:     limits.Memory,
+		NanoCPUs:   limits.NanoCPUs,
+		CpusetCpus: limits.CpusetCpus,
+		CpusetMems: limits.CpusetMems,
+	}
+}
+
+// UpdateResources updates resource limits of the running container with given ID.
+//
+// Docker sets swap limit to twice the memory limit, when the container is created with
+// memory limit only, so the same value is set on update, as otherwise Docker rejects memory
+// limits higher than the current swap limit. Warnings reported by Docker are returned.
+func (d *docker) UpdateResources(id string, limits types.ResourceLimits) ([]string, error) {
+	r := resources(limits)
+
+	if r.Memory > 0 {
+		r.MemorySwap = 2 * r.Memory
+	}
+
+	u, err := d.cli.ContainerUpdate(d.ctx, id, containertypes.UpdateConfig{Resources: r})
+	if err != nil {
+		return nil, fmt.Errorf("updating container resources failed: %w", err)
+	}
+
+	return u.Warnings, nil
+}
+
+// List returns all containers created for the container with given name, including stopped
+// ones. Containers are found using both their name and the label holding the name, which
+// is set on all created containers.
+func (d *docker) List(name string) ([]types.ContainerInstance, error) {
+	filtersArgs := []filters.KeyValuePair{
+		filters.Arg("name", fmt.Sprintf("^/%s$", name)),
+		filters.Arg("label", fmt.Sprintf("%s=%s", NameLabel, name)),
+	}
+
+	found := map[string]struct{}{}
+	r := []types.ContainerInstance{}
+
+	// Filters of different kinds are combined using logical AND, so each filter must be
+	// queried separately.
+	for _, f := range filtersArgs {
+		cs, err := d.cli.ContainerList(d.ctx, dockertypes.ContainerListOptions{
+			All:     true,
+			Filters: filters.NewArgs(f),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing containers failed: %w", err)
+		}
+
+		for _, c := range cs {
+			if _, ok := found[c.ID]; ok {
+				continue
+			}
+
+			found[c.ID] = struct{}{}
+
+			r = append(r, types.ContainerInstance{
+				ID:      c.ID,
+				Created: time.Unix(c.Created, 0),
+			})
+		}
+	}
+
+	return r, nil
+}
+
 // filesToTar converts list of container files to tar archive format.
 func filesToTar(files []*types.File) (io.Reader, error) {
 	buf := new(bytes.Buffer)
@@ -423,9 +919,12 @@ func (d *docker) imageID(image string) (string, error) {
 	return "", nil
 }
 
-// pullImage pulls specified container image.
-func (d *docker) pullImage(image string) error {
-	out, err := d.cli.ImagePull(d.ctx, image, dockertypes.ImagePullOptions{})
+// pullImage pulls specified container image. If platform is empty, image for the native
+// platform of the host is pulled.
+func (d *docker) pullImage(image, platform string) error {
+	out, err := d.cli.ImagePull(d.ctx, image, dockertypes.ImagePullOptions{
+		Platform: platform,
+	})
 	if err != nil {
 		return fmt.Errorf("pulling image failed: %w", err)
 	}
@@ -437,9 +936,80 @@ func (d *docker) pullImage(image string) error {
 	return out.Close()
 }
 
+// CheckImage checks, that given image is either present on the host or that it exists in the
+// registry and it is available for the platform of the host. Registry is queried by Docker daemon,
+// the same way as when pulling the image. If architecture is not specified, architecture reported
+// by Docker daemon is used.
+func (d *docker) CheckImage(image, architecture string) error {
+	v, err := d.cli.ServerVersion(d.ctx)
+	if err != nil {
+		return fmt.Errorf("failed getting Docker daemon platform: %w", err)
+	}
+
+	if architecture == "" {
+		architecture = v.Arch
+	}
+
+	id, err := d.imageID(image)
+	if err != nil {
+		return fmt.Errorf("failed checking for image presence: %w", err)
+	}
+
+	if id != "" {
+		i, _, err := d.cli.ImageInspectWithRaw(d.ctx, id)
+		if err != nil {
+			return fmt.Errorf("inspecting image failed: %w", err)
+		}
+
+		if i.Architecture != architecture {
+			return fmt.Errorf("image present on the host has architecture %q, expected %q", i.Architecture, architecture)
+		}
+
+		return nil
+	}
+
+	di, err := d.cli.DistributionInspect(d.ctx, image, "")
+	if err != nil {
+		return fmt.Errorf("inspecting image in the registry failed: %w", err)
+	}
+
+	// Registry may not report platforms, e.g. for single platform images, so there is nothing
+	// more to check.
+	if len(di.Platforms) == 0 {
+		return nil
+	}
+
+	for _, p := range di.Platforms {
+		if p.OS == v.Os && p.Architecture == architecture {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("image is not available for platform %s/%s", v.Os, architecture)
+}
+
 // DefaultConfig returns Docker's runtime default configuration.
 func DefaultConfig() *Config {
 	return &Config{
 		Host: client.DefaultDockerHost,
 	}
 }
+
+// CreateVolume creates named Docker volume. Docker returns existing volume, if volume
+// with given name already exists, so this operation is idempotent.
+func (d *docker) CreateVolume(name string) error {
+	if _, err := d.cli.VolumeCreate(d.ctx, volumetypes.VolumeCreateBody{Name: name}); err != nil {
+		return fmt.Errorf("creating volume %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// RemoveVolume removes named Docker volume. If volume does not exist, no error is returned.
+func (d *docker) RemoveVolume(name string) error {
+	if err := d.cli.VolumeRemove(d.ctx, name, false); err != nil && !client.IsErrNotFound(err) {
+		return fmt.Errorf("removing volume %q: %w", name, err)
+	}
+
+	return nil
+}
@@ -246,7 +246,7 @@ func TestImageID(t *testing.T) {
 	image := "haproxy:2.0.7-alpine"
 
 	// Make sure image is present on the host.
-	if err := d.pullImage(image); err != nil {
+	if err := d.pullImage(image, ""); err != nil {
 		t.Fatalf("Pulling image failed: %v", err)
 	}
 
@@ -294,7 +294,7 @@ func TestPullImage(t *testing.T) {
 		t.Fatalf("Deleted image should not be not found")
 	}
 
-	if err := d.pullImage(image); err != nil {
+	if err := d.pullImage(image, ""); err != nil {
 		t.Fatalf("Pulling image failed: %v", err)
 	}
 
@@ -2,21 +2,27 @@ package docker
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	dockertypes "github.com/docker/docker/api/types"
 	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
 	networktypes "github.com/docker/docker/api/types/network"
+	registrytypes "github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/google/go-cmp/cmp"
 
 	"github.com/flexkube/libflexkube/pkg/container/types"
@@ -104,6 +110,93 @@ func TestStatus(t *testing.T) {
 	}
 }
 
+func TestStatusHealth(t *testing.T) {
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerInspectF: func(ctx context.Context, id string) (dockertypes.ContainerJSON, error) {
+				return dockertypes.ContainerJSON{
+					ContainerJSONBase: &dockertypes.ContainerJSONBase{
+						State: &dockertypes.ContainerState{
+							Status: "running",
+							Health: &dockertypes.Health{
+								Status: "unhealthy",
+							},
+						},
+					},
+				}, nil
+			},
+		},
+	}
+
+	s, err := d.Status("foo")
+	if err != nil {
+		t.Fatalf("Checking for status should succeed, got: %v", err)
+	}
+
+	if s.Health != "unhealthy" {
+		t.Fatalf("Health reported by Docker should be included in the status, got %q", s.Health)
+	}
+}
+
+func TestStatusConfigHash(t *testing.T) {
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerInspectF: func(ctx context.Context, id string) (dockertypes.ContainerJSON, error) {
+				return dockertypes.ContainerJSON{
+					ContainerJSONBase: &dockertypes.ContainerJSONBase{
+						State: &dockertypes.ContainerState{
+							Status: "running",
+						},
+					},
+					Config: &containertypes.Config{
+						Labels: map[string]string{
+							ConfigHashLabel: "foo",
+						},
+					},
+				}, nil
+			},
+		},
+	}
+
+	s, err := d.Status("foo")
+	if err != nil {
+		t.Fatalf("Checking for status should succeed, got: %v", err)
+	}
+
+	if s.ConfigHash != "foo" {
+		t.Fatalf("Configuration hash from container labels should be included in the status, got %q", s.ConfigHash)
+	}
+}
+
+func TestStatusExitCode(t *testing.T) {
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerInspectF: func(ctx context.Context, id string) (dockertypes.ContainerJSON, error) {
+				return dockertypes.ContainerJSON{
+					ContainerJSONBase: &dockertypes.ContainerJSONBase{
+						State: &dockertypes.ContainerState{
+							Status:   "exited",
+							ExitCode: 2,
+						},
+					},
+				}, nil
+			},
+		},
+	}
+
+	s, err := d.Status("foo")
+	if err != nil {
+		t.Fatalf("Checking for status should succeed, got: %v", err)
+	}
+
+	if s.ExitCode != 2 {
+		t.Fatalf("Exit code reported by Docker should be included in the status, got %d", s.ExitCode)
+	}
+}
+
 func TestStatusNotFound(t *testing.T) {
 	d := &docker{
 		ctx: context.Background(),
@@ -139,6 +232,52 @@ func TestStatusRuntimeError(t *testing.T) {
 	}
 }
 
+// Exists() tests.
+func TestExists(t *testing.T) {
+	cases := map[string]struct {
+		err      error
+		expected types.Existence
+		fail     bool
+	}{
+		"found": {
+			expected: types.Existence{Exists: true, Reason: types.ExistenceFound},
+		},
+		"removed": {
+			err:      errdefs.NotFound(fmt.Errorf("not found")),
+			expected: types.Existence{Reason: types.ExistenceRemoved},
+		},
+		"runtime error": {
+			err:      fmt.Errorf("connection refused"),
+			expected: types.Existence{Reason: types.ExistenceUnknown},
+			fail:     true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			d := &docker{
+				ctx: context.Background(),
+				cli: &FakeClient{
+					ContainerInspectF: func(ctx context.Context, id string) (dockertypes.ContainerJSON, error) {
+						return dockertypes.ContainerJSON{}, c.err
+					},
+				},
+			}
+
+			e, err := d.Exists("foo")
+			if c.fail != (err != nil) {
+				t.Fatalf("Expected error: %v, got: %v", c.fail, err)
+			}
+
+			if diff := cmp.Diff(c.expected, e); diff != "" {
+				t.Fatalf("Unexpected existence result: %s", diff)
+			}
+		})
+	}
+}
+
 // Copy() tests.
 func TestCopyRuntimeError(t *testing.T) {
 	d := &docker{
@@ -411,6 +550,35 @@ func TestCreateSetUser(t *testing.T) {
 	}
 }
 
+func TestCreateSetEnv(t *testing.T) {
+	c := &types.ContainerConfig{
+		Env: []string{"FOO=bar"},
+	}
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerCreateF: func(ctx context.Context, config *containertypes.Config, hostConfig *containertypes.HostConfig, networkingConfig *networktypes.NetworkingConfig, containerName string) (containertypes.ContainerCreateCreatedBody, error) {
+				if diff := cmp.Diff(c.Env, config.Env); diff != "" {
+					t.Fatalf("Unexpected environment variables: %s", diff)
+				}
+
+				return containertypes.ContainerCreateCreatedBody{}, nil
+			},
+			ImagePullF: func(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error) {
+				return ioutil.NopCloser(strings.NewReader("")), nil
+			},
+			ImageListF: func(ctx context.Context, options dockertypes.ImageListOptions) ([]dockertypes.ImageSummary, error) {
+				return []dockertypes.ImageSummary{}, nil
+			},
+		},
+	}
+
+	if _, err := d.Create(c); err != nil {
+		t.Fatalf("Create should succeed, got: %v", err)
+	}
+}
+
 func TestCreateSetUserGroup(t *testing.T) {
 	c := &types.ContainerConfig{
 		User:  "test",
@@ -443,6 +611,81 @@ func TestCreateSetUserGroup(t *testing.T) {
 	}
 }
 
+func TestCreateSetInitAndStopSignal(t *testing.T) {
+	c := &types.ContainerConfig{
+		Init:        true,
+		StopSignal:  "SIGTERM",
+		StopTimeout: 60,
+	}
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerCreateF: func(ctx context.Context, config *containertypes.Config, hostConfig *containertypes.HostConfig, networkingConfig *networktypes.NetworkingConfig, containerName string) (containertypes.ContainerCreateCreatedBody, error) {
+				if hostConfig.Init == nil || !*hostConfig.Init {
+					t.Fatalf("init process should be enabled")
+				}
+
+				if config.StopSignal != c.StopSignal {
+					t.Fatalf("configured stop signal should be %s, got %s", c.StopSignal, config.StopSignal)
+				}
+
+				if config.StopTimeout == nil || *config.StopTimeout != c.StopTimeout {
+					t.Fatalf("configured stop timeout should be %d, got %v", c.StopTimeout, config.StopTimeout)
+				}
+
+				return containertypes.ContainerCreateCreatedBody{}, nil
+			},
+			ImagePullF: func(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error) {
+				return ioutil.NopCloser(strings.NewReader("")), nil
+			},
+			ImageListF: func(ctx context.Context, options dockertypes.ImageListOptions) ([]dockertypes.ImageSummary, error) {
+				return []dockertypes.ImageSummary{}, nil
+			},
+		},
+	}
+
+	if _, err := d.Create(c); err != nil {
+		t.Fatalf("Create should succeed, got: %v", err)
+	}
+}
+
+// command() tests.
+func TestCommand(t *testing.T) {
+	c := &types.ContainerConfig{
+		Args:       []string{"--foo"},
+		Entrypoint: []string{"/bin/foo"},
+	}
+
+	cmd, entrypoint := command(c)
+
+	if diff := cmp.Diff(c.Args, cmd); diff != "" {
+		t.Errorf("Unexpected args: %s", diff)
+	}
+
+	if diff := cmp.Diff(c.Entrypoint, entrypoint); diff != "" {
+		t.Errorf("Unexpected entrypoint: %s", diff)
+	}
+}
+
+func TestCommandDebug(t *testing.T) {
+	c := &types.ContainerConfig{
+		Args:         []string{"--foo"},
+		Entrypoint:   []string{"/bin/foo"},
+		DebugCommand: []string{"sleep", "infinity"},
+	}
+
+	cmd, entrypoint := command(c)
+
+	if len(cmd) != 0 {
+		t.Errorf("Debug command should clear container arguments, got: %v", cmd)
+	}
+
+	if diff := cmp.Diff(c.DebugCommand, entrypoint); diff != "" {
+		t.Errorf("Debug command should override entrypoint: %s", diff)
+	}
+}
+
 func TestCreateRuntimeFail(t *testing.T) {
 	d := &docker{
 		ctx: context.Background(),
@@ -500,3 +743,1073 @@ func TestGetAddress(t *testing.T) {
 		t.Fatalf("expected %q, got %q", f, a)
 	}
 }
+
+// hostname() tests.
+func TestHostname(t *testing.T) {
+	cases := map[string]struct {
+		config   *types.ContainerConfig
+		expected string
+	}{
+		"default to container name": {
+			config: &types.ContainerConfig{
+				Name: "foo",
+			},
+			expected: "foo",
+		},
+		"explicit hostname": {
+			config: &types.ContainerConfig{
+				Name:     "foo",
+				Hostname: "bar",
+			},
+			expected: "bar",
+		},
+		"host network": {
+			config: &types.ContainerConfig{
+				Name:        "foo",
+				NetworkMode: "host",
+			},
+			expected: "",
+		},
+		"host network with explicit hostname": {
+			config: &types.ContainerConfig{
+				Name:        "foo",
+				Hostname:    "bar",
+				NetworkMode: "host",
+			},
+			expected: "",
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			if h := hostname(c.config); h != c.expected {
+				t.Fatalf("expected hostname %q, got %q", c.expected, h)
+			}
+		})
+	}
+}
+
+func TestCreateSetHostnameAndWorkingDir(t *testing.T) {
+	c := &types.ContainerConfig{
+		Name:       "foo",
+		WorkingDir: "/foo",
+	}
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerCreateF: func(ctx context.Context, config *containertypes.Config, hostConfig *containertypes.HostConfig, networkingConfig *networktypes.NetworkingConfig, containerName string) (containertypes.ContainerCreateCreatedBody, error) {
+				if config.Hostname != c.Name {
+					t.Fatalf("hostname should default to container name %q, got %q", c.Name, config.Hostname)
+				}
+
+				if config.WorkingDir != c.WorkingDir {
+					t.Fatalf("configured working directory should be %q, got %q", c.WorkingDir, config.WorkingDir)
+				}
+
+				return containertypes.ContainerCreateCreatedBody{}, nil
+			},
+			ImagePullF: func(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error) {
+				return ioutil.NopCloser(strings.NewReader("")), nil
+			},
+			ImageListF: func(ctx context.Context, options dockertypes.ImageListOptions) ([]dockertypes.ImageSummary, error) {
+				return []dockertypes.ImageSummary{}, nil
+			},
+		},
+	}
+
+	if _, err := d.Create(c); err != nil {
+		t.Fatalf("Create should succeed, got: %v", err)
+	}
+}
+
+func TestCreateSetCpuset(t *testing.T) {
+	c := &types.ContainerConfig{
+		Name:         "foo",
+		CpusetCpus:   "0-1",
+		CpusetMems:   "0",
+		CgroupParent: "system.slice",
+	}
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerCreateF: func(ctx context.Context, config *containertypes.Config, hostConfig *containertypes.HostConfig, networkingConfig *networktypes.NetworkingConfig, containerName string) (containertypes.ContainerCreateCreatedBody, error) {
+				if hostConfig.CpusetCpus != c.CpusetCpus {
+					t.Fatalf("configured CPU set should be %q, got %q", c.CpusetCpus, hostConfig.CpusetCpus)
+				}
+
+				if hostConfig.CpusetMems != c.CpusetMems {
+					t.Fatalf("configured memory nodes set should be %q, got %q", c.CpusetMems, hostConfig.CpusetMems)
+				}
+
+				if hostConfig.CgroupParent != c.CgroupParent {
+					t.Fatalf("configured cgroup parent should be %q, got %q", c.CgroupParent, hostConfig.CgroupParent)
+				}
+
+				return containertypes.ContainerCreateCreatedBody{}, nil
+			},
+			ImagePullF: func(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error) {
+				return ioutil.NopCloser(strings.NewReader("")), nil
+			},
+			ImageListF: func(ctx context.Context, options dockertypes.ImageListOptions) ([]dockertypes.ImageSummary, error) {
+				return []dockertypes.ImageSummary{}, nil
+			},
+		},
+	}
+
+	if _, err := d.Create(c); err != nil {
+		t.Fatalf("Create should succeed, got: %v", err)
+	}
+}
+
+func TestCreateSetResourceLimits(t *testing.T) {
+	c := &types.ContainerConfig{
+		Name:     "foo",
+		Memory:   536870912,
+		NanoCPUs: 1500000000,
+	}
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerCreateF: func(ctx context.Context, config *containertypes.Config, hostConfig *containertypes.HostConfig, networkingConfig *networktypes.NetworkingConfig, containerName string) (containertypes.ContainerCreateCreatedBody, error) {
+				if hostConfig.Memory != c.Memory {
+					t.Fatalf("configured memory limit should be %d, got %d", c.Memory, hostConfig.Memory)
+				}
+
+				if hostConfig.NanoCPUs != c.NanoCPUs {
+					t.Fatalf("configured CPU limit should be %d, got %d", c.NanoCPUs, hostConfig.NanoCPUs)
+				}
+
+				return containertypes.ContainerCreateCreatedBody{}, nil
+			},
+			ImagePullF: func(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error) {
+				return ioutil.NopCloser(strings.NewReader("")), nil
+			},
+			ImageListF: func(ctx context.Context, options dockertypes.ImageListOptions) ([]dockertypes.ImageSummary, error) {
+				return []dockertypes.ImageSummary{}, nil
+			},
+		},
+	}
+
+	if _, err := d.Create(c); err != nil {
+		t.Fatalf("Create should succeed, got: %v", err)
+	}
+}
+
+func TestCreateSetShmSize(t *testing.T) {
+	c := &types.ContainerConfig{
+		Name:    "foo",
+		ShmSize: 268435456,
+	}
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerCreateF: func(ctx context.Context, config *containertypes.Config, hostConfig *containertypes.HostConfig, networkingConfig *networktypes.NetworkingConfig, containerName string) (containertypes.ContainerCreateCreatedBody, error) {
+				if hostConfig.ShmSize != c.ShmSize {
+					t.Fatalf("configured shm size should be %d, got %d", c.ShmSize, hostConfig.ShmSize)
+				}
+
+				return containertypes.ContainerCreateCreatedBody{}, nil
+			},
+			ImagePullF: func(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error) {
+				return ioutil.NopCloser(strings.NewReader("")), nil
+			},
+			ImageListF: func(ctx context.Context, options dockertypes.ImageListOptions) ([]dockertypes.ImageSummary, error) {
+				return []dockertypes.ImageSummary{}, nil
+			},
+		},
+	}
+
+	if _, err := d.Create(c); err != nil {
+		t.Fatalf("Create should succeed, got: %v", err)
+	}
+}
+
+func TestCreateSetVolumes(t *testing.T) {
+	c := &types.ContainerConfig{
+		Name: "foo",
+		Volumes: []types.VolumeMount{
+			{
+				Name:     "foo-data",
+				Target:   "/data",
+				ReadOnly: true,
+			},
+		},
+	}
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerCreateF: func(ctx context.Context, config *containertypes.Config, hostConfig *containertypes.HostConfig, networkingConfig *networktypes.NetworkingConfig, containerName string) (containertypes.ContainerCreateCreatedBody, error) {
+				expected := []mount.Mount{
+					{
+						Type:     mount.TypeVolume,
+						Source:   "foo-data",
+						Target:   "/data",
+						ReadOnly: true,
+					},
+				}
+
+				if diff := cmp.Diff(expected, hostConfig.Mounts); diff != "" {
+					t.Fatalf("Unexpected mounts: %s", diff)
+				}
+
+				return containertypes.ContainerCreateCreatedBody{}, nil
+			},
+			ImagePullF: func(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error) {
+				return ioutil.NopCloser(strings.NewReader("")), nil
+			},
+			ImageListF: func(ctx context.Context, options dockertypes.ImageListOptions) ([]dockertypes.ImageSummary, error) {
+				return []dockertypes.ImageSummary{}, nil
+			},
+		},
+	}
+
+	if _, err := d.Create(c); err != nil {
+		t.Fatalf("Create should succeed, got: %v", err)
+	}
+}
+
+// RemoveVolume() tests.
+func TestRemoveVolumeNotFound(t *testing.T) {
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			VolumeRemoveF: func(ctx context.Context, volumeID string, force bool) error {
+				return errdefs.NotFound(fmt.Errorf("not found"))
+			},
+		},
+	}
+
+	if err := d.RemoveVolume("foo"); err != nil {
+		t.Fatalf("Removing non-existing volume should succeed, got: %v", err)
+	}
+}
+
+func TestRemoveVolumeFail(t *testing.T) {
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			VolumeRemoveF: func(ctx context.Context, volumeID string, force bool) error {
+				return fmt.Errorf("volume in use")
+			},
+		},
+	}
+
+	if err := d.RemoveVolume("foo"); err == nil {
+		t.Fatalf("Removing volume should fail")
+	}
+}
+
+// Rename() tests.
+func renameTestClient(t *testing.T, exitCode int64, cmd *[]string, removed *bool) *FakeClient {
+	t.Helper()
+
+	return &FakeClient{
+		ContainerInspectF: func(ctx context.Context, id string) (dockertypes.ContainerJSON, error) {
+			return dockertypes.ContainerJSON{
+				Config: &containertypes.Config{
+					Image: "foo",
+				},
+			}, nil
+		},
+		ContainerCreateF: func(ctx context.Context, config *containertypes.Config, hostConfig *containertypes.HostConfig, networkingConfig *networktypes.NetworkingConfig, containerName string) (containertypes.ContainerCreateCreatedBody, error) {
+			if diff := cmp.Diff([]string{"foo"}, hostConfig.VolumesFrom); diff != "" {
+				t.Errorf("Rename container should use volumes from renamed container: %s", diff)
+			}
+
+			*cmd = config.Cmd
+
+			return containertypes.ContainerCreateCreatedBody{
+				ID: "bar",
+			}, nil
+		},
+		ContainerWaitF: func(ctx context.Context, container string, condition containertypes.WaitCondition) (<-chan containertypes.ContainerWaitOKBody, <-chan error) {
+			s := make(chan containertypes.ContainerWaitOKBody, 1)
+			s <- containertypes.ContainerWaitOKBody{
+				StatusCode: exitCode,
+			}
+
+			return s, make(chan error)
+		},
+		ContainerStartF: func(ctx context.Context, container string, options dockertypes.ContainerStartOptions) error {
+			return nil
+		},
+		ContainerRemoveF: func(ctx context.Context, container string, options dockertypes.ContainerRemoveOptions) error {
+			*removed = true
+
+			return nil
+		},
+	}
+}
+
+func TestRename(t *testing.T) {
+	cmd := []string{}
+	removed := false
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: renameTestClient(t, 0, &cmd, &removed),
+	}
+
+	if _, err := d.Rename("foo", map[string]string{"/.foo.tmp": "/foo", "/.bar'.tmp": "/bar'"}); err != nil {
+		t.Fatalf("Renaming should succeed, got: %v", err)
+	}
+
+	expected := []string{`mv -f '/.bar'"'"'.tmp' '/bar'"'"'' && mv -f '/.foo.tmp' '/foo'`}
+
+	if diff := cmp.Diff(expected, cmd); diff != "" {
+		t.Fatalf("Unexpected rename command: %s", diff)
+	}
+
+	if !removed {
+		t.Fatalf("Rename container should be removed")
+	}
+}
+
+func TestRenameFailedCommand(t *testing.T) {
+	cmd := []string{}
+	removed := false
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: renameTestClient(t, 1, &cmd, &removed),
+	}
+
+	if _, err := d.Rename("foo", map[string]string{"/.foo.tmp": "/foo"}); err == nil {
+		t.Fatalf("Renaming should fail when rename command fails")
+	}
+
+	if !removed {
+		t.Fatalf("Rename container should be removed also when renaming fails")
+	}
+}
+
+func TestRenameRemoveFailWarning(t *testing.T) {
+	cmd := []string{}
+	removed := false
+
+	c := renameTestClient(t, 0, &cmd, &removed)
+	c.ContainerRemoveF = func(ctx context.Context, container string, options dockertypes.ContainerRemoveOptions) error {
+		return fmt.Errorf("runtime error")
+	}
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: c,
+	}
+
+	warnings, err := d.Rename("foo", map[string]string{"/.foo.tmp": "/foo"})
+	if err != nil {
+		t.Fatalf("Renaming should succeed, when only removing rename container fails, got: %v", err)
+	}
+
+	if len(warnings) != 1 {
+		t.Fatalf("Failure of removing rename container should be returned as warning, got: %v", warnings)
+	}
+}
+
+func TestCanRename(t *testing.T) {
+	for _, shell := range []bool{true, false} {
+		d := &docker{
+			ctx: context.Background(),
+			cli: &FakeClient{
+				ContainerStatPathF: func(ctx context.Context, container, path string) (dockertypes.ContainerPathStat, error) {
+					if !shell {
+						return dockertypes.ContainerPathStat{}, errdefs.NotFound(fmt.Errorf("not found"))
+					}
+
+					return dockertypes.ContainerPathStat{Name: "sh"}, nil
+				},
+			},
+		}
+
+		ok, err := d.CanRename("foo")
+		if err != nil {
+			t.Fatalf("Checking if files can be renamed should succeed, got: %v", err)
+		}
+
+		if ok != shell {
+			t.Fatalf("Files should be renamable only when shell is available, shell: %v, got: %v", shell, ok)
+		}
+	}
+}
+
+func TestRenameNoPaths(t *testing.T) {
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{},
+	}
+
+	if _, err := d.Rename("foo", map[string]string{}); err != nil {
+		t.Fatalf("Renaming no files should be no-op, got: %v", err)
+	}
+}
+
+// Stop() tests.
+func testStopTimeout(t *testing.T, configured *int) time.Duration {
+	t.Helper()
+
+	var timeout time.Duration
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerInspectF: func(ctx context.Context, id string) (dockertypes.ContainerJSON, error) {
+				return dockertypes.ContainerJSON{
+					Config: &containertypes.Config{
+						StopTimeout: configured,
+					},
+				}, nil
+			},
+			ContainerStopF: func(ctx context.Context, container string, st *time.Duration) error {
+				timeout = *st
+
+				return nil
+			},
+		},
+	}
+
+	if err := d.Stop("foo"); err != nil {
+		t.Fatalf("Stopping container should succeed, got: %v", err)
+	}
+
+	return timeout
+}
+
+func TestStopDefaultTimeout(t *testing.T) {
+	if timeout := testStopTimeout(t, nil); timeout != stopTimeout {
+		t.Fatalf("Default stop timeout %s should be used, got %s", stopTimeout, timeout)
+	}
+}
+
+func TestStopConfiguredTimeout(t *testing.T) {
+	configured := 5
+
+	if timeout := testStopTimeout(t, &configured); timeout != 5*time.Second {
+		t.Fatalf("Configured stop timeout should be used, got %s", timeout)
+	}
+}
+
+func TestStopInspectFail(t *testing.T) {
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerInspectF: func(ctx context.Context, id string) (dockertypes.ContainerJSON, error) {
+				return dockertypes.ContainerJSON{}, fmt.Errorf("inspecting failed")
+			},
+		},
+	}
+
+	if err := d.Stop("foo"); err == nil {
+		t.Fatalf("Stopping container should fail when inspecting fails")
+	}
+}
+
+// restartPolicy() tests.
+func TestRestartPolicy(t *testing.T) {
+	cases := map[string]struct {
+		config   *types.ContainerConfig
+		expected containertypes.RestartPolicy
+	}{
+		"no limit": {
+			config: &types.ContainerConfig{},
+			expected: containertypes.RestartPolicy{
+				Name: "unless-stopped",
+			},
+		},
+		"limit switches to on-failure": {
+			config: &types.ContainerConfig{
+				RestartMaxRetries: 3,
+			},
+			expected: containertypes.RestartPolicy{
+				Name:              "on-failure",
+				MaximumRetryCount: 3,
+			},
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			if diff := cmp.Diff(c.expected, restartPolicy(c.config)); diff != "" {
+				t.Fatalf("Unexpected restart policy: %s", diff)
+			}
+		})
+	}
+}
+
+// labels() tests.
+func TestLabels(t *testing.T) {
+	c := &types.ContainerConfig{
+		Name: "foo",
+		Labels: map[string]string{
+			"bar": "baz",
+		},
+	}
+
+	expected := map[string]string{
+		"bar":     "baz",
+		NameLabel: "foo",
+	}
+
+	if diff := cmp.Diff(expected, labels(c)); diff != "" {
+		t.Fatalf("Unexpected labels: %s", diff)
+	}
+
+	if _, ok := c.Labels[NameLabel]; ok {
+		t.Fatalf("Container configuration should not be modified")
+	}
+}
+
+func TestLabelsAnnotations(t *testing.T) {
+	c := &types.ContainerConfig{
+		Name: "foo",
+		Annotations: map[string]string{
+			"example.com/bar": "baz",
+		},
+	}
+
+	expected := map[string]string{
+		AnnotationLabelPrefix + "example.com/bar": "baz",
+		NameLabel: "foo",
+	}
+
+	if diff := cmp.Diff(expected, labels(c)); diff != "" {
+		t.Fatalf("Unexpected labels: %s", diff)
+	}
+}
+
+func TestLabelsConfigHash(t *testing.T) {
+	c := &types.ContainerConfig{
+		Name:       "foo",
+		ConfigHash: "bar",
+	}
+
+	expected := map[string]string{
+		ConfigHashLabel: "bar",
+		NameLabel:       "foo",
+	}
+
+	if diff := cmp.Diff(expected, labels(c)); diff != "" {
+		t.Fatalf("Unexpected labels: %s", diff)
+	}
+}
+
+// List() tests.
+func TestList(t *testing.T) {
+	filters := []string{}
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerListF: func(ctx context.Context, options dockertypes.ContainerListOptions) ([]dockertypes.Container, error) {
+				if !options.All {
+					t.Errorf("Stopped containers should be listed as well")
+				}
+
+				if options.Filters.Contains("name") {
+					filters = append(filters, "name")
+
+					return []dockertypes.Container{
+						{ID: "foo", Created: 2},
+					}, nil
+				}
+
+				filters = append(filters, "label")
+
+				return []dockertypes.Container{
+					{ID: "foo", Created: 2},
+					{ID: "bar", Created: 1},
+				}, nil
+			},
+		},
+	}
+
+	instances, err := d.List("foo")
+	if err != nil {
+		t.Fatalf("Listing containers should succeed, got: %v", err)
+	}
+
+	expected := []types.ContainerInstance{
+		{ID: "foo", Created: time.Unix(2, 0)},
+		{ID: "bar", Created: time.Unix(1, 0)},
+	}
+
+	if diff := cmp.Diff(expected, instances); diff != "" {
+		t.Fatalf("Unexpected containers: %s", diff)
+	}
+
+	if diff := cmp.Diff([]string{"name", "label"}, filters); diff != "" {
+		t.Fatalf("Containers should be listed by name and label: %s", diff)
+	}
+}
+
+func TestListFail(t *testing.T) {
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerListF: func(ctx context.Context, options dockertypes.ContainerListOptions) ([]dockertypes.Container, error) {
+				return nil, fmt.Errorf("listing failed")
+			},
+		},
+	}
+
+	if _, err := d.List("foo"); err == nil {
+		t.Fatalf("Listing containers should fail when runtime fails")
+	}
+}
+
+// healthConfig() tests.
+func TestHealthConfig(t *testing.T) {
+	hc := &types.HealthCheck{
+		Command:     []string{"/bin/health", "--quiet"},
+		Interval:    "10s",
+		Timeout:     "5s",
+		StartPeriod: "1m",
+		Retries:     3,
+	}
+
+	expected := &containertypes.HealthConfig{
+		Test:        []string{"CMD", "/bin/health", "--quiet"},
+		Interval:    10 * time.Second,
+		Timeout:     5 * time.Second,
+		StartPeriod: time.Minute,
+		Retries:     3,
+	}
+
+	if diff := cmp.Diff(expected, healthConfig(hc)); diff != "" {
+		t.Fatalf("Unexpected health check configuration: %s", diff)
+	}
+}
+
+func TestHealthConfigNotSet(t *testing.T) {
+	if hc := healthConfig(nil); hc != nil {
+		t.Fatalf("Health check should not be configured, got: %+v", hc)
+	}
+}
+
+// applyRuntimeOptions() tests.
+func TestApplyRuntimeOptions(t *testing.T) {
+	config := &containertypes.Config{
+		Image: "foo",
+	}
+
+	hostConfig := &containertypes.HostConfig{
+		Privileged: true,
+	}
+
+	options := map[string]string{
+		"config.Domainname":     `"example.com"`,
+		"hostConfig.ShmSize":    "268435456",
+		"hostConfig.PidsLimit":  "100",
+		"hostConfig.GroupAdd":   `["video"]`,
+		"hostConfig.Privileged": "false",
+	}
+
+	if err := applyRuntimeOptions(options, config, hostConfig); err != nil {
+		t.Fatalf("Applying runtime options should succeed, got: %v", err)
+	}
+
+	if config.Domainname != "example.com" || config.Image != "foo" {
+		t.Errorf("Container configuration should be updated, got: %+v", config)
+	}
+
+	if hostConfig.ShmSize != 268435456 || hostConfig.Privileged {
+		t.Errorf("Host configuration should be updated, got: %+v", hostConfig)
+	}
+
+	if hostConfig.PidsLimit == nil || *hostConfig.PidsLimit != 100 {
+		t.Errorf("Fields of embedded structs should be updated, got: %v", hostConfig.PidsLimit)
+	}
+
+	if diff := cmp.Diff([]string{"video"}, hostConfig.GroupAdd); diff != "" {
+		t.Errorf("Unexpected group add: %s", diff)
+	}
+}
+
+func TestApplyRuntimeOptionsInvalid(t *testing.T) {
+	cases := map[string]map[string]string{
+		"unknown prefix": {"foo.ShmSize": "1"},
+		"unknown field":  {"hostConfig.Foo": "1"},
+		"invalid JSON":   {"config.Domainname": "example.com"},
+		"wrong type":     {"hostConfig.ShmSize": `"foo"`},
+	}
+
+	for n, options := range cases {
+		options := options
+
+		t.Run(n, func(t *testing.T) {
+			if err := applyRuntimeOptions(options, &containertypes.Config{}, &containertypes.HostConfig{}); err == nil {
+				t.Fatalf("Applying invalid runtime options should fail")
+			}
+		})
+	}
+}
+
+// pullImageIfNotPresent() tests.
+func TestPullImageIfNotPresentPlatform(t *testing.T) {
+	cases := map[string]struct {
+		image    string
+		platform string
+		pull     bool
+	}{
+		"present locally": {
+			image: "foo",
+		},
+		"present locally for requested platform": {
+			image:    "foo",
+			platform: "linux/amd64",
+		},
+		"present locally for different platform": {
+			image:    "foo",
+			platform: "linux/arm64",
+			pull:     true,
+		},
+		"not present": {
+			image:    "bar",
+			platform: "linux/arm64",
+			pull:     true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			pulled := false
+
+			d := &docker{
+				ctx: context.Background(),
+				cli: &FakeClient{
+					ImageListF: func(ctx context.Context, options dockertypes.ImageListOptions) ([]dockertypes.ImageSummary, error) {
+						return []dockertypes.ImageSummary{
+							{
+								ID:       "foo",
+								RepoTags: []string{"foo:latest"},
+							},
+						}, nil
+					},
+					ImageInspectWithRawF: func(ctx context.Context, image string) (dockertypes.ImageInspect, []byte, error) {
+						return dockertypes.ImageInspect{
+							ID:           image,
+							Os:           "linux",
+							Architecture: "amd64",
+						}, nil, nil
+					},
+					ImagePullF: func(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error) {
+						pulled = true
+
+						if options.Platform != c.platform {
+							t.Errorf("Expected platform %q to be pulled, got %q", c.platform, options.Platform)
+						}
+
+						return ioutil.NopCloser(strings.NewReader("")), nil
+					},
+				},
+			}
+
+			if err := d.pullImageIfNotPresent(c.image, c.platform); err != nil {
+				t.Fatalf("Pulling image should succeed, got: %v", err)
+			}
+
+			if pulled != c.pull {
+				t.Fatalf("Expected image to be pulled: %t, got: %t", c.pull, pulled)
+			}
+		})
+	}
+}
+
+// CheckImage() tests.
+func checkImageTestDocker(t *testing.T, distribution string) *docker {
+	t.Helper()
+
+	di := registrytypes.DistributionInspect{}
+
+	if err := json.Unmarshal([]byte(distribution), &di); err != nil {
+		t.Fatalf("Unmarshaling distribution inspect should succeed, got: %v", err)
+	}
+
+	return &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ImageListF: func(ctx context.Context, options dockertypes.ImageListOptions) ([]dockertypes.ImageSummary, error) {
+				return []dockertypes.ImageSummary{
+					{
+						ID:       "foo",
+						RepoTags: []string{"foo:latest"},
+					},
+				}, nil
+			},
+			DistributionInspectF: func(ctx context.Context, image, encodedRegistryAuth string) (registrytypes.DistributionInspect, error) {
+				if image != "bar" {
+					return di, fmt.Errorf("image not found")
+				}
+
+				return di, nil
+			},
+			ServerVersionF: func(ctx context.Context) (dockertypes.Version, error) {
+				return dockertypes.Version{
+					Os:   "linux",
+					Arch: "amd64",
+				}, nil
+			},
+			ImageInspectWithRawF: func(ctx context.Context, image string) (dockertypes.ImageInspect, []byte, error) {
+				return dockertypes.ImageInspect{
+					ID:           image,
+					Architecture: "amd64",
+				}, nil, nil
+			},
+		},
+	}
+}
+
+func TestCheckImage(t *testing.T) {
+	cases := map[string]struct {
+		image        string
+		architecture string
+		distribution string
+		err          bool
+	}{
+		"present locally": {
+			image:        "foo",
+			distribution: `{}`,
+		},
+		"present locally with different architecture": {
+			image:        "foo",
+			architecture: "arm64",
+			distribution: `{}`,
+			err:          true,
+		},
+		"not found": {
+			image:        "baz",
+			distribution: `{}`,
+			err:          true,
+		},
+		"no platforms reported": {
+			image:        "bar",
+			distribution: `{}`,
+		},
+		"matching platform": {
+			image:        "bar",
+			distribution: `{"Platforms": [{"os": "linux", "architecture": "arm64"}, {"os": "linux", "architecture": "amd64"}]}`,
+		},
+		"platform mismatch": {
+			image:        "bar",
+			distribution: `{"Platforms": [{"os": "linux", "architecture": "arm64"}]}`,
+			err:          true,
+		},
+		"requested architecture": {
+			image:        "bar",
+			architecture: "arm64",
+			distribution: `{"Platforms": [{"os": "linux", "architecture": "arm64"}]}`,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			err := checkImageTestDocker(t, c.distribution).CheckImage(c.image, c.architecture)
+
+			if c.err && err == nil {
+				t.Fatalf("Expected error")
+			}
+
+			if !c.err && err != nil {
+				t.Fatalf("Didn't expect error, got: %v", err)
+			}
+		})
+	}
+}
+
+// Logs() tests.
+func TestLogs(t *testing.T) {
+	var buf bytes.Buffer
+
+	if _, err := stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte("foo\n")); err != nil {
+		t.Fatalf("Writing stdout should succeed, got: %v", err)
+	}
+
+	if _, err := stdcopy.NewStdWriter(&buf, stdcopy.Stderr).Write([]byte("bar\n")); err != nil {
+		t.Fatalf("Writing stderr should succeed, got: %v", err)
+	}
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerLogsF: func(ctx context.Context, container string, options dockertypes.ContainerLogsOptions) (io.ReadCloser, error) {
+				if options.Tail != "10" {
+					t.Errorf("Expected tail of 10 lines, got %q", options.Tail)
+				}
+
+				return ioutil.NopCloser(&buf), nil
+			},
+		},
+	}
+
+	logs, err := d.Logs("foo", 10)
+	if err != nil {
+		t.Fatalf("Reading logs should succeed, got: %v", err)
+	}
+
+	if logs != "foo\nbar\n" {
+		t.Fatalf("Unexpected logs: %q", logs)
+	}
+}
+
+func TestLogsFail(t *testing.T) {
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerLogsF: func(ctx context.Context, container string, options dockertypes.ContainerLogsOptions) (io.ReadCloser, error) {
+				return nil, fmt.Errorf("runtime error")
+			},
+		},
+	}
+
+	if _, err := d.Logs("foo", 10); err == nil {
+		t.Fatalf("Reading logs should fail, when runtime error occurs")
+	}
+}
+
+// FollowLogs() tests.
+func TestFollowLogs(t *testing.T) {
+	var buf bytes.Buffer
+
+	if _, err := stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte("foo\n")); err != nil {
+		t.Fatalf("Writing stdout should succeed, got: %v", err)
+	}
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerLogsF: func(ctx context.Context, container string, options dockertypes.ContainerLogsOptions) (io.ReadCloser, error) {
+				if !options.Follow {
+					t.Errorf("Logs should be followed")
+				}
+
+				return ioutil.NopCloser(&buf), nil
+			},
+		},
+	}
+
+	var out bytes.Buffer
+
+	if err := d.FollowLogs(context.Background(), "foo", 10, &out); err != nil {
+		t.Fatalf("Following logs should succeed, got: %v", err)
+	}
+
+	if out.String() != "foo\n" {
+		t.Fatalf("Unexpected logs: %q", out.String())
+	}
+}
+
+func TestFollowLogsCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r, w := io.Pipe()
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerLogsF: func(ctx context.Context, container string, options dockertypes.ContainerLogsOptions) (io.ReadCloser, error) {
+				return r, nil
+			},
+		},
+	}
+
+	errCh := make(chan error)
+
+	go func() {
+		errCh <- d.FollowLogs(ctx, "foo", 10, &bytes.Buffer{})
+	}()
+
+	cancel()
+
+	if err := <-errCh; err != nil {
+		t.Fatalf("Following logs should stop cleanly on cancel, got: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Closing pipe should succeed, got: %v", err)
+	}
+}
+
+func TestFollowLogsFail(t *testing.T) {
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerLogsF: func(ctx context.Context, container string, options dockertypes.ContainerLogsOptions) (io.ReadCloser, error) {
+				return nil, fmt.Errorf("runtime error")
+			},
+		},
+	}
+
+	if err := d.FollowLogs(context.Background(), "foo", 10, &bytes.Buffer{}); err == nil {
+		t.Fatalf("Following logs should fail, when runtime error occurs")
+	}
+}
+
+// UpdateResources() tests.
+func TestUpdateResources(t *testing.T) {
+	var u containertypes.UpdateConfig
+
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerUpdateF: func(ctx context.Context, container string, updateConfig containertypes.UpdateConfig) (containertypes.ContainerUpdateOKBody, error) {
+				u = updateConfig
+
+				return containertypes.ContainerUpdateOKBody{Warnings: []string{"foo"}}, nil
+			},
+		},
+	}
+
+	limits := types.ResourceLimits{
+		Memory:     536870912,
+		NanoCPUs:   1500000000,
+		CpusetCpus: "0-1",
+	}
+
+	warnings, err := d.UpdateResources("foo", limits)
+	if err != nil {
+		t.Fatalf("Updating resources should succeed, got: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"foo"}, warnings); diff != "" {
+		t.Fatalf("Warnings reported by runtime should be returned: %s", diff)
+	}
+
+	expected := containertypes.Resources{
+		Memory:     536870912,
+		MemorySwap: 2 * 536870912,
+		NanoCPUs:   1500000000,
+		CpusetCpus: "0-1",
+	}
+
+	if diff := cmp.Diff(expected, u.Resources); diff != "" {
+		t.Fatalf("Unexpected resources update: %s", diff)
+	}
+}
+
+func TestUpdateResourcesFail(t *testing.T) {
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerUpdateF: func(ctx context.Context, container string, updateConfig containertypes.UpdateConfig) (containertypes.ContainerUpdateOKBody, error) {
+				return containertypes.ContainerUpdateOKBody{}, fmt.Errorf("runtime error")
+			},
+		},
+	}
+
+	if _, err := d.UpdateResources("foo", types.ResourceLimits{Memory: 1}); err == nil {
+		t.Fatalf("Updating resources should fail, when runtime error occurs")
+	}
+}
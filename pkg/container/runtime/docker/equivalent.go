@@ -0,0 +1,221 @@
+package docker
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+// defaultNetworkMode is a network mode reported by Docker for containers created without
+// network mode specified.
+const defaultNetworkMode = "default"
+
+// Equivalent checks, if existing container with given ID runs with configuration equivalent
+// to the given one, so it does not need to be recreated. As Docker merges container configuration
+// with configuration of the image and normalizes some fields, live configuration is compared with
+// configuration, which would be used for creating the container, with image defaults applied.
+//
+// Only fields, which are set when creating the container are compared, so fields modified
+// using runtime options may not be taken into account.
+func (d *docker) Equivalent(id string, config *types.ContainerConfig) (bool, error) {
+	c, err := d.cli.ContainerInspect(d.ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("inspecting container failed: %w", err)
+	}
+
+	if c.ContainerJSONBase == nil || c.Config == nil || c.HostConfig == nil {
+		return false, nil
+	}
+
+	i, _, err := d.cli.ImageInspectWithRaw(d.ctx, c.Image)
+	if err != nil {
+		return false, fmt.Errorf("inspecting image failed: %w", err)
+	}
+
+	dockerConfig, hostConfig, err := containerConfigs(config)
+	if err != nil {
+		return false, err
+	}
+
+	withImageDefaults(dockerConfig, i.Config)
+
+	// Configuration hash is not part of the configuration itself.
+	if h, ok := c.Config.Labels[ConfigHashLabel]; ok {
+		dockerConfig.Labels[ConfigHashLabel] = h
+	}
+
+	return equivalentConfig(dockerConfig, c.Config) && equivalentHostConfig(hostConfig, c.HostConfig), nil
+}
+
+// withImageDefaults applies defaults from given image configuration to given container
+// configuration the same way as Docker does, when creating the container.
+func withImageDefaults(config, image *containertypes.Config) {
+	if image == nil {
+		return
+	}
+
+	config.Env = mergeEnv(image.Env, config.Env)
+
+	labels := map[string]string{}
+
+	for k, v := range image.Labels {
+		labels[k] = v
+	}
+
+	for k, v := range config.Labels {
+		labels[k] = v
+	}
+
+	config.Labels = labels
+
+	if len(image.ExposedPorts) > 0 {
+		ports := nat.PortSet{}
+
+		for p := range image.ExposedPorts {
+			ports[p] = struct{}{}
+		}
+
+		for p := range config.ExposedPorts {
+			ports[p] = struct{}{}
+		}
+
+		config.ExposedPorts = ports
+	}
+
+	// Command from the image is only used, when entrypoint is not overridden.
+	if len(config.Entrypoint) == 0 {
+		config.Entrypoint = image.Entrypoint
+
+		if len(config.Cmd) == 0 {
+			config.Cmd = image.Cmd
+		}
+	}
+
+	if config.User == "" {
+		config.User = image.User
+	}
+
+	if config.WorkingDir == "" {
+		config.WorkingDir = image.WorkingDir
+	}
+
+	if config.StopSignal == "" {
+		config.StopSignal = image.StopSignal
+	}
+
+	if config.Healthcheck == nil {
+		config.Healthcheck = image.Healthcheck
+	}
+}
+
+// mergeEnv merges given environment variables in 'KEY=value' format, where variables from
+// override replace variables from base with the same name.
+func mergeEnv(base, override []string) []string {
+	env := map[string]string{}
+
+	for _, l := range append(append([]string{}, base...), override...) {
+		env[strings.SplitN(l, "=", 2)[0]] = l
+	}
+
+	merged := []string{}
+
+	for _, l := range env {
+		merged = append(merged, l)
+	}
+
+	sort.Strings(merged)
+
+	return merged
+}
+
+// equivalent checks, if given values are deeply equal, treating nil and empty values as equal.
+func equivalent(a, b interface{}) bool {
+	if isEmpty(a) && isEmpty(b) {
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// isEmpty checks, if given slice, map or pointer is nil or has no elements.
+func isEmpty(i interface{}) bool {
+	v := reflect.ValueOf(i)
+
+	switch v.Kind() { //nolint:exhaustive // Other kinds are never empty.
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// sameImage checks, if given image references point to the same image, e.g. 'nginx' and
+// 'docker.io/library/nginx:latest'. Invalid references are compared as they are.
+func sameImage(desired, live string) bool {
+	d, err := types.NormalizeImage(desired)
+	if err != nil {
+		return desired == live
+	}
+
+	l, err := types.NormalizeImage(live)
+	if err != nil {
+		return desired == live
+	}
+
+	return d == l
+}
+
+// equivalentConfig checks, if given Docker container configurations are equivalent.
+func equivalentConfig(desired, live *containertypes.Config) bool {
+	env := mergeEnv(nil, live.Env)
+
+	return sameImage(desired.Image, live.Image) &&
+		equivalent(desired.Cmd, live.Cmd) &&
+		equivalent(desired.Entrypoint, live.Entrypoint) &&
+		equivalent(desired.Env, env) &&
+		equivalent(desired.Labels, live.Labels) &&
+		equivalent(desired.ExposedPorts, live.ExposedPorts) &&
+		equivalent(desired.StopTimeout, live.StopTimeout) &&
+		equivalent(desired.Healthcheck, live.Healthcheck) &&
+		desired.User == live.User &&
+		desired.WorkingDir == live.WorkingDir &&
+		desired.StopSignal == live.StopSignal &&
+		(desired.Hostname == "" || desired.Hostname == live.Hostname)
+}
+
+// equivalentHostConfig checks, if given Docker host configurations are equivalent.
+func equivalentHostConfig(desired, live *containertypes.HostConfig) bool {
+	networkMode := desired.NetworkMode
+	if networkMode == "" {
+		networkMode = defaultNetworkMode
+	}
+
+	enabled := func(b *bool) bool {
+		return b != nil && *b
+	}
+
+	return equivalent(desired.Mounts, live.Mounts) &&
+		equivalent(desired.PortBindings, live.PortBindings) &&
+		desired.Privileged == live.Privileged &&
+		networkMode == live.NetworkMode &&
+		desired.PidMode == live.PidMode &&
+		(desired.IpcMode == "" || desired.IpcMode == live.IpcMode) &&
+		enabled(desired.Init) == enabled(live.Init) &&
+		desired.OomScoreAdj == live.OomScoreAdj &&
+		(desired.ShmSize == 0 || desired.ShmSize == live.ShmSize) &&
+		desired.RestartPolicy.Name == live.RestartPolicy.Name &&
+		desired.RestartPolicy.MaximumRetryCount == live.RestartPolicy.MaximumRetryCount &&
+		desired.Memory == live.Memory &&
+		desired.NanoCPUs == live.NanoCPUs &&
+		desired.CpusetCpus == live.CpusetCpus &&
+		desired.CpusetMems == live.CpusetMems &&
+		desired.CgroupParent == live.CgroupParent
+}
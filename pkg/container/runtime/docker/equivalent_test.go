@@ -0,0 +1,152 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	dockertypes "github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+func equivalentTestDocker(t *testing.T, config *types.ContainerConfig, mutate func(*containertypes.Config)) *docker {
+	t.Helper()
+
+	dockerConfig, hostConfig, err := containerConfigs(config)
+	if err != nil {
+		t.Fatalf("Building container configuration should succeed, got: %v", err)
+	}
+
+	// Simulate normalization and defaults applied by Docker.
+	dockerConfig.Env = append([]string{"PATH=/bin"}, dockerConfig.Env...)
+	dockerConfig.Labels["image"] = "label"
+	hostConfig.NetworkMode = defaultNetworkMode
+	hostConfig.IpcMode = "private"
+
+	if mutate != nil {
+		mutate(dockerConfig)
+	}
+
+	return &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerInspectF: func(ctx context.Context, id string) (dockertypes.ContainerJSON, error) {
+				return dockertypes.ContainerJSON{
+					ContainerJSONBase: &dockertypes.ContainerJSONBase{
+						Image:      "sha256:foo",
+						HostConfig: hostConfig,
+					},
+					Config: dockerConfig,
+				}, nil
+			},
+			ImageInspectWithRawF: func(ctx context.Context, image string) (dockertypes.ImageInspect, []byte, error) {
+				return dockertypes.ImageInspect{
+					Config: &containertypes.Config{
+						Env:    []string{"PATH=/bin"},
+						Labels: map[string]string{"image": "label"},
+						Cmd:    []string{"/bin/sh"},
+					},
+				}, nil, nil
+			},
+		},
+	}
+}
+
+// Equivalent() tests.
+func TestEquivalent(t *testing.T) {
+	config := &types.ContainerConfig{
+		Name:  "foo",
+		Image: "foo",
+		Args:  []string{"--bar"},
+		Env:   []string{"FOO=bar"},
+	}
+
+	cases := map[string]struct {
+		mutate     func(*containertypes.Config)
+		equivalent bool
+	}{
+		"equivalent": {
+			equivalent: true,
+		},
+		"different arguments": {
+			mutate: func(c *containertypes.Config) {
+				c.Cmd = []string{"--baz"}
+			},
+		},
+		"removed environment variable": {
+			mutate: func(c *containertypes.Config) {
+				c.Env = append(c.Env, "BAR=baz")
+			},
+		},
+		"different image": {
+			mutate: func(c *containertypes.Config) {
+				c.Image = "bar"
+			},
+		},
+		"different spelling of the same image": {
+			mutate: func(c *containertypes.Config) {
+				c.Image = "docker.io/library/foo:latest"
+			},
+			equivalent: true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			e, err := equivalentTestDocker(t, config, c.mutate).Equivalent("foo", config)
+			if err != nil {
+				t.Fatalf("Comparing configuration should succeed, got: %v", err)
+			}
+
+			if e != c.equivalent {
+				t.Fatalf("Expected equivalent to be %t, got %t", c.equivalent, e)
+			}
+		})
+	}
+}
+
+func TestEquivalentInspectFail(t *testing.T) {
+	d := &docker{
+		ctx: context.Background(),
+		cli: &FakeClient{
+			ContainerInspectF: func(ctx context.Context, id string) (dockertypes.ContainerJSON, error) {
+				return dockertypes.ContainerJSON{}, fmt.Errorf("runtime error")
+			},
+		},
+	}
+
+	if _, err := d.Equivalent("foo", &types.ContainerConfig{}); err == nil {
+		t.Fatalf("Comparing configuration should fail, when inspecting container fails")
+	}
+}
+
+// withImageDefaults() tests.
+func TestWithImageDefaults(t *testing.T) {
+	config := &containertypes.Config{
+		Env:        []string{"FOO=bar"},
+		Entrypoint: []string{"/bin/foo"},
+	}
+
+	withImageDefaults(config, &containertypes.Config{
+		Env:        []string{"FOO=baz", "PATH=/bin"},
+		Entrypoint: []string{"/bin/sh"},
+		Cmd:        []string{"-c"},
+		WorkingDir: "/tmp",
+	})
+
+	expected := &containertypes.Config{
+		Env:        []string{"FOO=bar", "PATH=/bin"},
+		Entrypoint: []string{"/bin/foo"},
+		Labels:     map[string]string{},
+		WorkingDir: "/tmp",
+	}
+
+	if diff := cmp.Diff(expected, config); diff != "" {
+		t.Fatalf("Unexpected configuration: %s", diff)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	dockertypes "github.com/docker/docker/api/types"
 	containertypes "github.com/docker/docker/api/types/container"
 	networktypes "github.com/docker/docker/api/types/network"
+	registrytypes "github.com/docker/docker/api/types/registry"
+	volumetypes "github.com/docker/docker/api/types/volume"
 )
 
 // FakeClient is a mock of Docker client, which should be used only for testing.
@@ -41,6 +43,33 @@ type FakeClient struct { //nolint:dupl
 
 	// ImagePullF will be called by ImagePull.
 	ImagePullF func(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error)
+
+	// VolumeCreateF will be called by VolumeCreate.
+	VolumeCreateF func(ctx context.Context, options volumetypes.VolumeCreateBody) (dockertypes.Volume, error)
+
+	// VolumeRemoveF will be called by VolumeRemove.
+	VolumeRemoveF func(ctx context.Context, volumeID string, force bool) error
+
+	// ContainerWaitF will be called by ContainerWait.
+	ContainerWaitF func(ctx context.Context, container string, condition containertypes.WaitCondition) (<-chan containertypes.ContainerWaitOKBody, <-chan error)
+
+	// ContainerListF will be called by ContainerList.
+	ContainerListF func(ctx context.Context, options dockertypes.ContainerListOptions) ([]dockertypes.Container, error)
+
+	// DistributionInspectF will be called by DistributionInspect.
+	DistributionInspectF func(ctx context.Context, image, encodedRegistryAuth string) (registrytypes.DistributionInspect, error)
+
+	// ServerVersionF will be called by ServerVersion.
+	ServerVersionF func(ctx context.Context) (dockertypes.Version, error)
+
+	// ImageInspectWithRawF will be called by ImageInspectWithRaw.
+	ImageInspectWithRawF func(ctx context.Context, image string) (dockertypes.ImageInspect, []byte, error)
+
+	// ContainerLogsF will be called by ContainerLogs.
+	ContainerLogsF func(ctx context.Context, container string, options dockertypes.ContainerLogsOptions) (io.ReadCloser, error)
+
+	// ContainerUpdateF will be called by ContainerUpdate.
+	ContainerUpdateF func(ctx context.Context, container string, updateConfig containertypes.UpdateConfig) (containertypes.ContainerUpdateOKBody, error)
 }
 
 // ContainerCreate mocks Docker client ContainerCreate().
@@ -92,3 +121,48 @@ func (f *FakeClient) ImageList(ctx context.Context, options dockertypes.ImageLis
 func (f *FakeClient) ImagePull(ctx context.Context, ref string, options dockertypes.ImagePullOptions) (io.ReadCloser, error) {
 	return f.ImagePullF(ctx, ref, options)
 }
+
+// VolumeCreate mocks Docker client VolumeCreate().
+func (f *FakeClient) VolumeCreate(ctx context.Context, options volumetypes.VolumeCreateBody) (dockertypes.Volume, error) {
+	return f.VolumeCreateF(ctx, options)
+}
+
+// VolumeRemove mocks Docker client VolumeRemove().
+func (f *FakeClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	return f.VolumeRemoveF(ctx, volumeID, force)
+}
+
+// ContainerWait mocks Docker client ContainerWait().
+func (f *FakeClient) ContainerWait(ctx context.Context, container string, condition containertypes.WaitCondition) (<-chan containertypes.ContainerWaitOKBody, <-chan error) {
+	return f.ContainerWaitF(ctx, container, condition)
+}
+
+// ContainerList mocks Docker client ContainerList().
+func (f *FakeClient) ContainerList(ctx context.Context, options dockertypes.ContainerListOptions) ([]dockertypes.Container, error) {
+	return f.ContainerListF(ctx, options)
+}
+
+// DistributionInspect mocks Docker client DistributionInspect().
+func (f *FakeClient) DistributionInspect(ctx context.Context, image, encodedRegistryAuth string) (registrytypes.DistributionInspect, error) {
+	return f.DistributionInspectF(ctx, image, encodedRegistryAuth)
+}
+
+// ServerVersion mocks Docker client ServerVersion().
+func (f *FakeClient) ServerVersion(ctx context.Context) (dockertypes.Version, error) {
+	return f.ServerVersionF(ctx)
+}
+
+// ImageInspectWithRaw mocks Docker client ImageInspectWithRaw().
+func (f *FakeClient) ImageInspectWithRaw(ctx context.Context, image string) (dockertypes.ImageInspect, []byte, error) {
+	return f.ImageInspectWithRawF(ctx, image)
+}
+
+// ContainerLogs mocks Docker client ContainerLogs().
+func (f *FakeClient) ContainerLogs(ctx context.Context, container string, options dockertypes.ContainerLogsOptions) (io.ReadCloser, error) {
+	return f.ContainerLogsF(ctx, container, options)
+}
+
+// ContainerUpdate mocks Docker client ContainerUpdate().
+func (f *FakeClient) ContainerUpdate(ctx context.Context, container string, updateConfig containertypes.UpdateConfig) (containertypes.ContainerUpdateOKBody, error) {
+	return f.ContainerUpdateF(ctx, container, updateConfig)
+}
@@ -1,7 +1,9 @@
 package runtime
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/flexkube/libflexkube/pkg/container/types"
@@ -21,6 +23,10 @@ type Fake struct {
 	// StatusF will be called by Status method.
 	StatusF func(id string) (types.ContainerStatus, error)
 
+	// ExistsF will be called by Exists method. If not set, result is determined
+	// using StatusF.
+	ExistsF func(id string) (types.Existence, error)
+
 	// StopF will be called by Stop method.
 	StopF func(id string) error
 
@@ -32,6 +38,12 @@ type Fake struct {
 
 	// StatF will be called by Stat method.
 	StatF func(id string, paths []string) (map[string]os.FileMode, error)
+
+	// CreateVolumeF will be called by CreateVolume method.
+	CreateVolumeF func(name string) error
+
+	// RemoveVolumeF will be called by RemoveVolume method.
+	RemoveVolumeF func(name string) error
 }
 
 // Create mocks runtime Create().
@@ -54,6 +66,24 @@ func (f Fake) Status(id string) (types.ContainerStatus, error) {
 	return f.StatusF(id)
 }
 
+// Exists mocks runtime Exists().
+func (f Fake) Exists(id string) (types.Existence, error) {
+	if f.ExistsF != nil {
+		return f.ExistsF(id)
+	}
+
+	s, err := f.StatusF(id)
+	if err != nil {
+		return types.Existence{Reason: types.ExistenceUnknown}, err
+	}
+
+	if s.ID == "" {
+		return types.Existence{Reason: types.ExistenceRemoved}, nil
+	}
+
+	return types.Existence{Exists: true, Reason: types.ExistenceFound}, nil
+}
+
 // Stop mocks runtime Stop().
 func (f Fake) Stop(id string) error {
 	return f.StopF(id)
@@ -74,6 +104,81 @@ func (f Fake) Stat(id string, paths []string) (map[string]os.FileMode, error) {
 	return f.StatF(id, paths)
 }
 
+// CreateVolume mocks runtime CreateVolume().
+func (f Fake) CreateVolume(name string) error {
+	return f.CreateVolumeF(name)
+}
+
+// RemoveVolume mocks runtime RemoveVolume().
+func (f Fake) RemoveVolume(name string) error {
+	return f.RemoveVolumeF(name)
+}
+
+// FakeLabelsUpdater is a fake runtime client, which also implements LabelsUpdater interface.
+type FakeLabelsUpdater struct {
+	Fake
+
+	// UpdateLabelsF will be called by UpdateLabels method.
+	UpdateLabelsF func(id string, labels map[string]string) error
+}
+
+// UpdateLabels mocks runtime UpdateLabels().
+func (f FakeLabelsUpdater) UpdateLabels(id string, labels map[string]string) error {
+	return f.UpdateLabelsF(id, labels)
+}
+
+// FakeResourcesUpdater is a fake runtime client, which also implements ResourcesUpdater interface.
+type FakeResourcesUpdater struct {
+	Fake
+
+	// UpdateResourcesF will be called by UpdateResources method.
+	UpdateResourcesF func(id string, limits types.ResourceLimits) ([]string, error)
+}
+
+// UpdateResources mocks runtime UpdateResources().
+func (f FakeResourcesUpdater) UpdateResources(id string, limits types.ResourceLimits) ([]string, error) {
+	return f.UpdateResourcesF(id, limits)
+}
+
+// FakeFileRenamer is a fake runtime client, which also implements FileRenamer interface.
+type FakeFileRenamer struct {
+	Fake
+
+	// RenameF will be called by Rename method.
+	RenameF func(id string, paths map[string]string) ([]string, error)
+
+	// CanRenameF will be called by CanRename method. If not set, renaming is
+	// always possible.
+	CanRenameF func(id string) (bool, error)
+}
+
+// CanRename mocks runtime CanRename().
+func (f FakeFileRenamer) CanRename(id string) (bool, error) {
+	if f.CanRenameF == nil {
+		return true, nil
+	}
+
+	return f.CanRenameF(id)
+}
+
+// Rename mocks runtime Rename().
+func (f FakeFileRenamer) Rename(id string, paths map[string]string) ([]string, error) {
+	return f.RenameF(id, paths)
+}
+
+// FakeLister is a fake runtime client, which also implements Lister interface.
+type FakeLister struct {
+	Fake
+
+	// ListF will be called by List method.
+	ListF func(name string) ([]types.ContainerInstance, error)
+}
+
+// List mocks runtime List().
+func (f FakeLister) List(name string) ([]types.ContainerInstance, error) {
+	return f.ListF(name)
+}
+
 // FakeConfig is a Fake runtime configuration struct.
 type FakeConfig struct {
 	// Runtime holds container runtime to return by New() method.
@@ -101,3 +206,55 @@ func (c FakeConfig) New() (Runtime, error) {
 
 	return c.Runtime, nil
 }
+
+// FakeImageChecker is a fake runtime client, which also implements ImageChecker interface.
+type FakeImageChecker struct {
+	Fake
+
+	// CheckImageF will be called by CheckImage method.
+	CheckImageF func(image, architecture string) error
+}
+
+// CheckImage mocks runtime CheckImage().
+func (f FakeImageChecker) CheckImage(image, architecture string) error {
+	return f.CheckImageF(image, architecture)
+}
+
+// FakeLogsReader is a fake runtime client, which also implements LogsReader interface.
+type FakeLogsReader struct {
+	Fake
+
+	// LogsF will be called by Logs method.
+	LogsF func(id string, lines int) (string, error)
+}
+
+// Logs mocks runtime Logs().
+func (f FakeLogsReader) Logs(id string, lines int) (string, error) {
+	return f.LogsF(id, lines)
+}
+
+// FakeLogsFollower is a fake runtime client, which also implements LogsFollower interface.
+type FakeLogsFollower struct {
+	Fake
+
+	// FollowLogsF will be called by FollowLogs method.
+	FollowLogsF func(ctx context.Context, id string, lines int, w io.Writer) error
+}
+
+// FollowLogs mocks runtime FollowLogs().
+func (f FakeLogsFollower) FollowLogs(ctx context.Context, id string, lines int, w io.Writer) error {
+	return f.FollowLogsF(ctx, id, lines, w)
+}
+
+// FakeConfigComparer is a fake runtime client, which also implements ConfigComparer interface.
+type FakeConfigComparer struct {
+	Fake
+
+	// EquivalentF will be called by Equivalent method.
+	EquivalentF func(id string, config *types.ContainerConfig) (bool, error)
+}
+
+// Equivalent mocks runtime Equivalent().
+func (f FakeConfigComparer) Equivalent(id string, config *types.ContainerConfig) (bool, error) {
+	return f.EquivalentF(id, config)
+}
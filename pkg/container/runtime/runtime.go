@@ -3,6 +3,8 @@
 package runtime
 
 import (
+	"context"
+	"io"
 	"os"
 
 	"github.com/flexkube/libflexkube/pkg/container/types"
@@ -23,6 +25,11 @@ type Runtime interface {
 	// Status returns status of the container.
 	Status(ID string) (types.ContainerStatus, error)
 
+	// Exists checks, if the container with given ID exists. If runtime can't be queried,
+	// error should be returned together with types.ExistenceUnknown reason, so callers can
+	// distinguish removed containers from transient errors.
+	Exists(ID string) (types.Existence, error)
+
 	// Stop takes unique identifier as a parameter and stops the container.
 	Stop(ID string) error
 
@@ -39,6 +46,84 @@ type Runtime interface {
 
 	// Stat returns os.FileMode for requested files from inside the container.
 	Stat(ID string, paths []string) (map[string]os.FileMode, error)
+
+	// CreateVolume creates named volume. If volume already exists, no error should be returned.
+	CreateVolume(name string) error
+
+	// RemoveVolume removes named volume. If volume does not exist, no error should be returned.
+	RemoveVolume(name string) error
+}
+
+// LabelsUpdater is an optional interface, which can be implemented by container runtimes,
+// which are able to update labels of existing containers without recreating them.
+type LabelsUpdater interface {
+	// UpdateLabels replaces labels of the container with given ID.
+	UpdateLabels(ID string, labels map[string]string) error
+}
+
+// ResourcesUpdater is an optional interface, which can be implemented by container runtimes,
+// which are able to update resource limits of existing containers without recreating them.
+type ResourcesUpdater interface {
+	// UpdateResources sets resource limits of the container with given ID to given values.
+	// It returns warnings reported by the runtime, which caller should log.
+	UpdateResources(ID string, limits types.ResourceLimits) ([]string, error)
+}
+
+// FileRenamer is an optional interface, which can be implemented by container runtimes, which
+// are able to rename files inside the container. Renaming allows to write files atomically.
+type FileRenamer interface {
+	// CanRename checks, if files can be renamed in the container with given ID, e.g. if tools
+	// required for renaming are available in the container image. If not, files should be
+	// written directly.
+	CanRename(ID string) (bool, error)
+
+	// Rename renames files in the container with given ID. Key of the given map is a source
+	// path and value is a destination path. It returns warnings about non-fatal failures,
+	// which caller should log.
+	Rename(ID string, paths map[string]string) ([]string, error)
+}
+
+// Lister is an optional interface, which can be implemented by container runtimes, which
+// are able to find all containers created for given container name. It allows finding
+// leftover containers, for example after interrupted deployment.
+type Lister interface {
+	// List returns all containers created for container with given name, including
+	// stopped ones.
+	List(name string) ([]types.ContainerInstance, error)
+}
+
+// LogsReader is an optional interface, which can be implemented by container runtimes, which
+// are able to read output of the containers.
+type LogsReader interface {
+	// Logs returns given number of last lines of combined standard output and standard error
+	// of the container with given ID.
+	Logs(ID string, lines int) (string, error)
+}
+
+// LogsFollower is an optional interface, which can be implemented by container runtimes, which
+// are able to stream output of the containers.
+type LogsFollower interface {
+	// FollowLogs writes given number of last lines of combined standard output and standard error
+	// of the container with given ID to given writer and then keeps writing new output, until
+	// given context is cancelled or container stops.
+	FollowLogs(ctx context.Context, ID string, lines int, w io.Writer) error
+}
+
+// ConfigComparer is an optional interface, which can be implemented by container runtimes, which
+// are able to compare configuration of existing containers with given configuration.
+type ConfigComparer interface {
+	// Equivalent returns true, if container with given ID runs with configuration equivalent
+	// to the given one, ignoring fields normalized or defaulted by the runtime.
+	Equivalent(ID string, config *types.ContainerConfig) (bool, error)
+}
+
+// ImageChecker is an optional interface, which can be implemented by container runtimes, which
+// are able to check, if the image can be used for creating containers without pulling it.
+type ImageChecker interface {
+	// CheckImage returns an error, if given image is not present and it can't be pulled
+	// for given CPU architecture. If architecture is empty, architecture reported by the
+	// runtime should be used.
+	CheckImage(image, architecture string) error
 }
 
 // Config defines interface for runtime configuration. Since some feature are generic to runtime,
@@ -0,0 +1,238 @@
+package container
+
+import (
+	"sort"
+
+	"github.com/flexkube/libflexkube/internal/util"
+)
+
+const (
+	// defaultConcurrency is a default global and per host number of container
+	// operations, which can be executed at the same time.
+	defaultConcurrency = 1
+)
+
+// task is a single unit of work executed by the scheduler.
+type task struct {
+	// name is a name of the container to process.
+	name string
+
+	// host is an identifier of the host, where container runs.
+	host string
+
+	// dependencies is a list of names of containers, which must be processed successfully
+	// before this container, like its pod container or containers it waits for to become healthy.
+	dependencies []string
+}
+
+// scheduler executes actions on multiple containers concurrently, respecting both
+// global and per host concurrency limits.
+type scheduler struct {
+	global  int
+	perHost int
+}
+
+// newScheduler creates new scheduler with given limits. Non-positive limits are
+// replaced with default value.
+func newScheduler(global, perHost int) *scheduler {
+	if global <= 0 {
+		global = defaultConcurrency
+	}
+
+	if perHost <= 0 {
+		perHost = defaultConcurrency
+	}
+
+	return &scheduler{
+		global:  global,
+		perHost: perHost,
+	}
+}
+
+// schedulerRun holds the progress of the single run of the scheduler.
+type schedulerRun struct {
+	tasks    []task
+	index    map[string]int
+	errs     []error
+	failed   []bool
+	started  []bool
+	finished []bool
+	running  int
+	hosts    map[string]int
+}
+
+// dependenciesState returns, if all dependencies of the task with given index finished
+// and if any of them failed. Only tasks scheduled earlier are awaited, so waiting for them
+// never deadlocks.
+func (r *schedulerRun) dependenciesState(i int) (bool, bool) {
+	for _, d := range r.tasks[i].dependencies {
+		j, ok := r.index[d]
+		if !ok || j >= i {
+			continue
+		}
+
+		if !r.finished[j] {
+			return false, false
+		}
+
+		if r.failed[j] {
+			return true, true
+		}
+	}
+
+	return true, false
+}
+
+// run executes given action for each task. Tasks are started in the given order, as soon as
+// their dependencies finish and both global and task's host limits allow it, so the more
+// restrictive limit is always respected. Tasks waiting for their dependencies or for a busy
+// host do not occupy any slots, so they never block tasks on other hosts.
+//
+// Failure of one action does not prevent independent tasks from being executed. Tasks depending
+// on the failed task, directly or transitively, are skipped right after the failure, instead of
+// waiting for their dependencies to become healthy. After all actions finish, errors of failed
+// tasks are aggregated in the order of the tasks, so the returned error is deterministic. Skipped
+// tasks do not contribute to the returned error, as their failure is caused by their dependencies.
+func (s *scheduler) run(tasks []task, action func(string) error) error {
+	r := &schedulerRun{
+		tasks:    tasks,
+		index:    map[string]int{},
+		errs:     make([]error, len(tasks)),
+		failed:   make([]bool, len(tasks)),
+		started:  make([]bool, len(tasks)),
+		finished: make([]bool, len(tasks)),
+		hosts:    map[string]int{},
+	}
+
+	for i, t := range tasks {
+		r.index[t.name] = i
+	}
+
+	type result struct {
+		i   int
+		err error
+	}
+
+	results := make(chan result)
+	remaining := len(tasks)
+
+	for remaining > 0 {
+		for i, t := range tasks {
+			if r.started[i] {
+				continue
+			}
+
+			ready, skip := r.dependenciesState(i)
+
+			// Dependents of failed tasks are skipped without occupying any slots.
+			if skip {
+				r.started[i] = true
+				r.failed[i] = true
+				r.finished[i] = true
+				remaining--
+
+				continue
+			}
+
+			if !ready || r.running >= s.global || r.hosts[t.host] >= s.perHost {
+				continue
+			}
+
+			r.started[i] = true
+			r.running++
+			r.hosts[t.host]++
+
+			go func(i int, n string) {
+				results <- result{i: i, err: action(n)}
+			}(i, t.name)
+		}
+
+		if r.running == 0 {
+			continue
+		}
+
+		res := <-results
+
+		r.errs[res.i] = res.err
+		r.failed[res.i] = res.err != nil
+		r.finished[res.i] = true
+		r.running--
+		r.hosts[tasks[res.i].host]--
+		remaining--
+	}
+
+	var aggregated util.ValidateError
+
+	for _, err := range r.errs {
+		if err != nil {
+			aggregated = append(aggregated, err)
+		}
+	}
+
+	return aggregated.Return()
+}
+
+// tasks returns list of tasks for all containers in given state, sorted by container
+// name, so the order of execution is deterministic. Containers, which other containers
+// wait for to become healthy are always placed before them.
+func (c *containers) tasks(s containersState) []task {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := []task{}
+
+	for n, hcc := range s {
+		st := task{
+			name: n,
+			host: hcc.host.ID(),
+		}
+
+		if d, ok := c.desiredState[n]; ok {
+			st.dependencies = d.dependencies()
+		}
+
+		t = append(t, st)
+	}
+
+	sort.Slice(t, func(i, j int) bool {
+		di, dj := c.waitDepth(t[i].name), c.waitDepth(t[j].name)
+		if di != dj {
+			return di < dj
+		}
+
+		return t[i].name < t[j].name
+	})
+
+	return t
+}
+
+// scheduler returns new scheduler configured with containers concurrency limits.
+func (c *containers) scheduler() *scheduler {
+	return newScheduler(c.maxConcurrency, c.maxPerHostConcurrency)
+}
+
+// current returns current state of given container in a thread-safe way.
+func (c *containers) current(n string) (*hostConfiguredContainer, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	r, ok := c.currentState[n]
+
+	return r, ok
+}
+
+// setCurrent sets current state of given container in a thread-safe way.
+func (c *containers) setCurrent(n string, r *hostConfiguredContainer) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.currentState[n] = r
+}
+
+// deleteCurrent removes given container from current state in a thread-safe way.
+func (c *containers) deleteCurrent(n string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.currentState, n)
+}
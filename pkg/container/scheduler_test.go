@@ -0,0 +1,261 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// run() tests.
+func TestSchedulerRunSequentialByDefault(t *testing.T) {
+	s := newScheduler(0, 0)
+
+	executed := []string{}
+
+	tasks := []task{
+		{name: foo, host: foo},
+		{name: bar, host: bar},
+	}
+
+	err := s.run(tasks, func(n string) error {
+		executed = append(executed, n)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Running tasks should succeed, got: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{foo, bar}, executed); diff != "" {
+		t.Fatalf("Tasks should be executed in given order: %s", diff)
+	}
+}
+
+func TestSchedulerRunAggregateErrors(t *testing.T) {
+	s := newScheduler(2, 2)
+
+	var lock sync.Mutex
+
+	executed := 0
+
+	tasks := []task{
+		{name: foo, host: foo},
+		{name: bar, host: bar},
+		{name: "baz", host: foo},
+	}
+
+	err := s.run(tasks, func(n string) error {
+		lock.Lock()
+		executed++
+		lock.Unlock()
+
+		if n == bar {
+			return nil
+		}
+
+		return fmt.Errorf("failed %s", n)
+	})
+	if err == nil {
+		t.Fatalf("Errors returned by actions should be returned")
+	}
+
+	if executed != len(tasks) {
+		t.Fatalf("All tasks should be executed despite failures, got %d executed", executed)
+	}
+
+	if diff := cmp.Diff("failed foo, failed baz", err.Error()); diff != "" {
+		t.Fatalf("Errors should be aggregated in order of tasks: %s", diff)
+	}
+}
+
+func TestSchedulerRunSkipDependentsOfFailedTask(t *testing.T) {
+	s := newScheduler(4, 4)
+
+	var lock sync.Mutex
+
+	executed := []string{}
+
+	tasks := []task{
+		{name: foo, host: foo},
+		{name: "qux", host: bar},
+		{name: bar, host: foo, dependencies: []string{foo}},
+		{name: "baz", host: bar, dependencies: []string{bar}},
+	}
+
+	err := s.run(tasks, func(n string) error {
+		lock.Lock()
+		executed = append(executed, n)
+		lock.Unlock()
+
+		return fmt.Errorf("failed %s", n)
+	})
+	if err == nil {
+		t.Fatalf("Errors returned by actions should be returned")
+	}
+
+	sort.Strings(executed)
+
+	if diff := cmp.Diff([]string{foo, "qux"}, executed); diff != "" {
+		t.Fatalf("Dependents of failed task should be skipped: %s", diff)
+	}
+
+	if diff := cmp.Diff("failed foo, failed qux", err.Error()); diff != "" {
+		t.Fatalf("Only errors of independent tasks should be aggregated: %s", diff)
+	}
+}
+
+// concurrencyCounter tracks maximum number of concurrently running actions per key.
+type concurrencyCounter struct {
+	lock    sync.Mutex
+	current map[string]int
+	max     map[string]int
+}
+
+func (c *concurrencyCounter) action(key func(string) string) func(string) error {
+	return func(n string) error {
+		k := key(n)
+
+		c.lock.Lock()
+		c.current[k]++
+		c.current[""]++
+
+		for _, i := range []string{k, ""} {
+			if c.current[i] > c.max[i] {
+				c.max[i] = c.current[i]
+			}
+		}
+		c.lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		c.lock.Lock()
+		c.current[k]--
+		c.current[""]--
+		c.lock.Unlock()
+
+		return nil
+	}
+}
+
+func TestSchedulerRunPerHostLimit(t *testing.T) {
+	s := newScheduler(4, 1)
+
+	hosts := map[string]string{}
+	tasks := []task{}
+
+	for i := 0; i < 8; i++ {
+		n := fmt.Sprintf("container-%d", i)
+		h := fmt.Sprintf("host-%d", i%2)
+		hosts[n] = h
+
+		tasks = append(tasks, task{name: n, host: h})
+	}
+
+	c := &concurrencyCounter{
+		current: map[string]int{},
+		max:     map[string]int{},
+	}
+
+	if err := s.run(tasks, c.action(func(n string) string { return hosts[n] })); err != nil {
+		t.Fatalf("Running tasks should succeed, got: %v", err)
+	}
+
+	for _, h := range []string{"host-0", "host-1"} {
+		if c.max[h] != 1 {
+			t.Errorf("Expected at most 1 concurrent operation on host %s, got %d", h, c.max[h])
+		}
+	}
+
+	if c.max[""] > 2 {
+		t.Errorf("Expected at most 2 concurrent operations in total, got %d", c.max[""])
+	}
+}
+
+func TestSchedulerRunGlobalLimit(t *testing.T) {
+	s := newScheduler(2, 4)
+
+	tasks := []task{}
+
+	for i := 0; i < 8; i++ {
+		tasks = append(tasks, task{name: fmt.Sprintf("container-%d", i), host: foo})
+	}
+
+	c := &concurrencyCounter{
+		current: map[string]int{},
+		max:     map[string]int{},
+	}
+
+	if err := s.run(tasks, c.action(func(string) string { return foo })); err != nil {
+		t.Fatalf("Running tasks should succeed, got: %v", err)
+	}
+
+	if c.max[""] > 2 {
+		t.Errorf("Expected at most 2 concurrent operations in total, got %d", c.max[""])
+	}
+}
+
+func TestSchedulerRunBusyHostDoesNotBlockIdleHost(t *testing.T) {
+	s := newScheduler(2, 1)
+
+	idleStarted := make(chan struct{})
+
+	tasks := []task{
+		{name: foo, host: foo},
+		{name: "baz", host: foo},
+		{name: bar, host: bar},
+	}
+
+	err := s.run(tasks, func(n string) error {
+		switch n {
+		case foo:
+			select {
+			case <-idleStarted:
+				return nil
+			case <-time.After(time.Second):
+				return fmt.Errorf("task on idle host has not been started while other host was busy")
+			}
+		case bar:
+			close(idleStarted)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Running tasks should succeed, got: %v", err)
+	}
+}
+
+func TestSchedulerRunWaitingTaskDoesNotOccupySlot(t *testing.T) {
+	s := newScheduler(2, 2)
+
+	independentStarted := make(chan struct{})
+
+	tasks := []task{
+		{name: foo, host: foo},
+		{name: "baz", host: foo, dependencies: []string{foo}},
+		{name: bar, host: foo},
+	}
+
+	err := s.run(tasks, func(n string) error {
+		switch n {
+		case foo:
+			select {
+			case <-independentStarted:
+				return nil
+			case <-time.After(time.Second):
+				return fmt.Errorf("independent task has not been started while other task waited for dependencies")
+			}
+		case bar:
+			close(independentStarted)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Running tasks should succeed, got: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package container
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/flexkube/libflexkube/pkg/host"
+)
+
+// Session allows multiple Containers instances, for example managing controlplane and addons
+// on the same hosts, to share connections to the hosts. Forwarded container runtime addresses
+// are cached, so each host connection and forwarding is established only once per session.
+//
+// Session is safe for concurrent use.
+type Session struct {
+	// lock protects forwarded addresses map from concurrent modifications.
+	lock sync.Mutex
+
+	// forwarded stores local addresses of already forwarded remote addresses, indexed by
+	// host identifier and remote address.
+	forwarded map[string]string
+}
+
+// NewSession creates new, empty session.
+func NewSession() *Session {
+	return &Session{
+		forwarded: map[string]string{},
+	}
+}
+
+// sessionKey returns a key identifying given address on given host.
+func sessionKey(h host.Host, a string) string {
+	return fmt.Sprintf("%s|%s", h.ID(), a)
+}
+
+// forward returns local address, where given remote address on given host is reachable.
+// If address has been already forwarded in this session, existing forwarding is reused.
+func (s *Session) forward(h host.Host, a string, connect func() (string, error)) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	k := sessionKey(h, a)
+
+	if l, ok := s.forwarded[k]; ok {
+		return l, nil
+	}
+
+	l, err := connect()
+	if err != nil {
+		return "", err
+	}
+
+	if s.forwarded == nil {
+		s.forwarded = map[string]string{}
+	}
+
+	s.forwarded[k] = l
+
+	return l, nil
+}
@@ -0,0 +1,59 @@
+package container
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+func TestSessionForwardReuse(t *testing.T) {
+	s := NewSession()
+	h := host.Host{DirectConfig: &direct.Config{}}
+	calls := 0
+
+	connect := func() (string, error) {
+		calls++
+
+		return bar, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		a, err := s.forward(h, foo, connect)
+		if err != nil {
+			t.Fatalf("Forwarding should succeed, got: %v", err)
+		}
+
+		if a != bar {
+			t.Fatalf("Expected forwarded address %q, got %q", bar, a)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("Forwarding should be reused within the session, got %d connections", calls)
+	}
+}
+
+func TestSessionForwardFail(t *testing.T) {
+	s := &Session{}
+	h := host.Host{DirectConfig: &direct.Config{}}
+
+	if _, err := s.forward(h, foo, func() (string, error) { return "", fmt.Errorf("expected") }); err == nil {
+		t.Fatalf("Forwarding should propagate connection error")
+	}
+
+	if len(s.forwarded) != 0 {
+		t.Fatalf("Failed forwarding should not be cached")
+	}
+}
+
+func TestContainersDeepCopySharesSession(t *testing.T) {
+	c := &Containers{
+		Session: NewSession(),
+	}
+
+	if c.DeepCopy().Session != c.Session {
+		t.Fatalf("Copied containers should share the session")
+	}
+}
@@ -0,0 +1,76 @@
+package container
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+)
+
+// startLogsLines is a number of last lines of container logs included in the error, when
+// container is not running after start settle period.
+const startLogsLines = 20
+
+// createAndStart creates and starts given desired container and verifies, that it keeps
+// running after start settle period.
+func (c *containers) createAndStart(n string) error {
+	return c.withTimeout(n, "creating", func() error {
+		if err := c.desiredState.CreateAndStart(n); err != nil {
+			return err
+		}
+
+		c.markCreated(n)
+
+		return c.verifyStarted(n)
+	})
+}
+
+// verifyStarted waits for start settle period and returns descriptive error, if given desired
+// container is not running anymore, so containers crashing right after start are detected
+// immediately. If start settle period is not configured, verification is skipped.
+func (c *containers) verifyStarted(n string) error {
+	if c.startSettlePeriod == 0 {
+		return nil
+	}
+
+	time.Sleep(c.startSettlePeriod)
+
+	d := c.desiredState[n]
+
+	if err := d.Status(); err != nil {
+		return fmt.Errorf("failed checking container status after start: %w", err)
+	}
+
+	s := d.container.Status()
+	if s.Running() {
+		return nil
+	}
+
+	logs, err := d.logs(startLogsLines)
+	if err != nil {
+		logs = fmt.Sprintf("failed reading logs: %v", err)
+	}
+
+	return fmt.Errorf("container is not running %s after start (status: %s, exit code: %d), last logs:\n%s",
+		c.startSettlePeriod, s.Status, s.ExitCode, logs)
+}
+
+// logs returns given number of last lines of container logs.
+func (m *hostConfiguredContainer) logs(lines int) (string, error) {
+	var logs string
+
+	err := m.withForwardedRuntime(func() error {
+		r, ok := m.container.Runtime().(runtime.LogsReader)
+		if !ok {
+			return fmt.Errorf("container runtime does not support reading logs")
+		}
+
+		var err error
+
+		logs, err = r.Logs(m.container.Status().ID, lines)
+
+		return err
+	})
+
+	return logs, err
+}
@@ -0,0 +1,92 @@
+package container
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+func startCheckTestContainers(status types.ContainerStatus) *containers {
+	return &containers{
+		startSettlePeriod: time.Millisecond,
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{
+				host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+				container: &container{
+					base: base{
+						status: types.ContainerStatus{
+							ID: foo,
+						},
+						runtimeConfig: &runtime.FakeConfig{
+							Runtime: &runtime.FakeLogsReader{
+								Fake: runtime.Fake{
+									StatusF: func(id string) (types.ContainerStatus, error) {
+										return status, nil
+									},
+								},
+								LogsF: func(id string, lines int) (string, error) {
+									return "invalid flag", nil
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// verifyStarted() tests.
+func TestVerifyStarted(t *testing.T) {
+	c := startCheckTestContainers(types.ContainerStatus{
+		ID:     foo,
+		Status: "running",
+	})
+
+	if err := c.verifyStarted(foo); err != nil {
+		t.Fatalf("Verifying running container should succeed, got: %v", err)
+	}
+}
+
+func TestVerifyStartedExited(t *testing.T) {
+	c := startCheckTestContainers(types.ContainerStatus{
+		ID:       foo,
+		Status:   "exited",
+		ExitCode: 2,
+	})
+
+	err := c.verifyStarted(foo)
+	if err == nil {
+		t.Fatalf("Verifying exited container should fail")
+	}
+
+	for _, s := range []string{"exited", "exit code: 2", "invalid flag"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("Error should contain %q, got: %v", s, err)
+		}
+	}
+
+	if c.desiredState[foo].container.Status().Status != "exited" {
+		t.Fatalf("Status of the container should be updated")
+	}
+}
+
+func TestVerifyStartedDisabled(t *testing.T) {
+	c := startCheckTestContainers(types.ContainerStatus{
+		ID:     foo,
+		Status: "exited",
+	})
+
+	c.startSettlePeriod = 0
+
+	if err := c.verifyStarted(foo); err != nil {
+		t.Fatalf("Verification should be skipped, when settle period is not configured, got: %v", err)
+	}
+}
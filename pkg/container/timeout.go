@@ -0,0 +1,124 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Names of deployment phases, which are used in phase timeout errors.
+const (
+	phaseCheck  = "checking for stopped and missing containers"
+	phaseCreate = "configuring and creating new containers"
+	phaseUpdate = "updating existing containers"
+)
+
+// withTimeout executes given operation on given container and returns an error, if operation
+// does not finish within configured operation timeout. If timeout is not configured, operation
+// is executed without any time limit.
+//
+// Operation, which timed out is not interrupted, as remote calls can't be cancelled, so it may
+// still finish in the background.
+func (c *containers) withTimeout(n, op string, f func() error) error {
+	if c.operationTimeout == 0 {
+		return f()
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- f()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(c.operationTimeout):
+		return fmt.Errorf("%s container '%s' timed out after %s", op, n, c.operationTimeout)
+	}
+}
+
+// phaseProgress tracks containers, which are currently processed in the deployment phase.
+type phaseProgress struct {
+	lock       sync.Mutex
+	inProgress map[string]struct{}
+}
+
+// track wraps given action, so containers are marked as in progress while action is executed.
+// If progress is nil, action is returned unchanged.
+func (p *phaseProgress) track(action func(string) error) func(string) error {
+	if p == nil {
+		return action
+	}
+
+	return func(n string) error {
+		p.lock.Lock()
+		p.inProgress[n] = struct{}{}
+		p.lock.Unlock()
+
+		defer func() {
+			p.lock.Lock()
+			delete(p.inProgress, n)
+			p.lock.Unlock()
+		}()
+
+		return action(n)
+	}
+}
+
+// names returns sorted names of containers, which are currently in progress.
+func (p *phaseProgress) names() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	n := []string{}
+
+	for k := range p.inProgress {
+		n = append(n, k)
+	}
+
+	sort.Strings(n)
+
+	return n
+}
+
+// withPhaseTimeout executes given deployment phase and returns an error identifying the phase
+// and containers still in progress, if phase does not finish within configured phase timeout.
+// If timeout is not configured, phase is executed without any time limit.
+//
+// Like with operation timeout, phase which timed out is not interrupted.
+//
+// Each phase is recorded as a child span of the deployment span.
+func (c *containers) withPhaseTimeout(phase string, f func() error) (err error) {
+	end := c.startSpan(phase, nil)
+
+	defer func() {
+		end(err)
+	}()
+
+	if c.phaseTimeout == 0 {
+		return f()
+	}
+
+	p := &phaseProgress{
+		inProgress: map[string]struct{}{},
+	}
+
+	c.progress = p
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- f()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(c.phaseTimeout):
+		return fmt.Errorf("phase '%s' timed out after %s, containers in progress: [%s]",
+			phase, c.phaseTimeout, strings.Join(p.names(), ", "))
+	}
+}
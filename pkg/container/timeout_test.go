@@ -0,0 +1,108 @@
+package container
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWithTimeoutNotSet(t *testing.T) {
+	c := &containers{}
+
+	if err := c.withTimeout(foo, "starting", func() error { return fmt.Errorf("expected") }); err == nil {
+		t.Fatalf("Error returned by operation should be propagated")
+	}
+}
+
+func TestWithTimeoutFinished(t *testing.T) {
+	c := &containers{
+		operationTimeout: time.Second,
+	}
+
+	if err := c.withTimeout(foo, "starting", func() error { return nil }); err != nil {
+		t.Fatalf("Operation finishing within timeout should succeed, got: %v", err)
+	}
+}
+
+func TestWithTimeoutExceeded(t *testing.T) {
+	c := &containers{
+		operationTimeout: time.Millisecond,
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	if err := c.withTimeout(foo, "starting", func() error {
+		<-done
+
+		return nil
+	}); err == nil {
+		t.Fatalf("Operation exceeding timeout should fail")
+	}
+}
+
+func TestToExportedOperationTimeout(t *testing.T) {
+	c := &containers{
+		operationTimeout: 5 * time.Minute,
+	}
+
+	if e := c.ToExported().OperationTimeout; e != "5m0s" {
+		t.Fatalf("Operation timeout should be preserved when exporting, got: %q", e)
+	}
+}
+
+func TestWithPhaseTimeoutNotSet(t *testing.T) {
+	c := &containers{}
+
+	if err := c.withPhaseTimeout(phaseCheck, func() error { return fmt.Errorf("expected") }); err == nil {
+		t.Fatalf("Error returned by phase should be propagated")
+	}
+}
+
+func TestWithPhaseTimeoutExceeded(t *testing.T) {
+	c := &containers{
+		phaseTimeout: 10 * time.Millisecond,
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	err := c.withPhaseTimeout(phaseCreate, func() error {
+		if err := c.progress.track(func(string) error { return nil })(bar); err != nil {
+			return err
+		}
+
+		return c.progress.track(func(string) error {
+			<-done
+
+			return nil
+		})(foo)
+	})
+	if err == nil {
+		t.Fatalf("Phase exceeding timeout should fail")
+	}
+
+	expected := "phase 'configuring and creating new containers' timed out after 10ms, containers in progress: [foo]"
+
+	if err.Error() != expected {
+		t.Fatalf("Expected error %q, got %q", expected, err.Error())
+	}
+}
+
+func TestPhaseProgressTrackNil(t *testing.T) {
+	var p *phaseProgress
+
+	if err := p.track(func(string) error { return fmt.Errorf("expected") })(foo); err == nil {
+		t.Fatalf("Error returned by action should be propagated")
+	}
+}
+
+func TestToExportedPhaseTimeout(t *testing.T) {
+	c := &containers{
+		phaseTimeout: time.Hour,
+	}
+
+	if e := c.ToExported().PhaseTimeout; e != "1h0m0s" {
+		t.Fatalf("Phase timeout should be preserved when exporting, got: %q", e)
+	}
+}
@@ -0,0 +1,95 @@
+package container
+
+import (
+	"context"
+)
+
+// Tracer allows deployment to participate in distributed tracing. Deploy creates a span for
+// the whole deployment, child spans for each deployment phase and child spans of the phase for
+// each container operation.
+//
+// Interface follows OpenTelemetry tracer semantics, so it can be implemented by a thin adapter
+// over OpenTelemetry tracer, which stores started span in the returned context.
+type Tracer interface {
+	// Start starts a new span with given name and attributes as a child of a span stored in
+	// given context. It returns context holding the new span and a function, which ends the
+	// span and records given error, if it is not nil.
+	Start(ctx context.Context, name string, attributes map[string]string) (context.Context, func(err error))
+}
+
+// Names of spans created by Deploy.
+const (
+	spanDeploy    = "deploy"
+	spanContainer = "container"
+)
+
+// noopTracer is a Tracer, which does nothing. It is used, when no tracer is configured.
+type noopTracer struct{}
+
+// Start implements Tracer interface.
+func (noopTracer) Start(ctx context.Context, _ string, _ map[string]string) (context.Context, func(error)) {
+	return ctx, func(error) {}
+}
+
+// getTracer returns configured tracer or no-op tracer, if tracer is not configured.
+func (c *containers) getTracer() Tracer {
+	if c.tracer == nil {
+		return noopTracer{}
+	}
+
+	return c.tracer
+}
+
+// startSpan starts a span as a child of the current span of the deployment and makes it a
+// current span. Returned function ends the span and restores previous current span.
+//
+// It must not be called concurrently, so it should be only used for deployment and phases spans.
+func (c *containers) startSpan(name string, attributes map[string]string) func(err error) {
+	parent := c.spanContext
+	if parent == nil {
+		parent = c.traceContext
+	}
+
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, end := c.getTracer().Start(parent, name, attributes)
+
+	c.spanContext = ctx
+
+	return func(err error) {
+		end(err)
+
+		c.spanContext = parent
+	}
+}
+
+// traced wraps given container action, so each execution creates a child span of the current
+// span of the deployment, with container name and host as attributes.
+func (c *containers) traced(action func(string) error) func(string) error {
+	return func(n string) error {
+		attributes := map[string]string{
+			"container": n,
+		}
+
+		if h, ok := c.desiredState[n]; ok {
+			attributes["host"] = h.host.ID()
+		} else if h, ok := c.current(n); ok {
+			attributes["host"] = h.host.ID()
+		}
+
+		parent := c.spanContext
+		if parent == nil {
+			parent = context.Background()
+		}
+
+		_, end := c.getTracer().Start(parent, spanContainer, attributes)
+
+		err := action(n)
+
+		end(err)
+
+		return err
+	}
+}
@@ -0,0 +1,104 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+type spanKey struct{}
+
+type fakeTracer struct {
+	lock  sync.Mutex
+	spans []string
+}
+
+func (f *fakeTracer) Start(ctx context.Context, name string, attributes map[string]string) (context.Context, func(error)) {
+	parent, _ := ctx.Value(spanKey{}).(string)
+
+	return context.WithValue(ctx, spanKey{}, name), func(err error) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		f.spans = append(f.spans, fmt.Sprintf("%s/%s %v: %v", parent, name, attributes, err))
+	}
+}
+
+// Deploy() tests.
+func TestDeployTracing(t *testing.T) {
+	tr := &fakeTracer{}
+
+	c := &containers{
+		currentState: containersState{},
+		desiredState: containersState{},
+		tracer:       tr,
+		traceContext: context.WithValue(context.Background(), spanKey{}, "parent"),
+	}
+
+	if err := c.Deploy(); err != nil {
+		t.Fatalf("Deploying should succeed, got: %v", err)
+	}
+
+	expected := []string{
+		"deploy/" + phaseCheck + " map[]: <nil>",
+		"deploy/" + phaseCreate + " map[]: <nil>",
+		"deploy/" + phaseUpdate + " map[]: <nil>",
+		"parent/deploy map[]: <nil>",
+	}
+
+	if diff := cmp.Diff(expected, tr.spans); diff != "" {
+		t.Fatalf("Unexpected spans: %s", diff)
+	}
+}
+
+// traced() tests.
+func TestTraced(t *testing.T) {
+	tr := &fakeTracer{}
+
+	c := &containers{
+		desiredState: containersState{
+			foo: &hostConfiguredContainer{
+				host: host.Host{
+					DirectConfig: &direct.Config{},
+				},
+			},
+		},
+		currentState: containersState{},
+		tracer:       tr,
+	}
+
+	end := c.startSpan(phaseCreate, nil)
+
+	if err := c.traced(func(string) error { return fmt.Errorf(bar) })(foo); err == nil {
+		t.Fatalf("Traced action should return error of the action")
+	}
+
+	end(nil)
+
+	expected := []string{
+		phaseCreate + "/container map[container:foo host:direct]: bar",
+		"/" + phaseCreate + " map[]: <nil>",
+	}
+
+	if diff := cmp.Diff(expected, tr.spans); diff != "" {
+		t.Fatalf("Unexpected spans: %s", diff)
+	}
+
+	if c.spanContext != nil {
+		t.Fatalf("Ending span should restore previous span")
+	}
+}
+
+func TestTracedNoTracer(t *testing.T) {
+	c := &containers{}
+
+	if err := c.traced(func(string) error { return nil })(foo); err != nil {
+		t.Fatalf("Traced action should succeed without tracer, got: %v", err)
+	}
+}
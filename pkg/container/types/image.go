@@ -0,0 +1,135 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// defaultRegistry is a registry used by Docker for images without registry in the reference.
+	defaultRegistry = "docker.io"
+
+	// legacyDefaultRegistry is an alias of defaultRegistry, which Docker normalizes to it.
+	legacyDefaultRegistry = "index.docker.io"
+
+	// officialRepositoryPrefix is a prefix of single component repositories in the default registry.
+	officialRepositoryPrefix = "library/"
+
+	// defaultTag is a tag used for images without tag and digest in the reference.
+	defaultTag = "latest"
+)
+
+var (
+	// imageRegistryRegexp matches registry host with optional port.
+	imageRegistryRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:[0-9]+)?$`)
+
+	// imagePathComponentRegexp matches single component of repository path.
+	imagePathComponentRegexp = regexp.MustCompile(`^[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*$`)
+
+	// imageTagRegexp matches image tag.
+	imageTagRegexp = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+
+	// imageDigestRegexp matches image digest.
+	imageDigestRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*([-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+)
+
+// imageHasRegistry checks, if given image name includes registry host, using the same
+// rules as Docker. First path component is considered a registry, if it contains a dot,
+// a port or if it's 'localhost'.
+func imageHasRegistry(name string) bool {
+	i := strings.Index(name, "/")
+	if i == -1 {
+		return false
+	}
+
+	host := name[:i]
+
+	return strings.ContainsAny(host, ".:") || host == "localhost"
+}
+
+// splitImage splits given image reference into name, tag and digest.
+func splitImage(image string) (string, string, string, error) {
+	name, digest := image, ""
+
+	if i := strings.Index(name, "@"); i != -1 {
+		name, digest = name[:i], name[i+1:]
+
+		if digest == "" {
+			return "", "", "", fmt.Errorf("digest is empty")
+		}
+	}
+
+	tag := ""
+
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+
+		if tag == "" {
+			return "", "", "", fmt.Errorf("tag is empty")
+		}
+	}
+
+	return name, tag, digest, nil
+}
+
+// NormalizeImage validates given image reference and returns it in canonical form, with registry
+// and tag included, using the same defaults as Docker. For example, 'nginx', 'nginx:latest' and
+// 'docker.io/library/nginx:latest' are all normalized to 'docker.io/library/nginx:latest'.
+// References with digest and without tag are kept without tag, as digest identifies the image.
+func NormalizeImage(image string) (string, error) {
+	name, tag, digest, err := splitImage(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid image %q: %w", image, err)
+	}
+
+	registry, path := defaultRegistry, name
+
+	if imageHasRegistry(name) {
+		i := strings.Index(name, "/")
+		registry, path = name[:i], name[i+1:]
+	}
+
+	if !imageRegistryRegexp.MatchString(registry) {
+		return "", fmt.Errorf("invalid registry %q in image %q", registry, image)
+	}
+
+	if registry == legacyDefaultRegistry {
+		registry = defaultRegistry
+	}
+
+	for _, c := range strings.Split(path, "/") {
+		if !imagePathComponentRegexp.MatchString(c) {
+			return "", fmt.Errorf("invalid repository name %q in image %q, it must be lowercase and "+
+				"contain only alphanumeric characters and separators", path, image)
+		}
+	}
+
+	if registry == defaultRegistry && !strings.Contains(path, "/") {
+		path = officialRepositoryPrefix + path
+	}
+
+	if tag != "" && !imageTagRegexp.MatchString(tag) {
+		return "", fmt.Errorf("invalid tag %q in image %q", tag, image)
+	}
+
+	if digest != "" && !imageDigestRegexp.MatchString(digest) {
+		return "", fmt.Errorf("invalid digest %q in image %q", digest, image)
+	}
+
+	r := registry + "/" + path
+
+	if tag == "" && digest == "" {
+		tag = defaultTag
+	}
+
+	if tag != "" {
+		r += ":" + tag
+	}
+
+	if digest != "" {
+		r += "@" + digest
+	}
+
+	return r, nil
+}
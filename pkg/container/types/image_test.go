@@ -0,0 +1,50 @@
+package types
+
+import (
+	"testing"
+)
+
+// NormalizeImage() tests.
+func TestNormalizeImage(t *testing.T) {
+	digest := "sha256:0123456789abcdef0123456789abcdef"
+
+	cases := map[string]string{
+		"nginx":                                 "docker.io/library/nginx:latest",
+		"nginx:latest":                          "docker.io/library/nginx:latest",
+		"docker.io/library/nginx:latest":        "docker.io/library/nginx:latest",
+		"index.docker.io/library/nginx":         "docker.io/library/nginx:latest",
+		"flexkube/flexkube:v0.1.0":              "docker.io/flexkube/flexkube:v0.1.0",
+		"k8s.gcr.io/hyperkube:v1.18.6":          "k8s.gcr.io/hyperkube:v1.18.6",
+		"localhost:5000/foo":                    "localhost:5000/foo:latest",
+		"localhost/foo/bar":                     "localhost/foo/bar:latest",
+		"nginx@" + digest:                       "docker.io/library/nginx@" + digest,
+		"quay.io/coreos/etcd:v3.4.10@" + digest: "quay.io/coreos/etcd:v3.4.10@" + digest,
+	}
+
+	for i, expected := range cases {
+		i, expected := i, expected
+
+		t.Run(i, func(t *testing.T) {
+			n, err := NormalizeImage(i)
+			if err != nil {
+				t.Fatalf("Normalizing image should succeed, got: %v", err)
+			}
+
+			if n != expected {
+				t.Fatalf("Expected %q, got %q", expected, n)
+			}
+		})
+	}
+}
+
+func TestNormalizeImageInvalid(t *testing.T) {
+	for _, i := range []string{"", "Nginx", "nginx:", "nginx@", "nginx:-foo", "nginx@sha256:foo", "foo//bar", "-foo.io/bar", "foo/"} {
+		i := i
+
+		t.Run(i, func(t *testing.T) {
+			if _, err := NormalizeImage(i); err == nil {
+				t.Fatalf("Normalizing invalid image %q should fail", i)
+			}
+		})
+	}
+}
@@ -2,6 +2,10 @@
 // to avoid cyclic dependencies while importing.
 package types
 
+import (
+	"time"
+)
+
 // ContainerConfig stores runtime-agnostic information how to run the container.
 type ContainerConfig struct {
 	// Name is a name of the container.
@@ -16,12 +20,34 @@ type ContainerConfig struct {
 	// Entrypoint is a binary, which will be started in the container.
 	Entrypoint []string `json:"entrypoint,omitempty"`
 
+	// Env is a list of environment variables in 'KEY=value' format, which will be set in
+	// the container. Variables defined here take precedence over variables from EnvFiles.
+	//
+	// Example value: '[]string{"GOMAXPROCS=2"}'.
+	Env []string `json:"env,omitempty"`
+
+	// EnvFiles is a list of absolute paths of files on the host, which contain environment
+	// variables for the container, one 'KEY=value' per line. Empty lines and lines starting
+	// with '#' are ignored. Files may be managed as configuration files of the container,
+	// otherwise they must exist on the host.
+	//
+	// Files are read when the container is created, so if content of managed file changes,
+	// container is recreated.
+	//
+	// Example value: '[]string{"/etc/default/kubelet"}'.
+	EnvFiles []string `json:"envFiles,omitempty"`
+
 	// Ports is a list of ports, which will be exposed by the container.
 	Ports []PortMap `json:"ports,omitempty"`
 
 	// Mounts is a list of mounts, which will be added to the container.
 	Mounts []Mount `json:"mounts,omitempty"`
 
+	// Volumes is a list of named volumes, which will be mounted into the container.
+	// Volumes are created if they don't exist and they are preserved when container
+	// is recreated, so they can be used for storing persistent data.
+	Volumes []VolumeMount `json:"volumes,omitempty"`
+
 	// Privileged controls, if created container should have full access to the
 	// host.
 	Privileged bool `json:"privileged,omitempty"`
@@ -46,6 +72,163 @@ type ContainerConfig struct {
 
 	// Group defines as which group the container should run.
 	Group string `json:"group,omitempty"`
+
+	// Init controls, if container runtime should run an init process inside the container,
+	// which forwards signals and reaps zombie processes.
+	Init bool `json:"init,omitempty"`
+
+	// StopSignal defines, which signal will be sent to the container to gracefully stop it.
+	// If empty, container runtime default will be used.
+	//
+	// Example value: 'SIGTERM'.
+	StopSignal string `json:"stopSignal,omitempty"`
+
+	// StopTimeout defines, how many seconds container runtime should wait for the container
+	// to stop after sending the stop signal, before killing it. If not set, container runtime
+	// default will be used.
+	//
+	// Example value: '30'.
+	StopTimeout int `json:"stopTimeout,omitempty"`
+
+	// DebugCommand, if set, replaces container entrypoint and arguments, while keeping
+	// all mounts and configuration files in place. This allows to keep failing container
+	// running, so it can be inspected.
+	//
+	// This field is intended only for debugging and it is stored in the state, so it is
+	// visible, that container does not run it's regular command.
+	//
+	// Example value: '[]string{"sleep", "infinity"}'.
+	DebugCommand []string `json:"debugCommand,omitempty"`
+
+	// Hostname is a hostname of the container. If empty, container name is used. If container
+	// runs in host network namespace, host's hostname is always used and this field is ignored.
+	Hostname string `json:"hostname,omitempty"`
+
+	// WorkingDir is a working directory for the container process. If empty, container
+	// image default will be used.
+	//
+	// Example value: '/var/lib/foo'.
+	WorkingDir string `json:"workingDir,omitempty"`
+
+	// CpusetCpus is a list of CPUs, on which container processes are allowed to run.
+	// If empty, all CPUs can be used.
+	//
+	// Example value: '0-2,4'.
+	CpusetCpus string `json:"cpusetCpus,omitempty"`
+
+	// CpusetMems is a list of memory nodes (NUMA nodes), from which container processes
+	// are allowed to allocate memory. If empty, all memory nodes can be used.
+	//
+	// Example value: '0'.
+	CpusetMems string `json:"cpusetMems,omitempty"`
+
+	// Memory is a maximum amount of memory in bytes, which container processes can use.
+	// If zero, memory usage is not limited. Container runtime may update this limit without
+	// re-creating the container.
+	//
+	// Example value: '536870912'.
+	Memory int64 `json:"memory,omitempty"`
+
+	// NanoCPUs is a maximum amount of CPU time container processes can use, in units of
+	// 10^-9 CPUs. If zero, CPU usage is not limited. Container runtime may update this limit
+	// without re-creating the container.
+	//
+	// Example value: '1500000000', which allows using 1.5 CPU.
+	NanoCPUs int64 `json:"nanoCPUs,omitempty"`
+
+	// OOMScoreAdj adjusts the score used by the kernel to select processes to kill,
+	// when the host runs out of memory. Lower values make the container less likely
+	// to be killed. Valid values are from -1000 to 1000.
+	//
+	// Example value: '-997'.
+	OOMScoreAdj int `json:"oomScoreAdj,omitempty"`
+
+	// CgroupParent is a parent cgroup of the container, which allows to account resource usage
+	// of the container under specific cgroup. When container runtime uses systemd cgroup driver,
+	// it should be a slice name, otherwise absolute cgroup path. If empty, container runtime
+	// default will be used.
+	//
+	// Example value: 'system.slice'.
+	CgroupParent string `json:"cgroupParent,omitempty"`
+
+	// ShmSize is a size of /dev/shm of the container in bytes. If not set, container runtime
+	// default will be used.
+	//
+	// Example value: '268435456'.
+	ShmSize int64 `json:"shmSize,omitempty"`
+
+	// Platform defines OS and architecture of the container image in 'os/arch[/variant]'
+	// format. If set, image for this platform is pulled instead of image for the native
+	// platform of the host, e.g. to run the container using emulation. If empty, native
+	// platform of the host is used.
+	//
+	// Example value: 'linux/arm64'.
+	Platform string `json:"platform,omitempty"`
+
+	// RuntimeOptions is a set of container runtime specific options, which are passed as-is
+	// to the container runtime, when the container is created. It allows using runtime features,
+	// which are not modeled by other fields. Options are NOT validated, so invalid options will
+	// only be reported by the container runtime. Changing options is treated as configuration
+	// change, so the container will be recreated.
+	//
+	// For Docker, key must be a field name of Docker container configuration prefixed with
+	// 'config.' or a field name of Docker host configuration prefixed with 'hostConfig.' and value
+	// must be a JSON-encoded value of the field.
+	//
+	// Example value: 'map[string]string{"hostConfig.ShmSize": "268435456"}'.
+	RuntimeOptions map[string]string `json:"runtimeOptions,omitempty"`
+
+	// RestartMaxRetries limits, how many times container can be restarted after it exits, to
+	// avoid restarting misconfigured container in a tight loop. If container runtime supports it,
+	// limit is also enforced by the runtime. Once container reaches the limit, it is not started
+	// again and it is reported as failed. If zero, container is restarted without limit.
+	//
+	// Setting the limit also changes, when container is restarted by the runtime. Without the
+	// limit, container is always restarted, unless it has been stopped explicitly (Docker's
+	// 'unless-stopped' policy). With the limit, container is only restarted, when it exits with
+	// non-zero exit code (Docker's 'on-failure' policy), so container exiting successfully
+	// stays stopped until next deployment starts it.
+	//
+	// Example value: '5'.
+	RestartMaxRetries int `json:"restartMaxRetries,omitempty"`
+
+	// RestartBackoff is a delay before starting the container, which has already been restarted
+	// by the runtime. Delay is doubled with each restart, up to 5 minutes. If container runtime
+	// does not support configuring the backoff, it is only applied when starting the container
+	// during deployment. If empty, container is started without delay.
+	//
+	// Example value: '10s'.
+	RestartBackoff string `json:"restartBackoff,omitempty"`
+
+	// HealthCheck configures health check built into the container runtime, which periodically
+	// probes the container. Health reported by the runtime is then available in container status.
+	// If container runtime does not support health checks, it is ignored.
+	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
+
+	// Labels is a set of key-value metadata attached to the container. Changing only
+	// labels does not require recreating the container, if container runtime supports
+	// updating them in place.
+	//
+	// Example value: 'map[string]string{"app": "etcd"}'.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations is a set of free-form key-value metadata attached to the container, intended
+	// for external tooling. Annotations are passed to the container runtime, which stores them
+	// in a runtime-specific way, e.g. Docker stores them as prefixed labels. Changing annotations
+	// requires recreating the container.
+	//
+	// Keys must be in format '[prefix/]name', where optional prefix is a DNS subdomain and name
+	// consists of alphanumeric characters, '-', '_' and '.', up to 63 characters.
+	//
+	// Example value: 'map[string]string{"example.com/owner": "team-a"}'.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// ConfigHash is a hash of the configuration of the container, which container runtime
+	// stores with the created container, so changes made outside of the deployment can be
+	// detected by comparing it with the hash of the desired configuration.
+	//
+	// Due to it's nature, it can only be set programmatically.
+	ConfigHash string `json:"-"`
 }
 
 // ContainerStatus stores status information received from the runtime.
@@ -58,6 +241,96 @@ type ContainerStatus struct {
 
 	// Status is a runtime specific status string.
 	Status string `json:"status,omitempty"`
+
+	// RestartCount is a number of times the container has been restarted by the runtime.
+	// High restart count may indicate, that container is crash looping, even if it's
+	// currently running.
+	RestartCount int `json:"restartCount,omitempty"`
+
+	// Health is a runtime specific health status of the container, reported by the container
+	// runtime, when container has HealthCheck configured.
+	Health string `json:"health,omitempty"`
+
+	// ExitCode is an exit code of the container process, if container is not running.
+	ExitCode int `json:"exitCode,omitempty"`
+
+	// ConfigHash is a hash of the configuration, which container has been created with, as
+	// reported by the container runtime. It is empty, if container runtime does not support it
+	// or if container has been created without it.
+	ConfigHash string `json:"configHash,omitempty"`
+}
+
+// HealthCheck describes health check executed by the container runtime.
+type HealthCheck struct {
+	// Command is a command executed inside the container. Container is considered healthy,
+	// if command exits with 0.
+	//
+	// Example value: '[]string{"/bin/health", "--quiet"}'.
+	Command []string `json:"command"`
+
+	// Interval is a time between running the checks. If empty, container runtime default
+	// will be used.
+	//
+	// Example value: '30s'.
+	Interval string `json:"interval,omitempty"`
+
+	// Timeout is a maximum time a single check is allowed to run. If empty, container runtime
+	// default will be used.
+	//
+	// Example value: '10s'.
+	Timeout string `json:"timeout,omitempty"`
+
+	// StartPeriod is a time given to container to start, before failing checks are counted.
+	// If empty, container runtime default will be used.
+	//
+	// Example value: '1m'.
+	StartPeriod string `json:"startPeriod,omitempty"`
+
+	// Retries is a number of consecutive failed checks, after which container is considered
+	// unhealthy. If zero, container runtime default will be used.
+	//
+	// Example value: '3'.
+	Retries int `json:"retries,omitempty"`
+}
+
+// HealthHealthy is a health status reported by the container runtime, when container is healthy.
+const HealthHealthy = "healthy"
+
+// ContainerInstance describes container found by the runtime.
+type ContainerInstance struct {
+	// ID is a runtime specific container ID.
+	ID string
+
+	// Created is a time, when container has been created.
+	Created time.Time
+}
+
+// ExistenceReason describes, why the container is considered existing or not.
+type ExistenceReason string
+
+const (
+	// ExistenceFound means, that container has been found by the runtime.
+	ExistenceFound ExistenceReason = "found"
+
+	// ExistenceNotCreated means, that container has never been created, as it has no ID assigned.
+	ExistenceNotCreated ExistenceReason = "not created"
+
+	// ExistenceRemoved means, that container has been created, but runtime reports, that it
+	// does not exist anymore, for example because it has been removed externally.
+	ExistenceRemoved ExistenceReason = "removed"
+
+	// ExistenceUnknown means, that runtime could not be queried, so it is not known, if
+	// the container exists.
+	ExistenceUnknown ExistenceReason = "unknown"
+)
+
+// Existence is a result of checking, if the container exists.
+type Existence struct {
+	// Exists is true, if the container exists.
+	Exists bool
+
+	// Reason describes, why container is considered existing or not.
+	Reason ExistenceReason
 }
 
 // PortMap is basically a github.com/docker/go-connections/nat.PortMap.
@@ -74,6 +347,40 @@ type PortMap struct {
 	Protocol string `json:"protocol"`
 }
 
+// ResourceLimits is a set of resource limits of the container, which container runtimes
+// usually allow to update without re-creating the container.
+type ResourceLimits struct {
+	// Memory is a maximum amount of memory in bytes. Zero means no limit.
+	Memory int64 `json:"memory,omitempty"`
+
+	// NanoCPUs is a maximum amount of CPU time, in units of 10^-9 CPUs. Zero means no limit.
+	NanoCPUs int64 `json:"nanoCPUs,omitempty"`
+
+	// CpusetCpus is a list of CPUs, on which container processes are allowed to run.
+	CpusetCpus string `json:"cpusetCpus,omitempty"`
+
+	// CpusetMems is a list of memory nodes, from which container processes can allocate memory.
+	CpusetMems string `json:"cpusetMems,omitempty"`
+}
+
+// ResourceLimits returns resource limits from the container configuration.
+func (c ContainerConfig) ResourceLimits() ResourceLimits {
+	return ResourceLimits{
+		Memory:     c.Memory,
+		NanoCPUs:   c.NanoCPUs,
+		CpusetCpus: c.CpusetCpus,
+		CpusetMems: c.CpusetMems,
+	}
+}
+
+// SetResourceLimits replaces resource limits in the container configuration with given ones.
+func (c *ContainerConfig) SetResourceLimits(r ResourceLimits) {
+	c.Memory = r.Memory
+	c.NanoCPUs = r.NanoCPUs
+	c.CpusetCpus = r.CpusetCpus
+	c.CpusetMems = r.CpusetMems
+}
+
 // Mount describe host bind mount.
 //
 // TODO: Same as PortMap.
@@ -94,6 +401,22 @@ type Mount struct {
 	Propagation string `json:"propagation,omitempty"`
 }
 
+// VolumeMount describes named volume, which should be mounted into the container.
+type VolumeMount struct {
+	// Name is a name of the volume. Volume is created by container runtime if it does not exist.
+	//
+	// Example value: 'etcd-data'.
+	Name string `json:"name"`
+
+	// Target is a path in container's filesystem where volume will be mounted.
+	//
+	// Example value: '/var/lib/etcd'.
+	Target string `json:"target"`
+
+	// ReadOnly controls, if volume should be mounted in read-only mode.
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
 // File describes file, which can be either copied to or from container.
 type File struct {
 	// Path is a path on the filesystem.
@@ -123,6 +446,12 @@ func (s *ContainerStatus) Running() bool {
 	return s.Exists() && s.Status == "running"
 }
 
+// Unhealthy returns true, if container runtime reports, that container is not healthy. As health
+// is only reported for containers with HealthCheck configured, other containers are never unhealthy.
+func (s *ContainerStatus) Unhealthy() bool {
+	return s.Exists() && s.Health != "" && s.Health != HealthHealthy
+}
+
 // Restarting returns true, if container is restarting in a loop, based on ContainerStatus.
 func (s *ContainerStatus) Restarting() bool {
 	return s.Exists() && s.Status == "restarting"
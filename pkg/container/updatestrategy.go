@@ -0,0 +1,46 @@
+package container
+
+import (
+	"fmt"
+)
+
+// UpdateStrategy defines, how changes to the container configuration are applied.
+type UpdateStrategy string
+
+const (
+	// UpdateStrategyRecreate applies all changes by recreating the container. This is
+	// the default strategy.
+	UpdateStrategyRecreate UpdateStrategy = "Recreate"
+
+	// UpdateStrategyInPlaceIfPossible applies changes to the existing container, if only labels
+	// or only resource limits changes and container runtime supports updating them. Other
+	// changes are applied by recreating the container.
+	UpdateStrategyInPlaceIfPossible UpdateStrategy = "InPlaceIfPossible"
+)
+
+// Validate checks, if update strategy is supported. Empty strategy means the default one.
+func (s UpdateStrategy) Validate() error {
+	switch s {
+	case "", UpdateStrategyRecreate, UpdateStrategyInPlaceIfPossible:
+		return nil
+	default:
+		return fmt.Errorf("unsupported update strategy %q, supported strategies: %s, %s",
+			s, UpdateStrategyRecreate, UpdateStrategyInPlaceIfPossible)
+	}
+}
+
+// inPlaceAllowed checks, if update strategy of the container allows applying changes to the
+// existing container.
+func (m *hostConfiguredContainer) inPlaceAllowed() bool {
+	return m.updateStrategy == UpdateStrategyInPlaceIfPossible
+}
+
+// inPlaceRefusal returns the reason, why change of given kind to given container will be
+// applied by recreating the container, even though it could be applied in place.
+func (c *containers) inPlaceRefusal(n, change string) string {
+	if !c.desiredState[n].inPlaceAllowed() {
+		return fmt.Sprintf("Update strategy of the container is %s", UpdateStrategyRecreate)
+	}
+
+	return fmt.Sprintf("Container runtime does not support updating %s", change)
+}
@@ -0,0 +1,118 @@
+package container
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/flexkube/libflexkube/pkg/container/runtime"
+	"github.com/flexkube/libflexkube/pkg/container/runtime/docker"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
+)
+
+func updateStrategyTestContainer(s UpdateStrategy) *HostConfiguredContainer {
+	return &HostConfiguredContainer{
+		Host: host.Host{
+			DirectConfig: &direct.Config{},
+		},
+		Container: Container{
+			Runtime: RuntimeConfig{
+				Docker: &docker.Config{},
+			},
+			Config: types.ContainerConfig{
+				Name:  foo,
+				Image: "busybox:latest",
+			},
+		},
+		UpdateStrategy: s,
+	}
+}
+
+// UpdateStrategy.Validate() tests.
+func TestUpdateStrategyValidate(t *testing.T) {
+	t.Parallel()
+
+	cases := map[UpdateStrategy]bool{
+		"":                              false,
+		UpdateStrategyRecreate:          false,
+		UpdateStrategyInPlaceIfPossible: false,
+		"InPlace":                       true,
+	}
+
+	for s, expectError := range cases {
+		s, expectError := s, expectError
+
+		t.Run(string(s), func(t *testing.T) {
+			t.Parallel()
+
+			err := s.Validate()
+			if !expectError && err != nil {
+				t.Fatalf("Didn't expect error, got: %v", err)
+			}
+
+			if expectError && err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+	}
+}
+
+func TestHostConfiguredContainerValidateUpdateStrategy(t *testing.T) {
+	t.Parallel()
+
+	hcc := updateStrategyTestContainer("foo")
+
+	if err := hcc.Validate(); err == nil {
+		t.Fatalf("Validating container with unsupported update strategy should fail")
+	}
+}
+
+func TestHostConfiguredContainerNewUpdateStrategy(t *testing.T) {
+	t.Parallel()
+
+	hcc := updateStrategyTestContainer(UpdateStrategyInPlaceIfPossible)
+
+	h, err := hcc.New()
+	if err != nil {
+		t.Fatalf("Initializing container should succeed, got: %v", err)
+	}
+
+	if !h.(*hostConfiguredContainer).inPlaceAllowed() {
+		t.Fatalf("Update strategy should be passed to created container")
+	}
+
+	cs := containersState{foo: h.(*hostConfiguredContainer)}
+
+	if s := cs.Export()[foo].UpdateStrategy; s != UpdateStrategyInPlaceIfPossible {
+		t.Fatalf("Update strategy should be exported, got %q", s)
+	}
+}
+
+func TestResourcesUpdatableRecreateStrategy(t *testing.T) {
+	t.Parallel()
+
+	c := resourcesTestContainers(&runtime.FakeConfig{
+		Runtime: &runtime.FakeResourcesUpdater{
+			UpdateResourcesF: func(id string, limits types.ResourceLimits) ([]string, error) {
+				return nil, nil
+			},
+		},
+	})
+
+	c.desiredState[foo].updateStrategy = ""
+
+	if c.resourcesUpdatable(foo) {
+		t.Fatalf("Resource limits should not be updated in place with default update strategy")
+	}
+
+	if r := c.inPlaceRefusal(foo, "resource limits"); !strings.Contains(r, string(UpdateStrategyRecreate)) {
+		t.Fatalf("Refusal reason should mention update strategy, got %q", r)
+	}
+
+	c.desiredState[foo].updateStrategy = UpdateStrategyInPlaceIfPossible
+
+	if !c.resourcesUpdatable(foo) {
+		t.Fatalf("Resource limits should be updated in place with %s update strategy", UpdateStrategyInPlaceIfPossible)
+	}
+}
@@ -0,0 +1,174 @@
+package container
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultWaitForHealthyTimeout is a default time, for which container creation waits
+	// for containers it depends on to become healthy.
+	defaultWaitForHealthyTimeout = 5 * time.Minute
+
+	// waitForHealthyInterval is a time between health checks of containers, which other
+	// container waits for.
+	waitForHealthyInterval = time.Second
+)
+
+// validateWaitForHealthy checks, that all containers referenced in WaitForHealthy fields
+// of containers in given state exist in the state and that there are no cycles, which
+// would block the deployment.
+func validateWaitForHealthy(s ContainersState) error {
+	for n, hcc := range s {
+		for _, w := range hcc.WaitForHealthy {
+			if w == n {
+				return fmt.Errorf("container %q can't wait for itself", n)
+			}
+
+			if _, ok := s[w]; !ok {
+				return fmt.Errorf("container %q waits for non-existing container %q", n, w)
+			}
+		}
+	}
+
+	visited := map[string]bool{}
+
+	var visit func(n string, path map[string]bool) error
+
+	visit = func(n string, path map[string]bool) error {
+		if path[n] {
+			return fmt.Errorf("container %q waits for itself through other containers", n)
+		}
+
+		if visited[n] {
+			return nil
+		}
+
+		path[n] = true
+
+		for _, w := range exportedDependencies(s[n]) {
+			if err := visit(w, path); err != nil {
+				return err
+			}
+		}
+
+		delete(path, n)
+
+		visited[n] = true
+
+		return nil
+	}
+
+	for n := range s {
+		if err := visit(n, map[string]bool{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportedDependencies returns names of containers, which given container depends on, including
+// it's pod container.
+func exportedDependencies(hcc *HostConfiguredContainer) []string {
+	if hcc.Pod == "" {
+		return hcc.WaitForHealthy
+	}
+
+	return append([]string{hcc.Pod}, hcc.WaitForHealthy...)
+}
+
+// waitDepth returns length of the longest chain of containers, which given container waits
+// for, using desired state. Containers, which do not wait for any other containers have depth 0.
+// Desired state must be validated before calling it, so there are no cycles.
+func (c *containers) waitDepth(n string) int {
+	d, ok := c.desiredState[n]
+	if !ok {
+		return 0
+	}
+
+	depth := 0
+
+	for _, w := range d.dependencies() {
+		if wd := c.waitDepth(w) + 1; wd > depth {
+			depth = wd
+		}
+	}
+
+	return depth
+}
+
+// isHealthy checks, if given container is running and if it's health check passes. If container
+// has runtime health check configured, container status is refreshed first and health reported by
+// the container runtime must be healthy as well.
+func (c *containers) isHealthy(n string) error {
+	r, ok := c.current(n)
+	if !ok {
+		return fmt.Errorf("container is not running")
+	}
+
+	d, desired := c.desiredState[n]
+
+	if desired && d.container.Config().HealthCheck != nil {
+		if err := r.Status(); err != nil {
+			return fmt.Errorf("failed updating container status: %w", err)
+		}
+	}
+
+	s := r.container.Status()
+
+	if !s.Running() {
+		return fmt.Errorf("container is not running")
+	}
+
+	if s.Unhealthy() {
+		return fmt.Errorf("container runtime reports container as %s", s.Health)
+	}
+
+	if !desired || d.hooks == nil || d.hooks.HealthCheck == nil {
+		return nil
+	}
+
+	return (*d.hooks.HealthCheck)()
+}
+
+// waitForHealthy blocks until all containers, which given container waits for, are healthy.
+// Containers without health check are considered healthy, once they are running. If containers
+// do not become healthy within configured timeout, error is returned.
+func (c *containers) waitForHealthy(n string) error {
+	d, ok := c.desiredState[n]
+	if !ok {
+		return nil
+	}
+
+	timeout := c.waitForHealthyTimeout
+	if timeout == 0 {
+		timeout = defaultWaitForHealthyTimeout
+	}
+
+	for _, w := range d.dependencies() {
+		c.logf(n, "Waiting for container '%s' to become healthy before starting container '%s'", w, n)
+
+		deadline := time.Now().Add(timeout)
+
+		for {
+			err := c.isHealthy(w)
+			if err == nil {
+				break
+			}
+
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return fmt.Errorf("container %s did not become healthy within %s: %w", w, timeout, err)
+			}
+
+			if remaining > waitForHealthyInterval {
+				remaining = waitForHealthyInterval
+			}
+
+			time.Sleep(remaining)
+		}
+	}
+
+	return nil
+}
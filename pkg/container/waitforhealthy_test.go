@@ -0,0 +1,189 @@
+package container
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+func runningTestContainer() *hostConfiguredContainer {
+	return &hostConfiguredContainer{
+		container: &container{
+			base: base{
+				status: types.ContainerStatus{
+					ID:     foo,
+					Status: "running",
+				},
+			},
+		},
+	}
+}
+
+// validateWaitForHealthy() tests.
+func TestValidateWaitForHealthy(t *testing.T) {
+	cases := map[string]struct {
+		state ContainersState
+		err   bool
+	}{
+		"valid": {
+			state: ContainersState{
+				foo: {WaitForHealthy: []string{bar}},
+				bar: {},
+			},
+		},
+		"self": {
+			state: ContainersState{
+				foo: {WaitForHealthy: []string{foo}},
+			},
+			err: true,
+		},
+		"non existing": {
+			state: ContainersState{
+				foo: {WaitForHealthy: []string{bar}},
+			},
+			err: true,
+		},
+		"cycle": {
+			state: ContainersState{
+				foo:   {WaitForHealthy: []string{bar}},
+				bar:   {WaitForHealthy: []string{"baz"}},
+				"baz": {WaitForHealthy: []string{foo}},
+			},
+			err: true,
+		},
+		"cycle through pod": {
+			state: ContainersState{
+				foo: {Pod: bar},
+				bar: {WaitForHealthy: []string{foo}},
+			},
+			err: true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			err := validateWaitForHealthy(c.state)
+
+			if c.err && err == nil {
+				t.Fatalf("Expected error")
+			}
+
+			if !c.err && err != nil {
+				t.Fatalf("Didn't expect error, got: %v", err)
+			}
+		})
+	}
+}
+
+// tasks() tests.
+func TestTasksWaitForHealthyOrder(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			"a": {waitForHealthy: []string{"c"}},
+			"b": {},
+			"c": {waitForHealthy: []string{"b"}},
+		},
+	}
+
+	tasks := c.tasks(c.desiredState)
+
+	names := []string{}
+
+	for _, t := range tasks {
+		names = append(names, t.name)
+	}
+
+	if fmt.Sprint(names) != "[b c a]" {
+		t.Fatalf("Containers should be ordered by wait dependencies, got: %v", names)
+	}
+}
+
+// waitForHealthy() tests.
+func TestWaitForHealthy(t *testing.T) {
+	checks := 0
+
+	hc := Hook(func() error {
+		checks++
+
+		if checks < 2 {
+			return fmt.Errorf("not healthy yet")
+		}
+
+		return nil
+	})
+
+	c := &containers{
+		desiredState: containersState{
+			foo: {waitForHealthy: []string{bar}},
+			bar: {container: &container{}, hooks: &Hooks{HealthCheck: &hc}},
+		},
+		currentState: containersState{
+			bar: runningTestContainer(),
+		},
+		waitForHealthyTimeout: 10 * time.Second,
+	}
+
+	if err := c.waitForHealthy(foo); err != nil {
+		t.Fatalf("Waiting for healthy container should succeed, got: %v", err)
+	}
+
+	if checks != 2 {
+		t.Fatalf("Health check should be retried until it succeeds, got %d checks", checks)
+	}
+}
+
+func TestWaitForHealthyNotRunning(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: {waitForHealthy: []string{bar}},
+			bar: {},
+		},
+		currentState:          containersState{},
+		waitForHealthyTimeout: time.Millisecond,
+	}
+
+	if err := c.waitForHealthy(foo); err == nil {
+		t.Fatalf("Waiting for not running container should time out")
+	}
+}
+
+func TestWaitForHealthyNoHealthCheck(t *testing.T) {
+	c := &containers{
+		desiredState: containersState{
+			foo: {waitForHealthy: []string{bar}},
+			bar: {container: &container{}},
+		},
+		currentState: containersState{
+			bar: runningTestContainer(),
+		},
+		waitForHealthyTimeout: time.Millisecond,
+	}
+
+	if err := c.waitForHealthy(foo); err != nil {
+		t.Fatalf("Running container without health check should be considered healthy, got: %v", err)
+	}
+}
+
+func TestWaitForHealthyRuntimeUnhealthy(t *testing.T) {
+	hcc := runningTestContainer()
+	hcc.container.Status().Health = "unhealthy"
+
+	c := &containers{
+		desiredState: containersState{
+			foo: {waitForHealthy: []string{bar}},
+			bar: {container: &container{}},
+		},
+		currentState: containersState{
+			bar: hcc,
+		},
+		waitForHealthyTimeout: time.Millisecond,
+	}
+
+	if err := c.waitForHealthy(foo); err == nil {
+		t.Fatalf("Waiting for container reported as unhealthy by the runtime should time out")
+	}
+}
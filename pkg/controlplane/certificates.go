@@ -0,0 +1,467 @@
+package controlplane
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flexkube/libflexkube/internal/util"
+	"github.com/flexkube/libflexkube/pkg/kubernetes/client"
+	"github.com/flexkube/libflexkube/pkg/types"
+)
+
+// signedCertificate describes expected relation between the certificate and the CA,
+// which should sign it.
+type signedCertificate struct {
+	name        string
+	certificate types.Certificate
+	caName      string
+	ca          types.Certificate
+}
+
+// parseCertificate decodes given PEM encoded X.509 certificate.
+func parseCertificate(c types.Certificate) (*x509.Certificate, error) {
+	der, _ := pem.Decode([]byte(c))
+	if der == nil {
+		return nil, fmt.Errorf("failed to decode PEM format")
+	}
+
+	cert, err := x509.ParseCertificate(der.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	return cert, nil
+}
+
+// verify checks, if the certificate is signed by the CA. If either the certificate
+// or the CA is not set, verification is skipped, as required fields are validated
+// by each component.
+func (s signedCertificate) verify() error {
+	if s.certificate == "" || s.ca == "" {
+		return nil
+	}
+
+	cert, err := parseCertificate(s.certificate)
+	if err != nil {
+		return fmt.Errorf("failed parsing %s: %w", s.name, err)
+	}
+
+	ca, err := parseCertificate(s.ca)
+	if err != nil {
+		return fmt.Errorf("failed parsing %s: %w", s.caName, err)
+	}
+
+	if err := ca.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		return fmt.Errorf("%s (CN=%q, issuer CN=%q) is not signed by %s (CN=%q): %w",
+			s.name, cert.Subject.CommonName, cert.Issuer.CommonName, s.caName, ca.Subject.CommonName, err)
+	}
+
+	return nil
+}
+
+// effectiveCommon returns common configuration of the component with values inherited
+// from the controlplane.
+func (c *Controlplane) effectiveCommon(co *Common) Common {
+	r := Common{}
+
+	if co != nil {
+		r = *co
+	}
+
+	c.propagateCommon(&r)
+
+	return r
+}
+
+// validateCertificates verifies, that all configured certificates are signed by the CAs,
+// which will be used to verify them. Mixing certificates signed by different CAs results
+// in TLS errors, which are hard to debug after deployment.
+//
+// This method must be called after components configuration is built.
+func (c *Controlplane) validateCertificates() error {
+	kas := c.KubeAPIServer
+	kasCommon := c.effectiveCommon(kas.Common)
+
+	kcm := c.KubeControllerManager
+	ks := c.KubeScheduler
+
+	scs := []signedCertificate{
+		{
+			name:        "kube-apiserver server certificate",
+			certificate: kas.APIServerCertificate,
+			caName:      "kube-controller-manager kubeconfig CA certificate",
+			ca:          kcm.Kubeconfig.CACertificate,
+		},
+		{
+			name:        "kube-apiserver server certificate",
+			certificate: kas.APIServerCertificate,
+			caName:      "kube-scheduler kubeconfig CA certificate",
+			ca:          ks.Kubeconfig.CACertificate,
+		},
+		{
+			name:        "kube-controller-manager kubeconfig client certificate",
+			certificate: kcm.Kubeconfig.ClientCertificate,
+			caName:      "kube-apiserver client CA certificate",
+			ca:          kasCommon.KubernetesCACertificate,
+		},
+		{
+			name:        "kube-scheduler kubeconfig client certificate",
+			certificate: ks.Kubeconfig.ClientCertificate,
+			caName:      "kube-apiserver client CA certificate",
+			ca:          kasCommon.KubernetesCACertificate,
+		},
+		{
+			name:        "kube-apiserver front proxy client certificate",
+			certificate: kas.FrontProxyCertificate,
+			caName:      "front proxy CA certificate",
+			ca:          kasCommon.FrontProxyCACertificate,
+		},
+		{
+			name:        "kube-controller-manager Kubernetes CA certificate",
+			certificate: c.effectiveCommon(kcm.Common).KubernetesCACertificate,
+			caName:      "kube-controller-manager root CA certificate",
+			ca:          kcm.RootCACertificate,
+		},
+	}
+
+	var errors util.ValidateError
+
+	for _, sc := range scs {
+		if err := sc.verify(); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	return errors.Return()
+}
+
+// requiredCertificate describes certificate, which must be present for the component to work.
+type requiredCertificate struct {
+	name        string
+	certificate types.Certificate
+}
+
+// requiredKey describes private key, which must be present for the component to work.
+type requiredKey struct {
+	name string
+	key  types.PrivateKey
+}
+
+// kubeconfigMaterial returns certificates and keys required by given kubeconfig of given
+// component. Client certificate and key are not required, if kubeconfig uses token.
+func kubeconfigMaterial(component string, k client.Config) ([]requiredCertificate, []requiredKey) {
+	rcs := []requiredCertificate{
+		{
+			name:        fmt.Sprintf("%s kubeconfig CA certificate", component),
+			certificate: k.CACertificate,
+		},
+	}
+
+	if k.Token != "" {
+		return rcs, nil
+	}
+
+	rcs = append(rcs, requiredCertificate{
+		name:        fmt.Sprintf("%s kubeconfig client certificate", component),
+		certificate: k.ClientCertificate,
+	})
+
+	return rcs, []requiredKey{
+		{
+			name: fmt.Sprintf("%s kubeconfig client key", component),
+			key:  k.ClientKey,
+		},
+	}
+}
+
+// validateRequiredCertificates verifies, that all certificates and private keys required by
+// controlplane components are set and that certificates can be parsed. Missing certificates
+// would otherwise only be reported as TLS errors after deployment.
+//
+// Aggregation layer is always enabled in kube-apiserver, so front proxy certificates are
+// always required.
+//
+// This method must be called after components configuration is built.
+func (c *Controlplane) validateRequiredCertificates() error {
+	kas := c.KubeAPIServer
+	kasCommon := c.effectiveCommon(kas.Common)
+
+	kcm := c.KubeControllerManager
+	ks := c.KubeScheduler
+
+	rcs := []requiredCertificate{
+		{
+			name:        "kube-apiserver Kubernetes CA certificate",
+			certificate: kasCommon.KubernetesCACertificate,
+		},
+		{
+			name:        "kube-apiserver front proxy CA certificate",
+			certificate: kasCommon.FrontProxyCACertificate,
+		},
+		{
+			name:        "kube-apiserver server certificate",
+			certificate: kas.APIServerCertificate,
+		},
+		{
+			name:        "kube-apiserver front proxy client certificate",
+			certificate: kas.FrontProxyCertificate,
+		},
+		{
+			name:        "kube-apiserver kubelet client certificate",
+			certificate: kas.KubeletClientCertificate,
+		},
+		{
+			name:        "kube-apiserver etcd CA certificate",
+			certificate: kas.EtcdCACertificate,
+		},
+		{
+			name:        "kube-apiserver etcd client certificate",
+			certificate: kas.EtcdClientCertificate,
+		},
+		{
+			name:        "kube-controller-manager Kubernetes CA certificate",
+			certificate: c.effectiveCommon(kcm.Common).KubernetesCACertificate,
+		},
+		{
+			name:        "kube-controller-manager root CA certificate",
+			certificate: kcm.RootCACertificate,
+		},
+	}
+
+	rks := []requiredKey{
+		{
+			name: "kube-apiserver server key",
+			key:  kas.APIServerKey,
+		},
+		{
+			name: "kube-apiserver front proxy client key",
+			key:  kas.FrontProxyKey,
+		},
+		{
+			name: "kube-apiserver kubelet client key",
+			key:  kas.KubeletClientKey,
+		},
+		{
+			name: "kube-apiserver etcd client key",
+			key:  kas.EtcdClientKey,
+		},
+		{
+			name: "kube-controller-manager Kubernetes CA key",
+			key:  kcm.KubernetesCAKey,
+		},
+		{
+			name: "kube-controller-manager service account private key",
+			key:  kcm.ServiceAccountPrivateKey,
+		},
+	}
+
+	kcmCerts, kcmKeys := kubeconfigMaterial("kube-controller-manager", kcm.Kubeconfig)
+	ksCerts, ksKeys := kubeconfigMaterial("kube-scheduler", ks.Kubeconfig)
+
+	rcs = append(append(rcs, kcmCerts...), ksCerts...)
+	rks = append(append(rks, kcmKeys...), ksKeys...)
+
+	var errors util.ValidateError
+
+	for _, rc := range rcs {
+		if rc.certificate == "" {
+			errors = append(errors, fmt.Errorf("%s is not set", rc.name))
+
+			continue
+		}
+
+		if _, err := parseCertificate(rc.certificate); err != nil {
+			errors = append(errors, fmt.Errorf("failed parsing %s: %w", rc.name, err))
+		}
+	}
+
+	for _, rk := range rks {
+		if rk.key == "" {
+			errors = append(errors, fmt.Errorf("%s is not set", rk.name))
+		}
+	}
+
+	return errors.Return()
+}
+
+// certificateExpiryThreshold returns parsed CertificateExpiryThreshold. If threshold is not set,
+// zero is returned.
+func (c *Controlplane) certificateExpiryThreshold() (time.Duration, error) {
+	if c.CertificateExpiryThreshold == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(c.CertificateExpiryThreshold)
+	if err != nil {
+		return 0, fmt.Errorf("failed parsing certificate expiry threshold %q: %w", c.CertificateExpiryThreshold, err)
+	}
+
+	if d < 0 {
+		return 0, fmt.Errorf("certificate expiry threshold can't be negative")
+	}
+
+	return d, nil
+}
+
+// checkExpiry returns an error, if the certificate is expired at given time or it expires
+// before given deadline. If the certificate is not set, check is skipped, as required
+// certificates are validated separately.
+func (rc requiredCertificate) checkExpiry(now, deadline time.Time) error {
+	if rc.certificate == "" {
+		return nil
+	}
+
+	cert, err := parseCertificate(rc.certificate)
+	if err != nil {
+		return fmt.Errorf("failed parsing %s: %w", rc.name, err)
+	}
+
+	if cert.NotAfter.Before(now) {
+		return fmt.Errorf("%s (CN=%q) expired at %s", rc.name, cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339))
+	}
+
+	if cert.NotAfter.Before(deadline) {
+		return fmt.Errorf("%s (CN=%q) expires at %s, which is within certificate expiry threshold",
+			rc.name, cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// validateCertificatesExpiry verifies, that client certificates used by controlplane components
+// are not expired at given time and remain valid for at least configured expiry threshold.
+// Deploying expired certificates, e.g. generated during previous deployment, would break
+// communication between the components.
+//
+// This method must be called after components configuration is built.
+func (c *Controlplane) validateCertificatesExpiry(now time.Time) error {
+	// Threshold is validated separately.
+	threshold, _ := c.certificateExpiryThreshold()
+
+	kas := c.KubeAPIServer
+
+	rcs := []requiredCertificate{
+		{
+			name:        "kube-apiserver front proxy client certificate",
+			certificate: kas.FrontProxyCertificate,
+		},
+		{
+			name:        "kube-apiserver kubelet client certificate",
+			certificate: kas.KubeletClientCertificate,
+		},
+		{
+			name:        "kube-apiserver etcd client certificate",
+			certificate: kas.EtcdClientCertificate,
+		},
+	}
+
+	// Client certificates are not used, if kubeconfig uses token.
+	if kcm := c.KubeControllerManager.Kubeconfig; kcm.Token == "" {
+		rcs = append(rcs, requiredCertificate{
+			name:        "kube-controller-manager kubeconfig client certificate",
+			certificate: kcm.ClientCertificate,
+		})
+	}
+
+	if ks := c.KubeScheduler.Kubeconfig; ks.Token == "" {
+		rcs = append(rcs, requiredCertificate{
+			name:        "kube-scheduler kubeconfig client certificate",
+			certificate: ks.ClientCertificate,
+		})
+	}
+
+	var errors util.ValidateError
+
+	for _, rc := range rcs {
+		if err := rc.checkExpiry(now, now.Add(threshold)); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	return errors.Return()
+}
+
+// stripPort removes port from given address, if present.
+func stripPort(a string) string {
+	h, _, err := net.SplitHostPort(a)
+	if err != nil {
+		return a
+	}
+
+	return h
+}
+
+// apiServerAddresses returns sorted list of unique addresses and hostnames, which will be
+// used to reach kube-apiserver.
+func (c *Controlplane) apiServerAddresses() []string {
+	kas := c.KubeAPIServer
+
+	addresses := []string{
+		c.APIServerAddress,
+		kas.AdvertiseAddress,
+		stripPort(c.KubeControllerManager.Kubeconfig.Server),
+		stripPort(c.KubeScheduler.Kubeconfig.Server),
+	}
+
+	// Unspecified bind address means listening on all interfaces, which is not an address
+	// used by the clients.
+	if ip := net.ParseIP(kas.BindAddress); ip == nil || !ip.IsUnspecified() {
+		addresses = append(addresses, kas.BindAddress)
+	}
+
+	unique := map[string]struct{}{}
+
+	for _, a := range addresses {
+		if a != "" {
+			unique[a] = struct{}{}
+		}
+	}
+
+	r := []string{}
+
+	for a := range unique {
+		r = append(r, a)
+	}
+
+	sort.Strings(r)
+
+	return r
+}
+
+// ValidateAPIServerCertificateSANs verifies, that kube-apiserver server certificate is valid
+// for all configured kube-apiserver addresses and hostnames, which will be used by the clients.
+// If some addresses are not covered by certificate's subject alternative names, error listing
+// them is returned, as otherwise clients would fail to connect after deployment.
+//
+// If kube-apiserver server certificate is not set, validation is skipped.
+func (c *Controlplane) ValidateAPIServerCertificateSANs() error {
+	c.buildComponents()
+
+	if c.KubeAPIServer.APIServerCertificate == "" {
+		return nil
+	}
+
+	cert, err := parseCertificate(c.KubeAPIServer.APIServerCertificate)
+	if err != nil {
+		return fmt.Errorf("failed parsing kube-apiserver server certificate: %w", err)
+	}
+
+	missing := []string{}
+
+	for _, a := range c.apiServerAddresses() {
+		if err := cert.VerifyHostname(a); err != nil {
+			missing = append(missing, a)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("kube-apiserver server certificate is not valid for: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,282 @@
+package controlplane
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flexkube/libflexkube/internal/utiltest"
+	"github.com/flexkube/libflexkube/pkg/kubernetes/client"
+	"github.com/flexkube/libflexkube/pkg/pki"
+	"github.com/flexkube/libflexkube/pkg/types"
+)
+
+func TestValidateCertificatesSameCA(t *testing.T) {
+	cert := types.Certificate(utiltest.GenerateX509Certificate(t))
+
+	c := &Controlplane{
+		Common: &Common{
+			KubernetesCACertificate: cert,
+			FrontProxyCACertificate: cert,
+		},
+		KubeAPIServer: KubeAPIServer{
+			APIServerCertificate:  cert,
+			FrontProxyCertificate: cert,
+		},
+		KubeScheduler: KubeScheduler{
+			Kubeconfig: client.Config{
+				ClientCertificate: cert,
+			},
+		},
+	}
+
+	c.buildComponents()
+
+	if err := c.validateCertificates(); err != nil {
+		t.Fatalf("certificates signed by the same CA should be valid, got: %v", err)
+	}
+}
+
+func TestValidateCertificatesDifferentCA(t *testing.T) {
+	cert := types.Certificate(utiltest.GenerateX509Certificate(t))
+
+	cases := map[string]*Controlplane{
+		"client certificate": {
+			Common: &Common{
+				KubernetesCACertificate: cert,
+			},
+			KubeScheduler: KubeScheduler{
+				Kubeconfig: client.Config{
+					ClientCertificate: types.Certificate(utiltest.GenerateX509Certificate(t)),
+				},
+			},
+		},
+		"server certificate": {
+			Common: &Common{
+				KubernetesCACertificate: cert,
+			},
+			KubeAPIServer: KubeAPIServer{
+				APIServerCertificate: types.Certificate(utiltest.GenerateX509Certificate(t)),
+			},
+		},
+		"front proxy certificate": {
+			Common: &Common{
+				FrontProxyCACertificate: cert,
+			},
+			KubeAPIServer: KubeAPIServer{
+				FrontProxyCertificate: types.Certificate(utiltest.GenerateX509Certificate(t)),
+			},
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			c.buildComponents()
+
+			if err := c.validateCertificates(); err == nil {
+				t.Fatalf("certificates signed by different CAs should be rejected")
+			}
+		})
+	}
+}
+
+func testSANsControlplane(t *testing.T, address string) *Controlplane {
+	t.Helper()
+
+	pki := &pki.PKI{
+		Kubernetes: &pki.Kubernetes{
+			KubeAPIServer: &pki.KubeAPIServer{
+				ServerIPs:     []string{"10.0.0.1"},
+				ExternalNames: []string{"api.example.com"},
+			},
+		},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("Generating PKI should succeed, got: %v", err)
+	}
+
+	return &Controlplane{
+		PKI:              pki,
+		APIServerAddress: address,
+		APIServerPort:    6443,
+	}
+}
+
+// ValidateAPIServerCertificateSANs() tests.
+func TestValidateAPIServerCertificateSANs(t *testing.T) {
+	for _, a := range []string{"10.0.0.1", "api.example.com", "127.0.0.1"} {
+		c := testSANsControlplane(t, a)
+
+		if err := c.ValidateAPIServerCertificateSANs(); err != nil {
+			t.Fatalf("Certificate should be valid for address %q, got: %v", a, err)
+		}
+	}
+}
+
+func TestValidateAPIServerCertificateSANsMissing(t *testing.T) {
+	c := testSANsControlplane(t, "10.0.0.2")
+	c.KubeAPIServer.BindAddress = "0.0.0.0"
+
+	err := c.ValidateAPIServerCertificateSANs()
+	if err == nil {
+		t.Fatalf("Certificate not covering API server address should be rejected")
+	}
+
+	if !strings.Contains(err.Error(), "10.0.0.2") || strings.Contains(err.Error(), "0.0.0.0") {
+		t.Fatalf("Error should list only missing addresses, got: %v", err)
+	}
+}
+
+func TestValidateAPIServerCertificateSANsNoCertificate(t *testing.T) {
+	c := &Controlplane{
+		APIServerAddress: "10.0.0.1",
+	}
+
+	if err := c.ValidateAPIServerCertificateSANs(); err != nil {
+		t.Fatalf("Validation should be skipped when certificate is not set, got: %v", err)
+	}
+}
+
+// validateRequiredCertificates() tests.
+func requiredCertificatesControlplane(t *testing.T) *Controlplane {
+	t.Helper()
+
+	pki := &pki.PKI{
+		Etcd: &pki.Etcd{
+			ClientCNs: []string{"kube-apiserver"},
+		},
+		Kubernetes: &pki.Kubernetes{},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("Generating PKI should succeed, got: %v", err)
+	}
+
+	c := &Controlplane{
+		PKI: pki,
+	}
+
+	c.buildComponents()
+
+	return c
+}
+
+func TestValidateRequiredCertificates(t *testing.T) {
+	c := requiredCertificatesControlplane(t)
+
+	if err := c.validateRequiredCertificates(); err != nil {
+		t.Fatalf("All certificates from PKI should be valid, got: %v", err)
+	}
+}
+
+func TestValidateRequiredCertificatesMissing(t *testing.T) {
+	c := &Controlplane{}
+
+	c.buildComponents()
+
+	err := c.validateRequiredCertificates()
+	if err == nil {
+		t.Fatalf("Validation should fail when certificates are missing")
+	}
+
+	for _, name := range []string{
+		"kube-apiserver Kubernetes CA certificate is not set",
+		"kube-apiserver front proxy CA certificate is not set",
+		"kube-scheduler kubeconfig client key is not set",
+	} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Error should name missing %q, got: %v", name, err)
+		}
+	}
+}
+
+func TestValidateRequiredCertificatesInvalid(t *testing.T) {
+	c := requiredCertificatesControlplane(t)
+	c.KubeAPIServer.Common.FrontProxyCACertificate = "doh"
+
+	err := c.validateRequiredCertificates()
+	if err == nil {
+		t.Fatalf("Validation should fail when certificate is malformed")
+	}
+
+	if !strings.Contains(err.Error(), "front proxy CA certificate") {
+		t.Fatalf("Error should name malformed certificate, got: %v", err)
+	}
+}
+
+func TestValidateRequiredCertificatesKubeconfigToken(t *testing.T) {
+	c := requiredCertificatesControlplane(t)
+	c.KubeScheduler.Kubeconfig.ClientCertificate = ""
+	c.KubeScheduler.Kubeconfig.ClientKey = ""
+	c.KubeScheduler.Kubeconfig.Token = "foo"
+
+	if err := c.validateRequiredCertificates(); err != nil {
+		t.Fatalf("Client certificate should not be required when token is used, got: %v", err)
+	}
+}
+
+// validateCertificatesExpiry() tests.
+func TestValidateCertificatesExpiry(t *testing.T) {
+	c := requiredCertificatesControlplane(t)
+
+	if err := c.validateCertificatesExpiry(time.Now()); err != nil {
+		t.Fatalf("Freshly generated certificates should be valid, got: %v", err)
+	}
+}
+
+func TestValidateCertificatesExpiryExpired(t *testing.T) {
+	c := requiredCertificatesControlplane(t)
+
+	err := c.validateCertificatesExpiry(time.Now().Add(9000 * time.Hour))
+	if err == nil {
+		t.Fatalf("Validation should fail when certificates are expired")
+	}
+
+	if !strings.Contains(err.Error(), "kube-scheduler kubeconfig client certificate") || !strings.Contains(err.Error(), "expired at") {
+		t.Fatalf("Error should name expired certificate, got: %v", err)
+	}
+}
+
+func TestValidateCertificatesExpiryThreshold(t *testing.T) {
+	c := requiredCertificatesControlplane(t)
+	c.CertificateExpiryThreshold = "9000h"
+
+	err := c.validateCertificatesExpiry(time.Now())
+	if err == nil {
+		t.Fatalf("Validation should fail when certificates expire within threshold")
+	}
+
+	if !strings.Contains(err.Error(), "within certificate expiry threshold") {
+		t.Fatalf("Error should mention expiry threshold, got: %v", err)
+	}
+}
+
+func TestValidateCertificatesExpiryKubeconfigToken(t *testing.T) {
+	c := requiredCertificatesControlplane(t)
+	c.KubeScheduler.Kubeconfig.Token = "foo"
+
+	err := c.validateCertificatesExpiry(time.Now().Add(9000 * time.Hour))
+	if err == nil {
+		t.Fatalf("Validation should fail when certificates are expired")
+	}
+
+	if strings.Contains(err.Error(), "kube-scheduler") {
+		t.Fatalf("Client certificate should not be checked when token is used, got: %v", err)
+	}
+}
+
+// certificateExpiryThreshold() tests.
+func TestCertificateExpiryThresholdInvalid(t *testing.T) {
+	for _, threshold := range []string{"doh", "-1h"} {
+		c := &Controlplane{
+			CertificateExpiryThreshold: threshold,
+		}
+
+		if _, err := c.certificateExpiryThreshold(); err == nil {
+			t.Fatalf("Threshold %q should be rejected", threshold)
+		}
+	}
+}
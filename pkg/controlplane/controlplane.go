@@ -4,11 +4,14 @@ package controlplane
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"sigs.k8s.io/yaml"
 
 	"github.com/flexkube/libflexkube/internal/util"
 	"github.com/flexkube/libflexkube/pkg/container"
+	containertypes "github.com/flexkube/libflexkube/pkg/container/types"
 	"github.com/flexkube/libflexkube/pkg/defaults"
 	"github.com/flexkube/libflexkube/pkg/host"
 	"github.com/flexkube/libflexkube/pkg/host/transport/ssh"
@@ -17,12 +20,34 @@ import (
 	"github.com/flexkube/libflexkube/pkg/types"
 )
 
+const (
+	// defaultStopSignal is a signal, which will be sent to controlplane containers to
+	// gracefully stop them.
+	defaultStopSignal = "SIGTERM"
+
+	// extraCACertificatesPath is a path in the container, where extra CA certificates will be mounted.
+	// Certificates placed in this directory are trusted by Go programs in addition to system bundle.
+	extraCACertificatesPath = "/etc/ssl/certs/flexkube-extra-ca.crt"
+
+	// defaultOOMScoreAdj is an OOM score adjustment set for controlplane containers, so they
+	// are less likely to be killed than regular workloads, when the host runs out of memory.
+	// It is the same value which kubelet uses for Guaranteed pods.
+	defaultOOMScoreAdj = -997
+)
+
+// components is a list of names of all controlplane components.
+var components = []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler"}
+
 // Common struct contains fields, which are common between all controlplane components.
 type Common struct {
 	// Image allows to set Docker image with tag, which will be used by all controlplane containers,
 	// if they have no image set. If empty, hyperkube image defined in pkg/defaults
 	// will be used.
 	//
+	// When set in Common of the specific component, it takes precedence over the image set in
+	// Controlplane Common, which allows running different versions of the components, e.g. during
+	// upgrades.
+	//
 	// Example value: 'k8s.gcr.io/hyperkube:v1.18.3'.
 	//
 	// This field is optional.
@@ -36,11 +61,87 @@ type Common struct {
 	// FrontProxyCACertificate stores Kubernetes front proxy X.509 CA certificate, PEM
 	// encoded.
 	FrontProxyCACertificate types.Certificate `json:"frontProxyCACertificate,omitempty"`
+
+	// ExtraCACertificates is a list of X.509 CA certificates, PEM encoded, which will be
+	// added to the trust store of the containers. This allows components to talk to services
+	// using private CAs, like private registries or admission webhooks.
+	//
+	// This field is optional.
+	ExtraCACertificates []types.Certificate `json:"extraCACertificates,omitempty"`
+
+	// CpusetCpus is a list of CPUs, to which controlplane containers will be pinned.
+	//
+	// Example value: '0-1'.
+	//
+	// This field is optional.
+	CpusetCpus string `json:"cpusetCpus,omitempty"`
+
+	// CpusetMems is a list of memory nodes, from which controlplane containers will be
+	// allowed to allocate memory.
+	//
+	// Example value: '0'.
+	//
+	// This field is optional.
+	CpusetMems string `json:"cpusetMems,omitempty"`
+
+	// CgroupParent is a parent cgroup of controlplane containers, which allows to account
+	// their resource usage under dedicated cgroup. When container runtime uses systemd
+	// cgroup driver, it should be a slice name.
+	//
+	// Example value: 'system.slice'.
+	//
+	// This field is optional.
+	CgroupParent string `json:"cgroupParent,omitempty"`
+
+	// ShmSize is a size of /dev/shm of controlplane containers in bytes. If not set, container
+	// runtime default will be used.
+	//
+	// Example value: '268435456'.
+	//
+	// This field is optional.
+	ShmSize int64 `json:"shmSize,omitempty"`
+
+	// RegistryMirrors allows to rewrite registry of all controlplane images, which is useful
+	// in air-gapped environments. Key is a registry prefix to replace and value is a replacement.
+	//
+	// Example value: 'map[string]string{"k8s.gcr.io": "registry.internal"}'.
+	//
+	// This field is optional.
+	RegistryMirrors map[string]string `json:"registryMirrors,omitempty"`
 }
 
-// GetImage returns either image defined in common config or Kubernetes default image.
+// GetImage returns either image defined in common config or Kubernetes default image,
+// with configured registry mirrors applied, in canonical form. Invalid image is returned
+// unchanged, so it gets rejected by the container validation.
 func (co Common) GetImage() string {
-	return util.PickString(co.Image, defaults.KubernetesImage)
+	image := container.RewriteImage(util.PickString(co.Image, defaults.KubernetesImage), co.RegistryMirrors)
+
+	if i, err := containertypes.NormalizeImage(image); err == nil {
+		return i
+	}
+
+	return image
+}
+
+// withExtraCACertificates writes configured extra CA certificates as a bundle to given
+// host path and mounts it into the container's trust store.
+func (co Common) withExtraCACertificates(hcc *container.HostConfiguredContainer, hostPath string) {
+	if len(co.ExtraCACertificates) == 0 {
+		return
+	}
+
+	bundle := []string{}
+
+	for _, c := range co.ExtraCACertificates {
+		bundle = append(bundle, strings.TrimSpace(string(c)))
+	}
+
+	hcc.ConfigFiles[hostPath] = strings.Join(bundle, "\n") + "\n"
+
+	hcc.Container.Config.Mounts = append(hcc.Container.Config.Mounts, containertypes.Mount{
+		Source: hostPath,
+		Target: extraCACertificatesPath,
+	})
 }
 
 // Controlplane allows creating static Kubernetes controlplane running as containers.
@@ -82,6 +183,14 @@ type Controlplane struct {
 	// containers will be removed.
 	Destroy bool `json:"destroy,omitempty"`
 
+	// Suspended controls, if controlplane should be temporarily scaled down to zero. If set to true,
+	// all managed containers will be removed, but unlike with Destroy, configuration is still
+	// validated and configuration files are kept on the hosts. Setting it back to false resumes
+	// the controlplane, by creating containers again from the same configuration.
+	//
+	// This allows turning off ephemeral controlplanes, for example in development environments.
+	Suspended bool `json:"suspended,omitempty"`
+
 	// PKI field allows to use PKI resource for managing all Kubernetes certificates. It will be used for
 	// components configuration, if they don't have certificates defined.
 	PKI *pki.PKI `json:"pki,omitempty"`
@@ -89,11 +198,54 @@ type Controlplane struct {
 	// State stores state of the created containers. After deployment, it is up to the user to export
 	// the state and restore it on consecutive runs.
 	State *container.ContainersState `json:"state,omitempty"`
+
+	// KubernetesVersion is a version of Kubernetes used by configured image. If set, flags and feature
+	// gates of all components will be validated against the list of flags removed in this version.
+	//
+	// Example value: 'v1.18.6'.
+	//
+	// This field is optional.
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+
+	// CertificateExpiryThreshold defines minimum remaining validity time of client certificates
+	// used by controlplane components. If any of them expires sooner, validation fails. Already
+	// expired certificates are always rejected.
+	//
+	// Example value: '720h'.
+	//
+	// This field is optional.
+	CertificateExpiryThreshold string `json:"certificateExpiryThreshold,omitempty"`
+
+	// VerifyEtcdHealth controls, if health of etcd cluster should be verified before creating
+	// or updating kube-apiserver. If etcd cluster has no quorum, deployment is aborted, as
+	// re-created kube-apiserver would not be able to start. Etcd servers and client certificate
+	// configured for kube-apiserver are used, so they must be reachable from the machine running
+	// the deployment.
+	//
+	// This field is optional.
+	VerifyEtcdHealth bool `json:"verifyEtcdHealth,omitempty"`
+
+	// VerifyTimeout defines, how long Verify() waits for each verification check to pass.
+	// Defaults to 1 minute.
+	//
+	// Example value: '5m'.
+	//
+	// This field is optional.
+	VerifyTimeout string `json:"verifyTimeout,omitempty"`
+
+	// Logger is an optional logger, which receives messages logged during deployment. If not
+	// set, messages are written to standard output.
+	//
+	// Due to it's nature, it can only be set programmatically.
+	Logger container.Logger `json:"-"`
 }
 
 // controlplane is executable version of Controlplane, with validated fields and calculated containers.
 type controlplane struct {
 	containers container.ContainersInterface
+
+	// etcdHealthCheck is an optional function called before deploying kube-apiserver changes.
+	etcdHealthCheck func() error
 }
 
 // propagateKubeconfig merges given client config with values stored in Controlplane.
@@ -125,9 +277,10 @@ func (c *Controlplane) propagateHost(h *host.Host) *host.Host {
 	return &nh
 }
 
-// propagateCommon merges given common configuration with values stored in Controlplane.
+// propagateCommon merges given common configuration with values stored in Controlplane and
+// returns it, so components without common configuration also inherit the values.
 // Values in given common configuration has priority over ones from the Controlplane.
-func (c *Controlplane) propagateCommon(co *Common) {
+func (c *Controlplane) propagateCommon(co *Common) *Common {
 	if co == nil {
 		co = &Common{}
 	}
@@ -137,6 +290,18 @@ func (c *Controlplane) propagateCommon(co *Common) {
 	}
 
 	co.Image = util.PickString(co.Image, c.Common.Image)
+	co.CpusetCpus = util.PickString(co.CpusetCpus, c.Common.CpusetCpus)
+	co.CpusetMems = util.PickString(co.CpusetMems, c.Common.CpusetMems)
+	co.CgroupParent = util.PickString(co.CgroupParent, c.Common.CgroupParent)
+	co.RegistryMirrors = util.PickStringMap(co.RegistryMirrors, c.Common.RegistryMirrors)
+
+	if len(co.ExtraCACertificates) == 0 {
+		co.ExtraCACertificates = c.Common.ExtraCACertificates
+	}
+
+	if co.ShmSize == 0 {
+		co.ShmSize = c.Common.ShmSize
+	}
 
 	var pkiCA types.Certificate
 	if c.PKI != nil && c.PKI.Kubernetes != nil && c.PKI.Kubernetes.CA != nil {
@@ -150,6 +315,8 @@ func (c *Controlplane) propagateCommon(co *Common) {
 
 	co.KubernetesCACertificate = co.KubernetesCACertificate.Pick(c.Common.KubernetesCACertificate, pkiCA)
 	co.FrontProxyCACertificate = co.FrontProxyCACertificate.Pick(c.Common.FrontProxyCACertificate, frontProxyCA)
+
+	return co
 }
 
 // buildKubeScheduler fills KubeSheduler struct with all default values.
@@ -158,7 +325,7 @@ func (c *Controlplane) buildKubeScheduler() {
 
 	c.propagateKubeconfig(&k.Kubeconfig)
 
-	c.propagateCommon(k.Common)
+	k.Common = c.propagateCommon(k.Common)
 
 	// TODO: can be moved to function, which takes Kubeconfig and *pki.Certificate as an input
 	if c.PKI != nil && c.PKI.Kubernetes != nil && c.PKI.Kubernetes.KubeSchedulerCertificate != nil {
@@ -175,7 +342,7 @@ func (c *Controlplane) buildKubeControllerManager() {
 
 	c.propagateKubeconfig(&k.Kubeconfig)
 
-	c.propagateCommon(k.Common)
+	k.Common = c.propagateCommon(k.Common)
 
 	if c.PKI != nil && c.PKI.Kubernetes != nil {
 		if c.PKI.Kubernetes.KubeControllerManagerCertificate != nil {
@@ -187,6 +354,11 @@ func (c *Controlplane) buildKubeControllerManager() {
 			k.KubernetesCAKey = k.KubernetesCAKey.Pick(c.PKI.Kubernetes.CA.PrivateKey)
 		}
 
+		if ca := c.PKI.Kubernetes.KubeletServingCA; ca != nil {
+			k.KubeletServingCACertificate = k.KubeletServingCACertificate.Pick(ca.X509Certificate)
+			k.KubeletServingCAKey = k.KubeletServingCAKey.Pick(ca.PrivateKey)
+		}
+
 		if c.PKI.RootCA != nil {
 			k.RootCACertificate = k.RootCACertificate.Pick(c.PKI.RootCA.X509Certificate)
 		}
@@ -232,6 +404,10 @@ func (c *Controlplane) kubeAPIServerPKIIntegration() {
 		k.ServiceAccountPublicKey = util.PickString(k.ServiceAccountPublicKey, p.PublicKey)
 	}
 
+	if ca := c.PKI.Kubernetes.KubeletServingCA; ca != nil {
+		k.KubeletServingCACertificate = k.KubeletServingCACertificate.Pick(ca.X509Certificate)
+	}
+
 	p := c.PKI.Kubernetes.KubeAPIServer
 	if p == nil {
 		return
@@ -269,7 +445,7 @@ func (c *Controlplane) buildKubeAPIServer() {
 		k.SecurePort = c.APIServerPort
 	}
 
-	c.propagateCommon(k.Common)
+	k.Common = c.propagateCommon(k.Common)
 
 	c.kubeAPIServerPKIIntegration()
 
@@ -285,11 +461,34 @@ func (c *Controlplane) New() (types.Resource, error) {
 
 	controlplane, cc, _ := c.containersWithState()
 
-	// If shutdown is requested, don't fill DesiredState to remove everything.
-	if c.Destroy {
+	// If shutdown or suspension is requested, don't fill DesiredState to remove everything.
+	if c.Destroy || c.Suspended {
 		return controlplane, nil
 	}
 
+	cc.DesiredState = c.desiredState()
+
+	co, _ := cc.New()
+
+	controlplane.containers = co
+	controlplane.etcdHealthCheck = c.etcdHealthCheck()
+
+	return controlplane, nil
+}
+
+// etcdHealthCheck returns function verifying health of etcd cluster used by kube-apiserver
+// or nil, if verification is not enabled.
+func (c *Controlplane) etcdHealthCheck() func() error {
+	if !c.VerifyEtcdHealth {
+		return nil
+	}
+
+	return c.KubeAPIServer.checkEtcdHealth
+}
+
+// desiredState returns desired state of all controlplane components. Configuration must be
+// validated before calling it.
+func (c *Controlplane) desiredState() container.ContainersState {
 	// Make sure all values are filled.
 	c.buildComponents()
 
@@ -303,17 +502,11 @@ func (c *Controlplane) New() (types.Resource, error) {
 	ks, _ := c.KubeScheduler.New()
 	ksHcc, _ := ks.ToHostConfiguredContainer()
 
-	cc.DesiredState = container.ContainersState{
+	return container.ContainersState{
 		"kube-apiserver":          kasHcc,
 		"kube-controller-manager": kcmHcc,
 		"kube-scheduler":          ksHcc,
 	}
-
-	co, _ := cc.New()
-
-	controlplane.containers = co
-
-	return controlplane, nil
 }
 
 // buildComponents fills controlplane component structs with default values inherited
@@ -326,14 +519,23 @@ func (c *Controlplane) buildComponents() {
 
 func (c *Controlplane) containersWithState() (*controlplane, *container.Containers, error) {
 	cp := &controlplane{}
-	cc := &container.Containers{}
+	cc := &container.Containers{
+		// Destroying or suspending controlplane removes all containers on purpose.
+		AllowRemoveAll: c.Destroy || c.Suspended,
+		Logger:         c.Logger,
+	}
+
+	empty := c.State == nil || len(*c.State) == 0
 
-	// If state is empty, just return initialized containers config and controlplane.
-	if c.State == nil || len(*c.State) == 0 {
+	// If state is empty, just return initialized containers config and controlplane, unless
+	// controlplane is suspended, as then containers are required for no-op deployment.
+	if empty && !c.Suspended {
 		return cp, cc, nil
 	}
 
-	cc.PreviousState = *c.State
+	if !empty {
+		cc.PreviousState = *c.State
+	}
 
 	ci, err := cc.New()
 	if err != nil {
@@ -403,6 +605,18 @@ func (c *Controlplane) Validate() error {
 		errors = append(errors, fmt.Errorf("failed to verify kube-scheduler configuration: %w", err))
 	}
 
+	if err := c.validateRequiredCertificates(); err != nil {
+		errors = append(errors, fmt.Errorf("missing or invalid certificates: %w", err))
+	}
+
+	if _, err := c.certificateExpiryThreshold(); err != nil {
+		errors = append(errors, err)
+	}
+
+	if _, err := c.verifyTimeout(); err != nil {
+		errors = append(errors, err)
+	}
+
 	// If there were any errors while creating objects, it's not safe to proceed.
 	if len(errors) > 0 {
 		return errors.Return()
@@ -414,6 +628,30 @@ func (c *Controlplane) Validate() error {
 		"kube-scheduler":          ksHcc,
 	}
 
+	if err := c.validateKubernetesVersion(cc.DesiredState); err != nil {
+		errors = append(errors, fmt.Errorf("failed validating flags against Kubernetes version: %w", err))
+	}
+
+	if err := validateSharedFlags(componentsArgs(cc.DesiredState)); err != nil {
+		errors = append(errors, fmt.Errorf("components configuration is inconsistent: %w", err))
+	}
+
+	if err := validateHostPorts(cc.DesiredState); err != nil {
+		errors = append(errors, fmt.Errorf("components bind the same host ports: %w", err))
+	}
+
+	if err := c.validateCertificates(); err != nil {
+		errors = append(errors, fmt.Errorf("certificates are not signed by matching CAs: %w", err))
+	}
+
+	if err := c.validateNetworkCIDRs(); err != nil {
+		errors = append(errors, fmt.Errorf("network CIDRs overlap: %w", err))
+	}
+
+	if err := c.validateCertificatesExpiry(time.Now()); err != nil {
+		errors = append(errors, fmt.Errorf("certificates are expired or about to expire: %w", err))
+	}
+
 	if _, err = cc.New(); err != nil {
 		errors = append(errors, fmt.Errorf("failed to generate containers configuration: %w", err))
 	}
@@ -421,6 +659,179 @@ func (c *Controlplane) Validate() error {
 	return errors.Return()
 }
 
+// validateKubernetesVersion validates arguments of given containers against configured
+// Kubernetes version. If version is not set, validation is skipped.
+func (c *Controlplane) validateKubernetesVersion(cs container.ContainersState) error {
+	if c.KubernetesVersion == "" {
+		return nil
+	}
+
+	minor, err := kubernetesMinorVersion(c.KubernetesVersion)
+	if err != nil {
+		return err
+	}
+
+	var errors util.ValidateError
+
+	for _, n := range components {
+		if err := validateFlags(n, minor, cs[n].Container.Config.Args); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	return errors.Return()
+}
+
+// componentsArgs returns arguments of all controlplane components from given containers
+// state, indexed by component name.
+func componentsArgs(cs container.ContainersState) map[string][]string {
+	args := map[string][]string{}
+
+	for _, n := range components {
+		args[n] = cs[n].Container.Config.Args
+	}
+
+	return args
+}
+
+// isComponent checks, if given name is a name of one of controlplane components.
+func isComponent(name string) bool {
+	for _, n := range components {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RefreshComponent checks the current state of given controlplane component and updates
+// the stored state with it, without touching other components. This allows to reconcile
+// the state after manual changes to a single component without running full deployment.
+func (c *Controlplane) RefreshComponent(name string) error {
+	if !isComponent(name) {
+		return fmt.Errorf("unknown controlplane component %q, expected one of %v", name, components)
+	}
+
+	if c.State == nil {
+		return fmt.Errorf("can't refresh component %q without state", name)
+	}
+
+	if _, ok := (*c.State)[name]; !ok {
+		return fmt.Errorf("component %q does not exist in the state", name)
+	}
+
+	cc := &container.Containers{
+		PreviousState: *c.State,
+	}
+
+	co, err := cc.New()
+	if err != nil {
+		return fmt.Errorf("unable to create containers state: %w", err)
+	}
+
+	if err := co.CheckCurrentStateOf(name); err != nil {
+		return fmt.Errorf("failed checking state of component %q: %w", name, err)
+	}
+
+	s := co.ToExported().PreviousState
+	c.State = &s
+
+	return nil
+}
+
+// DeployComponent deploys only given controlplane component, leaving other components
+// untouched. Entire configuration is still validated, so consistency between the components
+// is checked.
+//
+// Stored state of given component is updated also when deployment fails, so it should be
+// persisted by the caller.
+func (c *Controlplane) DeployComponent(name string) error {
+	if !isComponent(name) {
+		return fmt.Errorf("unknown controlplane component %q, expected one of %v", name, components)
+	}
+
+	if c.Destroy {
+		return fmt.Errorf("deploying single component is not supported when destroying controlplane")
+	}
+
+	if c.Suspended {
+		return fmt.Errorf("deploying single component is not supported when controlplane is suspended")
+	}
+
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("failed to validate controlplane configuration: %w", err)
+	}
+
+	cc := &container.Containers{
+		PreviousState: container.ContainersState{},
+		DesiredState: container.ContainersState{
+			name: c.desiredState()[name],
+		},
+		Logger: c.Logger,
+	}
+
+	if c.State != nil {
+		if hcc, ok := (*c.State)[name]; ok {
+			cc.PreviousState[name] = hcc
+		}
+	}
+
+	co, err := cc.New()
+	if err != nil {
+		return fmt.Errorf("unable to create containers for component %q: %w", name, err)
+	}
+
+	if err := co.CheckCurrentState(); err != nil {
+		return fmt.Errorf("failed checking state of component %q: %w", name, err)
+	}
+
+	// Use the same deployment logic as for the entire controlplane, so kube-apiserver is
+	// also deployed only when etcd is healthy.
+	cp := &controlplane{
+		containers:      co,
+		etcdHealthCheck: c.etcdHealthCheck(),
+	}
+
+	deployErr := cp.Deploy()
+
+	// Update state even if deployment failed, so information about created container is not lost.
+	c.setComponentState(name, co.ToExported().PreviousState)
+
+	if deployErr != nil {
+		return fmt.Errorf("failed deploying component %q: %w", name, deployErr)
+	}
+
+	return nil
+}
+
+// setComponentState replaces state of given component with the state from given containers
+// state, keeping state of other components untouched.
+func (c *Controlplane) setComponentState(name string, cs container.ContainersState) {
+	s := container.ContainersState{}
+
+	if c.State != nil {
+		for n, hcc := range *c.State {
+			s[n] = hcc
+		}
+	}
+
+	delete(s, name)
+
+	if hcc, ok := cs[name]; ok {
+		s[name] = hcc
+	}
+
+	c.State = &s
+}
+
+// DeepCopy returns a copy of Controlplane configuration, which does not share any maps
+// or slices with the original struct, so it can be safely modified. Functions and values
+// stored in interfaces are shared.
+func (c *Controlplane) DeepCopy() *Controlplane {
+	return util.DeepCopy(c).(*Controlplane)
+}
+
 // FromYaml allows to restore controlplane configuration and state from YAML format.
 func FromYaml(c []byte) (types.Resource, error) {
 	return types.ResourceFromYaml(c, &Controlplane{})
@@ -436,10 +847,37 @@ func (c *controlplane) CheckCurrentState() error {
 }
 
 // Deploy checks the status of the control plane and deploys configuration updates.
+//
+// If etcd health check is configured and kube-apiserver is about to be created or updated,
+// deployment is aborted when etcd cluster is not healthy.
 func (c *controlplane) Deploy() error {
+	if err := c.verifyEtcdHealth(); err != nil {
+		return err
+	}
+
 	return c.containers.Deploy()
 }
 
+// verifyEtcdHealth runs etcd health check, if it's configured and kube-apiserver has pending
+// changes.
+func (c *controlplane) verifyEtcdHealth() error {
+	if c.etcdHealthCheck == nil {
+		return nil
+	}
+
+	e := c.containers.ToExported()
+
+	if !kubeAPIServerChanged(e.DesiredState, e.PreviousState) {
+		return nil
+	}
+
+	if err := c.etcdHealthCheck(); err != nil {
+		return fmt.Errorf("refusing to deploy kube-apiserver changes: %w", err)
+	}
+
+	return nil
+}
+
 // Containers implement types.Resource interface.
 func (c *controlplane) Containers() container.ContainersInterface {
 	return c.containers
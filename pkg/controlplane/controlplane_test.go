@@ -8,6 +8,7 @@ import (
 
 	"github.com/flexkube/libflexkube/internal/util"
 	"github.com/flexkube/libflexkube/internal/utiltest"
+	"github.com/flexkube/libflexkube/pkg/container"
 	"github.com/flexkube/libflexkube/pkg/pki"
 )
 
@@ -81,8 +82,8 @@ func controlplaneYAML(t *testing.T) string {
 		CertificateDeep string
 		PrivateKeyDeep  string
 	}{
-		strings.TrimSpace(util.Indent(utiltest.GenerateX509Certificate(t), "    ")),
-		strings.TrimSpace(util.Indent(utiltest.GenerateRSAPrivateKey(t), "    ")),
+		strings.TrimSpace(util.Indent(pki.Certificate, "    ")),
+		strings.TrimSpace(util.Indent(pki.PrivateKey, "    ")),
 		strings.TrimSpace(util.Indent(pki.Certificate, "      ")),
 		strings.TrimSpace(util.Indent(pki.PrivateKey, "      ")),
 	}
@@ -120,6 +121,18 @@ func TestControlplaneFromYaml(t *testing.T) {
 	}
 }
 
+func TestControlplaneValidateKubernetesVersion(t *testing.T) {
+	y := controlplaneYAML(t)
+
+	if _, err := FromYaml([]byte(y + "kubernetesVersion: v1.18.6\n")); err != nil {
+		t.Fatalf("Controlplane with supported Kubernetes version should be valid, got: %v", err)
+	}
+
+	if _, err := FromYaml([]byte(y + "kubernetesVersion: v1.24.0\n")); err == nil {
+		t.Fatalf("Controlplane using flags removed in configured Kubernetes version should fail validation")
+	}
+}
+
 // GetImage() tests.
 func TestCommonGetImage(t *testing.T) {
 	c := Common{}
@@ -134,8 +147,108 @@ func TestCommonGetImageSpecified(t *testing.T) {
 		Image: i,
 	}
 
-	if a := c.GetImage(); a != i {
-		t.Fatalf("GetImage() should return specified image, if it's defined")
+	if a := c.GetImage(); a != "docker.io/library/foo:latest" {
+		t.Fatalf("GetImage() should return specified image in canonical form, if it's defined, got %q", a)
+	}
+}
+
+// propagateCommon() tests.
+func TestControlplanePropagateCommonCpuset(t *testing.T) {
+	c := &Controlplane{
+		Common: &Common{
+			CpusetCpus: "0-1",
+			CpusetMems: "0",
+		},
+	}
+
+	co := &Common{
+		CpusetCpus: "2",
+	}
+
+	c.propagateCommon(co)
+
+	if co.CpusetCpus != "2" {
+		t.Fatalf("CPU set defined for component should not be overridden, got %q", co.CpusetCpus)
+	}
+
+	if co.CpusetMems != "0" {
+		t.Fatalf("memory nodes set should be inherited from controlplane, got %q", co.CpusetMems)
+	}
+}
+
+func TestControlplanePropagateCommonCgroupParent(t *testing.T) {
+	c := &Controlplane{
+		Common: &Common{
+			CgroupParent: "system.slice",
+		},
+	}
+
+	co := c.propagateCommon(nil)
+
+	if co.CgroupParent != "system.slice" {
+		t.Fatalf("cgroup parent should be inherited from controlplane, got %q", co.CgroupParent)
+	}
+}
+
+func TestControlplanePropagateCommonShmSize(t *testing.T) {
+	c := &Controlplane{
+		Common: &Common{
+			ShmSize: 268435456,
+		},
+	}
+
+	co := c.propagateCommon(&Common{})
+
+	if co.ShmSize != 268435456 {
+		t.Fatalf("shm size should be inherited from controlplane, got %d", co.ShmSize)
+	}
+
+	co = c.propagateCommon(&Common{
+		ShmSize: 1024,
+	})
+
+	if co.ShmSize != 1024 {
+		t.Fatalf("shm size of the component should take precedence, got %d", co.ShmSize)
+	}
+}
+
+func TestControlplaneComponentImage(t *testing.T) {
+	c := &Controlplane{
+		Common: &Common{
+			Image: "k8s.gcr.io/hyperkube:v1.18.3",
+		},
+		KubeScheduler: KubeScheduler{
+			Common: &Common{
+				Image: "k8s.gcr.io/kube-scheduler:v1.19.0",
+			},
+		},
+	}
+
+	c.buildComponents()
+
+	if a, e := c.KubeScheduler.Common.GetImage(), "k8s.gcr.io/kube-scheduler:v1.19.0"; a != e {
+		t.Fatalf("image defined for component should take precedence, expected %q, got %q", e, a)
+	}
+
+	if a, e := c.KubeControllerManager.Common.GetImage(), "k8s.gcr.io/hyperkube:v1.18.3"; a != e {
+		t.Fatalf("component without image should inherit image from controlplane, expected %q, got %q", e, a)
+	}
+
+	if a, e := c.KubeAPIServer.Common.GetImage(), "k8s.gcr.io/hyperkube:v1.18.3"; a != e {
+		t.Fatalf("component without image should inherit image from controlplane, expected %q, got %q", e, a)
+	}
+}
+
+func TestCommonGetImageRegistryMirrors(t *testing.T) {
+	c := Common{
+		Image: "k8s.gcr.io/hyperkube:v1.18.3",
+		RegistryMirrors: map[string]string{
+			"k8s.gcr.io": "registry.internal",
+		},
+	}
+
+	if a, e := c.GetImage(), "registry.internal/hyperkube:v1.18.3"; a != e {
+		t.Fatalf("GetImage() should apply registry mirrors, expected %q, got %q", e, a)
 	}
 }
 
@@ -194,6 +307,65 @@ state:
 	}
 }
 
+func TestControlplaneSuspended(t *testing.T) {
+	y := controlplaneYAML(t)
+
+	y += `suspended: true
+state:
+  kube-scheduler:
+    host:
+      direct: {}
+    container:
+      runtime:
+        docker:
+          host: unix:///nonexistent
+      config:
+        name: kube-scheduler
+        image: busybox
+      status:
+        id: foo
+        status: running
+`
+
+	co, err := FromYaml([]byte(y))
+	if err != nil {
+		t.Fatalf("creating suspended controlplane should succeed, got: %v", err)
+	}
+
+	if ds := co.Containers().DesiredState(); len(ds) != 0 {
+		t.Fatalf("suspended controlplane should have no desired containers, got: %v", ds)
+	}
+}
+
+func TestControlplaneSuspendedNoState(t *testing.T) {
+	y := controlplaneYAML(t)
+
+	y += `suspended: true`
+
+	co, err := FromYaml([]byte(y))
+	if err != nil {
+		t.Fatalf("creating suspended controlplane without state should succeed, got: %v", err)
+	}
+
+	if err := co.CheckCurrentState(); err != nil {
+		t.Fatalf("checking current state of suspended controlplane should succeed, got: %v", err)
+	}
+
+	if err := co.Deploy(); err != nil {
+		t.Fatalf("deploying already suspended controlplane should be no-op, got: %v", err)
+	}
+}
+
+func TestControlplaneSuspendedValidate(t *testing.T) {
+	c := &Controlplane{
+		Suspended: true,
+	}
+
+	if _, err := c.New(); err == nil {
+		t.Fatalf("configuration of suspended controlplane should still be validated")
+	}
+}
+
 func TestControlplaneNewPKIIntegration(t *testing.T) {
 	pki := &pki.PKI{
 		Etcd: &pki.Etcd{
@@ -219,3 +391,142 @@ func TestControlplaneNewPKIIntegration(t *testing.T) {
 		t.Fatalf("creating new controlplane with valid PKI should succeed, got: %v", err)
 	}
 }
+
+func TestControlplaneNewPKIKubeletServingCA(t *testing.T) {
+	pki := &pki.PKI{
+		Etcd: &pki.Etcd{
+			ClientCNs: []string{"kube-apiserver", "root"},
+		},
+		Kubernetes: &pki.Kubernetes{
+			KubeletServingCA: &pki.Certificate{},
+		},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("generating PKI should succeed, got: %v", err)
+	}
+
+	c := &Controlplane{
+		PKI:              pki,
+		APIServerAddress: "127.0.0.1",
+		APIServerPort:    6443,
+		KubeAPIServer: KubeAPIServer{
+			EtcdServers: []string{"https://127.0.0.1:2379"},
+		},
+	}
+
+	if _, err := c.New(); err != nil {
+		t.Fatalf("creating new controlplane with valid PKI should succeed, got: %v", err)
+	}
+
+	ds := c.desiredState()
+
+	kasArgs := strings.Join(ds["kube-apiserver"].Container.Config.Args, " ")
+	if e := "--kubelet-certificate-authority=/etc/kubernetes/pki/kubelet-serving-ca.crt"; !strings.Contains(kasArgs, e) {
+		t.Fatalf("kube-apiserver should verify kubelets using kubelet serving CA, expected %q in %q", e, kasArgs)
+	}
+
+	kcmArgs := strings.Join(ds["kube-controller-manager"].Container.Config.Args, " ")
+	if e := "--cluster-signing-kubelet-serving-cert-file="; !strings.Contains(kcmArgs, e) {
+		t.Fatalf("kube-controller-manager should sign kubelet serving certificates using dedicated CA, expected %q in %q", e, kcmArgs)
+	}
+}
+
+// RefreshComponent() tests.
+func TestControlplaneRefreshComponentUnknown(t *testing.T) {
+	c := &Controlplane{}
+
+	if err := c.RefreshComponent("foo"); err == nil {
+		t.Fatalf("refreshing unknown component should fail")
+	}
+}
+
+func TestControlplaneRefreshComponentNotInState(t *testing.T) {
+	c := &Controlplane{
+		State: &container.ContainersState{},
+	}
+
+	if err := c.RefreshComponent("kube-scheduler"); err == nil {
+		t.Fatalf("refreshing component, which does not exist in the state should fail")
+	}
+}
+
+// DeployComponent() tests.
+func TestControlplaneDeployComponentUnknown(t *testing.T) {
+	c := &Controlplane{}
+
+	if err := c.DeployComponent("foo"); err == nil {
+		t.Fatalf("deploying unknown component should fail")
+	}
+}
+
+func TestControlplaneDeployComponentValidate(t *testing.T) {
+	c := &Controlplane{}
+
+	if err := c.DeployComponent("kube-scheduler"); err == nil {
+		t.Fatalf("deploying component with invalid controlplane configuration should fail")
+	}
+
+	if c.State != nil {
+		t.Fatalf("state should not be modified when validation fails")
+	}
+}
+
+// setComponentState() tests.
+func TestControlplaneSetComponentState(t *testing.T) {
+	kas := &container.HostConfiguredContainer{}
+	ks := &container.HostConfiguredContainer{}
+	newKs := &container.HostConfiguredContainer{}
+
+	c := &Controlplane{
+		State: &container.ContainersState{
+			"kube-apiserver": kas,
+			"kube-scheduler": ks,
+		},
+	}
+
+	c.setComponentState("kube-scheduler", container.ContainersState{"kube-scheduler": newKs})
+
+	if (*c.State)["kube-apiserver"] != kas {
+		t.Fatalf("state of other components should not be modified")
+	}
+
+	if (*c.State)["kube-scheduler"] != newKs {
+		t.Fatalf("state of given component should be updated")
+	}
+
+	c.setComponentState("kube-scheduler", container.ContainersState{})
+
+	if _, ok := (*c.State)["kube-scheduler"]; ok {
+		t.Fatalf("removed component should be removed from the state")
+	}
+}
+
+// DeepCopy() tests.
+func TestControlplaneDeepCopy(t *testing.T) {
+	c := &Controlplane{
+		KubeAPIServer: KubeAPIServer{
+			ServiceCIDR: "11.0.0.0/24",
+		},
+		State: &container.ContainersState{
+			"kube-scheduler": &container.HostConfiguredContainer{
+				ConfigFiles: map[string]string{
+					"/foo": "foo",
+				},
+			},
+		},
+	}
+
+	n := c.DeepCopy()
+
+	n.KubeAPIServer.ServiceCIDR = "12.0.0.0/24"
+	(*n.State)["kube-scheduler"].ConfigFiles["/foo"] = "bar"
+
+	if c.KubeAPIServer.ServiceCIDR != "11.0.0.0/24" {
+		t.Fatalf("modifying copy should not modify the original")
+	}
+
+	if (*c.State)["kube-scheduler"].ConfigFiles["/foo"] != "foo" {
+		t.Fatalf("modifying state in the copy should not modify the original")
+	}
+}
@@ -0,0 +1,207 @@
+package controlplane
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/flexkube/libflexkube/internal/util"
+	"github.com/flexkube/libflexkube/pkg/kubernetes/client"
+)
+
+// EncryptionKey represents a key used by kube-apiserver to encrypt secrets stored in etcd
+// using AES-CBC.
+type EncryptionKey struct {
+	// Name is a unique name of the key. It is stored next to encrypted data, so kube-apiserver
+	// knows, which key should be used for decryption.
+	Name string `json:"name"`
+
+	// Secret is a base64 encoded, random 16, 24 or 32 bytes long key.
+	//
+	// Example value generated using 'head -c 32 /dev/urandom | base64'.
+	Secret string `json:"secret"`
+}
+
+// Validate validates encryption key.
+func (e EncryptionKey) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("name must be set")
+	}
+
+	s, err := base64.StdEncoding.DecodeString(e.Secret)
+	if err != nil {
+		return fmt.Errorf("secret of key %q is not valid base64: %w", e.Name, err)
+	}
+
+	if l := len(s); l != 16 && l != 24 && l != 32 {
+		return fmt.Errorf("secret of key %q must be 16, 24 or 32 bytes long, got %d", e.Name, l)
+	}
+
+	return nil
+}
+
+// validateEncryptionKeys validates given list of encryption keys and ensures that
+// their names are unique.
+func validateEncryptionKeys(keys []EncryptionKey) error {
+	var errors util.ValidateError
+
+	names := map[string]struct{}{}
+
+	for i, k := range keys {
+		if err := k.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("encryption key %d is not valid: %w", i, err))
+		}
+
+		if _, ok := names[k.Name]; ok {
+			errors = append(errors, fmt.Errorf("encryption key name %q is used more than once", k.Name))
+		}
+
+		names[k.Name] = struct{}{}
+	}
+
+	return errors.Return()
+}
+
+// encryptionConfiguration is a minimal representation of kube-apiserver EncryptionConfiguration.
+type encryptionConfiguration struct {
+	APIVersion string                      `json:"apiVersion"`
+	Kind       string                      `json:"kind"`
+	Resources  []encryptionResourcesConfig `json:"resources"`
+}
+
+type encryptionResourcesConfig struct {
+	Resources []string                   `json:"resources"`
+	Providers []encryptionProviderConfig `json:"providers"`
+}
+
+type encryptionProviderConfig struct {
+	AESCBC   *encryptionAESConfig `json:"aescbc,omitempty"`
+	Identity *struct{}            `json:"identity,omitempty"`
+}
+
+type encryptionAESConfig struct {
+	Keys []EncryptionKey `json:"keys"`
+}
+
+// encryptionConfig renders EncryptionConfiguration for given keys. First key is used
+// for encryption, all keys are used for decryption. Identity provider is always added as
+// a last one, so data written before enabling the encryption can still be read.
+func encryptionConfig(keys []EncryptionKey) (string, error) {
+	c := encryptionConfiguration{
+		APIVersion: "apiserver.config.k8s.io/v1",
+		Kind:       "EncryptionConfiguration",
+		Resources: []encryptionResourcesConfig{
+			{
+				Resources: []string{"secrets"},
+				Providers: []encryptionProviderConfig{
+					{
+						AESCBC: &encryptionAESConfig{
+							Keys: keys,
+						},
+					},
+					{
+						Identity: &struct{}{},
+					},
+				},
+			},
+		},
+	}
+
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize encryption configuration: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// encryptionConfigFile returns name of the file, which should be used for given encryption
+// configuration. kube-apiserver only reads encryption configuration on start, so file name
+// includes the checksum of the content, which changes kube-apiserver flags and triggers the
+// container recreation when the keys change.
+func encryptionConfigFile(config string) string {
+	sum := sha256.Sum256([]byte(config))
+
+	return fmt.Sprintf("encryption-config-%x.yaml", sum[:6])
+}
+
+// RotateEncryptionKey replaces configured kube-apiserver encryption keys with given key
+// without making stored secrets unreadable. Rotation is done in the following steps, each
+// deploying the controlplane:
+//
+// - new key is added as a last key, so kube-apiserver is able to decrypt data encrypted with it,
+// while still encrypting with the old key.
+//
+// - new key is moved to the first position, so all new writes are encrypted using it.
+//
+// - if Kubernetes client is given, all secrets are re-written, so they get encrypted with the new key.
+//
+// - old keys are removed.
+//
+// If no keys were configured before, only the new key is deployed and secrets are re-written.
+//
+// If client is given, availability of Kubernetes API is awaited after each deployment.
+//
+// Controlplane state is updated after each step, so it should be persisted by the caller
+// also when rotation fails.
+func (c *Controlplane) RotateEncryptionKey(key EncryptionKey, kc client.Client) error {
+	if err := key.Validate(); err != nil {
+		return fmt.Errorf("new encryption key is not valid: %w", err)
+	}
+
+	old := c.KubeAPIServer.EncryptionKeys
+
+	for _, k := range old {
+		if k.Name == key.Name {
+			return fmt.Errorf("encryption key named %q is already configured", key.Name)
+		}
+	}
+
+	if len(old) > 0 {
+		if err := c.deployEncryptionKeys(append(append([]EncryptionKey{}, old...), key), kc); err != nil {
+			return fmt.Errorf("failed adding new encryption key: %w", err)
+		}
+	}
+
+	if err := c.deployEncryptionKeys(append([]EncryptionKey{key}, old...), kc); err != nil {
+		return fmt.Errorf("failed switching to new encryption key: %w", err)
+	}
+
+	if kc != nil {
+		if err := kc.ReencryptSecrets(); err != nil {
+			return fmt.Errorf("failed re-encrypting secrets: %w", err)
+		}
+	}
+
+	if len(old) == 0 {
+		return nil
+	}
+
+	if err := c.deployEncryptionKeys([]EncryptionKey{key}, kc); err != nil {
+		return fmt.Errorf("failed removing old encryption keys: %w", err)
+	}
+
+	return nil
+}
+
+// deployEncryptionKeys deploys controlplane with given set of encryption keys and updates
+// the state.
+func (c *Controlplane) deployEncryptionKeys(keys []EncryptionKey, kc client.Client) error {
+	c.KubeAPIServer.EncryptionKeys = keys
+
+	if err := c.deploy(); err != nil {
+		return err
+	}
+
+	if kc == nil {
+		return nil
+	}
+
+	if err := kc.PingWait(); err != nil {
+		return fmt.Errorf("failed waiting for Kubernetes API: %w", err)
+	}
+
+	return nil
+}
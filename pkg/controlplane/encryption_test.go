@@ -0,0 +1,113 @@
+package controlplane
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	// testEncryptionSecret is a base64 encoded 32 bytes key used for testing.
+	testEncryptionSecret = "MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE="
+
+	// otherTestEncryptionSecret is a base64 encoded 16 bytes key used for testing.
+	otherTestEncryptionSecret = "MDEyMzQ1Njc4OTAxMjM0NQ=="
+)
+
+// Validate() tests.
+func TestEncryptionKeyValidate(t *testing.T) {
+	cases := map[string]struct {
+		Key   EncryptionKey
+		Error bool
+	}{
+		"valid": {
+			Key:   EncryptionKey{Name: "foo", Secret: testEncryptionSecret},
+			Error: false,
+		},
+		"require name": {
+			Key:   EncryptionKey{Secret: testEncryptionSecret},
+			Error: true,
+		},
+		"require base64 secret": {
+			Key:   EncryptionKey{Name: "foo", Secret: "!!!"},
+			Error: true,
+		},
+		"require valid secret length": {
+			Key:   EncryptionKey{Name: "foo", Secret: "Zm9v"},
+			Error: true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			err := c.Key.Validate()
+			if !c.Error && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if c.Error && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestValidateEncryptionKeysDuplicatedName(t *testing.T) {
+	keys := []EncryptionKey{
+		{Name: "foo", Secret: testEncryptionSecret},
+		{Name: "foo", Secret: otherTestEncryptionSecret},
+	}
+
+	if err := validateEncryptionKeys(keys); err == nil {
+		t.Fatalf("duplicated key names should be rejected")
+	}
+}
+
+// encryptionConfig() tests.
+func TestEncryptionConfigKeysOrder(t *testing.T) {
+	c, err := encryptionConfig([]EncryptionKey{
+		{Name: "new", Secret: testEncryptionSecret},
+		{Name: "old", Secret: otherTestEncryptionSecret},
+	})
+	if err != nil {
+		t.Fatalf("rendering encryption config should succeed, got: %v", err)
+	}
+
+	if n, o := strings.Index(c, "name: new"), strings.Index(c, "name: old"); n == -1 || o == -1 || n > o {
+		t.Fatalf("keys should be rendered in the given order, got:\n%s", c)
+	}
+
+	if !strings.Contains(c, "identity: {}") {
+		t.Fatalf("identity provider should be added, so unencrypted data can be read, got:\n%s", c)
+	}
+}
+
+func TestEncryptionConfigFileChangesWithContent(t *testing.T) {
+	if encryptionConfigFile("foo") == encryptionConfigFile("bar") {
+		t.Fatalf("file name should change when configuration changes, to trigger kube-apiserver restart")
+	}
+}
+
+// RotateEncryptionKey() tests.
+func TestControlplaneRotateEncryptionKeyInvalidKey(t *testing.T) {
+	c := &Controlplane{}
+
+	if err := c.RotateEncryptionKey(EncryptionKey{Name: "foo"}, nil); err == nil {
+		t.Fatalf("rotating to invalid key should fail")
+	}
+}
+
+func TestControlplaneRotateEncryptionKeyAlreadyConfigured(t *testing.T) {
+	k := EncryptionKey{Name: "foo", Secret: testEncryptionSecret}
+
+	c := &Controlplane{
+		KubeAPIServer: KubeAPIServer{
+			EncryptionKeys: []EncryptionKey{k},
+		},
+	}
+
+	if err := c.RotateEncryptionKey(k, nil); err == nil {
+		t.Fatalf("rotating to already configured key should fail")
+	}
+}
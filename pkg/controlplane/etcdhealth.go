@@ -0,0 +1,117 @@
+package controlplane
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"go.etcd.io/etcd/clientv3"
+
+	"github.com/flexkube/libflexkube/pkg/container"
+)
+
+const (
+	// etcdHealthCheckTimeout is a maximum time of waiting for etcd cluster to respond
+	// when checking it's health.
+	etcdHealthCheckTimeout = 10 * time.Second
+
+	// etcdHealthCheckKey is a key read from etcd to verify, that cluster has a quorum.
+	etcdHealthCheckKey = "health"
+)
+
+// etcdGetter represents capability of reading keys from etcd. It is implemented by
+// etcd client.
+type etcdGetter interface {
+	Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error)
+}
+
+// checkEtcdQuorum verifies, that etcd cluster has a quorum, by performing a linearizable read,
+// which succeeds only if majority of the cluster members agree on it.
+func checkEtcdQuorum(cli etcdGetter) error {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdHealthCheckTimeout)
+	defer cancel()
+
+	if _, err := cli.Get(ctx, etcdHealthCheckKey); err != nil {
+		return fmt.Errorf("linearizable read failed: %w", err)
+	}
+
+	return nil
+}
+
+// etcdClient creates etcd client using etcd servers and client certificate configured for
+// kube-apiserver.
+func (k *KubeAPIServer) etcdClient() (*clientv3.Client, error) {
+	cert, err := tls.X509KeyPair([]byte(k.EtcdClientCertificate), []byte(k.EtcdClientKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed loading etcd client certificate: %w", err)
+	}
+
+	ca, err := parseCertificate(k.EtcdCACertificate)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing etcd CA certificate: %w", err)
+	}
+
+	p := x509.NewCertPool()
+	p.AddCert(ca)
+
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   k.EtcdServers,
+		DialTimeout: etcdHealthCheckTimeout,
+		TLS: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			RootCAs:      p,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed creating etcd client: %w", err)
+	}
+
+	return cli, nil
+}
+
+// checkEtcdHealth verifies, that etcd cluster used by kube-apiserver has a quorum. Etcd servers
+// must be reachable from the machine running the deployment.
+func (k *KubeAPIServer) checkEtcdHealth() error {
+	cli, err := k.etcdClient()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	if err := checkEtcdQuorum(cli); err != nil {
+		return fmt.Errorf("etcd cluster at %s is not healthy: %w", strings.Join(k.EtcdServers, ", "), err)
+	}
+
+	return nil
+}
+
+// kubeAPIServerChanged checks, if deploying given desired state would create, start or modify
+// kube-apiserver container in given current state. Container, which is not known to be running,
+// is considered changed, as deployment will start or recreate it.
+func kubeAPIServerChanged(desired, current container.ContainersState) bool {
+	d, ok := desired[containerName]
+	if !ok {
+		return false
+	}
+
+	c, ok := current[containerName]
+	if !ok || c.Container.Status == nil || !c.Container.Status.Running() {
+		return true
+	}
+
+	// State may be deserialized, so treat nil and empty values the same way.
+	opt := cmpopts.EquateEmpty()
+
+	return !cmp.Equal(d.Container.Config, c.Container.Config, opt) ||
+		!cmp.Equal(d.Container.Runtime, c.Container.Runtime, opt) ||
+		!cmp.Equal(d.Host, c.Host, opt) ||
+		!container.SameConfigFiles(d.ConfigFiles, c.ConfigFiles)
+}
@@ -0,0 +1,170 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.etcd.io/etcd/clientv3"
+
+	"github.com/flexkube/libflexkube/pkg/container"
+	containertypes "github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+type fakeEtcdGetter struct {
+	err error
+}
+
+func (f *fakeEtcdGetter) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	return &clientv3.GetResponse{}, f.err
+}
+
+// checkEtcdQuorum() tests.
+func TestCheckEtcdQuorum(t *testing.T) {
+	if err := checkEtcdQuorum(&fakeEtcdGetter{}); err != nil {
+		t.Fatalf("Checking quorum should succeed, got: %v", err)
+	}
+}
+
+func TestCheckEtcdQuorumFail(t *testing.T) {
+	if err := checkEtcdQuorum(&fakeEtcdGetter{err: fmt.Errorf("context deadline exceeded")}); err == nil {
+		t.Fatalf("Checking quorum should fail, when read fails")
+	}
+}
+
+// checkEtcdHealth() tests.
+func TestCheckEtcdHealthBadCertificate(t *testing.T) {
+	k := &KubeAPIServer{
+		EtcdServers: []string{"https://127.0.0.1:2379"},
+	}
+
+	if err := k.checkEtcdHealth(); err == nil {
+		t.Fatalf("Checking etcd health should fail without client certificate")
+	}
+}
+
+// kubeAPIServerChanged() tests.
+func etcdHealthTestState(image string) container.ContainersState {
+	return container.ContainersState{
+		containerName: {
+			Container: container.Container{
+				Config: containertypes.ContainerConfig{
+					Image: image,
+				},
+				Status: &containertypes.ContainerStatus{
+					ID:     "foo",
+					Status: "running",
+				},
+			},
+			ConfigFiles: map[string]string{
+				"/etc/foo": "foo",
+			},
+		},
+	}
+}
+
+func TestKubeAPIServerChanged(t *testing.T) {
+	cases := map[string]struct {
+		desired container.ContainersState
+		current container.ContainersState
+		changed bool
+	}{
+		"not desired": {
+			desired: container.ContainersState{},
+			current: etcdHealthTestState("foo"),
+		},
+		"new": {
+			desired: etcdHealthTestState("foo"),
+			current: container.ContainersState{},
+			changed: true,
+		},
+		"unchanged": {
+			desired: etcdHealthTestState("foo"),
+			current: etcdHealthTestState("foo"),
+		},
+		"updated": {
+			desired: etcdHealthTestState("bar"),
+			current: etcdHealthTestState("foo"),
+			changed: true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			if changed := kubeAPIServerChanged(c.desired, c.current); changed != c.changed {
+				t.Fatalf("Expected changed to be %t, got %t", c.changed, changed)
+			}
+		})
+	}
+}
+
+func TestKubeAPIServerChangedEmptyConfigFiles(t *testing.T) {
+	desired := etcdHealthTestState("foo")
+	desired[containerName].ConfigFiles = map[string]string{}
+
+	current := etcdHealthTestState("foo")
+	current[containerName].ConfigFiles = nil
+
+	if kubeAPIServerChanged(desired, current) {
+		t.Fatalf("Nil and empty configuration files should be treated as equal")
+	}
+}
+
+func TestKubeAPIServerChangedNoStatus(t *testing.T) {
+	current := etcdHealthTestState("foo")
+	current[containerName].Container.Status = nil
+
+	if !kubeAPIServerChanged(etcdHealthTestState("foo"), current) {
+		t.Fatalf("Container without status should be considered changed")
+	}
+}
+
+func TestKubeAPIServerChangedNotRunning(t *testing.T) {
+	current := etcdHealthTestState("foo")
+	current[containerName].Container.Status.Status = "exited"
+
+	if !kubeAPIServerChanged(etcdHealthTestState("foo"), current) {
+		t.Fatalf("Container, which is not running should be considered changed")
+	}
+}
+
+func TestKubeAPIServerChangedConfigFileReference(t *testing.T) {
+	current := etcdHealthTestState("foo")
+	current[containerName].ConfigFiles["/etc/foo"] = container.ConfigFileReference("foo")
+
+	if kubeAPIServerChanged(etcdHealthTestState("foo"), current) {
+		t.Fatalf("Configuration file reference with the same content should not be considered changed")
+	}
+
+	current[containerName].ConfigFiles["/etc/foo"] = container.ConfigFileReference("bar")
+
+	if !kubeAPIServerChanged(etcdHealthTestState("foo"), current) {
+		t.Fatalf("Configuration file reference with different content should be considered changed")
+	}
+}
+
+// etcdHealthCheck() tests.
+func TestControlplaneEtcdHealthCheck(t *testing.T) {
+	c := &Controlplane{}
+
+	if c.etcdHealthCheck() != nil {
+		t.Fatalf("Health check should not be configured by default")
+	}
+
+	c.VerifyEtcdHealth = true
+
+	if c.etcdHealthCheck() == nil {
+		t.Fatalf("Health check should be configured, when etcd health verification is enabled")
+	}
+}
+
+// verifyEtcdHealth() tests.
+func TestVerifyEtcdHealthNotConfigured(t *testing.T) {
+	c := &controlplane{}
+
+	if err := c.verifyEtcdHealth(); err != nil {
+		t.Fatalf("Verification should be skipped, when health check is not configured, got: %v", err)
+	}
+}
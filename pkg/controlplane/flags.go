@@ -0,0 +1,132 @@
+package controlplane
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/flexkube/libflexkube/internal/util"
+)
+
+// removedFlags is a curated list of the most common flags of controlplane components,
+// with Kubernetes minor version, where given flag has been removed.
+var removedFlags = map[string]map[string]int{
+	"kube-apiserver": {
+		"--basic-auth-file":       19,
+		"--kubelet-https":         22,
+		"--insecure-bind-address": 24,
+		"--insecure-port":         24,
+	},
+	"kube-controller-manager": {
+		"--address": 24,
+		"--port":    24,
+		"--experimental-cluster-signing-duration": 25,
+	},
+	"kube-scheduler": {
+		"--address": 23,
+		"--port":    23,
+	},
+}
+
+// removedFeatureGates is a curated list of feature gates, with Kubernetes minor version,
+// where given feature gate has been removed.
+var removedFeatureGates = map[string]int{
+	"TTLAfterFinished":     25,
+	"DynamicKubeletConfig": 26,
+}
+
+// sharedFlags is a list of flags, which may be set on multiple controlplane components and
+// must have the same value on all of them, otherwise cluster networking breaks.
+var sharedFlags = []string{
+	"--service-cluster-ip-range",
+	"--cluster-cidr",
+}
+
+// kubernetesMinorVersion parses given Kubernetes version, e.g. 'v1.18.6' and returns
+// minor version from it.
+func kubernetesMinorVersion(v string) (int, error) {
+	p := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(p) < 2 || p[0] != "1" {
+		return 0, fmt.Errorf("unsupported Kubernetes version %q, expected format 'v1.<minor>[.<patch>]'", v)
+	}
+
+	minor, err := strconv.Atoi(p[1])
+	if err != nil {
+		return 0, fmt.Errorf("failed parsing minor version from %q: %w", v, err)
+	}
+
+	return minor, nil
+}
+
+// validateFlags checks, if given component arguments are supported by given Kubernetes minor
+// version, using curated list of removed flags and feature gates.
+func validateFlags(component string, minor int, args []string) error {
+	var errors util.ValidateError
+
+	for _, a := range args {
+		kv := strings.SplitN(a, "=", 2)
+
+		if v, ok := removedFlags[component][kv[0]]; ok && minor >= v {
+			errors = append(errors, fmt.Errorf("%s: flag %q has been removed in Kubernetes v1.%d", component, kv[0], v))
+		}
+
+		if kv[0] != "--feature-gates" || len(kv) != 2 {
+			continue
+		}
+
+		for _, g := range strings.Split(kv[1], ",") {
+			n := strings.SplitN(g, "=", 2)[0]
+
+			if v, ok := removedFeatureGates[n]; ok && minor >= v {
+				errors = append(errors, fmt.Errorf("%s: feature gate %q has been removed in Kubernetes v1.%d", component, n, v))
+			}
+		}
+	}
+
+	return errors.Return()
+}
+
+// flagValue returns value of given flag from given arguments and a boolean indicating,
+// if flag has been found.
+func flagValue(args []string, flag string) (string, bool) {
+	for _, a := range args {
+		kv := strings.SplitN(a, "=", 2)
+
+		if kv[0] == flag && len(kv) == 2 {
+			return kv[1], true
+		}
+	}
+
+	return "", false
+}
+
+// validateSharedFlags checks, if flags from the sharedFlags list have the same value on all
+// components, which set them. Arguments are indexed by component name.
+func validateSharedFlags(args map[string][]string) error {
+	var errors util.ValidateError
+
+	for _, f := range sharedFlags {
+		first := ""
+		value := ""
+
+		for _, c := range components {
+			v, ok := flagValue(args[c], f)
+			if !ok {
+				continue
+			}
+
+			if first == "" {
+				first = c
+				value = v
+
+				continue
+			}
+
+			if v != value {
+				errors = append(errors, fmt.Errorf("flag %q has different values: %s=%q, %s=%q", f, first, value, c, v))
+			}
+		}
+	}
+
+	return errors.Return()
+}
@@ -0,0 +1,117 @@
+package controlplane
+
+import (
+	"testing"
+)
+
+// kubernetesMinorVersion() tests.
+func TestKubernetesMinorVersion(t *testing.T) {
+	cases := map[string]struct {
+		Version string
+		Minor   int
+		Error   bool
+	}{
+		"full version": {
+			Version: "v1.18.6",
+			Minor:   18,
+		},
+		"without prefix": {
+			Version: "1.24",
+			Minor:   24,
+		},
+		"no minor": {
+			Version: "v1",
+			Error:   true,
+		},
+		"bad major": {
+			Version: "v2.0.0",
+			Error:   true,
+		},
+		"bad minor": {
+			Version: "v1.x.0",
+			Error:   true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			m, err := kubernetesMinorVersion(c.Version)
+			if !c.Error && err != nil {
+				t.Fatalf("didn't expect error, got: %v", err)
+			}
+
+			if c.Error && err == nil {
+				t.Fatalf("expected error")
+			}
+
+			if m != c.Minor {
+				t.Fatalf("expected minor version %d, got %d", c.Minor, m)
+			}
+		})
+	}
+}
+
+// validateFlags() tests.
+func TestValidateFlagsRemovedFlag(t *testing.T) {
+	args := []string{"kube-apiserver", "--insecure-port=0"}
+
+	if err := validateFlags("kube-apiserver", 18, args); err != nil {
+		t.Fatalf("flag supported in given version should pass validation, got: %v", err)
+	}
+
+	if err := validateFlags("kube-apiserver", 24, args); err == nil {
+		t.Fatalf("flag removed in given version should fail validation")
+	}
+}
+
+func TestValidateFlagsRemovedFeatureGate(t *testing.T) {
+	args := []string{"kube-controller-manager", "--feature-gates=Foo=true,TTLAfterFinished=true"}
+
+	if err := validateFlags("kube-controller-manager", 25, args); err == nil {
+		t.Fatalf("feature gate removed in given version should fail validation")
+	}
+}
+
+// validateSharedFlags() tests.
+func TestValidateSharedFlags(t *testing.T) {
+	cases := map[string]struct {
+		Args  map[string][]string
+		Error bool
+	}{
+		"matching values": {
+			Args: map[string][]string{
+				"kube-apiserver":          {"--service-cluster-ip-range=10.96.0.0/12"},
+				"kube-controller-manager": {"--service-cluster-ip-range=10.96.0.0/12"},
+			},
+		},
+		"set on single component": {
+			Args: map[string][]string{
+				"kube-apiserver": {"--service-cluster-ip-range=10.96.0.0/12"},
+			},
+		},
+		"different values": {
+			Args: map[string][]string{
+				"kube-apiserver":          {"--service-cluster-ip-range=10.96.0.0/12"},
+				"kube-controller-manager": {"--service-cluster-ip-range=10.0.0.0/16"},
+			},
+			Error: true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			err := validateSharedFlags(c.Args)
+			if !c.Error && err != nil {
+				t.Fatalf("didn't expect error, got: %v", err)
+			}
+
+			if c.Error && err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
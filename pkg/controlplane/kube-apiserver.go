@@ -2,7 +2,9 @@ package controlplane
 
 import (
 	"fmt"
+	"net"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/flexkube/libflexkube/internal/util"
@@ -82,6 +84,12 @@ type KubeAPIServer struct {
 	// It must match certificate defined in KubeletClientCertificate field.
 	KubeletClientKey types.PrivateKey `json:"kubeletClientKey"`
 
+	// KubeletServingCACertificate stores X.509 CA certificate, PEM encoded, which signs kubelet
+	// serving certificates. If empty, Kubernetes CA certificate is used to verify kubelets.
+	//
+	// This field is optional.
+	KubeletServingCACertificate types.Certificate `json:"kubeletServingCACertificate,omitempty"`
+
 	// EtcdCACertificate stores X.509 CA certificate, PEM encoded, which will be used by
 	// kube-apiserver to validate etcd servers certificate.
 	EtcdCACertificate types.Certificate `json:"etcdCACertificate"`
@@ -94,6 +102,39 @@ type KubeAPIServer struct {
 	//
 	// It must match certificate defined in EtcdClientCertificate field.
 	EtcdClientKey types.PrivateKey `json:"etcdClientKey"`
+
+	// EncryptionKeys is a list of keys, which will be used to encrypt secrets stored in etcd.
+	// First key is used for encryption, all of them are used for decryption. If empty, secrets
+	// are stored unencrypted.
+	//
+	// To rotate the key without making existing secrets unreadable, use
+	// Controlplane.RotateEncryptionKey().
+	//
+	// This field is optional.
+	EncryptionKeys []EncryptionKey `json:"encryptionKeys,omitempty"`
+
+	// HealthCheck configures readiness probe of kube-apiserver, which is used to verify,
+	// that kube-apiserver is healthy while removing containers. If URL is empty, /readyz
+	// endpoint on advertised address will be used. Server certificate is verified using
+	// Kubernetes CA certificate, unless CA certificate is set or verification is skipped.
+	//
+	// This field is optional.
+	HealthCheck *container.HTTPHealthCheck `json:"healthCheck,omitempty"`
+
+	// SecretArgs allows to set flags, which take a path to a file with sensitive content.
+	// Key is a flag name and value is a content of the file. Content is written to a file
+	// readable only by the owner, so it is not visible in process command line.
+	//
+	// Example value: 'map[string]string{"--token-auth-file": "token,user,uid"}'.
+	//
+	// This field is optional.
+	SecretArgs map[string]string `json:"secretArgs,omitempty"`
+
+	// Stop configures, how kube-apiserver container is stopped. If not set, kube-apiserver
+	// gets 60 seconds to gracefully stop and drain in-flight requests, before it is killed.
+	//
+	// This field is optional.
+	Stop *Stop `json:"stop,omitempty"`
 }
 
 // kubeAPIServer is a validated version of KubeAPIServer.
@@ -112,9 +153,14 @@ type kubeAPIServer struct {
 	frontProxyKey            string
 	kubeletClientCertificate string
 	kubeletClientKey         string
+	kubeletServingCA         string
 	etcdCACertificate        string
 	etcdClientCertificate    string
 	etcdClientKey            string
+	encryptionConfig         string
+	healthCheck              *container.HTTPHealthCheck
+	secretArgs               map[string]string
+	stop                     *Stop
 }
 
 const (
@@ -131,9 +177,11 @@ const (
 	proxyClientKeyFile        = "front-proxy-client.key"
 	kubeletClientCertificate  = "apiserver-kubelet-client.crt"
 	kubeletClientKey          = "apiserver-kubelet-client.key"
+	kubeletServingCAFile      = "kubelet-serving-ca.crt"
 	etcdCAFile                = "etcd/ca.crt"
 	etcdCertificate           = "apiserver-etcd-client.crt"
 	etcdKeyfile               = "apiserver-etcd-client.key"
+	extraCAFile               = "extra-ca.crt"
 )
 
 // configFiles returns map of file for kube-apiserver.
@@ -153,6 +201,14 @@ func (k *kubeAPIServer) configFiles() map[string]string {
 		etcdKeyfile:               k.etcdClientKey,
 	}
 
+	if k.encryptionConfig != "" {
+		m[encryptionConfigFile(k.encryptionConfig)] = k.encryptionConfig
+	}
+
+	if k.kubeletServingCA != "" {
+		m[kubeletServingCAFile] = k.kubeletServingCA
+	}
+
 	r := map[string]string{}
 
 	// Append base path to map.
@@ -160,12 +216,17 @@ func (k *kubeAPIServer) configFiles() map[string]string {
 		r[path.Join(hostConfigPath, k)] = v
 	}
 
+	sf, _ := secretArgsFiles(k.secretArgs, hostConfigPath, containerConfigPath)
+	for k, v := range sf {
+		r[k] = v
+	}
+
 	return r
 }
 
 // args returns kube-apiserver set of flags.
 func (k *kubeAPIServer) args() []string {
-	return []string{
+	a := []string{
 		"kube-apiserver",
 		fmt.Sprintf("--etcd-servers=%s", strings.Join(k.etcdServers, ",")),
 		fmt.Sprintf("--client-ca-file=%s", path.Join(containerConfigPath, clientCAFile)),
@@ -202,7 +263,7 @@ func (k *kubeAPIServer) args() []string {
 		// Required for communicating with kubelet.
 		fmt.Sprintf("--kubelet-client-certificate=%s", path.Join(containerConfigPath, kubeletClientCertificate)),
 		fmt.Sprintf("--kubelet-client-key=%s", path.Join(containerConfigPath, kubeletClientKey)),
-		fmt.Sprintf("--kubelet-certificate-authority=%s", path.Join(containerConfigPath, clientCAFile)),
+		fmt.Sprintf("--kubelet-certificate-authority=%s", path.Join(containerConfigPath, k.kubeletCAFile())),
 		// To secure communication to etcd servers.
 		fmt.Sprintf("--etcd-cafile=%s", path.Join(containerConfigPath, etcdCAFile)),
 		fmt.Sprintf("--etcd-certfile=%s", path.Join(containerConfigPath, etcdCertificate)),
@@ -214,21 +275,49 @@ func (k *kubeAPIServer) args() []string {
 		// To limit memory consumption of bootstrap controlplane, limit it to 512 MB.
 		"--target-ram-mb=512",
 	}
+
+	if k.encryptionConfig != "" {
+		a = append(a, fmt.Sprintf("--encryption-provider-config=%s", path.Join(containerConfigPath, encryptionConfigFile(k.encryptionConfig))))
+	}
+
+	_, sa := secretArgsFiles(k.secretArgs, hostConfigPath, containerConfigPath)
+
+	return append(a, sa...)
+}
+
+// kubeletCAFile returns name of the file with CA certificate used to verify kubelet serving
+// certificates.
+func (k *kubeAPIServer) kubeletCAFile() string {
+	if k.kubeletServingCA != "" {
+		return kubeletServingCAFile
+	}
+
+	return clientCAFile
 }
 
 // ToHostConfiguredContainer takes configured values and converts them to generic container configuration.
 func (k *kubeAPIServer) ToHostConfiguredContainer() (*container.HostConfiguredContainer, error) {
-	return &container.HostConfiguredContainer{
+	hcc := &container.HostConfiguredContainer{
 		Host:        k.host,
 		ConfigFiles: k.configFiles(),
+		// Certificates and keys are only read on start.
+		RestartOnConfigChange: true,
 		Container: container.Container{
 			// TODO: This is weird. This sets docker as default runtime config.
 			Runtime: container.RuntimeConfig{
 				Docker: docker.DefaultConfig(),
 			},
 			Config: containertypes.ContainerConfig{
-				Name:  containerName,
-				Image: k.common.GetImage(),
+				Name:         containerName,
+				Image:        k.common.GetImage(),
+				Init:         true,
+				StopSignal:   k.stop.signal(),
+				StopTimeout:  k.stop.timeoutSeconds(kubeAPIServerStopTimeout),
+				CpusetCpus:   k.common.CpusetCpus,
+				CpusetMems:   k.common.CpusetMems,
+				CgroupParent: k.common.CgroupParent,
+				ShmSize:      k.common.ShmSize,
+				OOMScoreAdj:  defaultOOMScoreAdj,
 				Mounts: []containertypes.Mount{
 					{
 						Source: hostConfigPath,
@@ -245,7 +334,23 @@ func (k *kubeAPIServer) ToHostConfiguredContainer() (*container.HostConfiguredCo
 				Args: k.args(),
 			},
 		},
-	}, nil
+	}
+
+	if k.encryptionConfig != "" {
+		hcc.ConfigFileTypes = map[string]container.ConfigFileType{
+			path.Join(hostConfigPath, encryptionConfigFile(k.encryptionConfig)): container.ConfigFileTypeYAML,
+		}
+	}
+
+	k.common.withExtraCACertificates(hcc, path.Join(hostConfigPath, extraCAFile))
+
+	if k.healthCheck != nil {
+		hcc.Hooks = &container.Hooks{
+			HealthCheck: k.healthCheck.Hook(),
+		}
+	}
+
+	return hcc, nil
 }
 
 // New validates KubeAPIServer configuration and populates default for some fields, if they are empty.
@@ -262,6 +367,9 @@ func (k *KubeAPIServer) New() (container.ResourceInstance, error) {
 		return nil, fmt.Errorf("failed to validate Kubernetes API server configuration: %w", err)
 	}
 
+	// It's fine to skip the error, Validate() will handle it.
+	ec, _ := k.encryptionConfig()
+
 	return &kubeAPIServer{
 		common:                   *k.Common,
 		host:                     *k.Host,
@@ -277,9 +385,14 @@ func (k *KubeAPIServer) New() (container.ResourceInstance, error) {
 		frontProxyKey:            string(k.FrontProxyKey),
 		kubeletClientCertificate: string(k.KubeletClientCertificate),
 		kubeletClientKey:         string(k.KubeletClientKey),
+		kubeletServingCA:         string(k.KubeletServingCACertificate),
 		etcdCACertificate:        string(k.EtcdCACertificate),
 		etcdClientCertificate:    string(k.EtcdClientCertificate),
 		etcdClientKey:            string(k.EtcdClientKey),
+		encryptionConfig:         ec,
+		healthCheck:              k.healthCheck(),
+		secretArgs:               k.SecretArgs,
+		stop:                     k.Stop,
 	}, nil
 }
 
@@ -303,5 +416,69 @@ func (k *KubeAPIServer) Validate() error {
 		errors = append(errors, fmt.Errorf("at least one etcd server must be defined"))
 	}
 
+	if k.ServiceCIDR != "" {
+		if _, err := parseCIDRs(k.ServiceCIDR); err != nil {
+			errors = append(errors, fmt.Errorf("invalid service CIDR %q: %w", k.ServiceCIDR, err))
+		}
+	}
+
+	if k.KubeletServingCACertificate != "" {
+		if _, err := parseCertificate(k.KubeletServingCACertificate); err != nil {
+			errors = append(errors, fmt.Errorf("invalid kubelet serving CA certificate: %w", err))
+		}
+	}
+
+	if _, err := k.encryptionConfig(); err != nil {
+		errors = append(errors, fmt.Errorf("invalid encryption configuration: %w", err))
+	}
+
+	if err := validateSecretArgs(containerName, k.SecretArgs); err != nil {
+		errors = append(errors, fmt.Errorf("invalid secret arguments: %w", err))
+	}
+
+	if err := k.Stop.Validate(); err != nil {
+		errors = append(errors, fmt.Errorf("invalid stop configuration: %w", err))
+	}
+
+	if hc := k.healthCheck(); hc != nil {
+		if err := hc.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("invalid health check configuration: %w", err))
+		}
+	}
+
 	return errors.Return()
 }
+
+// encryptionConfig returns EncryptionConfiguration for configured encryption keys.
+// If no keys are configured, empty string is returned.
+func (k *KubeAPIServer) encryptionConfig() (string, error) {
+	if len(k.EncryptionKeys) == 0 {
+		return "", nil
+	}
+
+	if err := validateEncryptionKeys(k.EncryptionKeys); err != nil {
+		return "", err
+	}
+
+	return encryptionConfig(k.EncryptionKeys)
+}
+
+// healthCheck returns configured health check with default values filled. If health check
+// is not configured, nil is returned.
+func (k *KubeAPIServer) healthCheck() *container.HTTPHealthCheck {
+	if k.HealthCheck == nil {
+		return nil
+	}
+
+	hc := *k.HealthCheck
+
+	if hc.URL == "" {
+		hc.URL = fmt.Sprintf("https://%s/readyz", net.JoinHostPort(k.AdvertiseAddress, strconv.Itoa(k.SecurePort)))
+	}
+
+	if hc.CACertificate == "" && !hc.InsecureSkipVerify && k.Common != nil {
+		hc.CACertificate = string(k.Common.KubernetesCACertificate)
+	}
+
+	return &hc
+}
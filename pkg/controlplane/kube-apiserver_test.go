@@ -1,10 +1,13 @@
 package controlplane
 
 import (
+	"path"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/flexkube/libflexkube/internal/utiltest"
+	"github.com/flexkube/libflexkube/pkg/container"
 	"github.com/flexkube/libflexkube/pkg/host"
 	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
 	"github.com/flexkube/libflexkube/pkg/types"
@@ -16,6 +19,9 @@ const (
 
 	// nonEmptyString is a string used for testing.
 	nonEmptyString = "foo"
+
+	// serviceCIDR is a valid service CIDR used for testing.
+	serviceCIDR = "11.0.0.0/24"
 )
 
 func TestKubeAPIServerToHostConfiguredContainer(t *testing.T) {
@@ -33,7 +39,7 @@ func TestKubeAPIServerToHostConfiguredContainer(t *testing.T) {
 		BindAddress:              nonEmptyString,
 		AdvertiseAddress:         nonEmptyString,
 		EtcdServers:              []string{nonEmptyString},
-		ServiceCIDR:              nonEmptyString,
+		ServiceCIDR:              serviceCIDR,
 		SecurePort:               securePort,
 		FrontProxyCertificate:    cert,
 		FrontProxyKey:            privateKey,
@@ -64,6 +70,14 @@ func TestKubeAPIServerToHostConfiguredContainer(t *testing.T) {
 	if hcc.Container.Config.Image == "" {
 		t.Fatalf("New() should set default image if it's not present")
 	}
+
+	if hcc.Container.Config.OOMScoreAdj != defaultOOMScoreAdj {
+		t.Fatalf("kube-apiserver container should have protective OOM score adjustment set, got: %d", hcc.Container.Config.OOMScoreAdj)
+	}
+
+	if e := int(kubeAPIServerStopTimeout / time.Second); hcc.Container.Config.StopTimeout != e {
+		t.Fatalf("kube-apiserver container should have long stop timeout %d by default, got: %d", e, hcc.Container.Config.StopTimeout)
+	}
 }
 
 // Validate() tests.
@@ -93,7 +107,7 @@ func TestKubeAPIServerValidate(t *testing.T) { //nolint:funlen
 				BindAddress:             nonEmptyString,
 				AdvertiseAddress:        nonEmptyString,
 				EtcdServers:             []string{nonEmptyString},
-				ServiceCIDR:             nonEmptyString,
+				ServiceCIDR:             serviceCIDR,
 				SecurePort:              securePort,
 				FrontProxyCertificate:   cert,
 				FrontProxyKey:           privateKey,
@@ -114,7 +128,7 @@ func TestKubeAPIServerValidate(t *testing.T) { //nolint:funlen
 				BindAddress:              nonEmptyString,
 				AdvertiseAddress:         nonEmptyString,
 				EtcdServers:              []string{nonEmptyString},
-				ServiceCIDR:              nonEmptyString,
+				ServiceCIDR:              serviceCIDR,
 				SecurePort:               securePort,
 				FrontProxyCertificate:    cert,
 				FrontProxyKey:            privateKey,
@@ -136,7 +150,7 @@ func TestKubeAPIServerValidate(t *testing.T) { //nolint:funlen
 				BindAddress:              nonEmptyString,
 				AdvertiseAddress:         nonEmptyString,
 				EtcdServers:              []string{},
-				ServiceCIDR:              nonEmptyString,
+				ServiceCIDR:              serviceCIDR,
 				SecurePort:               securePort,
 				FrontProxyCertificate:    cert,
 				FrontProxyKey:            privateKey,
@@ -158,7 +172,7 @@ func TestKubeAPIServerValidate(t *testing.T) { //nolint:funlen
 				BindAddress:              nonEmptyString,
 				AdvertiseAddress:         nonEmptyString,
 				EtcdServers:              []string{nonEmptyString},
-				ServiceCIDR:              nonEmptyString,
+				ServiceCIDR:              serviceCIDR,
 				SecurePort:               securePort,
 				FrontProxyCertificate:    cert,
 				FrontProxyKey:            privateKey,
@@ -171,6 +185,52 @@ func TestKubeAPIServerValidate(t *testing.T) { //nolint:funlen
 			},
 			Error: true,
 		},
+		"validate kubeletServingCACertificate": {
+			Config: &KubeAPIServer{
+				Common:                      common,
+				APIServerCertificate:        cert,
+				APIServerKey:                privateKey,
+				ServiceAccountPublicKey:     nonEmptyString,
+				BindAddress:                 nonEmptyString,
+				AdvertiseAddress:            nonEmptyString,
+				EtcdServers:                 []string{nonEmptyString},
+				ServiceCIDR:                 serviceCIDR,
+				SecurePort:                  securePort,
+				FrontProxyCertificate:       cert,
+				FrontProxyKey:               privateKey,
+				KubeletClientKey:            privateKey,
+				EtcdCACertificate:           cert,
+				EtcdClientCertificate:       cert,
+				EtcdClientKey:               privateKey,
+				Host:                        hostConfig,
+				KubeletClientCertificate:    cert,
+				KubeletServingCACertificate: nonEmptyString,
+			},
+			Error: true,
+		},
+		"valid with kubeletServingCACertificate": {
+			Config: &KubeAPIServer{
+				Common:                      common,
+				APIServerCertificate:        cert,
+				APIServerKey:                privateKey,
+				ServiceAccountPublicKey:     nonEmptyString,
+				BindAddress:                 nonEmptyString,
+				AdvertiseAddress:            nonEmptyString,
+				EtcdServers:                 []string{nonEmptyString},
+				ServiceCIDR:                 serviceCIDR,
+				SecurePort:                  securePort,
+				FrontProxyCertificate:       cert,
+				FrontProxyKey:               privateKey,
+				KubeletClientKey:            privateKey,
+				EtcdCACertificate:           cert,
+				EtcdClientCertificate:       cert,
+				EtcdClientKey:               privateKey,
+				Host:                        hostConfig,
+				KubeletClientCertificate:    cert,
+				KubeletServingCACertificate: cert,
+			},
+			Error: false,
+		},
 		"valid": {
 			Config: &KubeAPIServer{
 				Common:                   common,
@@ -180,7 +240,7 @@ func TestKubeAPIServerValidate(t *testing.T) { //nolint:funlen
 				BindAddress:              nonEmptyString,
 				AdvertiseAddress:         nonEmptyString,
 				EtcdServers:              []string{nonEmptyString},
-				ServiceCIDR:              nonEmptyString,
+				ServiceCIDR:              serviceCIDR,
 				SecurePort:               securePort,
 				FrontProxyCertificate:    cert,
 				FrontProxyKey:            privateKey,
@@ -232,7 +292,7 @@ func TestKubeAPIServerConfigFiles(t *testing.T) {
 		BindAddress:              nonEmptyString,
 		AdvertiseAddress:         nonEmptyString,
 		EtcdServers:              []string{nonEmptyString},
-		ServiceCIDR:              nonEmptyString,
+		ServiceCIDR:              serviceCIDR,
 		SecurePort:               securePort,
 		FrontProxyCertificate:    cert,
 		FrontProxyKey:            privateKey,
@@ -258,6 +318,49 @@ func TestKubeAPIServerConfigFiles(t *testing.T) {
 	}
 }
 
+// args() tests.
+func TestKubeAPIServerKubeletCertificateAuthority(t *testing.T) {
+	cases := map[string]struct {
+		kubeletServingCA string
+		expected         string
+	}{
+		"default to Kubernetes CA": {
+			expected: path.Join(containerConfigPath, clientCAFile),
+		},
+		"kubelet serving CA": {
+			kubeletServingCA: nonEmptyString,
+			expected:         path.Join(containerConfigPath, kubeletServingCAFile),
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			k := &kubeAPIServer{
+				kubeletServingCA: c.kubeletServingCA,
+			}
+
+			flag := "--kubelet-certificate-authority=" + c.expected
+			found := false
+
+			for _, a := range k.args() {
+				if a == flag {
+					found = true
+				}
+			}
+
+			if !found {
+				t.Fatalf("Arguments should contain %q, got: %v", flag, k.args())
+			}
+
+			if _, ok := k.configFiles()[path.Join(hostConfigPath, kubeletServingCAFile)]; ok != (c.kubeletServingCA != "") {
+				t.Fatalf("Kubelet serving CA file should only be written, when CA is configured")
+			}
+		})
+	}
+}
+
 // New() tests.
 func TestKubeAPIServerNewEmptyHost(t *testing.T) {
 	c := &KubeAPIServer{}
@@ -271,3 +374,99 @@ func TestKubeAPIServerNewEmptyHost(t *testing.T) {
 		t.Errorf("New should not return kube-apiserver object in case of error")
 	}
 }
+
+func TestKubeAPIServerEncryptionKeys(t *testing.T) {
+	cert := types.Certificate(utiltest.GenerateX509Certificate(t))
+	privateKey := types.PrivateKey(utiltest.GenerateRSAPrivateKey(t))
+
+	c := &KubeAPIServer{
+		Common: &Common{
+			KubernetesCACertificate: cert,
+			FrontProxyCACertificate: cert,
+		},
+		APIServerCertificate:     cert,
+		APIServerKey:             privateKey,
+		ServiceAccountPublicKey:  nonEmptyString,
+		BindAddress:              nonEmptyString,
+		AdvertiseAddress:         nonEmptyString,
+		EtcdServers:              []string{nonEmptyString},
+		ServiceCIDR:              serviceCIDR,
+		SecurePort:               securePort,
+		FrontProxyCertificate:    cert,
+		FrontProxyKey:            privateKey,
+		KubeletClientCertificate: cert,
+		KubeletClientKey:         privateKey,
+		EtcdCACertificate:        cert,
+		EtcdClientCertificate:    cert,
+		EtcdClientKey:            privateKey,
+		Host: &host.Host{
+			DirectConfig: &direct.Config{},
+		},
+		EncryptionKeys: []EncryptionKey{
+			{
+				Name:   "foo",
+				Secret: testEncryptionSecret,
+			},
+		},
+	}
+
+	ki, err := c.New()
+	if err != nil {
+		t.Fatalf("kubeAPIServer object should be created, got: %v", err)
+	}
+
+	k := ki.(*kubeAPIServer)
+
+	f := path.Join(containerConfigPath, encryptionConfigFile(k.encryptionConfig))
+
+	if !strings.Contains(strings.Join(k.args(), " "), "--encryption-provider-config="+f) {
+		t.Fatalf("encryption provider config flag should be set when encryption keys are configured, got: %v", k.args())
+	}
+
+	if _, ok := k.configFiles()[path.Join(hostConfigPath, encryptionConfigFile(k.encryptionConfig))]; !ok {
+		t.Fatalf("encryption configuration file should be created")
+	}
+
+	c.EncryptionKeys[0].Secret = nonEmptyString
+
+	if _, err := c.New(); err == nil {
+		t.Fatalf("invalid encryption key should be rejected")
+	}
+}
+
+// healthCheck() tests.
+func TestKubeAPIServerHealthCheckDefaults(t *testing.T) {
+	k := &KubeAPIServer{
+		Common: &Common{
+			KubernetesCACertificate: "foo",
+		},
+		AdvertiseAddress: "192.168.1.2",
+		SecurePort:       6443,
+	}
+
+	if hc := k.healthCheck(); hc != nil {
+		t.Fatalf("health check should not be configured by default, got: %+v", hc)
+	}
+
+	k.HealthCheck = &container.HTTPHealthCheck{}
+
+	hc := k.healthCheck()
+
+	if e := "https://192.168.1.2:6443/readyz"; hc.URL != e {
+		t.Fatalf("expected default URL %q, got %q", e, hc.URL)
+	}
+
+	if hc.CACertificate != "foo" {
+		t.Fatalf("Kubernetes CA certificate should be used by default, got %q", hc.CACertificate)
+	}
+
+	k.HealthCheck.InsecureSkipVerify = true
+
+	if hc := k.healthCheck(); hc.CACertificate != "" {
+		t.Fatalf("CA certificate should not be set when verification is skipped, got %q", hc.CACertificate)
+	}
+
+	if k.HealthCheck.URL != "" {
+		t.Fatalf("filling defaults should not modify configuration")
+	}
+}
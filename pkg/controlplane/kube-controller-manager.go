@@ -3,6 +3,7 @@ package controlplane
 import (
 	"fmt"
 
+	"github.com/flexkube/libflexkube/internal/util"
 	"github.com/flexkube/libflexkube/pkg/container"
 	"github.com/flexkube/libflexkube/pkg/container/runtime/docker"
 	containertypes "github.com/flexkube/libflexkube/pkg/container/types"
@@ -38,13 +39,66 @@ type KubeControllerManager struct {
 	// perform full validation of Kubernetes API certificate.
 	RootCACertificate types.Certificate `json:"rootCACertificate"`
 
+	// KubeletServingCACertificate is a X.509 CA certificate, PEM encoded, which will be used
+	// for signing kubelet serving certificates requested via TLS bootstrapping. If empty,
+	// Kubernetes CA is used.
+	//
+	// This field is optional.
+	KubeletServingCACertificate types.Certificate `json:"kubeletServingCACertificate,omitempty"`
+
+	// KubeletServingCAKey is a PEM encoded, private key in either PKCS1, PKCS8 or EC format,
+	// matching KubeletServingCACertificate.
+	//
+	// This field is optional.
+	KubeletServingCAKey types.PrivateKey `json:"kubeletServingCAKey,omitempty"`
+
 	// FlexVolumePluginDir is a plugin directory for FlexVolumes, which must be defined for
 	// kube-controller-manager, as stated in Flexvolume specification.
 	//
 	// Example value: '/usr/libexec/kubernetes/kubelet-plugins/volume/exec/'.
 	FlexVolumePluginDir string `json:"flexVolumePluginDir"`
+
+	// LeaderElection configures leader election of kube-controller-manager. If not set,
+	// Kubernetes defaults are used.
+	LeaderElection *LeaderElection `json:"leaderElection,omitempty"`
+
+	// ServiceCIDR is a CIDR used for Service type ClusterIP. It must be the same as configured
+	// on kube-apiserver.
+	//
+	// Example value: '10.96.0.0/12'.
+	//
+	// This field is optional.
+	ServiceCIDR string `json:"serviceCIDR,omitempty"`
+
+	// PodCIDR is a CIDR, from which kube-controller-manager will allocate pod CIDRs for
+	// the nodes. If empty, pod CIDRs are not allocated.
+	//
+	// Example value: '10.1.0.0/16'.
+	//
+	// This field is optional.
+	PodCIDR string `json:"podCIDR,omitempty"`
+
+	// SecretArgs allows to set flags, which take a path to a file with sensitive content.
+	// Key is a flag name and value is a content of the file. Content is written to a file
+	// readable only by the owner, so it is not visible in process command line.
+	//
+	// Example value: 'map[string]string{"--cloud-config": "[Global]\n..."}'.
+	//
+	// This field is optional.
+	SecretArgs map[string]string `json:"secretArgs,omitempty"`
+
+	// Stop configures, how kube-controller-manager container is stopped. If not set,
+	// kube-controller-manager gets 10 seconds to gracefully stop, before it is killed.
+	//
+	// This field is optional.
+	Stop *Stop `json:"stop,omitempty"`
 }
 
+const (
+	kubeControllerManagerHostConfigPath      = "/etc/kubernetes/kube-controller-manager"
+	kubeControllerManagerContainerConfigPath = "/etc/kubernetes"
+)
+
 // kubeControllerManager is a validated version of KubeControllerManager.
 type kubeControllerManager struct {
 	common                   Common
@@ -54,11 +108,18 @@ type kubeControllerManager struct {
 	rootCACertificate        string
 	kubeconfig               string
 	flexVolumePluginDir      string
+	kubeletServingCA         string
+	kubeletServingCAKey      string
+	leaderElection           *LeaderElection
+	secretArgs               map[string]string
+	serviceCIDR              string
+	podCIDR                  string
+	stop                     *Stop
 }
 
 // args returns kube-controller-manager arguments passed to the container.
 func (k *kubeControllerManager) args() []string {
-	return []string{
+	args := []string{
 		"kube-controller-manager",
 		// This makes controller manager use built-in roles, which already has all required
 		// roles binded. As kubeconfig file we use should use kube-controller-manager service
@@ -91,6 +152,32 @@ func (k *kubeControllerManager) args() []string {
 		"--client-ca-file=/etc/kubernetes/pki/ca.crt",
 		fmt.Sprintf("--flex-volume-plugin-dir=%s", k.flexVolumePluginDir),
 	}
+
+	if k.kubeletServingCA != "" {
+		args = append(args,
+			// Sign kubelet serving certificates using dedicated CA.
+			"--cluster-signing-kubelet-serving-cert-file=/etc/kubernetes/pki/kubelet-serving-ca.crt",
+			"--cluster-signing-kubelet-serving-key-file=/etc/kubernetes/pki/kubelet-serving-ca.key",
+		)
+	}
+
+	if k.serviceCIDR != "" {
+		args = append(args, fmt.Sprintf("--service-cluster-ip-range=%s", k.serviceCIDR))
+	}
+
+	if k.podCIDR != "" {
+		args = append(args,
+			// Allocate pod CIDRs for nodes, so network plugins can use them.
+			"--allocate-node-cidrs=true",
+			fmt.Sprintf("--cluster-cidr=%s", k.podCIDR),
+		)
+	}
+
+	args = append(args, k.leaderElection.args()...)
+
+	_, sa := secretArgsFiles(k.secretArgs, kubeControllerManagerHostConfigPath, kubeControllerManagerContainerConfigPath)
+
+	return append(args, sa...)
 }
 
 // ToHostConfiguredContainer takes configured parameters and returns generic HostConfiguredContainer.
@@ -107,14 +194,32 @@ func (k *kubeControllerManager) ToHostConfiguredContainer() (*container.HostConf
 	configFiles["/etc/kubernetes/kube-controller-manager/pki/root.crt"] = fmt.Sprintf("%s%s", k.rootCACertificate, string(k.common.KubernetesCACertificate))
 	configFiles["/etc/kubernetes/kube-controller-manager/pki/front-proxy-ca.crt"] = string(k.common.FrontProxyCACertificate)
 
+	if k.kubeletServingCA != "" {
+		configFiles["/etc/kubernetes/kube-controller-manager/pki/kubelet-serving-ca.crt"] = k.kubeletServingCA
+		configFiles["/etc/kubernetes/kube-controller-manager/pki/kubelet-serving-ca.key"] = k.kubeletServingCAKey
+	}
+
+	sf, _ := secretArgsFiles(k.secretArgs, kubeControllerManagerHostConfigPath, kubeControllerManagerContainerConfigPath)
+	for p, v := range sf {
+		configFiles[p] = v
+	}
+
 	c := container.Container{
 		// TODO this is weird. This sets docker as default runtime config
 		Runtime: container.RuntimeConfig{
 			Docker: docker.DefaultConfig(),
 		},
 		Config: containertypes.ContainerConfig{
-			Name:  "kube-controller-manager",
-			Image: k.common.GetImage(),
+			Name:         "kube-controller-manager",
+			Image:        k.common.GetImage(),
+			Init:         true,
+			StopSignal:   k.stop.signal(),
+			StopTimeout:  k.stop.timeoutSeconds(defaultStopTimeout),
+			CpusetCpus:   k.common.CpusetCpus,
+			CpusetMems:   k.common.CpusetMems,
+			CgroupParent: k.common.CgroupParent,
+			ShmSize:      k.common.ShmSize,
+			OOMScoreAdj:  defaultOOMScoreAdj,
 			Mounts: []containertypes.Mount{
 				{
 					Source: "/etc/kubernetes/kube-controller-manager/",
@@ -125,11 +230,20 @@ func (k *kubeControllerManager) ToHostConfiguredContainer() (*container.HostConf
 		},
 	}
 
-	return &container.HostConfiguredContainer{
+	hcc := &container.HostConfiguredContainer{
 		Host:        k.host,
 		ConfigFiles: configFiles,
-		Container:   c,
-	}, nil
+		ConfigFileTypes: map[string]container.ConfigFileType{
+			"/etc/kubernetes/kube-controller-manager/kubeconfig": container.ConfigFileTypeKubeconfig,
+		},
+		Container: c,
+		// Certificates and kubeconfig are only read on start.
+		RestartOnConfigChange: true,
+	}
+
+	k.common.withExtraCACertificates(hcc, "/etc/kubernetes/kube-controller-manager/pki/extra-ca.crt")
+
+	return hcc, nil
 }
 
 // New validates KubeControllerManager and returns usable kubeControllerManager.
@@ -157,6 +271,13 @@ func (k *KubeControllerManager) New() (container.ResourceInstance, error) {
 		rootCACertificate:        string(k.RootCACertificate),
 		kubeconfig:               kubeconfig,
 		flexVolumePluginDir:      k.FlexVolumePluginDir,
+		kubeletServingCA:         string(k.KubeletServingCACertificate),
+		kubeletServingCAKey:      string(k.KubeletServingCAKey),
+		leaderElection:           k.LeaderElection,
+		secretArgs:               k.SecretArgs,
+		serviceCIDR:              k.ServiceCIDR,
+		podCIDR:                  k.PodCIDR,
+		stop:                     k.Stop,
 	}
 
 	return nk, nil
@@ -164,6 +285,8 @@ func (k *KubeControllerManager) New() (container.ResourceInstance, error) {
 
 // Validate validates KubeControllerManager configuration.
 func (k *KubeControllerManager) Validate() error {
+	var errors util.ValidateError
+
 	v := validator{
 		Common:     k.Common,
 		Host:       k.Host,
@@ -171,5 +294,37 @@ func (k *KubeControllerManager) Validate() error {
 		YAML:       k,
 	}
 
-	return v.validate(true)
+	if err := v.validate(true); err != nil {
+		errors = append(errors, err)
+	}
+
+	if k.ServiceCIDR != "" {
+		if _, err := parseCIDRs(k.ServiceCIDR); err != nil {
+			errors = append(errors, fmt.Errorf("invalid service CIDR %q: %w", k.ServiceCIDR, err))
+		}
+	}
+
+	if k.PodCIDR != "" {
+		if _, err := parseCIDRs(k.PodCIDR); err != nil {
+			errors = append(errors, fmt.Errorf("invalid pod CIDR %q: %w", k.PodCIDR, err))
+		}
+	}
+
+	if (k.KubeletServingCACertificate == "") != (k.KubeletServingCAKey == "") {
+		errors = append(errors, fmt.Errorf("kubelet serving CA certificate and key must be set together"))
+	}
+
+	if err := k.LeaderElection.Validate(); err != nil {
+		errors = append(errors, fmt.Errorf("invalid leader election configuration: %w", err))
+	}
+
+	if err := k.Stop.Validate(); err != nil {
+		errors = append(errors, fmt.Errorf("invalid stop configuration: %w", err))
+	}
+
+	if err := validateSecretArgs("kube-controller-manager", k.SecretArgs); err != nil {
+		errors = append(errors, fmt.Errorf("invalid secret arguments: %w", err))
+	}
+
+	return errors.Return()
 }
@@ -93,6 +93,30 @@ func TestKubeControllerManagerValidate(t *testing.T) { //nolint:funlen
 			},
 			Error: true,
 		},
+		"bad service CIDR": {
+			Config: &KubeControllerManager{
+				KubernetesCAKey:          types.PrivateKey(pki.PrivateKey),
+				ServiceAccountPrivateKey: types.PrivateKey(pki.PrivateKey),
+				RootCACertificate:        types.Certificate(pki.Certificate),
+				Host:                     hostConfig,
+				Kubeconfig:               kubeconfig,
+				Common:                   common,
+				ServiceCIDR:              "foo",
+			},
+			Error: true,
+		},
+		"bad pod CIDR": {
+			Config: &KubeControllerManager{
+				KubernetesCAKey:          types.PrivateKey(pki.PrivateKey),
+				ServiceAccountPrivateKey: types.PrivateKey(pki.PrivateKey),
+				RootCACertificate:        types.Certificate(pki.Certificate),
+				Host:                     hostConfig,
+				Kubeconfig:               kubeconfig,
+				Common:                   common,
+				PodCIDR:                  "10.1.0.0",
+			},
+			Error: true,
+		},
 		"valid": {
 			Config: &KubeControllerManager{
 				KubernetesCAKey:          types.PrivateKey(pki.PrivateKey),
@@ -3,6 +3,7 @@ package controlplane
 import (
 	"fmt"
 
+	"github.com/flexkube/libflexkube/internal/util"
 	"github.com/flexkube/libflexkube/pkg/container"
 	"github.com/flexkube/libflexkube/pkg/container/runtime/docker"
 	containertypes "github.com/flexkube/libflexkube/pkg/container/types"
@@ -21,13 +22,25 @@ type KubeScheduler struct {
 	// Kubeconfig stores client information used by kube-scheduler to talk to
 	// Kubernetes API.
 	Kubeconfig client.Config `json:"kubeconfig"`
+
+	// LeaderElection configures leader election of kube-scheduler. If not set, Kubernetes
+	// defaults are used.
+	LeaderElection *LeaderElection `json:"leaderElection,omitempty"`
+
+	// Stop configures, how kube-scheduler container is stopped. If not set, kube-scheduler
+	// gets 10 seconds to gracefully stop, before it is killed.
+	//
+	// This field is optional.
+	Stop *Stop `json:"stop,omitempty"`
 }
 
 // kubeScheduler is validated and usable version of KubeScheduler.
 type kubeScheduler struct {
-	common     Common
-	host       host.Host
-	kubeconfig string
+	common         Common
+	host           host.Host
+	kubeconfig     string
+	leaderElection string
+	stop           *Stop
 }
 
 // ToHostConfiguredContainer converts kubeScheduler into generic container struct.
@@ -41,7 +54,7 @@ func (k *kubeScheduler) ToHostConfiguredContainer() (*container.HostConfiguredCo
 kind: KubeSchedulerConfiguration
 clientConnection:
   kubeconfig: /etc/kubernetes/kubeconfig
-`
+` + k.leaderElection
 
 	c := container.Container{
 		// TODO: This is weird. This sets docker as default runtime config.
@@ -49,8 +62,16 @@ clientConnection:
 			Docker: docker.DefaultConfig(),
 		},
 		Config: containertypes.ContainerConfig{
-			Name:  "kube-scheduler",
-			Image: k.common.GetImage(),
+			Name:         "kube-scheduler",
+			Image:        k.common.GetImage(),
+			Init:         true,
+			StopSignal:   k.stop.signal(),
+			StopTimeout:  k.stop.timeoutSeconds(defaultStopTimeout),
+			CpusetCpus:   k.common.CpusetCpus,
+			CpusetMems:   k.common.CpusetMems,
+			CgroupParent: k.common.CgroupParent,
+			ShmSize:      k.common.ShmSize,
+			OOMScoreAdj:  defaultOOMScoreAdj,
 			Mounts: []containertypes.Mount{
 				{
 					Source: "/etc/kubernetes/kube-scheduler/",
@@ -74,11 +95,21 @@ clientConnection:
 		},
 	}
 
-	return &container.HostConfiguredContainer{
+	hcc := &container.HostConfiguredContainer{
 		Host:        k.host,
 		ConfigFiles: configFiles,
-		Container:   c,
-	}, nil
+		ConfigFileTypes: map[string]container.ConfigFileType{
+			"/etc/kubernetes/kube-scheduler/kubeconfig":          container.ConfigFileTypeKubeconfig,
+			"/etc/kubernetes/kube-scheduler/kube-scheduler.yaml": container.ConfigFileTypeYAML,
+		},
+		Container: c,
+		// Certificates and kubeconfig are only read on start.
+		RestartOnConfigChange: true,
+	}
+
+	k.common.withExtraCACertificates(hcc, "/etc/kubernetes/kube-scheduler/pki/extra-ca.crt")
+
+	return hcc, nil
 }
 
 // New validates KubeScheduler struct and returns it's usable version.
@@ -95,18 +126,23 @@ func (k *KubeScheduler) New() (container.ResourceInstance, error) {
 		return nil, fmt.Errorf("failed to validate Kubernetes Scheduler configuration: %w", err)
 	}
 
-	// It's fine to skip the error, Validate() will handle it.
+	// It's fine to skip the errors, Validate() will handle it.
 	kubeconfig, _ := k.Kubeconfig.ToYAMLString()
+	leaderElection, _ := k.LeaderElection.config()
 
 	return &kubeScheduler{
-		common:     *k.Common,
-		host:       *k.Host,
-		kubeconfig: kubeconfig,
+		common:         *k.Common,
+		host:           *k.Host,
+		kubeconfig:     kubeconfig,
+		leaderElection: leaderElection,
+		stop:           k.Stop,
 	}, nil
 }
 
 // Validate validates kube-scheduler configuration.
 func (k *KubeScheduler) Validate() error {
+	var errors util.ValidateError
+
 	v := validator{
 		Common:     k.Common,
 		Host:       k.Host,
@@ -114,5 +150,17 @@ func (k *KubeScheduler) Validate() error {
 		YAML:       k,
 	}
 
-	return v.validate(true)
+	if err := v.validate(true); err != nil {
+		errors = append(errors, err)
+	}
+
+	if err := k.LeaderElection.Validate(); err != nil {
+		errors = append(errors, fmt.Errorf("invalid leader election configuration: %w", err))
+	}
+
+	if err := k.Stop.Validate(); err != nil {
+		errors = append(errors, fmt.Errorf("invalid stop configuration: %w", err))
+	}
+
+	return errors.Return()
 }
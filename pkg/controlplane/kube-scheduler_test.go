@@ -2,6 +2,7 @@ package controlplane
 
 import (
 	"testing"
+	"time"
 
 	"github.com/flexkube/libflexkube/internal/utiltest"
 	"github.com/flexkube/libflexkube/pkg/host"
@@ -45,6 +46,60 @@ func TestKubeSchedulerToHostConfiguredContainer(t *testing.T) {
 	if hcc.Container.Config.Image == "" {
 		t.Fatalf("New() should set default image if it's not present")
 	}
+
+	if !hcc.Container.Config.Init {
+		t.Fatalf("kube-scheduler container should run with init process")
+	}
+
+	if hcc.Container.Config.StopSignal != defaultStopSignal {
+		t.Fatalf("expected stop signal %q, got %q", defaultStopSignal, hcc.Container.Config.StopSignal)
+	}
+
+	if e := int(defaultStopTimeout / time.Second); hcc.Container.Config.StopTimeout != e {
+		t.Fatalf("expected stop timeout %d, got %d", e, hcc.Container.Config.StopTimeout)
+	}
+}
+
+func TestKubeSchedulerExtraCACertificates(t *testing.T) {
+	pki := utiltest.GeneratePKI(t)
+
+	ks := &KubeScheduler{
+		Common: &Common{
+			KubernetesCACertificate: types.Certificate(pki.Certificate),
+			FrontProxyCACertificate: types.Certificate(pki.Certificate),
+			ExtraCACertificates:     []types.Certificate{types.Certificate(pki.Certificate)},
+		},
+		Kubeconfig: client.Config{
+			Server:            "localhost",
+			CACertificate:     types.Certificate(pki.Certificate),
+			ClientCertificate: types.Certificate(pki.Certificate),
+			ClientKey:         types.PrivateKey(pki.PrivateKey),
+		},
+		Host: &host.Host{
+			DirectConfig: &direct.Config{},
+		},
+	}
+
+	o, err := ks.New()
+	if err != nil {
+		t.Fatalf("new should not return error, got: %v", err)
+	}
+
+	hcc, err := o.ToHostConfiguredContainer()
+	if err != nil {
+		t.Fatalf("Generating HostConfiguredContainer should work, got: %v", err)
+	}
+
+	p := "/etc/kubernetes/kube-scheduler/pki/extra-ca.crt"
+
+	if _, ok := hcc.ConfigFiles[p]; !ok {
+		t.Fatalf("extra CA certificates bundle should be written to %q", p)
+	}
+
+	mounts := hcc.Container.Config.Mounts
+	if m := mounts[len(mounts)-1]; m.Source != p || m.Target != extraCACertificatesPath {
+		t.Fatalf("extra CA certificates bundle should be mounted into container trust store, got: %+v", m)
+	}
 }
 
 // New() tests.
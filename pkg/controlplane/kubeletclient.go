@@ -0,0 +1,80 @@
+package controlplane
+
+import (
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// kubeletAPIAdminClusterRole is a built-in ClusterRole, which grants full access to
+	// kubelet API.
+	kubeletAPIAdminClusterRole = "system:kubelet-api-admin"
+
+	// kubeletAPIAdminBindingName is a name of ClusterRoleBinding, which grants kube-apiserver
+	// access to kubelet API.
+	kubeletAPIAdminBindingName = "flexkube:kube-apiserver-kubelet-api-admin"
+
+	// mastersGroup is a group, which members bypass authorization.
+	mastersGroup = "system:masters"
+)
+
+// ClusterRoleBindingApplier represents capability of creating or updating ClusterRoleBindings.
+// It is implemented by Kubernetes client from client package.
+type ClusterRoleBindingApplier interface {
+	ApplyClusterRoleBinding(binding *rbacv1.ClusterRoleBinding) error
+}
+
+// kubeletAPIAdminBinding returns ClusterRoleBinding, which grants access to kubelet API
+// to the user with given name.
+func kubeletAPIAdminBinding(user string) *rbacv1.ClusterRoleBinding {
+	return &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: kubeletAPIAdminBindingName,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     kubeletAPIAdminClusterRole,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				APIGroup: rbacv1.GroupName,
+				Kind:     rbacv1.UserKind,
+				Name:     user,
+			},
+		},
+	}
+}
+
+// BindKubeletAPIAdmin grants kube-apiserver access to kubelet API using RBAC, when kube-apiserver
+// kubelet client certificate is not a member of 'system:masters' group, e.g. when it has been
+// generated with ScopedKubeletCertificate enabled in PKI. Access is granted to the common name
+// of the certificate using 'system:kubelet-api-admin' ClusterRole.
+//
+// If certificate is a member of 'system:masters' group, binding is not needed and nothing is done.
+func (c *Controlplane) BindKubeletAPIAdmin(a ClusterRoleBindingApplier) error {
+	c.buildComponents()
+
+	if c.KubeAPIServer.KubeletClientCertificate == "" {
+		return fmt.Errorf("kube-apiserver kubelet client certificate is not set")
+	}
+
+	cert, err := parseCertificate(c.KubeAPIServer.KubeletClientCertificate)
+	if err != nil {
+		return fmt.Errorf("failed parsing kube-apiserver kubelet client certificate: %w", err)
+	}
+
+	for _, o := range cert.Subject.Organization {
+		if o == mastersGroup {
+			return nil
+		}
+	}
+
+	if err := a.ApplyClusterRoleBinding(kubeletAPIAdminBinding(cert.Subject.CommonName)); err != nil {
+		return fmt.Errorf("failed granting kubelet API access to %q: %w", cert.Subject.CommonName, err)
+	}
+
+	return nil
+}
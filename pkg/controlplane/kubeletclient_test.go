@@ -0,0 +1,92 @@
+package controlplane
+
+import (
+	"fmt"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/flexkube/libflexkube/pkg/pki"
+)
+
+type fakeClusterRoleBindingApplier struct {
+	bindings []*rbacv1.ClusterRoleBinding
+	err      error
+}
+
+func (f *fakeClusterRoleBindingApplier) ApplyClusterRoleBinding(binding *rbacv1.ClusterRoleBinding) error {
+	f.bindings = append(f.bindings, binding)
+
+	return f.err
+}
+
+func kubeletClientControlplane(t *testing.T, scoped bool) *Controlplane {
+	t.Helper()
+
+	pki := &pki.PKI{
+		Kubernetes: &pki.Kubernetes{
+			KubeAPIServer: &pki.KubeAPIServer{
+				ScopedKubeletCertificate: scoped,
+			},
+		},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("Generating PKI should succeed, got: %v", err)
+	}
+
+	return &Controlplane{
+		PKI: pki,
+	}
+}
+
+// BindKubeletAPIAdmin() tests.
+func TestBindKubeletAPIAdminScoped(t *testing.T) {
+	c := kubeletClientControlplane(t, true)
+	a := &fakeClusterRoleBindingApplier{}
+
+	if err := c.BindKubeletAPIAdmin(a); err != nil {
+		t.Fatalf("Binding kubelet API admin should succeed, got: %v", err)
+	}
+
+	if len(a.bindings) != 1 {
+		t.Fatalf("Expected one binding to be applied, got %d", len(a.bindings))
+	}
+
+	b := a.bindings[0]
+
+	if b.RoleRef.Name != kubeletAPIAdminClusterRole {
+		t.Fatalf("Expected binding to %q, got %q", kubeletAPIAdminClusterRole, b.RoleRef.Name)
+	}
+
+	if s := b.Subjects[0]; s.Kind != rbacv1.UserKind || s.Name != pki.KubeAPIServerKubeletClientCN {
+		t.Fatalf("Expected binding for user %q, got: %+v", pki.KubeAPIServerKubeletClientCN, s)
+	}
+}
+
+func TestBindKubeletAPIAdminMasters(t *testing.T) {
+	c := kubeletClientControlplane(t, false)
+	a := &fakeClusterRoleBindingApplier{}
+
+	if err := c.BindKubeletAPIAdmin(a); err != nil {
+		t.Fatalf("Binding kubelet API admin should succeed, got: %v", err)
+	}
+
+	if len(a.bindings) != 0 {
+		t.Fatalf("Binding should not be applied for system:masters certificate, got: %v", a.bindings)
+	}
+}
+
+func TestBindKubeletAPIAdminFail(t *testing.T) {
+	c := kubeletClientControlplane(t, true)
+
+	if err := c.BindKubeletAPIAdmin(&fakeClusterRoleBindingApplier{err: fmt.Errorf("failed")}); err == nil {
+		t.Fatalf("Binding kubelet API admin should fail when applying fails")
+	}
+}
+
+func TestBindKubeletAPIAdminNoCertificate(t *testing.T) {
+	if err := (&Controlplane{}).BindKubeletAPIAdmin(&fakeClusterRoleBindingApplier{}); err == nil {
+		t.Fatalf("Binding kubelet API admin should fail without kubelet client certificate")
+	}
+}
@@ -0,0 +1,151 @@
+package controlplane
+
+import (
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/flexkube/libflexkube/internal/util"
+)
+
+// LeaderElection configures leader election of kube-controller-manager or kube-scheduler.
+// Leader election must be enabled, when running multiple replicas of the component.
+type LeaderElection struct {
+	// Enabled controls, if component should use leader election before executing the main loop.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// LeaseDuration is a duration, which non-leader candidates will wait after observing leadership
+	// renewal before attempting to acquire leadership. If empty, Kubernetes default is used.
+	//
+	// Example value: '15s'.
+	LeaseDuration string `json:"leaseDuration,omitempty"`
+
+	// RenewDeadline is an interval between attempts by the leader to renew leadership before it
+	// stops leading. It must be shorter than LeaseDuration. If empty, Kubernetes default is used.
+	//
+	// Example value: '10s'.
+	RenewDeadline string `json:"renewDeadline,omitempty"`
+
+	// RetryPeriod is a duration the clients should wait between attempting acquisition and renewal
+	// of leadership. It must be shorter than RenewDeadline. If empty, Kubernetes default is used.
+	//
+	// Example value: '2s'.
+	RetryPeriod string `json:"retryPeriod,omitempty"`
+}
+
+// Default leader election durations used by Kubernetes components. They are used for
+// validating ordering of durations, when only some of them are set.
+const (
+	defaultLeaseDuration = 15 * time.Second
+	defaultRenewDeadline = 10 * time.Second
+	defaultRetryPeriod   = 2 * time.Second
+)
+
+// parseDuration parses given duration. If duration is empty, default value is returned.
+func parseDuration(d string, defaultDuration time.Duration) (time.Duration, error) {
+	if d == "" {
+		return defaultDuration, nil
+	}
+
+	return time.ParseDuration(d)
+}
+
+// Validate validates leader election configuration.
+func (l *LeaderElection) Validate() error {
+	if l == nil {
+		return nil
+	}
+
+	var errors util.ValidateError
+
+	lease, err := parseDuration(l.LeaseDuration, defaultLeaseDuration)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("failed parsing lease duration: %w", err))
+	}
+
+	renew, err := parseDuration(l.RenewDeadline, defaultRenewDeadline)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("failed parsing renew deadline: %w", err))
+	}
+
+	retry, err := parseDuration(l.RetryPeriod, defaultRetryPeriod)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("failed parsing retry period: %w", err))
+	}
+
+	if len(errors) > 0 {
+		return errors.Return()
+	}
+
+	if retry <= 0 {
+		errors = append(errors, fmt.Errorf("retry period must be positive"))
+	}
+
+	if renew >= lease {
+		errors = append(errors, fmt.Errorf("renew deadline %s must be shorter than lease duration %s", renew, lease))
+	}
+
+	if retry >= renew {
+		errors = append(errors, fmt.Errorf("retry period %s must be shorter than renew deadline %s", retry, renew))
+	}
+
+	return errors.Return()
+}
+
+// args returns command line flags for configured leader election. If leader election
+// is not configured, no flags are returned.
+func (l *LeaderElection) args() []string {
+	if l == nil {
+		return []string{}
+	}
+
+	args := []string{fmt.Sprintf("--leader-elect=%t", l.Enabled)}
+
+	if l.LeaseDuration != "" {
+		args = append(args, fmt.Sprintf("--leader-elect-lease-duration=%s", l.LeaseDuration))
+	}
+
+	if l.RenewDeadline != "" {
+		args = append(args, fmt.Sprintf("--leader-elect-renew-deadline=%s", l.RenewDeadline))
+	}
+
+	if l.RetryPeriod != "" {
+		args = append(args, fmt.Sprintf("--leader-elect-retry-period=%s", l.RetryPeriod))
+	}
+
+	return args
+}
+
+// leaderElectionConfiguration is a representation of LeaderElectionConfiguration
+// used in component configuration files.
+type leaderElectionConfiguration struct {
+	LeaderElect   bool   `json:"leaderElect"`
+	LeaseDuration string `json:"leaseDuration,omitempty"`
+	RenewDeadline string `json:"renewDeadline,omitempty"`
+	RetryPeriod   string `json:"retryPeriod,omitempty"`
+}
+
+// config returns leaderElection section for component configuration files. If leader
+// election is not configured, empty string is returned.
+func (l *LeaderElection) config() (string, error) {
+	if l == nil {
+		return "", nil
+	}
+
+	c := map[string]leaderElectionConfiguration{
+		"leaderElection": {
+			LeaderElect:   l.Enabled,
+			LeaseDuration: l.LeaseDuration,
+			RenewDeadline: l.RenewDeadline,
+			RetryPeriod:   l.RetryPeriod,
+		},
+	}
+
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed serializing leader election configuration: %w", err)
+	}
+
+	return string(b), nil
+}
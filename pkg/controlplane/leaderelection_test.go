@@ -0,0 +1,103 @@
+package controlplane
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// Validate() tests.
+func TestLeaderElectionValidate(t *testing.T) {
+	cases := map[string]struct {
+		LeaderElection *LeaderElection
+		Error          bool
+	}{
+		"nil": {
+			LeaderElection: nil,
+			Error:          false,
+		},
+		"defaults": {
+			LeaderElection: &LeaderElection{Enabled: true},
+			Error:          false,
+		},
+		"valid": {
+			LeaderElection: &LeaderElection{
+				Enabled:       true,
+				LeaseDuration: "30s",
+				RenewDeadline: "20s",
+				RetryPeriod:   "5s",
+			},
+			Error: false,
+		},
+		"invalid duration": {
+			LeaderElection: &LeaderElection{LeaseDuration: "foo"},
+			Error:          true,
+		},
+		"renew deadline longer than lease duration": {
+			LeaderElection: &LeaderElection{LeaseDuration: "10s", RenewDeadline: "15s"},
+			Error:          true,
+		},
+		"renew deadline longer than default lease duration": {
+			LeaderElection: &LeaderElection{RenewDeadline: "20s"},
+			Error:          true,
+		},
+		"retry period longer than renew deadline": {
+			LeaderElection: &LeaderElection{RetryPeriod: "12s"},
+			Error:          true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			err := c.LeaderElection.Validate()
+			if !c.Error && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if c.Error && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+// args() tests.
+func TestLeaderElectionArgs(t *testing.T) {
+	var l *LeaderElection
+
+	if a := l.args(); len(a) != 0 {
+		t.Fatalf("no flags should be returned when leader election is not configured, got: %v", a)
+	}
+
+	l = &LeaderElection{
+		Enabled:       true,
+		LeaseDuration: "30s",
+	}
+
+	expected := []string{"--leader-elect=true", "--leader-elect-lease-duration=30s"}
+
+	if diff := cmp.Diff(expected, l.args()); diff != "" {
+		t.Fatalf("unexpected flags: %s", diff)
+	}
+}
+
+// config() tests.
+func TestLeaderElectionConfig(t *testing.T) {
+	l := &LeaderElection{
+		RenewDeadline: "5s",
+	}
+
+	c, err := l.config()
+	if err != nil {
+		t.Fatalf("rendering config should succeed, got: %v", err)
+	}
+
+	for _, e := range []string{"leaderElection:", "leaderElect: false", "renewDeadline: 5s"} {
+		if !strings.Contains(c, e) {
+			t.Fatalf("config should contain %q, got: %s", e, c)
+		}
+	}
+}
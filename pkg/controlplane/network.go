@@ -0,0 +1,90 @@
+package controlplane
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/flexkube/libflexkube/internal/util"
+)
+
+// parseCIDRs parses given comma separated list of CIDRs, as accepted by Kubernetes
+// components for dual-stack configuration, e.g. '10.96.0.0/12,fd00::/108'.
+func parseCIDRs(cidrs string) ([]*net.IPNet, error) {
+	nets := []*net.IPNet{}
+
+	for _, cidr := range strings.Split(cidrs, ",") {
+		_, n, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, err
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+// overlaps checks, if two given networks share any addresses.
+func overlaps(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// validateCIDRsOverlap returns error, if any of networks from given CIDR lists overlap.
+// Both lists must be valid.
+func validateCIDRsOverlap(aName, a, bName, b string) error {
+	aNets, _ := parseCIDRs(a)
+	bNets, _ := parseCIDRs(b)
+
+	var errors util.ValidateError
+
+	for _, an := range aNets {
+		for _, bn := range bNets {
+			if overlaps(an, bn) {
+				errors = append(errors, fmt.Errorf("%s %s overlaps with %s %s", aName, an, bName, bn))
+			}
+		}
+	}
+
+	return errors.Return()
+}
+
+// validateNetworkCIDRs verifies, that service CIDR does not overlap with pod CIDR. Overlapping
+// ranges cause routing failures, which are hard to debug after deployment. CIDRs are validated
+// by each component, so invalid ones are skipped here.
+//
+// This method must be called after components configuration is built.
+func (c *Controlplane) validateNetworkCIDRs() error {
+	podCIDR := c.KubeControllerManager.PodCIDR
+	if _, err := parseCIDRs(podCIDR); err != nil {
+		return nil
+	}
+
+	serviceCIDRs := []struct {
+		name string
+		cidr string
+	}{
+		{
+			name: "kube-apiserver service CIDR",
+			cidr: c.KubeAPIServer.ServiceCIDR,
+		},
+		{
+			name: "kube-controller-manager service CIDR",
+			cidr: c.KubeControllerManager.ServiceCIDR,
+		},
+	}
+
+	var errors util.ValidateError
+
+	for _, s := range serviceCIDRs {
+		if _, err := parseCIDRs(s.cidr); err != nil {
+			continue
+		}
+
+		if err := validateCIDRsOverlap(s.name, s.cidr, "pod CIDR", podCIDR); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	return errors.Return()
+}
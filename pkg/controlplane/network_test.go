@@ -0,0 +1,85 @@
+package controlplane
+
+import (
+	"strings"
+	"testing"
+)
+
+// parseCIDRs() tests.
+func TestParseCIDRs(t *testing.T) {
+	nets, err := parseCIDRs("10.96.0.0/12, fd00::/108")
+	if err != nil {
+		t.Fatalf("Parsing valid dual-stack CIDRs should succeed, got: %v", err)
+	}
+
+	if len(nets) != 2 {
+		t.Fatalf("Expected 2 networks, got %d", len(nets))
+	}
+}
+
+func TestParseCIDRsInvalid(t *testing.T) {
+	for _, c := range []string{"", "foo", "10.96.0.0/12,", "10.0.0.1"} {
+		if _, err := parseCIDRs(c); err == nil {
+			t.Errorf("Parsing %q should fail", c)
+		}
+	}
+}
+
+// validateNetworkCIDRs() tests.
+func TestValidateNetworkCIDRs(t *testing.T) {
+	cases := map[string]struct {
+		kasServiceCIDR string
+		kcmServiceCIDR string
+		podCIDR        string
+		err            bool
+	}{
+		"not set":            {},
+		"no pod CIDR":        {kasServiceCIDR: "10.96.0.0/12"},
+		"disjoint":           {kasServiceCIDR: "10.96.0.0/12", kcmServiceCIDR: "10.96.0.0/12", podCIDR: "10.1.0.0/16"},
+		"invalid is skipped": {kasServiceCIDR: "foo", podCIDR: "10.1.0.0/16"},
+		"pod CIDR contains service CIDR": {
+			kasServiceCIDR: "10.96.0.0/12",
+			podCIDR:        "10.0.0.0/8",
+			err:            true,
+		},
+		"service CIDR contains pod CIDR": {
+			kcmServiceCIDR: "10.0.0.0/8",
+			podCIDR:        "10.1.0.0/16",
+			err:            true,
+		},
+		"dual-stack overlap": {
+			kasServiceCIDR: "10.96.0.0/12,fd00::/108",
+			podCIDR:        "10.1.0.0/16,fd00::/64",
+			err:            true,
+		},
+	}
+
+	for n, tc := range cases {
+		tc := tc
+
+		t.Run(n, func(t *testing.T) {
+			c := &Controlplane{
+				KubeAPIServer: KubeAPIServer{
+					ServiceCIDR: tc.kasServiceCIDR,
+				},
+				KubeControllerManager: KubeControllerManager{
+					ServiceCIDR: tc.kcmServiceCIDR,
+					PodCIDR:     tc.podCIDR,
+				},
+			}
+
+			err := c.validateNetworkCIDRs()
+			if !tc.err && err != nil {
+				t.Fatalf("Didn't expect error, got: %v", err)
+			}
+
+			if tc.err && err == nil {
+				t.Fatalf("Expected error")
+			}
+
+			if tc.err && !strings.Contains(err.Error(), "overlaps with pod CIDR") {
+				t.Fatalf("Error should describe overlapping CIDRs, got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package controlplane
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/flexkube/libflexkube/internal/util"
+	"github.com/flexkube/libflexkube/pkg/container"
+)
+
+// portFlags is a list of flags of controlplane components, which configure TCP ports, where
+// component serves API, health and metrics endpoints. Those ports are bound on the host only,
+// if the component runs in host network.
+var portFlags = []string{
+	"--secure-port",
+	"--port",
+}
+
+// hostPort is a port bound by a controlplane component on the host.
+type hostPort struct {
+	// host is an identifier of the host, where the port is bound.
+	host string
+
+	// ip is an address, on which port is bound. Empty value means all addresses.
+	ip string
+
+	// port is a port number.
+	port int
+
+	// protocol is a protocol of the port, e.g. 'tcp'.
+	protocol string
+
+	// component is a name of the component binding the port.
+	component string
+}
+
+// overlaps checks, if two ports can't be bound at the same time on the same host.
+func (p hostPort) overlaps(o hostPort) bool {
+	if p.host != o.host || p.port != o.port || p.protocol != o.protocol {
+		return false
+	}
+
+	return p.ip == o.ip || isAnyAddress(p.ip) || isAnyAddress(o.ip)
+}
+
+// isAnyAddress checks, if given address means binding on all addresses.
+func isAnyAddress(ip string) bool {
+	return ip == "" || ip == "0.0.0.0" || ip == "::"
+}
+
+// componentHostPorts returns ports, which given controlplane component binds on the host. This includes
+// ports published from the container and, when running in host network, ports configured via flags.
+// Ports set to 0 are disabled, so they are ignored.
+func componentHostPorts(component string, hcc *container.HostConfiguredContainer) ([]hostPort, error) {
+	h := hcc.Host.ID()
+	ports := []hostPort{}
+
+	for _, p := range hcc.Container.Config.Ports {
+		if p.Port == 0 {
+			continue
+		}
+
+		ports = append(ports, hostPort{
+			host:      h,
+			ip:        p.IP,
+			port:      p.Port,
+			protocol:  util.PickString(p.Protocol, "tcp"),
+			component: component,
+		})
+	}
+
+	if hcc.Container.Config.NetworkMode != "host" {
+		return ports, nil
+	}
+
+	for _, f := range portFlags {
+		v, ok := flagValue(hcc.Container.Config.Args, f)
+		if !ok {
+			continue
+		}
+
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed parsing value %q of flag %q: %w", component, v, f, err)
+		}
+
+		if port == 0 {
+			continue
+		}
+
+		ports = append(ports, hostPort{
+			host:      h,
+			port:      port,
+			protocol:  "tcp",
+			component: component,
+		})
+	}
+
+	return ports, nil
+}
+
+// validateHostPorts checks, that no two controlplane components from given containers state
+// bind the same port on the same host, as then the component created later fails to start.
+func validateHostPorts(cs container.ContainersState) error {
+	var errors util.ValidateError
+
+	bound := []hostPort{}
+
+	for _, n := range components {
+		hcc, ok := cs[n]
+		if !ok {
+			continue
+		}
+
+		ports, err := componentHostPorts(n, hcc)
+		if err != nil {
+			errors = append(errors, err)
+
+			continue
+		}
+
+		for _, p := range ports {
+			for _, b := range bound {
+				if b.component != p.component && b.overlaps(p) {
+					errors = append(errors, fmt.Errorf("%s port %d on host %s is bound by both %s and %s",
+						p.protocol, p.port, p.host, b.component, p.component))
+				}
+			}
+		}
+
+		bound = append(bound, ports...)
+	}
+
+	return errors.Return()
+}
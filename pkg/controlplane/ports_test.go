@@ -0,0 +1,148 @@
+package controlplane
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/flexkube/libflexkube/pkg/container"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+	"github.com/flexkube/libflexkube/pkg/host"
+	"github.com/flexkube/libflexkube/pkg/host/transport/ssh"
+)
+
+func portsTestContainer(address string, config types.ContainerConfig) *container.HostConfiguredContainer {
+	return &container.HostConfiguredContainer{
+		Host: host.Host{
+			SSHConfig: &ssh.Config{
+				Address: address,
+				Port:    22,
+			},
+		},
+		Container: container.Container{
+			Config: config,
+		},
+	}
+}
+
+// validateHostPorts() tests.
+func TestValidateHostPorts(t *testing.T) {
+	t.Parallel()
+
+	kas := types.ContainerConfig{
+		Ports: []types.PortMap{
+			{
+				IP:       "0.0.0.0",
+				Port:     10257,
+				Protocol: "tcp",
+			},
+		},
+	}
+
+	kcm := types.ContainerConfig{
+		NetworkMode: "host",
+		Args:        []string{"kube-controller-manager", "--secure-port=10257"},
+	}
+
+	cases := map[string]struct {
+		state container.ContainersState
+		err   bool
+	}{
+		"secure port collides with host network component": {
+			state: container.ContainersState{
+				"kube-apiserver":          portsTestContainer("foo", kas),
+				"kube-controller-manager": portsTestContainer("foo", kcm),
+			},
+			err: true,
+		},
+		"same port on different hosts": {
+			state: container.ContainersState{
+				"kube-apiserver":          portsTestContainer("foo", kas),
+				"kube-controller-manager": portsTestContainer("bar", kcm),
+			},
+		},
+		"flag without host network": {
+			state: container.ContainersState{
+				"kube-apiserver": portsTestContainer("foo", kas),
+				"kube-controller-manager": portsTestContainer("foo", types.ContainerConfig{
+					Args: []string{"--secure-port=10257"},
+				}),
+			},
+		},
+		"disabled port": {
+			state: container.ContainersState{
+				"kube-controller-manager": portsTestContainer("foo", types.ContainerConfig{
+					NetworkMode: "host",
+					Args:        []string{"--port=0"},
+				}),
+				"kube-scheduler": portsTestContainer("foo", types.ContainerConfig{
+					NetworkMode: "host",
+					Args:        []string{"--port=0"},
+				}),
+			},
+		},
+		"different addresses": {
+			state: container.ContainersState{
+				"kube-apiserver": portsTestContainer("foo", types.ContainerConfig{
+					Ports: []types.PortMap{{IP: "10.0.0.1", Port: 6443, Protocol: "tcp"}},
+				}),
+				"kube-scheduler": portsTestContainer("foo", types.ContainerConfig{
+					Ports: []types.PortMap{{IP: "10.0.0.2", Port: 6443, Protocol: "tcp"}},
+				}),
+			},
+		},
+		"malformed port flag": {
+			state: container.ContainersState{
+				"kube-scheduler": portsTestContainer("foo", types.ContainerConfig{
+					NetworkMode: "host",
+					Args:        []string{"--secure-port=foo"},
+				}),
+			},
+			err: true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateHostPorts(c.state)
+			if c.err && err == nil {
+				t.Fatalf("Expected error")
+			}
+
+			if !c.err && err != nil {
+				t.Fatalf("Didn't expect error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateHostPortsNamesComponents(t *testing.T) {
+	t.Parallel()
+
+	hostNetwork := func(port string) types.ContainerConfig {
+		return types.ContainerConfig{
+			NetworkMode: "host",
+			Args:        []string{"--secure-port=" + port},
+		}
+	}
+
+	cs := container.ContainersState{
+		"kube-apiserver":          portsTestContainer("foo", hostNetwork("10259")),
+		"kube-controller-manager": portsTestContainer("foo", hostNetwork("10259")),
+		"kube-scheduler":          portsTestContainer("foo", hostNetwork("10259")),
+	}
+
+	err := validateHostPorts(cs)
+	if err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	for _, s := range []string{"kube-apiserver and kube-controller-manager", "kube-controller-manager and kube-scheduler", "10259"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("Error should contain %q, got: %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package controlplane
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/flexkube/libflexkube/pkg/container"
+	"github.com/flexkube/libflexkube/pkg/kubernetes/client"
+)
+
+// deployResultResource is a resource name, under which controlplane deploy results are recorded.
+const deployResultResource = "controlplane"
+
+// deploy deploys the controlplane and updates the state.
+func (c *Controlplane) deploy() error {
+	r, err := c.New()
+	if err != nil {
+		return fmt.Errorf("failed to create controlplane: %w", err)
+	}
+
+	if err := r.CheckCurrentState(); err != nil {
+		return fmt.Errorf("failed checking current state: %w", err)
+	}
+
+	deployErr := r.Deploy()
+
+	// Update state even if deployment failed, so information about created containers is not lost.
+	s := r.Containers().ToExported().PreviousState
+	c.State = &s
+
+	if deployErr != nil {
+		return fmt.Errorf("failed deploying controlplane: %w", deployErr)
+	}
+
+	return nil
+}
+
+// DeployAndRecord deploys the controlplane, updates the state and records the outcome
+// of the deployment in the cluster using given client, both as a Kubernetes Event and in
+// the well-known ConfigMap. See client.DeployResultsConfigMap for details.
+//
+// Recording is best-effort. If the cluster is not reachable, warning is logged and only
+// the deployment error, if any, is returned.
+func (c *Controlplane) DeployAndRecord(kc client.Client) error {
+	var previous container.ContainersState
+	if c.State != nil {
+		previous = *c.State
+	}
+
+	deployErr := c.deploy()
+
+	if kc == nil {
+		return deployErr
+	}
+
+	var current container.ContainersState
+	if c.State != nil {
+		current = *c.State
+	}
+
+	r := client.DeployResult{
+		Resource: deployResultResource,
+		Changed:  changedComponents(previous, current),
+		Time:     time.Now(),
+	}
+
+	if deployErr != nil {
+		r.Error = deployErr.Error()
+	}
+
+	if err := kc.RecordDeployResult(r); err != nil {
+		c.logger().Log(container.LogEntry{
+			Level:   container.LogLevelWarning,
+			Message: fmt.Sprintf("Failed recording deploy result in the cluster: %v", err),
+		})
+	}
+
+	return deployErr
+}
+
+// logger returns configured logger or logger writing to standard output, if logger is not
+// configured.
+func (c *Controlplane) logger() container.Logger {
+	if c.Logger == nil {
+		return container.NewWriterLogger(os.Stdout)
+	}
+
+	return c.Logger
+}
+
+// changedComponents returns sorted names of containers, which configuration differs between
+// given states. Status of the containers is ignored, as it changes also without deployment,
+// e.g. when container gets restarted by the runtime.
+func changedComponents(previous, current container.ContainersState) []string {
+	changed := []string{}
+
+	for n, hcc := range current {
+		if !equalContainers(previous[n], hcc) {
+			changed = append(changed, n)
+		}
+	}
+
+	for n := range previous {
+		if _, ok := current[n]; !ok {
+			changed = append(changed, n)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
+
+// equalContainers compares serialized configuration of given containers, as this is what gets
+// persisted in the state. Status of the containers is not compared.
+func equalContainers(a, b *container.HostConfiguredContainer) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	ay, aErr := yaml.Marshal(withoutStatus(*a))
+	by, bErr := yaml.Marshal(withoutStatus(*b))
+
+	return aErr == nil && bErr == nil && string(ay) == string(by)
+}
+
+// withoutStatus returns given container with status removed.
+func withoutStatus(hcc container.HostConfiguredContainer) container.HostConfiguredContainer {
+	hcc.Container.Status = nil
+
+	return hcc
+}
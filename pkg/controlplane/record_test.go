@@ -0,0 +1,72 @@
+package controlplane
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/flexkube/libflexkube/pkg/container"
+	"github.com/flexkube/libflexkube/pkg/container/types"
+)
+
+func testContainer(image string) *container.HostConfiguredContainer {
+	return &container.HostConfiguredContainer{
+		Container: container.Container{
+			Config: types.ContainerConfig{
+				Name:  "foo",
+				Image: image,
+			},
+		},
+	}
+}
+
+func TestChangedComponents(t *testing.T) {
+	previous := container.ContainersState{
+		"kube-apiserver":          testContainer("foo"),
+		"kube-controller-manager": testContainer("foo"),
+		"kube-scheduler":          testContainer("foo"),
+	}
+
+	current := container.ContainersState{
+		"kube-apiserver":          testContainer("foo"),
+		"kube-controller-manager": testContainer("bar"),
+		"foo":                     testContainer("foo"),
+	}
+
+	expected := []string{"foo", "kube-controller-manager", "kube-scheduler"}
+
+	if diff := cmp.Diff(expected, changedComponents(previous, current)); diff != "" {
+		t.Fatalf("Unexpected changed components: %s", diff)
+	}
+}
+
+func TestChangedComponentsIgnoreStatus(t *testing.T) {
+	previous := container.ContainersState{
+		"kube-apiserver": testContainer("foo"),
+	}
+
+	current := container.ContainersState{
+		"kube-apiserver": testContainer("foo"),
+	}
+
+	previous["kube-apiserver"].Container.Status = &types.ContainerStatus{ID: "foo", RestartCount: 1}
+	current["kube-apiserver"].Container.Status = &types.ContainerStatus{ID: "bar", RestartCount: 2}
+
+	if c := changedComponents(previous, current); len(c) != 0 {
+		t.Fatalf("Changes of container status should be ignored, got: %v", c)
+	}
+
+	if previous["kube-apiserver"].Container.Status.ID != "foo" {
+		t.Fatalf("Comparing containers should not modify them")
+	}
+}
+
+func TestChangedComponentsNoChanges(t *testing.T) {
+	s := container.ContainersState{
+		"kube-apiserver": testContainer("foo"),
+	}
+
+	if c := changedComponents(s, s); len(c) != 0 {
+		t.Fatalf("No components should be changed, got: %v", c)
+	}
+}
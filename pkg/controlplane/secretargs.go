@@ -0,0 +1,77 @@
+package controlplane
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/flexkube/libflexkube/internal/util"
+)
+
+// secretsDir is a directory name, where content of secret arguments is written.
+const secretsDir = "secrets"
+
+// secretFileFlags is a curated list of flags of controlplane components, which take a path
+// to a file with possibly sensitive content. Such flags can be set using SecretArgs, so content
+// is written to a file instead of being passed via command line, where it's visible to all users
+// on the host, e.g. via 'ps'.
+var secretFileFlags = map[string][]string{
+	"kube-apiserver": {
+		"--admission-control-config-file",
+		"--audit-webhook-config-file",
+		"--authentication-token-webhook-config-file",
+		"--authorization-webhook-config-file",
+		"--token-auth-file",
+	},
+	"kube-controller-manager": {
+		"--cloud-config",
+	},
+}
+
+// isSecretFileFlag checks, if given flag of given component can be used as secret argument.
+func isSecretFileFlag(component, flag string) bool {
+	for _, f := range secretFileFlags[component] {
+		if f == flag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateSecretArgs checks, if all given secret arguments are supported by given component.
+func validateSecretArgs(component string, args map[string]string) error {
+	var errors util.ValidateError
+
+	for f, v := range args {
+		if !isSecretFileFlag(component, f) {
+			errors = append(errors, fmt.Errorf("flag %q can't be used as secret argument for %s, supported flags: %s",
+				f, component, strings.Join(secretFileFlags[component], ", ")))
+		}
+
+		if v == "" {
+			errors = append(errors, fmt.Errorf("content of secret argument %q must not be empty", f))
+		}
+	}
+
+	return errors.Return()
+}
+
+// secretArgsFiles returns configuration files with content of given secret arguments, placed in
+// given host directory and flags pointing to those files mounted in given container directory.
+func secretArgsFiles(args map[string]string, hostDir, containerDir string) (map[string]string, []string) {
+	files := map[string]string{}
+	flags := []string{}
+
+	for f, v := range args {
+		n := strings.TrimPrefix(f, "--")
+
+		files[path.Join(hostDir, secretsDir, n)] = v
+		flags = append(flags, fmt.Sprintf("%s=%s", f, path.Join(containerDir, secretsDir, n)))
+	}
+
+	sort.Strings(flags)
+
+	return files, flags
+}
@@ -0,0 +1,85 @@
+package controlplane
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// validateSecretArgs() tests.
+func TestValidateSecretArgs(t *testing.T) {
+	cases := map[string]struct {
+		Component string
+		Args      map[string]string
+		Error     bool
+	}{
+		"nil": {
+			Component: "kube-apiserver",
+			Args:      nil,
+			Error:     false,
+		},
+		"supported flag": {
+			Component: "kube-apiserver",
+			Args:      map[string]string{"--token-auth-file": "foo"},
+			Error:     false,
+		},
+		"flag not supported by component": {
+			Component: "kube-controller-manager",
+			Args:      map[string]string{"--token-auth-file": "foo"},
+			Error:     true,
+		},
+		"unknown flag": {
+			Component: "kube-apiserver",
+			Args:      map[string]string{"--foo": "bar"},
+			Error:     true,
+		},
+		"empty content": {
+			Component: "kube-apiserver",
+			Args:      map[string]string{"--token-auth-file": ""},
+			Error:     true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			err := validateSecretArgs(c.Component, c.Args)
+			if !c.Error && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if c.Error && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+// secretArgsFiles() tests.
+func TestSecretArgsFiles(t *testing.T) {
+	args := map[string]string{
+		"--token-auth-file":                          "foo",
+		"--authentication-token-webhook-config-file": "bar",
+	}
+
+	files, flags := secretArgsFiles(args, "/host", "/container")
+
+	expectedFiles := map[string]string{
+		"/host/secrets/token-auth-file":                          "foo",
+		"/host/secrets/authentication-token-webhook-config-file": "bar",
+	}
+
+	if diff := cmp.Diff(expectedFiles, files); diff != "" {
+		t.Errorf("unexpected files: %s", diff)
+	}
+
+	expectedFlags := []string{
+		"--authentication-token-webhook-config-file=/container/secrets/authentication-token-webhook-config-file",
+		"--token-auth-file=/container/secrets/token-auth-file",
+	}
+
+	if diff := cmp.Diff(expectedFlags, flags); diff != "" {
+		t.Errorf("unexpected flags: %s", diff)
+	}
+}
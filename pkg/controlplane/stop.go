@@ -0,0 +1,79 @@
+package controlplane
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flexkube/libflexkube/internal/util"
+)
+
+const (
+	// defaultStopTimeout is how long controlplane components have to gracefully stop,
+	// before they get killed.
+	defaultStopTimeout = 10 * time.Second
+
+	// kubeAPIServerStopTimeout is how long kube-apiserver has to gracefully stop, before
+	// it gets killed. It is longer than for other components, so in-flight requests can
+	// be drained.
+	kubeAPIServerStopTimeout = 60 * time.Second
+)
+
+// Stop configures, how controlplane component container is stopped. Container first
+// receives stop signal and if it does not stop within the timeout, it is killed.
+type Stop struct {
+	// Signal is a signal, which will be sent to the container to gracefully stop it.
+	// If empty, SIGTERM is used.
+	//
+	// Example value: 'SIGINT'.
+	Signal string `json:"signal,omitempty"`
+
+	// Timeout is a duration, for which container runtime waits for the container to stop
+	// after sending the stop signal, before killing it. If empty, component specific default
+	// is used, which is longer for kube-apiserver than for other components.
+	//
+	// Example value: '30s'.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Validate validates stop configuration.
+func (s *Stop) Validate() error {
+	if s == nil {
+		return nil
+	}
+
+	var errors util.ValidateError
+
+	if s.Timeout != "" {
+		t, err := time.ParseDuration(s.Timeout)
+
+		switch {
+		case err != nil:
+			errors = append(errors, fmt.Errorf("failed parsing timeout: %w", err))
+		case t < time.Second:
+			errors = append(errors, fmt.Errorf("timeout must be at least 1s, got %s", t))
+		}
+	}
+
+	return errors.Return()
+}
+
+// signal returns configured stop signal or the default one.
+func (s *Stop) signal() string {
+	if s == nil || s.Signal == "" {
+		return defaultStopSignal
+	}
+
+	return s.Signal
+}
+
+// timeoutSeconds returns configured stop timeout in seconds or given default timeout,
+// if timeout is not configured. Configuration must be validated before calling it.
+func (s *Stop) timeoutSeconds(defaultTimeout time.Duration) int {
+	t := defaultTimeout
+
+	if s != nil && s.Timeout != "" {
+		t, _ = time.ParseDuration(s.Timeout)
+	}
+
+	return int(t / time.Second)
+}
@@ -0,0 +1,91 @@
+package controlplane
+
+import (
+	"testing"
+	"time"
+)
+
+// Validate() tests.
+func TestStopValidate(t *testing.T) {
+	cases := map[string]struct {
+		Stop  *Stop
+		Error bool
+	}{
+		"nil": {
+			Stop:  nil,
+			Error: false,
+		},
+		"valid": {
+			Stop: &Stop{
+				Signal:  "SIGINT",
+				Timeout: "30s",
+			},
+			Error: false,
+		},
+		"malformed timeout": {
+			Stop: &Stop{
+				Timeout: "foo",
+			},
+			Error: true,
+		},
+		"too short timeout": {
+			Stop: &Stop{
+				Timeout: "500ms",
+			},
+			Error: true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			err := c.Stop.Validate()
+			if !c.Error && err != nil {
+				t.Fatalf("Didn't expect error, got: %v", err)
+			}
+
+			if c.Error && err == nil {
+				t.Fatalf("Expected error")
+			}
+		})
+	}
+}
+
+// signal() tests.
+func TestStopSignalDefault(t *testing.T) {
+	var s *Stop
+
+	if sig := s.signal(); sig != defaultStopSignal {
+		t.Fatalf("Expected default stop signal %q, got %q", defaultStopSignal, sig)
+	}
+}
+
+func TestStopSignal(t *testing.T) {
+	s := &Stop{
+		Signal: "SIGINT",
+	}
+
+	if sig := s.signal(); sig != "SIGINT" {
+		t.Fatalf("Expected configured stop signal, got %q", sig)
+	}
+}
+
+// timeoutSeconds() tests.
+func TestStopTimeoutSecondsDefault(t *testing.T) {
+	s := &Stop{}
+
+	if ts := s.timeoutSeconds(time.Minute); ts != 60 {
+		t.Fatalf("Expected default timeout of 60 seconds, got %d", ts)
+	}
+}
+
+func TestStopTimeoutSeconds(t *testing.T) {
+	s := &Stop{
+		Timeout: "2m",
+	}
+
+	if ts := s.timeoutSeconds(time.Second); ts != 120 {
+		t.Fatalf("Expected configured timeout of 120 seconds, got %d", ts)
+	}
+}
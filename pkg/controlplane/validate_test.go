@@ -55,3 +55,13 @@ func TestValidateMarshalFail(t *testing.T) {
 		t.Fatalf("validating unmarshalable struct should fail")
 	}
 }
+
+func TestValidateBadExtraCACertificate(t *testing.T) {
+	v := validValidator(t)
+
+	v.Common.ExtraCACertificates = []types.Certificate{"foo"}
+
+	if err := v.validate(true); err == nil {
+		t.Fatalf("validating common with malformed extra CA certificate should fail")
+	}
+}
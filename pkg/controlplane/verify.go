@@ -0,0 +1,282 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flexkube/libflexkube/internal/util"
+)
+
+const (
+	// defaultVerifyTimeout is a default maximum time of waiting for single verification
+	// check to pass.
+	defaultVerifyTimeout = time.Minute
+
+	// verifyPollInterval is a time between attempts of failing verification check.
+	verifyPollInterval = 2 * time.Second
+
+	// leaderElectionNamespace is a namespace, where kube-controller-manager and kube-scheduler
+	// keep their leader election leases.
+	leaderElectionNamespace = "kube-system"
+)
+
+// ClusterChecker represents capability of checking, if Kubernetes cluster functions. It is
+// implemented by Kubernetes client from client package.
+type ClusterChecker interface {
+	APIServerReady(ctx context.Context) error
+	NodeNames(ctx context.Context) ([]string, error)
+	LeaseRenewTime(ctx context.Context, namespace, name string) (time.Time, error)
+}
+
+// VerifyCheck is a result of a single controlplane verification check.
+type VerifyCheck struct {
+	// Name is a name of the check.
+	Name string `json:"name"`
+
+	// Passed indicates, if the check passed.
+	Passed bool `json:"passed"`
+
+	// Skipped indicates, that the check has not been performed, as it does not apply to
+	// the controlplane configuration. Skipped checks are not considered failed.
+	Skipped bool `json:"skipped,omitempty"`
+
+	// Message contains the details of the check result or the reason of failure.
+	Message string `json:"message,omitempty"`
+}
+
+// VerifyReport is a list of results of controlplane verification checks.
+type VerifyReport []VerifyCheck
+
+// String returns human readable report with one line per check.
+func (r VerifyReport) String() string {
+	lines := []string{}
+
+	for _, c := range r {
+		result := "FAIL"
+
+		switch {
+		case c.Skipped:
+			result = "SKIP"
+		case c.Passed:
+			result = "PASS"
+		}
+
+		lines = append(lines, fmt.Sprintf("%s %s: %s", result, c.Name, c.Message))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// verifyTimeout returns parsed verification timeout or the default one.
+func (c *Controlplane) verifyTimeout() (time.Duration, error) {
+	if c.VerifyTimeout == "" {
+		return defaultVerifyTimeout, nil
+	}
+
+	d, err := time.ParseDuration(c.VerifyTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("failed parsing verify timeout %q: %w", c.VerifyTimeout, err)
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("verify timeout must be positive")
+	}
+
+	return d, nil
+}
+
+// poll calls given check until it succeeds or given context expires. If check never
+// succeeds, last error is returned.
+func poll(ctx context.Context, check func(context.Context) error) error {
+	for {
+		err := check(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(verifyPollInterval):
+		}
+	}
+}
+
+// checkAPIServerReady verifies, that API server reports readiness.
+func checkAPIServerReady(ctx context.Context, cc ClusterChecker) (string, error) {
+	if err := poll(ctx, cc.APIServerReady); err != nil {
+		return "", err
+	}
+
+	return "API server is ready", nil
+}
+
+// checkListNodes verifies, that Node objects can be listed.
+func checkListNodes(ctx context.Context, cc ClusterChecker) (string, error) {
+	var nodes []string
+
+	if err := poll(ctx, func(ctx context.Context) error {
+		n, err := cc.NodeNames(ctx)
+		nodes = n
+
+		return err
+	}); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("listed %d nodes", len(nodes)), nil
+}
+
+// checkLeaseRenewed verifies, that leader of given component is alive, by waiting until it
+// renews it's leader election lease. Only renew times reported by the cluster are compared,
+// so the check is not affected by clock skew between the cluster and the local machine.
+func checkLeaseRenewed(ctx context.Context, cc ClusterChecker, component string) (string, error) {
+	var first time.Time
+
+	if err := poll(ctx, func(ctx context.Context) error {
+		t, err := cc.LeaseRenewTime(ctx, leaderElectionNamespace, component)
+		first = t
+
+		return err
+	}); err != nil {
+		return "", fmt.Errorf("failed getting leader election lease: %w", err)
+	}
+
+	var renewed time.Time
+
+	if err := poll(ctx, func(ctx context.Context) error {
+		t, err := cc.LeaseRenewTime(ctx, leaderElectionNamespace, component)
+		if err != nil {
+			return err
+		}
+
+		if !t.After(first) {
+			return fmt.Errorf("leader election lease has not been renewed since %s", first.Format(time.RFC3339))
+		}
+
+		renewed = t
+
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("leader renewed the lease at %s", renewed.Format(time.RFC3339)), nil
+}
+
+// verifyCheck is a single verification check.
+type verifyCheck struct {
+	// name is a name of the check.
+	name string
+
+	// skipReason is set, when check should be skipped.
+	skipReason string
+
+	// run performs the check and returns the details of successful result.
+	run func(context.Context) (string, error)
+}
+
+// verifyChecks returns all verification checks for the controlplane, in the order they
+// should be executed.
+func (c *Controlplane) verifyChecks(cc ClusterChecker) []verifyCheck {
+	checks := []verifyCheck{
+		{
+			name: "kube-apiserver ready",
+			run: func(ctx context.Context) (string, error) {
+				return checkAPIServerReady(ctx, cc)
+			},
+		},
+		{
+			name: "list nodes",
+			run: func(ctx context.Context) (string, error) {
+				return checkListNodes(ctx, cc)
+			},
+		},
+	}
+
+	leaderElections := map[string]*LeaderElection{
+		"kube-controller-manager": c.KubeControllerManager.LeaderElection,
+		"kube-scheduler":          c.KubeScheduler.LeaderElection,
+	}
+
+	for _, component := range []string{"kube-controller-manager", "kube-scheduler"} {
+		component := component
+
+		vc := verifyCheck{
+			name: fmt.Sprintf("%s running", component),
+			run: func(ctx context.Context) (string, error) {
+				return checkLeaseRenewed(ctx, cc, component)
+			},
+		}
+
+		// Without leader election, there is no lease to observe.
+		if l := leaderElections[component]; l != nil && !l.Enabled {
+			vc.skipReason = "leader election is disabled"
+		}
+
+		checks = append(checks, vc)
+	}
+
+	return checks
+}
+
+// Verify checks, that deployed controlplane functions end-to-end, using given client, which
+// should be created from admin kubeconfig. It verifies, that API server reports readiness,
+// that Node objects can be listed and that kube-controller-manager and kube-scheduler are
+// running, by observing renewals of their leader election leases. If leader election is
+// disabled for the component, it's check is skipped.
+//
+// Each check is retried until it passes or VerifyTimeout elapses. Report with results of all
+// checks is returned. If any of the checks fails, error listing failed checks is returned as well.
+func (c *Controlplane) Verify(cc ClusterChecker) (VerifyReport, error) {
+	if c.Destroy || c.Suspended {
+		return nil, fmt.Errorf("can't verify destroyed or suspended controlplane")
+	}
+
+	timeout, err := c.verifyTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	report := VerifyReport{}
+
+	var errors util.ValidateError
+
+	for _, vc := range c.verifyChecks(cc) {
+		if vc.skipReason != "" {
+			report = append(report, VerifyCheck{
+				Name:    vc.name,
+				Skipped: true,
+				Message: vc.skipReason,
+			})
+
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		message, err := vc.run(ctx)
+
+		cancel()
+
+		if err != nil {
+			report = append(report, VerifyCheck{
+				Name:    vc.name,
+				Message: err.Error(),
+			})
+
+			errors = append(errors, fmt.Errorf("check %q failed: %w", vc.name, err))
+
+			continue
+		}
+
+		report = append(report, VerifyCheck{
+			Name:    vc.name,
+			Passed:  true,
+			Message: message,
+		})
+	}
+
+	return report, errors.Return()
+}
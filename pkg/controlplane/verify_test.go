@@ -0,0 +1,185 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeClusterChecker struct {
+	readyErr  error
+	nodes     []string
+	nodesErr  error
+	renewing  bool
+	leaseCall int
+}
+
+func (f *fakeClusterChecker) APIServerReady(ctx context.Context) error {
+	return f.readyErr
+}
+
+func (f *fakeClusterChecker) NodeNames(ctx context.Context) ([]string, error) {
+	return f.nodes, f.nodesErr
+}
+
+func (f *fakeClusterChecker) LeaseRenewTime(ctx context.Context, namespace, name string) (time.Time, error) {
+	t := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if f.renewing {
+		f.leaseCall++
+
+		t = t.Add(time.Duration(f.leaseCall) * time.Second)
+	}
+
+	return t, nil
+}
+
+// Verify() tests.
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	c := &Controlplane{}
+
+	report, err := c.Verify(&fakeClusterChecker{
+		nodes:    []string{"foo"},
+		renewing: true,
+	})
+	if err != nil {
+		t.Fatalf("Verifying working controlplane should succeed, got: %v", err)
+	}
+
+	if len(report) != 4 {
+		t.Fatalf("Expected 4 checks in the report, got:\n%s", report)
+	}
+
+	for _, vc := range report {
+		if !vc.Passed {
+			t.Errorf("Check %q should pass, got: %s", vc.Name, vc.Message)
+		}
+	}
+
+	if report[1].Message != "listed 1 nodes" {
+		t.Errorf("Unexpected nodes check message: %q", report[1].Message)
+	}
+}
+
+func TestVerifyFailedChecks(t *testing.T) {
+	t.Parallel()
+
+	c := &Controlplane{
+		VerifyTimeout: "10ms",
+	}
+
+	report, err := c.Verify(&fakeClusterChecker{
+		readyErr: fmt.Errorf("etcd not ready"),
+		nodes:    []string{"foo"},
+	})
+	if err == nil {
+		t.Fatalf("Verifying should fail, when checks fail")
+	}
+
+	for _, s := range []string{"kube-apiserver ready", "etcd not ready", "kube-controller-manager running", "kube-scheduler running"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("Error should contain %q, got: %v", s, err)
+		}
+	}
+
+	if strings.Contains(err.Error(), "list nodes") {
+		t.Errorf("Error should not contain passed checks, got: %v", err)
+	}
+
+	if report[0].Passed || !report[1].Passed {
+		t.Fatalf("Report should include results of all checks, got:\n%s", report)
+	}
+}
+
+func TestVerifyLeaderElectionDisabled(t *testing.T) {
+	t.Parallel()
+
+	c := &Controlplane{
+		KubeScheduler: KubeScheduler{
+			LeaderElection: &LeaderElection{},
+		},
+	}
+
+	report, err := c.Verify(&fakeClusterChecker{
+		renewing: true,
+	})
+	if err != nil {
+		t.Fatalf("Verifying should succeed, got: %v", err)
+	}
+
+	if !report[3].Skipped || report[3].Name != "kube-scheduler running" {
+		t.Fatalf("Check of kube-scheduler should be skipped, got:\n%s", report)
+	}
+
+	if !strings.Contains(report.String(), "SKIP kube-scheduler running: leader election is disabled") {
+		t.Fatalf("Report should include skipped check, got:\n%s", report)
+	}
+}
+
+func TestVerifySuspended(t *testing.T) {
+	t.Parallel()
+
+	c := &Controlplane{
+		Suspended: true,
+	}
+
+	if _, err := c.Verify(&fakeClusterChecker{}); err == nil {
+		t.Fatalf("Verifying suspended controlplane should fail")
+	}
+}
+
+// verifyTimeout() tests.
+func TestVerifyTimeout(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		timeout  string
+		expected time.Duration
+		err      bool
+	}{
+		"default": {
+			expected: defaultVerifyTimeout,
+		},
+		"custom": {
+			timeout:  "5m",
+			expected: 5 * time.Minute,
+		},
+		"malformed": {
+			timeout: "foo",
+			err:     true,
+		},
+		"zero": {
+			timeout: "0s",
+			err:     true,
+		},
+	}
+
+	for n, tc := range cases {
+		tc := tc
+
+		t.Run(n, func(t *testing.T) {
+			t.Parallel()
+
+			c := &Controlplane{
+				VerifyTimeout: tc.timeout,
+			}
+
+			d, err := c.verifyTimeout()
+			if tc.err && err == nil {
+				t.Fatalf("Expected error")
+			}
+
+			if !tc.err && err != nil {
+				t.Fatalf("Didn't expect error, got: %v", err)
+			}
+
+			if d != tc.expected {
+				t.Fatalf("Expected timeout %v, got %v", tc.expected, d)
+			}
+		})
+	}
+}
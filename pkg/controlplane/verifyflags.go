@@ -0,0 +1,63 @@
+package controlplane
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FlagsReporter represents capability of fetching flags, which running API server has been
+// started with. It is implemented by Kubernetes client from client package.
+type FlagsReporter interface {
+	APIServerFlags() (map[string]string, error)
+}
+
+// VerifyAPIServerFlags compares flags of the desired kube-apiserver container with flags
+// reported by the running API server and returns sorted list of mismatches. This allows to
+// detect API server running with unexpected arguments, for example when image override changes
+// the entrypoint or defaults.
+//
+// Flags, which are not reported by the API server are not compared.
+func (c *Controlplane) VerifyAPIServerFlags(r FlagsReporter) ([]string, error) {
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to validate controlplane configuration: %w", err)
+	}
+
+	reported, err := r.APIServerFlags()
+	if err != nil {
+		return nil, fmt.Errorf("failed getting flags reported by API server: %w", err)
+	}
+
+	kas := c.desiredState()["kube-apiserver"]
+
+	return flagsMismatches(kas.Container.Config.Args, reported), nil
+}
+
+// flagsMismatches returns sorted list of flags from given arguments, which values differ from
+// given reported flags. Arguments which are not flags are ignored and flags without value are
+// treated as boolean flags set to 'true'.
+func flagsMismatches(args []string, reported map[string]string) []string {
+	mismatches := []string{}
+
+	for _, a := range args {
+		if !strings.HasPrefix(a, "--") {
+			continue
+		}
+
+		kv := strings.SplitN(a, "=", 2)
+		if len(kv) == 1 {
+			kv = append(kv, "true")
+		}
+
+		v, ok := reported[kv[0]]
+		if !ok || v == kv[1] {
+			continue
+		}
+
+		mismatches = append(mismatches, fmt.Sprintf("flag %s: expected %q, got %q", kv[0], kv[1], v))
+	}
+
+	sort.Strings(mismatches)
+
+	return mismatches
+}
@@ -0,0 +1,88 @@
+package controlplane
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/yaml"
+)
+
+type fakeFlagsReporter struct {
+	flags map[string]string
+	err   error
+}
+
+func (f *fakeFlagsReporter) APIServerFlags() (map[string]string, error) {
+	return f.flags, f.err
+}
+
+// flagsMismatches() tests.
+func TestFlagsMismatches(t *testing.T) {
+	args := []string{
+		"kube-apiserver",
+		"--secure-port=6443",
+		"--allow-privileged",
+		"--authorization-mode=RBAC,Node",
+		"--not-reported=foo",
+	}
+
+	reported := map[string]string{
+		"--secure-port":        "443",
+		"--allow-privileged":   "false",
+		"--authorization-mode": "RBAC,Node",
+	}
+
+	expected := []string{
+		`flag --allow-privileged: expected "true", got "false"`,
+		`flag --secure-port: expected "6443", got "443"`,
+	}
+
+	if diff := cmp.Diff(expected, flagsMismatches(args, reported)); diff != "" {
+		t.Fatalf("Unexpected mismatches: %s", diff)
+	}
+}
+
+// VerifyAPIServerFlags() tests.
+func TestVerifyAPIServerFlags(t *testing.T) {
+	c := &Controlplane{}
+
+	if err := yaml.Unmarshal([]byte(controlplaneYAML(t)), c); err != nil {
+		t.Fatalf("Unmarshaling controlplane configuration should succeed, got: %v", err)
+	}
+
+	r := &fakeFlagsReporter{
+		flags: map[string]string{
+			"--secure-port": "443",
+		},
+	}
+
+	m, err := c.VerifyAPIServerFlags(r)
+	if err != nil {
+		t.Fatalf("Verifying API server flags should succeed, got: %v", err)
+	}
+
+	expected := []string{`flag --secure-port: expected "6443", got "443"`}
+
+	if diff := cmp.Diff(expected, m); diff != "" {
+		t.Fatalf("Unexpected mismatches: %s", diff)
+	}
+}
+
+func TestVerifyAPIServerFlagsReporterFail(t *testing.T) {
+	c := &Controlplane{}
+
+	if err := yaml.Unmarshal([]byte(controlplaneYAML(t)), c); err != nil {
+		t.Fatalf("Unmarshaling controlplane configuration should succeed, got: %v", err)
+	}
+
+	if _, err := c.VerifyAPIServerFlags(&fakeFlagsReporter{err: fmt.Errorf("failed")}); err == nil {
+		t.Fatalf("Verifying API server flags should fail, when fetching reported flags fails")
+	}
+}
+
+func TestVerifyAPIServerFlagsValidate(t *testing.T) {
+	if _, err := (&Controlplane{}).VerifyAPIServerFlags(&fakeFlagsReporter{}); err == nil {
+		t.Fatalf("Verifying API server flags should validate controlplane configuration")
+	}
+}
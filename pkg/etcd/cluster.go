@@ -73,7 +73,8 @@ type Cluster struct {
 	//
 	// If there is no state defined, this list must not be empty.
 	//
-	// If state is defined and list of members is empty, all created containers will be removed.
+	// If state is defined and list of members is empty, all created containers will be removed,
+	// if AllowRemoveAll is set.
 	Members map[string]Member `json:"members,omitempty"`
 
 	// PKI field allows to use PKI resource for managing all etcd certificates. It will be used for
@@ -83,6 +84,11 @@ type Cluster struct {
 	// State stores state of the created containers. After deployment, it is up to the user to export
 	// the state and restore it on consecutive runs.
 	State container.ContainersState `json:"state,omitempty"`
+
+	// AllowRemoveAll allows removing all created containers, when list of members is empty. Without it,
+	// deployment fails in such case, to prevent accidental removal, e.g. caused by invalid
+	// configuration.
+	AllowRemoveAll bool `json:"allowRemoveAll,omitempty"`
 }
 
 // cluster is executable version of Cluster, with validated fields and calculated containers.
@@ -148,8 +154,9 @@ func (c *Cluster) New() (types.Resource, error) {
 	}
 
 	cc := container.Containers{
-		PreviousState: c.State,
-		DesiredState:  make(container.ContainersState),
+		PreviousState:  c.State,
+		DesiredState:   make(container.ContainersState),
+		AllowRemoveAll: c.AllowRemoveAll,
 	}
 
 	cluster := &cluster{
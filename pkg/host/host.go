@@ -4,6 +4,9 @@ package host
 
 import (
 	"fmt"
+	"io"
+	"sort"
+	"strings"
 
 	"github.com/flexkube/libflexkube/internal/util"
 	"github.com/flexkube/libflexkube/pkg/host/transport"
@@ -22,6 +25,16 @@ type Host struct {
 
 	// SSHConfig configures given addresses to be forwarded using SSH tunneling.
 	SSHConfig *ssh.Config `json:"ssh,omitempty"`
+
+	// PreDeployCommands is a list of shell commands, which will be executed in order on the host
+	// using configured transport method, before any containers are deployed on it. It allows
+	// preparing the host, e.g. loading kernel modules or creating directories. Commands are
+	// executed on every deployment, so they must be idempotent.
+	//
+	// Example value: '[]string{"modprobe br_netfilter"}'.
+	//
+	// This field is optional.
+	PreDeployCommands []string `json:"preDeployCommands,omitempty"`
 }
 
 type host struct {
@@ -76,9 +89,40 @@ func (h *Host) Validate() error {
 		}
 	}
 
+	for i, c := range h.PreDeployCommands {
+		if strings.TrimSpace(c) == "" {
+			errors = append(errors, fmt.Errorf("pre-deploy command %d is empty", i))
+		}
+	}
+
 	return errors.Return()
 }
 
+// ID returns identifier of the host, which allows to determine, if two host configurations
+// point to the same machine.
+func (h *Host) ID() string {
+	if h.SSHConfig != nil {
+		return fmt.Sprintf("ssh://%s:%d", h.SSHConfig.Address, h.SSHConfig.Port)
+	}
+
+	return "direct"
+}
+
+// Verify connects to the host using configured transport method, to check, that host is reachable
+// and configured credentials are valid.
+func (h *Host) Verify() error {
+	t, err := h.New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize host: %w", err)
+	}
+
+	if _, err := t.Connect(); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	return nil
+}
+
 // selectTransport returns transport protocol configured for container.
 //
 // It returns error if transport protocol configuration is invalid.
@@ -93,6 +137,16 @@ func (h *host) Connect() (transport.Connected, error) {
 	}, nil
 }
 
+// Run executes given command on the host, if configured transport method supports it.
+func (h *hostConnected) Run(command string) (string, error) {
+	r, ok := h.transport.(transport.CommandRunner)
+	if !ok {
+		return "", fmt.Errorf("configured transport method does not support running commands")
+	}
+
+	return r.Run(command)
+}
+
 // ForwardUnixSocket forwards given unix socket path using configured transport method and returns
 // local unix socket address.
 func (h *hostConnected) ForwardUnixSocket(path string) (string, error) {
@@ -108,6 +162,10 @@ func (h *hostConnected) ForwardTCP(address string) (string, error) {
 // BuildConfig merges values from both host objects. This is a helper method used for building hierarchical
 // configuration.
 func BuildConfig(config, defaults Host) Host {
+	if len(config.PreDeployCommands) == 0 {
+		config.PreDeployCommands = defaults.PreDeployCommands
+	}
+
 	// If config has no direct config configured or has SSH config configured, build SSH configuration.
 	if (config.DirectConfig == nil && defaults.SSHConfig != nil) || config.SSHConfig != nil {
 		config.SSHConfig = ssh.BuildConfig(config.SSHConfig, defaults.SSHConfig)
@@ -117,9 +175,151 @@ func BuildConfig(config, defaults Host) Host {
 	// return direct config as a default.
 	if config.DirectConfig == nil && config.SSHConfig == nil && defaults.SSHConfig == nil {
 		return Host{
-			DirectConfig: &direct.Config{},
+			DirectConfig:      &direct.Config{},
+			PreDeployCommands: config.PreDeployCommands,
 		}
 	}
 
 	return config
 }
+
+// privilegeCheckCommand is a command executed on the host to check, if configured
+// user has root privileges.
+const privilegeCheckCommand = "id -u"
+
+// Preflight connects to the host and verifies, that configured user has root privileges,
+// which are required for managing containers and their configuration files. This allows
+// to detect missing privileges before the deployment starts.
+func (h *Host) Preflight() error {
+	out, err := h.run(privilegeCheckCommand)
+	if err != nil {
+		return fmt.Errorf("failed checking privileges: %w", err)
+	}
+
+	if uid := strings.TrimSpace(out); uid != "0" {
+		return fmt.Errorf("configured user must have root privileges, got user ID %q", uid)
+	}
+
+	return nil
+}
+
+// architectureCommand is a command executed on the host to discover it's CPU architecture.
+const architectureCommand = "uname -m"
+
+// Architecture connects to the host and returns it's CPU architecture, using the naming
+// from Go and OCI image specification, e.g. 'amd64' or 'arm64'. This allows to check, if
+// container images are available for the host, before deploying containers on it.
+func (h *Host) Architecture() (string, error) {
+	out, err := h.run(architectureCommand)
+	if err != nil {
+		return "", fmt.Errorf("failed discovering architecture: %w", err)
+	}
+
+	return normalizeArchitecture(strings.TrimSpace(out)), nil
+}
+
+// normalizeArchitecture converts machine hardware name reported by the kernel to the
+// architecture name used by container images. Unknown names are returned as is.
+func normalizeArchitecture(machine string) string {
+	architectures := map[string]string{
+		"x86_64":  "amd64",
+		"aarch64": "arm64",
+		"armv7l":  "arm",
+		"armv6l":  "arm",
+		"i386":    "386",
+		"i686":    "386",
+		"ppc64le": "ppc64le",
+		"s390x":   "s390x",
+	}
+
+	if a, ok := architectures[machine]; ok {
+		return a
+	}
+
+	return machine
+}
+
+// commandRunner connects to the host and returns connection, which can be used for
+// executing commands on it.
+func (h *Host) commandRunner() (transport.CommandRunner, error) {
+	t, err := h.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize host: %w", err)
+	}
+
+	c, err := t.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	r, ok := c.(transport.CommandRunner)
+	if !ok {
+		return nil, fmt.Errorf("connected host does not support running commands")
+	}
+
+	return r, nil
+}
+
+// run connects to the host and executes given command on it.
+func (h *Host) run(command string) (string, error) {
+	r, err := h.commandRunner()
+	if err != nil {
+		return "", err
+	}
+
+	return r.Run(command)
+}
+
+// RunPreDeployCommands connects to the host and executes configured pre-deploy commands in
+// order, writing each command followed by it's output to given writer. Execution stops on
+// the first failing command.
+func (h *Host) RunPreDeployCommands(w io.Writer) error {
+	if len(h.PreDeployCommands) == 0 {
+		return nil
+	}
+
+	r, err := h.commandRunner()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range h.PreDeployCommands {
+		if _, err := fmt.Fprintf(w, "$ %s\n", c); err != nil {
+			return fmt.Errorf("failed writing output: %w", err)
+		}
+
+		out, err := r.Run(c)
+		if err != nil {
+			return fmt.Errorf("pre-deploy command %q failed: %w", c, err)
+		}
+
+		if _, err := io.WriteString(w, out); err != nil {
+			return fmt.Errorf("failed writing output: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// PreflightHosts runs Preflight on all given hosts and returns an error, which contains
+// results for all hosts, which failed the checks.
+func PreflightHosts(hosts map[string]Host) error {
+	var errors util.ValidateError
+
+	names := []string{}
+	for n := range hosts {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	for _, n := range names {
+		h := hosts[n]
+
+		if err := h.Preflight(); err != nil {
+			errors = append(errors, fmt.Errorf("host %q failed preflight checks: %w", n, err))
+		}
+	}
+
+	return errors.Return()
+}
@@ -1,7 +1,9 @@
 package host
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/flexkube/libflexkube/pkg/host/transport/direct"
@@ -57,6 +59,14 @@ func TestValidate(t *testing.T) {
 			"Validate must validate ssh configuration",
 			true,
 		},
+		{
+			&Host{
+				DirectConfig:      &direct.Config{},
+				PreDeployCommands: []string{" "},
+			},
+			"Validate should reject empty pre-deploy commands",
+			true,
+		},
 	}
 
 	for n, c := range cases {
@@ -204,3 +214,151 @@ func TestBuildConfigSSH(t *testing.T) {
 		t.Fatalf("BuildConfig should merge ssh config, got: %+v", h)
 	}
 }
+
+func TestBuildConfigPreDeployCommands(t *testing.T) {
+	d := Host{
+		PreDeployCommands: []string{"foo"},
+	}
+
+	if h := BuildConfig(Host{}, d); len(h.PreDeployCommands) != 1 {
+		t.Fatalf("BuildConfig should use pre-deploy commands from defaults, got: %+v", h)
+	}
+
+	c := Host{
+		DirectConfig:      &direct.Config{},
+		PreDeployCommands: []string{"bar", "baz"},
+	}
+
+	if h := BuildConfig(c, d); len(h.PreDeployCommands) != 2 {
+		t.Fatalf("BuildConfig should prefer configured pre-deploy commands, got: %+v", h)
+	}
+}
+
+// ID() tests.
+func TestIDDirect(t *testing.T) {
+	h := &Host{
+		DirectConfig: &direct.Config{},
+	}
+
+	if id := h.ID(); id != "direct" {
+		t.Fatalf("Expected direct host ID, got %q", id)
+	}
+}
+
+func TestIDSSH(t *testing.T) {
+	h := &Host{
+		SSHConfig: &ssh.Config{
+			Address: "localhost",
+			Port:    22,
+		},
+	}
+
+	if id := h.ID(); id != "ssh://localhost:22" {
+		t.Fatalf("Expected SSH host ID, got %q", id)
+	}
+}
+
+// Verify() tests.
+func TestVerify(t *testing.T) {
+	h := &Host{
+		DirectConfig: &direct.Config{},
+	}
+
+	if err := h.Verify(); err != nil {
+		t.Fatalf("Verifying direct host should succeed, got: %v", err)
+	}
+}
+
+func TestVerifyValidate(t *testing.T) {
+	h := &Host{}
+
+	if err := h.Verify(); err == nil {
+		t.Fatalf("Verifying invalid host should fail")
+	}
+}
+
+// PreflightHosts() tests.
+func TestPreflightHostsReportHostName(t *testing.T) {
+	err := PreflightHosts(map[string]Host{
+		"foo": {},
+	})
+	if err == nil {
+		t.Fatalf("preflight of invalid host should fail")
+	}
+
+	if !strings.Contains(err.Error(), "foo") {
+		t.Fatalf("error should include name of the failing host, got: %v", err)
+	}
+}
+
+// normalizeArchitecture() tests.
+func TestNormalizeArchitecture(t *testing.T) {
+	cases := map[string]string{
+		"x86_64":  "amd64",
+		"aarch64": "arm64",
+		"armv7l":  "arm",
+		"riscv64": "riscv64",
+	}
+
+	for machine, expected := range cases {
+		machine, expected := machine, expected
+
+		t.Run(machine, func(t *testing.T) {
+			if a := normalizeArchitecture(machine); a != expected {
+				t.Fatalf("Expected architecture %q, got %q", expected, a)
+			}
+		})
+	}
+}
+
+// Architecture() tests.
+func TestArchitectureValidate(t *testing.T) {
+	h := &Host{}
+
+	if _, err := h.Architecture(); err == nil {
+		t.Fatalf("Discovering architecture of invalid host should fail")
+	}
+}
+
+// RunPreDeployCommands() tests.
+func TestRunPreDeployCommands(t *testing.T) {
+	h := &Host{
+		DirectConfig:      &direct.Config{},
+		PreDeployCommands: []string{"echo foo", "echo bar"},
+	}
+
+	var buf bytes.Buffer
+
+	if err := h.RunPreDeployCommands(&buf); err != nil {
+		t.Fatalf("Running pre-deploy commands should succeed, got: %v", err)
+	}
+
+	if e := "$ echo foo\nfoo\n$ echo bar\nbar\n"; buf.String() != e {
+		t.Fatalf("Expected output %q, got %q", e, buf.String())
+	}
+}
+
+func TestRunPreDeployCommandsFail(t *testing.T) {
+	h := &Host{
+		DirectConfig:      &direct.Config{},
+		PreDeployCommands: []string{"false", "echo foo"},
+	}
+
+	var buf bytes.Buffer
+
+	if err := h.RunPreDeployCommands(&buf); err == nil {
+		t.Fatalf("Running failing pre-deploy command should fail")
+	}
+
+	if strings.Contains(buf.String(), "echo foo") {
+		t.Fatalf("Commands after failing command should not be executed, got output: %q", buf.String())
+	}
+}
+
+func TestRunPreDeployCommandsNone(t *testing.T) {
+	h := &Host{}
+
+	if err := h.RunPreDeployCommands(&bytes.Buffer{}); err != nil {
+		t.Fatalf("Running no pre-deploy commands should not connect to the host, got: %v", err)
+	}
+}
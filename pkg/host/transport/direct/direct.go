@@ -3,8 +3,11 @@
 package direct
 
 import (
+	"bytes"
 	"fmt"
 	"net"
+	"os/exec"
+	"strings"
 
 	"github.com/flexkube/libflexkube/pkg/host/transport"
 )
@@ -55,3 +58,19 @@ func (d *direct) ForwardTCP(address string) (string, error) {
 
 	return address, nil
 }
+
+// Run executes given command on local machine using 'sh' and returns it's standard output.
+func (d *direct) Run(command string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	// #nosec G204
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running command %q failed: %w, stderr: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
@@ -59,3 +59,24 @@ func TestForwardTCPBadAddress(t *testing.T) {
 		t.Fatalf("TCP forwarding should fail when forwarding bad address")
 	}
 }
+
+func TestRun(t *testing.T) {
+	d := &direct{}
+
+	out, err := d.Run("echo foo")
+	if err != nil {
+		t.Fatalf("running command should succeed, got: %v", err)
+	}
+
+	if out != "foo\n" {
+		t.Fatalf("expected command output to be returned, got: %q", out)
+	}
+}
+
+func TestRunFail(t *testing.T) {
+	d := &direct{}
+
+	if _, err := d.Run("exit 1"); err == nil {
+		t.Fatalf("running failing command should fail")
+	}
+}
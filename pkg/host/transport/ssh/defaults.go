@@ -48,5 +48,9 @@ func BuildConfig(sshConfig, defaults *Config) *Config {
 
 	sshConfig.Password = util.PickString(sshConfig.Password, defaults.Password)
 
+	if sshConfig.PrivilegeEscalation == nil {
+		sshConfig.PrivilegeEscalation = defaults.PrivilegeEscalation
+	}
+
 	return sshConfig
 }
@@ -0,0 +1,127 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+)
+
+const (
+	// sudoCommand is a name of sudo binary, which supports reading the password from
+	// standard input.
+	sudoCommand = "sudo"
+
+	// socatCommand is a command used to connect to remote UNIX sockets with escalated
+	// privileges.
+	socatCommand = "socat STDIO UNIX-CONNECT:%s"
+)
+
+// PrivilegeEscalation configures, how commands executed on the host should gain root
+// privileges, when configured SSH user is not root.
+//
+// When configured, forwarded UNIX sockets (e.g. Docker socket) are also accessed with
+// escalated privileges using 'socat', so it must be installed on the host.
+type PrivilegeEscalation struct {
+	// Command is a privilege escalation binary, which will prefix executed commands.
+	//
+	// Example value: 'sudo'.
+	Command string `json:"command,omitempty"`
+
+	// Flags is a list of additional flags passed to the privilege escalation command.
+	//
+	// Example value: '[]string{"-E"}'.
+	Flags []string `json:"flags,omitempty"`
+
+	// Password is a password, which will be passed to privilege escalation command via standard
+	// input, if it requires one. It is only supported with 'sudo' command.
+	//
+	// As password is a secret, it is not serialized, so it does not end up in the state.
+	// It can only be set programmatically.
+	//
+	// This field is optional.
+	Password string `json:"-"`
+}
+
+// Validate validates privilege escalation configuration.
+func (p *PrivilegeEscalation) Validate() error {
+	if p.Command == "" {
+		return fmt.Errorf("command must be set")
+	}
+
+	if p.Password != "" && p.Command != sudoCommand {
+		return fmt.Errorf("password is only supported with %q command", sudoCommand)
+	}
+
+	return nil
+}
+
+// wrap wraps given command with configured privilege escalation command and returns
+// the standard input, which should be passed to the wrapped command.
+func (p *PrivilegeEscalation) wrap(command string) (string, io.Reader) {
+	args := append([]string{p.Command}, p.Flags...)
+
+	var stdin io.Reader
+
+	if p.Password != "" {
+		// Read the password from standard input and don't print the prompt, so it does not
+		// end up in the output.
+		args = append(args, "-S", "-p", "''")
+		stdin = strings.NewReader(p.Password + "\n")
+	} else if p.Command == sudoCommand {
+		// Fail instead of waiting for the password forever.
+		args = append(args, "-n")
+	}
+
+	args = append(args, "sh", "-c", shellQuote(command))
+
+	return strings.Join(args, " "), stdin
+}
+
+// shellQuote quotes given string, so it can be safely passed as a single argument
+// to the shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// privilegedDialer implements dialer interface, by connecting to remote UNIX sockets
+// using 'socat' executed with escalated privileges, as SSH user may not have access
+// to the socket.
+type privilegedDialer struct {
+	runner    func(command string, stdin io.Reader, stdout, stderr io.Writer) error
+	privilege *PrivilegeEscalation
+}
+
+// Dial starts 'socat' with escalated privileges on the remote host and returns connection,
+// which is attached to it's standard input and output.
+func (p *privilegedDialer) Dial(network, address string) (net.Conn, error) {
+	if network != "unix" {
+		return nil, fmt.Errorf("only unix network is supported with privilege escalation, got %q", network)
+	}
+
+	command, stdin := p.privilege.wrap(fmt.Sprintf(socatCommand, shellQuote(address)))
+
+	local, remote := net.Pipe()
+
+	var in io.Reader = remote
+
+	// Pass the password before forwarded data.
+	if stdin != nil {
+		in = io.MultiReader(stdin, remote)
+	}
+
+	go func() {
+		defer func() {
+			if err := remote.Close(); err != nil {
+				fmt.Printf("failed closing remote connection: %v\n", err)
+			}
+		}()
+
+		if err := p.runner(command, in, remote, ioutil.Discard); err != nil {
+			fmt.Printf("failed running %q: %v\n", command, err)
+		}
+	}()
+
+	return local, nil
+}
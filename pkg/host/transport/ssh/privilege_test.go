@@ -0,0 +1,197 @@
+package ssh
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// Validate() tests.
+func TestPrivilegeEscalationValidate(t *testing.T) {
+	cases := map[string]struct {
+		Config *PrivilegeEscalation
+		Error  bool
+	}{
+		"valid": {
+			Config: &PrivilegeEscalation{Command: "sudo", Password: "foo"},
+		},
+		"require command": {
+			Config: &PrivilegeEscalation{},
+			Error:  true,
+		},
+		"password only with sudo": {
+			Config: &PrivilegeEscalation{Command: "doas", Password: "foo"},
+			Error:  true,
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			err := c.Config.Validate()
+			if !c.Error && err != nil {
+				t.Errorf("didn't expect error, got: %v", err)
+			}
+
+			if c.Error && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestPrivilegeEscalationPasswordNotSerialized(t *testing.T) {
+	p := &PrivilegeEscalation{
+		Command:  "sudo",
+		Password: "secret",
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("serializing should succeed, got: %v", err)
+	}
+
+	if strings.Contains(string(b), p.Password) {
+		t.Fatalf("password should not be serialized, got: %s", string(b))
+	}
+}
+
+// wrap() tests.
+func TestPrivilegeEscalationWrapNonInteractive(t *testing.T) {
+	p := &PrivilegeEscalation{
+		Command: "sudo",
+		Flags:   []string{"-E"},
+	}
+
+	c, stdin := p.wrap("echo 'foo'")
+
+	if e := `sudo -E -n sh -c 'echo '\''foo'\'''`; c != e {
+		t.Fatalf("expected command %q, got %q", e, c)
+	}
+
+	if stdin != nil {
+		t.Fatalf("no standard input should be passed without password")
+	}
+}
+
+func TestPrivilegeEscalationWrapPassword(t *testing.T) {
+	p := &PrivilegeEscalation{
+		Command:  "sudo",
+		Password: "foo",
+	}
+
+	c, stdin := p.wrap("id -u")
+
+	if e := `sudo -S -p '' sh -c 'id -u'`; c != e {
+		t.Fatalf("expected command %q, got %q", e, c)
+	}
+
+	b, err := ioutil.ReadAll(stdin)
+	if err != nil {
+		t.Fatalf("reading standard input should succeed, got: %v", err)
+	}
+
+	if string(b) != "foo\n" {
+		t.Fatalf("password should be passed via standard input, got: %q", string(b))
+	}
+}
+
+// Run() tests.
+func TestRunWithPrivilegeEscalation(t *testing.T) {
+	d := newConnected("localhost:80", nil).(*sshConnected)
+	d.privilege = &PrivilegeEscalation{
+		Command: "doas",
+	}
+
+	d.runner = func(command string, stdin io.Reader, stdout, stderr io.Writer) error {
+		if e := `doas sh -c 'id -u'`; command != e {
+			t.Fatalf("expected command %q, got %q", e, command)
+		}
+
+		return nil
+	}
+
+	if _, err := d.Run("id -u"); err != nil {
+		t.Fatalf("running command should succeed, got: %v", err)
+	}
+}
+
+// socketDialer() tests.
+func TestSocketDialerWithPrivilegeEscalation(t *testing.T) {
+	d := newConnected("localhost:80", nil).(*sshConnected)
+	d.privilege = &PrivilegeEscalation{
+		Command: "sudo",
+	}
+	d.runner = func(command string, stdin io.Reader, stdout, stderr io.Writer) error {
+		return nil
+	}
+
+	if _, ok := d.socketDialer().(*privilegedDialer); !ok {
+		t.Fatalf("UNIX sockets should be forwarded with privilege escalation when configured")
+	}
+}
+
+// Dial() tests.
+func TestPrivilegedDialerDial(t *testing.T) {
+	p := &privilegedDialer{
+		privilege: &PrivilegeEscalation{
+			Command:  "sudo",
+			Password: "secret",
+		},
+		runner: func(command string, stdin io.Reader, stdout, stderr io.Writer) error {
+			if e := `sudo -S -p '' sh -c 'socat STDIO UNIX-CONNECT:'\''/run/docker.sock'\'''`; command != e {
+				return fmt.Errorf("expected command %q, got %q", e, command)
+			}
+
+			input := "secret\n" + expectedMessage
+
+			b := make([]byte, len(input))
+
+			if _, err := io.ReadFull(stdin, b); err != nil {
+				return err
+			}
+
+			if string(b) != input {
+				return fmt.Errorf("expected input %q, got %q", input, string(b))
+			}
+
+			_, err := stdout.Write([]byte(expectedResponse))
+
+			return err
+		},
+	}
+
+	c, err := p.Dial("unix", "/run/docker.sock")
+	if err != nil {
+		t.Fatalf("dialing should succeed, got: %v", err)
+	}
+
+	if _, err := c.Write([]byte(expectedMessage)); err != nil {
+		t.Fatalf("writing to connection should succeed, got: %v", err)
+	}
+
+	b, err := ioutil.ReadAll(c)
+	if err != nil {
+		t.Fatalf("reading from connection should succeed, got: %v", err)
+	}
+
+	if string(b) != expectedResponse {
+		t.Fatalf("expected response %q, got %q", expectedResponse, string(b))
+	}
+}
+
+func TestPrivilegedDialerDialBadNetwork(t *testing.T) {
+	p := &privilegedDialer{
+		privilege: &PrivilegeEscalation{
+			Command: "sudo",
+		},
+	}
+
+	if _, err := p.Dial("tcp", "localhost:80"); err == nil {
+		t.Fatalf("dialing TCP address with privilege escalation should fail")
+	}
+}
@@ -3,11 +3,13 @@
 package ssh
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -52,6 +54,12 @@ type Config struct {
 	// PrivateKey adds private key as authentication method.
 	// It must be defined as valid SSH private key in PEM format.
 	PrivateKey string `json:"privateKey,omitempty"`
+
+	// PrivilegeEscalation configures, how commands executed on the host should gain
+	// root privileges, when User is not root.
+	//
+	// This field is optional.
+	PrivilegeEscalation *PrivilegeEscalation `json:"privilegeEscalation,omitempty"`
 }
 
 // ssh is an implementation of Transport interface over SSH protocol.
@@ -63,13 +71,16 @@ type ssh struct {
 	retryInterval     time.Duration
 	auth              []gossh.AuthMethod
 	sshClientGetter   func(network, address string, config *gossh.ClientConfig) (*gossh.Client, error)
+	privilege         *PrivilegeEscalation
 }
 
 type sshConnected struct {
-	client   dialer
-	address  string
-	uuid     func() (uuid.UUID, error)
-	listener func(string, string) (net.Listener, error)
+	client    dialer
+	address   string
+	uuid      func() (uuid.UUID, error)
+	listener  func(string, string) (net.Listener, error)
+	runner    func(command string, stdin io.Reader, stdout, stderr io.Writer) error
+	privilege *PrivilegeEscalation
 }
 
 type dialer interface {
@@ -95,6 +106,7 @@ func (d *Config) New() (transport.Interface, error) {
 		retryInterval:     ri,
 		auth:              []gossh.AuthMethod{},
 		sshClientGetter:   gossh.Dial,
+		privilege:         d.PrivilegeEscalation,
 	}
 
 	if d.Password != "" {
@@ -167,6 +179,12 @@ func (d *Config) Validate() error {
 		errors = append(errors, fmt.Errorf("unable to parse private key: %w", err))
 	}
 
+	if d.PrivilegeEscalation != nil {
+		if err := d.PrivilegeEscalation.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("invalid privilege escalation configuration: %w", err))
+		}
+	}
+
 	return errors.Return()
 }
 
@@ -193,7 +211,10 @@ func (d *ssh) Connect() (transport.Connected, error) {
 	// Try until we timeout.
 	for time.Since(start) < d.retryTimeout {
 		if connection, err = d.sshClientGetter("tcp", d.address, sshConfig); err == nil {
-			return newConnected(d.address, connection), nil
+			c := newConnected(d.address, connection).(*sshConnected)
+			c.privilege = d.privilege
+
+			return c, nil
 		}
 
 		time.Sleep(d.retryInterval)
@@ -203,12 +224,66 @@ func (d *ssh) Connect() (transport.Connected, error) {
 }
 
 func newConnected(address string, connection dialer) transport.Connected {
-	return &sshConnected{
+	c := &sshConnected{
 		client:   connection,
 		address:  address,
 		uuid:     uuid.NewRandom,
 		listener: net.Listen,
 	}
+
+	if client, ok := connection.(*gossh.Client); ok {
+		c.runner = sessionRunner(client)
+	}
+
+	return c
+}
+
+// sessionRunner returns function, which executes commands using new SSH session
+// opened from given client.
+func sessionRunner(client *gossh.Client) func(string, io.Reader, io.Writer, io.Writer) error {
+	return func(command string, stdin io.Reader, stdout, stderr io.Writer) error {
+		s, err := client.NewSession()
+		if err != nil {
+			return fmt.Errorf("failed opening SSH session: %w", err)
+		}
+
+		defer func() {
+			// Session is closed by the server after the command exits, so ignore this error.
+			_ = s.Close()
+		}()
+
+		s.Stdin = stdin
+		s.Stdout = stdout
+		s.Stderr = stderr
+
+		return s.Run(command)
+	}
+}
+
+// Run executes given command on the host using new SSH session and returns it's standard output.
+// If privilege escalation is configured, command is wrapped with it.
+//
+// If command fails, error includes command's standard error output.
+func (d *sshConnected) Run(command string) (string, error) {
+	if d.runner == nil {
+		return "", fmt.Errorf("running commands is not supported by this connection")
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	var stdin io.Reader
+
+	wrapped := command
+
+	if d.privilege != nil {
+		wrapped, stdin = d.privilege.wrap(command)
+	}
+
+	if err := d.runner(wrapped, stdin, &stdout, &stderr); err != nil {
+		return "", fmt.Errorf("running command %q failed: %w, stderr: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
 }
 
 // ForwardUnixSocket takes remote UNIX socket path as an argument and forwards
@@ -230,11 +305,25 @@ func (d *sshConnected) ForwardUnixSocket(path string) (string, error) {
 	}
 
 	// Schedule accepting connections and return.
-	go forwardConnection(localSock, d.client, path, "unix")
+	go forwardConnection(localSock, d.socketDialer(), path, "unix")
 
 	return fmt.Sprintf("unix://%s", unixAddr.String()), nil
 }
 
+// socketDialer returns dialer, which should be used for forwarding UNIX sockets. If privilege
+// escalation is configured, SSH user may not have access to the socket, so connections are
+// made using privileged 'socat' instead.
+func (d *sshConnected) socketDialer() dialer {
+	if d.privilege == nil || d.runner == nil {
+		return d.client
+	}
+
+	return &privilegedDialer{
+		runner:    d.runner,
+		privilege: d.privilege,
+	}
+}
+
 // handleClient is responsible for copying incoming and outgoing data going
 // through the forwarded connection.
 func handleClient(client net.Conn, remote io.ReadWriter) {
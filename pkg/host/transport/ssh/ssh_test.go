@@ -6,6 +6,7 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"reflect"
@@ -759,3 +760,50 @@ func TestNewSSHAgentWrongSocket(t *testing.T) {
 		t.Fatalf("creating new SSH object with bad ssh-agent socket should fail")
 	}
 }
+
+// Run() tests.
+func TestRun(t *testing.T) {
+	d := newConnected("localhost:80", nil).(*sshConnected)
+	d.runner = func(command string, stdin io.Reader, stdout, stderr io.Writer) error {
+		_, err := stdout.Write([]byte("0\n"))
+
+		return err
+	}
+
+	out, err := d.Run("id -u")
+	if err != nil {
+		t.Fatalf("running command should succeed, got: %v", err)
+	}
+
+	if out != "0\n" {
+		t.Fatalf("expected command output to be returned, got: %q", out)
+	}
+}
+
+func TestRunIncludeStderr(t *testing.T) {
+	d := newConnected("localhost:80", nil).(*sshConnected)
+	d.runner = func(command string, stdin io.Reader, stdout, stderr io.Writer) error {
+		if _, err := stderr.Write([]byte("permission denied")); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("exited with 1")
+	}
+
+	_, err := d.Run("id -u")
+	if err == nil {
+		t.Fatalf("running failing command should fail")
+	}
+
+	if !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("error should include command's standard error output, got: %v", err)
+	}
+}
+
+func TestRunNotSupported(t *testing.T) {
+	d := newConnected("localhost:80", nil).(*sshConnected)
+
+	if _, err := d.Run("id -u"); err == nil {
+		t.Fatalf("running command without SSH client should fail")
+	}
+}
@@ -19,6 +19,14 @@ type Connected interface {
 	ForwardTCP(remoteAddr string) (localAddr string, err error)
 }
 
+// CommandRunner is an optional interface, which connected transports may implement, if they are
+// able to execute commands on the host.
+type CommandRunner interface {
+	// Run executes given shell command on the host and returns it's standard output. If command
+	// fails, returned error should include it's standard error output.
+	Run(command string) (string, error)
+}
+
 // Config describes how Transport interface should be created.
 type Config interface {
 	// New returns new instance of Transport object.
@@ -125,6 +125,11 @@ type Pool struct {
 	// Serializable fields.
 	State container.ContainersState `json:"state,omitempty"`
 
+	// AllowRemoveAll allows removing all created containers, when list of kubelets is empty. Without it,
+	// deployment fails in such case, to prevent accidental removal, e.g. caused by invalid
+	// configuration.
+	AllowRemoveAll bool `json:"allowRemoveAll,omitempty"`
+
 	// WaitForNodeReady controls, if deploy should wait until node becomes ready.
 	WaitForNodeReady bool `json:"waitForNodeReady,omitempty"`
 }
@@ -208,8 +213,9 @@ func (p *Pool) New() (types.Resource, error) {
 	}
 
 	cc := &container.Containers{
-		PreviousState: p.State,
-		DesiredState:  make(container.ContainersState),
+		PreviousState:  p.State,
+		DesiredState:   make(container.ContainersState),
+		AllowRemoveAll: p.AllowRemoveAll,
 	}
 
 	for i := range p.Kubelets {
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// readyzPath is a path of API server endpoint, which reports readiness of the API server,
+// including connectivity to etcd.
+const readyzPath = "/readyz"
+
+// APIServerReady checks readiness of API server using it's readyz endpoint. On failure,
+// returned error includes the response, which lists failed readiness checks.
+func (c *client) APIServerReady(ctx context.Context) error {
+	b, err := c.Discovery().RESTClient().Get().AbsPath(readyzPath).Param("verbose", "true").DoRaw(ctx)
+	if err != nil {
+		if r := strings.TrimSpace(string(b)); r != "" {
+			return fmt.Errorf("failed fetching %s: %w: %s", readyzPath, err, r)
+		}
+
+		return fmt.Errorf("failed fetching %s: %w", readyzPath, err)
+	}
+
+	return nil
+}
+
+// NodeNames returns names of all Node objects in the cluster.
+func (c *client) NodeNames(ctx context.Context) ([]string, error) {
+	nodes, err := c.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed listing nodes: %w", err)
+	}
+
+	names := []string{}
+
+	for _, n := range nodes.Items {
+		names = append(names, n.Name)
+	}
+
+	return names, nil
+}
+
+// LeaseRenewTime returns the time, when given Lease object has been renewed for the last
+// time. Leases are used for leader election, so it allows to check, if elected leader of
+// the component is alive.
+func (c *client) LeaseRenewTime(ctx context.Context, namespace, name string) (time.Time, error) {
+	l, err := c.CoordinationV1().Leases(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed getting lease %s/%s: %w", namespace, name, err)
+	}
+
+	if l.Spec.RenewTime == nil {
+		return time.Time{}, fmt.Errorf("lease %s/%s has never been renewed", namespace, name)
+	}
+
+	return l.Spec.RenewTime.Time, nil
+}
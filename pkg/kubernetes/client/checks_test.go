@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func checksTestClient(t *testing.T) (Client, context.Context, context.CancelFunc) {
+	t.Helper()
+
+	c, err := NewClient([]byte(GetKubeconfig(t)))
+	if err != nil {
+		t.Fatalf("Failed creating client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+
+	return c, ctx, cancel
+}
+
+// APIServerReady() tests.
+func TestAPIServerReadyFakeKubeconfig(t *testing.T) {
+	c, ctx, cancel := checksTestClient(t)
+	defer cancel()
+
+	if err := c.APIServerReady(ctx); err == nil {
+		t.Fatalf("Checking API server readiness should always fail with fake kubeconfig")
+	}
+}
+
+// NodeNames() tests.
+func TestNodeNamesFakeKubeconfig(t *testing.T) {
+	c, ctx, cancel := checksTestClient(t)
+	defer cancel()
+
+	if _, err := c.NodeNames(ctx); err == nil {
+		t.Fatalf("Listing nodes should always fail with fake kubeconfig")
+	}
+}
+
+// LeaseRenewTime() tests.
+func TestLeaseRenewTimeFakeKubeconfig(t *testing.T) {
+	c, ctx, cancel := checksTestClient(t)
+	defer cancel()
+
+	if _, err := c.LeaseRenewTime(ctx, "kube-system", "kube-scheduler"); err == nil {
+		t.Fatalf("Getting lease should always fail with fake kubeconfig")
+	}
+}
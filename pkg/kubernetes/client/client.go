@@ -9,6 +9,7 @@ import (
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -43,6 +44,30 @@ type Client interface {
 
 	// PingWait waits until API server becomes available.
 	PingWait() error
+
+	// ReencryptSecrets re-writes all secrets, so they get encrypted with current encryption key.
+	ReencryptSecrets() error
+
+	// RecordDeployResult records given deploy result in the cluster.
+	RecordDeployResult(result DeployResult) error
+
+	// ApplySecret creates given Secret or updates it, if it already exists.
+	ApplySecret(secret *v1.Secret) error
+
+	// APIServerFlags returns flags reported by the API server.
+	APIServerFlags() (map[string]string, error)
+
+	// ApplyClusterRoleBinding creates given ClusterRoleBinding or updates it, if it already exists.
+	ApplyClusterRoleBinding(binding *rbacv1.ClusterRoleBinding) error
+
+	// APIServerReady checks, if API server reports, that it's ready to serve requests.
+	APIServerReady(ctx context.Context) error
+
+	// NodeNames returns names of all Node objects.
+	NodeNames(ctx context.Context) ([]string, error)
+
+	// LeaseRenewTime returns last renewal time of given Lease object.
+	LeaseRenewTime(ctx context.Context, namespace, name string) (time.Time, error)
 }
 
 type client struct {
@@ -156,3 +181,129 @@ func (c *client) LabelNode(name string, labels map[string]string) error {
 
 	return nil
 }
+
+// ReencryptSecrets updates all Secret objects in the cluster without changing them, which
+// makes kube-apiserver store them encrypted with currently configured encryption key.
+//
+// Secrets modified in the meantime are skipped, as they have been already re-written.
+func (c *client) ReencryptSecrets() error {
+	secrets, err := c.CoreV1().Secrets("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed listing secrets: %w", err)
+	}
+
+	for i := range secrets.Items {
+		s := &secrets.Items[i]
+
+		_, err := c.CoreV1().Secrets(s.Namespace).Update(context.TODO(), s, metav1.UpdateOptions{})
+		if err != nil && !errors.IsConflict(err) && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed updating secret %s/%s: %w", s.Namespace, s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ApplySecret creates given Secret. If Secret already exists, it's data and labels
+// are updated to match given Secret, so the function can be called repeatedly.
+func (c *client) ApplySecret(secret *v1.Secret) error {
+	secrets := c.CoreV1().Secrets(secret.Namespace)
+
+	existing, err := secrets.Get(context.TODO(), secret.Name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed getting secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+
+	if errors.IsNotFound(err) {
+		if _, err := secrets.Create(context.TODO(), secret, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed creating secret %s/%s: %w", secret.Namespace, secret.Name, err)
+		}
+
+		return nil
+	}
+
+	if existing.Type != secret.Type {
+		return fmt.Errorf("secret %s/%s has type %q, expected %q", secret.Namespace, secret.Name, existing.Type, secret.Type)
+	}
+
+	if existing.Labels == nil {
+		existing.Labels = map[string]string{}
+	}
+
+	for k, v := range secret.Labels {
+		existing.Labels[k] = v
+	}
+
+	existing.Data = secret.Data
+
+	if _, err := secrets.Update(context.TODO(), existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed updating secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+
+	return nil
+}
+
+// ApplyClusterRoleBinding creates given ClusterRoleBinding. If ClusterRoleBinding already
+// exists, it's subjects are updated to match given ClusterRoleBinding. As role reference
+// can't be changed, error is returned, if it differs.
+func (c *client) ApplyClusterRoleBinding(binding *rbacv1.ClusterRoleBinding) error {
+	bindings := c.RbacV1().ClusterRoleBindings()
+
+	existing, err := bindings.Get(context.TODO(), binding.Name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed getting cluster role binding %s: %w", binding.Name, err)
+	}
+
+	if errors.IsNotFound(err) {
+		if _, err := bindings.Create(context.TODO(), binding, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed creating cluster role binding %s: %w", binding.Name, err)
+		}
+
+		return nil
+	}
+
+	if existing.RoleRef != binding.RoleRef {
+		return fmt.Errorf("cluster role binding %s references %+v, expected %+v", binding.Name, existing.RoleRef, binding.RoleRef)
+	}
+
+	existing.Subjects = binding.Subjects
+
+	if _, err := bindings.Update(context.TODO(), existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed updating cluster role binding %s: %w", binding.Name, err)
+	}
+
+	return nil
+}
+
+// flagzPath is a path, where Kubernetes components report flags they have been started with.
+// It requires ComponentFlagz feature gate to be enabled.
+const flagzPath = "/flagz"
+
+// APIServerFlags fetches flags, which API server has been started with, from it's flagz
+// endpoint. Keys of returned map are flag names with '--' prefix, so they can be easily
+// compared with container arguments.
+func (c *client) APIServerFlags() (map[string]string, error) {
+	b, err := c.Discovery().RESTClient().Get().AbsPath(flagzPath).DoRaw(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching %s: %w", flagzPath, err)
+	}
+
+	return parseFlagz(string(b)), nil
+}
+
+// parseFlagz parses flagz endpoint response in 'name=value' per line format. Lines without
+// '=' character, like headers and warnings, are ignored.
+func parseFlagz(s string) map[string]string {
+	flags := map[string]string{}
+
+	for _, l := range strings.Split(s, "\n") {
+		kv := strings.SplitN(strings.TrimSpace(l), "=", 2)
+		if len(kv) != 2 || kv[0] == "" || strings.ContainsAny(kv[0], " \t") {
+			continue
+		}
+
+		flags["--"+strings.TrimPrefix(kv[0], "--")] = kv[1]
+	}
+
+	return flags
+}
@@ -2,6 +2,12 @@ package client
 
 import (
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestCheckNodeExistsFakeKubeconfig(t *testing.T) {
@@ -92,3 +98,79 @@ func TestCheckNodeReadyFakeKubeconfig(t *testing.T) {
 		t.Errorf("check should swallow all errors and just return boolean value")
 	}
 }
+
+// ApplySecret() tests.
+func TestApplySecretFakeKubeconfig(t *testing.T) {
+	kubeconfig := GetKubeconfig(t)
+
+	c, err := NewClient([]byte(kubeconfig))
+	if err != nil {
+		t.Fatalf("Failed creating client: %v", err)
+	}
+
+	s := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "kube-system",
+		},
+	}
+
+	if err := c.ApplySecret(s); err == nil {
+		t.Errorf("Applying secret should always fail with fake kubeconfig")
+	}
+}
+
+// ApplyClusterRoleBinding() tests.
+func TestApplyClusterRoleBindingFakeKubeconfig(t *testing.T) {
+	kubeconfig := GetKubeconfig(t)
+
+	c, err := NewClient([]byte(kubeconfig))
+	if err != nil {
+		t.Fatalf("Failed creating client: %v", err)
+	}
+
+	b := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+		},
+	}
+
+	if err := c.ApplyClusterRoleBinding(b); err == nil {
+		t.Errorf("Applying cluster role binding should always fail with fake kubeconfig")
+	}
+}
+
+// APIServerFlags() tests.
+func TestAPIServerFlagsFakeKubeconfig(t *testing.T) {
+	kubeconfig := GetKubeconfig(t)
+
+	c, err := NewClient([]byte(kubeconfig))
+	if err != nil {
+		t.Fatalf("Failed creating client: %v", err)
+	}
+
+	if _, err := c.APIServerFlags(); err == nil {
+		t.Fatalf("Fetching API server flags should always fail with fake kubeconfig")
+	}
+}
+
+// parseFlagz() tests.
+func TestParseFlagz(t *testing.T) {
+	s := `kube-apiserver flags
+Warning: This endpoint is not meant to be machine parseable.
+
+advertise-address=192.168.1.2
+--secure-port=6443
+requestheader-allowed-names=
+`
+
+	expected := map[string]string{
+		"--advertise-address":           "192.168.1.2",
+		"--secure-port":                 "6443",
+		"--requestheader-allowed-names": "",
+	}
+
+	if diff := cmp.Diff(expected, parseFlagz(s)); diff != "" {
+		t.Fatalf("Unexpected flags: %s", diff)
+	}
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DeployResultsNamespace is a namespace, where deploy results are recorded.
+	DeployResultsNamespace = "kube-system"
+
+	// DeployResultsConfigMap is a name of the ConfigMap, which stores the latest deploy
+	// result of each resource.
+	DeployResultsConfigMap = "flexkube-deploy-results"
+
+	// deployResultsComponent is a component name used as a source of recorded events.
+	deployResultsComponent = "flexkube"
+)
+
+// DeployResult describes the outcome of deploying a resource.
+type DeployResult struct {
+	// Resource is a name of the deployed resource, e.g. 'controlplane'.
+	Resource string `json:"resource"`
+
+	// Changed is a list of components, which has been changed by the deployment.
+	Changed []string `json:"changed,omitempty"`
+
+	// Time is a time, when the deployment finished.
+	Time time.Time `json:"time"`
+
+	// Error is an error message, if the deployment failed.
+	Error string `json:"error,omitempty"`
+}
+
+// reason returns Event reason for the deploy result.
+func (r DeployResult) reason() string {
+	if r.Error != "" {
+		return "DeployFailed"
+	}
+
+	return "DeploySucceeded"
+}
+
+// message returns human readable description of the deploy result.
+func (r DeployResult) message() string {
+	changed := "no components changed"
+
+	if len(r.Changed) > 0 {
+		changed = fmt.Sprintf("changed components: %s", strings.Join(r.Changed, ", "))
+	}
+
+	if r.Error != "" {
+		return fmt.Sprintf("Deploying %s failed, %s: %s", r.Resource, changed, r.Error)
+	}
+
+	return fmt.Sprintf("Deployed %s, %s", r.Resource, changed)
+}
+
+// RecordDeployResult stores given deploy result in the well-known ConfigMap, under the
+// key named after the resource and emits an Event referring to this ConfigMap.
+func (c *client) RecordDeployResult(r DeployResult) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed encoding deploy result: %w", err)
+	}
+
+	if err := c.updateDeployResults(r.Resource, string(data)); err != nil {
+		return fmt.Errorf("failed updating deploy results ConfigMap: %w", err)
+	}
+
+	eventType := v1.EventTypeNormal
+	if r.Error != "" {
+		eventType = v1.EventTypeWarning
+	}
+
+	t := metav1.NewTime(r.Time)
+
+	e := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-", DeployResultsConfigMap),
+			Namespace:    DeployResultsNamespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Namespace:  DeployResultsNamespace,
+			Name:       DeployResultsConfigMap,
+		},
+		Reason:         r.reason(),
+		Message:        r.message(),
+		Type:           eventType,
+		FirstTimestamp: t,
+		LastTimestamp:  t,
+		Count:          1,
+		Source: v1.EventSource{
+			Component: deployResultsComponent,
+		},
+	}
+
+	if _, err := c.CoreV1().Events(DeployResultsNamespace).Create(context.TODO(), e, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed creating event: %w", err)
+	}
+
+	return nil
+}
+
+// updateDeployResults sets given key in deploy results ConfigMap, creating the ConfigMap
+// if it does not exist.
+func (c *client) updateDeployResults(key, value string) error {
+	cms := c.CoreV1().ConfigMaps(DeployResultsNamespace)
+
+	cm, err := cms.Get(context.TODO(), DeployResultsConfigMap, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if errors.IsNotFound(err) {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      DeployResultsConfigMap,
+				Namespace: DeployResultsNamespace,
+			},
+			Data: map[string]string{
+				key: value,
+			},
+		}
+
+		_, err := cms.Create(context.TODO(), cm, metav1.CreateOptions{})
+
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+
+	cm.Data[key] = value
+
+	_, err = cms.Update(context.TODO(), cm, metav1.UpdateOptions{})
+
+	return err
+}
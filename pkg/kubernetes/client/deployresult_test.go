@@ -0,0 +1,50 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeployResultMessage(t *testing.T) {
+	r := DeployResult{
+		Resource: "controlplane",
+		Changed:  []string{"kube-apiserver", "kube-scheduler"},
+	}
+
+	if r.reason() != "DeploySucceeded" {
+		t.Fatalf("Unexpected reason for successful deploy: %q", r.reason())
+	}
+
+	if m := r.message(); !strings.Contains(m, "kube-apiserver, kube-scheduler") {
+		t.Fatalf("Message should contain changed components, got: %q", m)
+	}
+
+	r.Error = "foo failed"
+
+	if r.reason() != "DeployFailed" {
+		t.Fatalf("Unexpected reason for failed deploy: %q", r.reason())
+	}
+
+	if m := r.message(); !strings.Contains(m, r.Error) {
+		t.Fatalf("Message should contain error, got: %q", m)
+	}
+}
+
+func TestRecordDeployResultFakeKubeconfig(t *testing.T) {
+	kubeconfig := GetKubeconfig(t)
+
+	c, err := NewClient([]byte(kubeconfig))
+	if err != nil {
+		t.Fatalf("Failed creating client: %v", err)
+	}
+
+	r := DeployResult{
+		Resource: "controlplane",
+		Time:     time.Now(),
+	}
+
+	if err := c.RecordDeployResult(r); err == nil {
+		t.Errorf("Recording deploy result should always fail with fake kubeconfig")
+	}
+}
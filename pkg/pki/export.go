@@ -0,0 +1,143 @@
+package pki
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/flexkube/libflexkube/internal/util"
+)
+
+const (
+	// defaultExportConcurrency is a default number of certificates exported at the same time.
+	defaultExportConcurrency = 1
+
+	// exportDirectoryMode is a permission set on directories created by ExportToDirectory().
+	exportDirectoryMode = 0o700
+
+	// exportCertificateMode is a permission set on certificate files created by ExportToDirectory().
+	exportCertificateMode = 0o644
+
+	// exportPrivateKeyMode is a permission set on private key files created by ExportToDirectory().
+	exportPrivateKeyMode = 0o600
+)
+
+// exportConcurrency returns configured export concurrency or the default one.
+func (k *Kubernetes) exportConcurrency() int {
+	if k.ExportConcurrency <= 0 {
+		return defaultExportConcurrency
+	}
+
+	return k.ExportConcurrency
+}
+
+// exportAll calls given export function for each given certificate, running at most
+// given number of exports at the same time. Failure of single export does not stop
+// the others. All errors are returned combined, in the order of the certificates.
+func exportAll(ncs []namedCertificate, concurrency int, export func(namedCertificate) error) error {
+	if concurrency <= 0 {
+		concurrency = defaultExportConcurrency
+	}
+
+	var wg sync.WaitGroup
+
+	slots := make(chan struct{}, concurrency)
+	errs := make([]error, len(ncs))
+
+	for i, nc := range ncs {
+		wg.Add(1)
+
+		slots <- struct{}{}
+
+		go func(i int, nc namedCertificate) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			errs[i] = export(nc)
+		}(i, nc)
+	}
+
+	wg.Wait()
+
+	var errors util.ValidateError
+
+	for _, err := range errs {
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	return errors.Return()
+}
+
+// generated returns all Kubernetes certificates, which has been generated.
+func (k *Kubernetes) generated() []namedCertificate {
+	r := []namedCertificate{}
+
+	for _, nc := range k.certificates() {
+		if nc.certificate.X509Certificate != "" {
+			r = append(r, nc)
+		}
+	}
+
+	return r
+}
+
+// writeToDirectory writes the certificate, it's private key and the CA certificate
+// into a sub-directory of given directory named after the certificate, using the same
+// file names as keys in the Secret.
+func (nc namedCertificate) writeToDirectory(dir string) error {
+	s := nc.secret("")
+	d := filepath.Join(dir, nc.name)
+
+	if err := os.MkdirAll(d, exportDirectoryMode); err != nil {
+		return fmt.Errorf("failed creating directory %q: %w", d, err)
+	}
+
+	for _, k := range []string{v1.TLSCertKey, secretCAKey, v1.TLSPrivateKeyKey} {
+		content, ok := s.Data[k]
+		if !ok {
+			continue
+		}
+
+		var mode os.FileMode = exportCertificateMode
+		if k == v1.TLSPrivateKeyKey {
+			mode = exportPrivateKeyMode
+		}
+
+		p := filepath.Join(d, k)
+
+		if err := ioutil.WriteFile(p, content, mode); err != nil {
+			return fmt.Errorf("failed writing file %q: %w", p, err)
+		}
+	}
+
+	return nil
+}
+
+// ExportToDirectory writes all generated Kubernetes certificates with their private keys
+// into given directory. Each certificate is written into a sub-directory named after the
+// certificate, e.g. 'kubernetes-ca', which contains 'tls.crt', 'tls.key' and 'ca.crt' files,
+// like the Secrets created by ExportToSecrets().
+//
+// Certificates are written concurrently, respecting ExportConcurrency. Failure to write one
+// certificate does not stop writing the others and all failures are returned combined.
+func (k *Kubernetes) ExportToDirectory(dir string) error {
+	if dir == "" {
+		return fmt.Errorf("directory must be set")
+	}
+
+	return exportAll(k.generated(), k.exportConcurrency(), func(nc namedCertificate) error {
+		if err := nc.writeToDirectory(dir); err != nil {
+			return fmt.Errorf("failed exporting certificate %q: %w", nc.name, err)
+		}
+
+		return nil
+	})
+}
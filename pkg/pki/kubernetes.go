@@ -12,6 +12,13 @@ const (
 	// KubernetesFrontProxyCACN is a default CN for Kubernetes front proxy CA certificate,
 	// as recommended by https://kubernetes.io/docs/setup/best-practices/certificates/.
 	KubernetesFrontProxyCACN = "kubernetes-front-proxy-ca"
+
+	// KubernetesKubeletServingCACN is a default CN for Kubernetes kubelet serving CA certificate.
+	KubernetesKubeletServingCACN = "kubernetes-kubelet-serving-ca"
+
+	// KubeAPIServerKubeletClientCN is a default CN for kube-apiserver client certificate used
+	// for talking to kubelets.
+	KubeAPIServerKubeletClientCN = "kube-apiserver-kubelet-client"
 )
 
 // Kubernetes stores Kubernetes PKI and settings.
@@ -25,6 +32,11 @@ type Kubernetes struct {
 	// FrontProxyCA stores Kubernetes front-proxy CA certificate, required for API aggregation.
 	FrontProxyCA *Certificate `json:"frontProxyCA,omitempty"`
 
+	// KubeletServingCA stores optional CA certificate, which will be used for signing kubelet
+	// serving certificates instead of Kubernetes CA. It is only generated, if this field is set,
+	// so to request generation, set it to an empty struct.
+	KubeletServingCA *Certificate `json:"kubeletServingCA,omitempty"`
+
 	// KubeAPIServer stores kube-apiserver specific certificates.
 	KubeAPIServer *KubeAPIServer `json:"kubeAPIServer,omitempty"`
 
@@ -40,6 +52,16 @@ type Kubernetes struct {
 	// ServiceAccountCertificate stores public and private key used for signing and verifying
 	// service account tokens by kube-controller-manager and kube-apiserver.
 	ServiceAccountCertificate *Certificate `json:"serviceAccountCertificate,omitempty"`
+
+	// ExportConcurrency is a maximum number of certificates, which are exported at the same
+	// time by ExportToSecrets() and ExportToDirectory(). Defaults to 1.
+	ExportConcurrency int `json:"exportConcurrency,omitempty"`
+
+	// OnCertificateEvent is an optional callback, which will be called each time
+	// Kubernetes certificate is issued or renewed.
+	//
+	// Due to it's nature, it can only be set programmatically.
+	OnCertificateEvent func(CertEvent) `json:"-"`
 }
 
 // KubeAPIServer stores kube-apiserver certificates.
@@ -61,6 +83,15 @@ type KubeAPIServer struct {
 	// KubeletCertificate stores client certificate used for talking to kubelet on the nodes.
 	KubeletCertificate *Certificate `json:"kubeletCertificate,omitempty"`
 
+	// ScopedKubeletCertificate controls, if client certificate used for talking to kubelet
+	// should be generated without 'system:masters' organization. Such certificate only has
+	// permissions granted via RBAC, so 'system:kubelet-api-admin' ClusterRole must be bound to
+	// it's common name. Common name and organization can be customized using KubeletCertificate
+	// field.
+	//
+	// Changing this field does not regenerate already generated certificate.
+	ScopedKubeletCertificate bool `json:"scopedKubeletCertificate,omitempty"`
+
 	// FrontProxyClientCertificate stores client certificate used for talking to extending
 	// API servers.
 	FrontProxyClientCertificate *Certificate `json:"frontProxyClientCertificate,omitempty"`
@@ -72,6 +103,7 @@ func (k *Kubernetes) kubernetesCACR(rootCA *Certificate, defaultCertificate Cert
 	}
 
 	return &certificateRequest{
+		Name:   "kubernetes-ca",
 		Target: k.CA,
 		CA:     rootCA,
 		Certificates: []*Certificate{
@@ -89,6 +121,7 @@ func (k *Kubernetes) kubernetesFrontProxyCACR(rootCA *Certificate, defaultCertif
 	}
 
 	return &certificateRequest{
+		Name:   "kubernetes-front-proxy-ca",
 		Target: k.FrontProxyCA,
 		CA:     rootCA,
 		Certificates: []*Certificate{
@@ -100,12 +133,27 @@ func (k *Kubernetes) kubernetesFrontProxyCACR(rootCA *Certificate, defaultCertif
 	}
 }
 
+func (k *Kubernetes) kubeletServingCACR(rootCA *Certificate, defaultCertificate Certificate) *certificateRequest {
+	return &certificateRequest{
+		Name:   "kubernetes-kubelet-serving-ca",
+		Target: k.KubeletServingCA,
+		CA:     rootCA,
+		Certificates: []*Certificate{
+			&defaultCertificate,
+			&k.Certificate,
+			caCertificate(KubernetesKubeletServingCACN),
+			k.KubeletServingCA,
+		},
+	}
+}
+
 func (k *Kubernetes) kubeAPIServerServerCR(defaultCertificate Certificate) *certificateRequest {
 	if k.KubeAPIServer.ServerCertificate == nil {
 		k.KubeAPIServer.ServerCertificate = &Certificate{}
 	}
 
 	return &certificateRequest{
+		Name:   "kube-apiserver-server",
 		Target: k.KubeAPIServer.ServerCertificate,
 		CA:     k.CA,
 		Certificates: []*Certificate{
@@ -124,13 +172,14 @@ func (k *Kubernetes) kubeAPIServerKubeletCR(defaultCertificate Certificate) *cer
 	}
 
 	return &certificateRequest{
+		Name:   "kube-apiserver-kubelet-client",
 		Target: k.KubeAPIServer.KubeletCertificate,
 		CA:     k.CA,
 		Certificates: []*Certificate{
 			&defaultCertificate,
 			&k.Certificate,
 			&k.KubeAPIServer.Certificate,
-			defaultKubeAPIServerKubeletCertificate(),
+			defaultKubeAPIServerKubeletCertificate(k.KubeAPIServer.ScopedKubeletCertificate),
 			k.KubeAPIServer.KubeletCertificate,
 		},
 	}
@@ -142,6 +191,7 @@ func (k *Kubernetes) kubeAPIServerFrontProxyClientCR(defaultCertificate Certific
 	}
 
 	return &certificateRequest{
+		Name:   "kube-apiserver-front-proxy-client",
 		Target: k.KubeAPIServer.FrontProxyClientCertificate,
 		CA:     k.FrontProxyCA,
 		Certificates: []*Certificate{
@@ -160,6 +210,7 @@ func (k *Kubernetes) adminCR(defaultCertificate Certificate) *certificateRequest
 	}
 
 	return &certificateRequest{
+		Name:   "admin",
 		Target: k.AdminCertificate,
 		CA:     k.CA,
 		Certificates: []*Certificate{
@@ -181,6 +232,7 @@ func (k *Kubernetes) kubeControllerManagerCR(defaultCertificate Certificate) *ce
 	}
 
 	return &certificateRequest{
+		Name:   "kube-controller-manager",
 		Target: k.KubeControllerManagerCertificate,
 		CA:     k.CA,
 		Certificates: []*Certificate{
@@ -202,7 +254,12 @@ func (k *Kubernetes) Generate(rootCA *Certificate, defaultCertificate Certificat
 		k.kubernetesFrontProxyCACR(rootCA, defaultCertificate),
 	}
 
-	if err := buildAndGenerate(crs...); err != nil {
+	// Dedicated kubelet serving CA is optional, so only generate it when requested.
+	if k.KubeletServingCA != nil {
+		crs = append(crs, k.kubeletServingCACR(rootCA, defaultCertificate))
+	}
+
+	if err := buildAndGenerate(k.withCallback(crs)...); err != nil {
 		return fmt.Errorf("failed to generate kubernetes CA certificates: %w", err)
 	}
 
@@ -220,7 +277,16 @@ func (k *Kubernetes) Generate(rootCA *Certificate, defaultCertificate Certificat
 		k.serviceAccountCR(defaultCertificate),
 	}
 
-	return buildAndGenerate(crs...)
+	return buildAndGenerate(k.withCallback(crs)...)
+}
+
+// withCallback sets configured certificate event callback for all given certificate requests.
+func (k *Kubernetes) withCallback(crs []*certificateRequest) []*certificateRequest {
+	for _, cr := range crs {
+		cr.OnGenerated = k.OnCertificateEvent
+	}
+
+	return crs
 }
 
 func (k *Kubernetes) serviceAccountCR(defaultCertificate Certificate) *certificateRequest {
@@ -229,6 +295,7 @@ func (k *Kubernetes) serviceAccountCR(defaultCertificate Certificate) *certifica
 	}
 
 	return &certificateRequest{
+		Name:   "service-account",
 		Target: k.ServiceAccountCertificate,
 		CA:     k.CA,
 		Certificates: []*Certificate{
@@ -245,6 +312,7 @@ func (k *Kubernetes) kubeSchedulerCR(defaultCertificate Certificate) *certificat
 	}
 
 	return &certificateRequest{
+		Name:   "kube-scheduler",
 		Target: k.KubeSchedulerCertificate,
 		CA:     k.CA,
 		Certificates: []*Certificate{
@@ -283,12 +351,21 @@ func defaultKubeAPIServerServerCertificate(k *KubeAPIServer) *Certificate {
 	return c
 }
 
-func defaultKubeAPIServerKubeletCertificate() *Certificate {
-	return &Certificate{
-		CommonName:   "kube-apiserver-kubelet-client",
+// defaultKubeAPIServerKubeletCertificate returns default kube-apiserver kubelet client
+// certificate. If scoped certificate is requested, it is not member of 'system:masters'
+// group, so it's permissions can be limited using RBAC.
+func defaultKubeAPIServerKubeletCertificate(scoped bool) *Certificate {
+	c := &Certificate{
+		CommonName:   KubeAPIServerKubeletClientCN,
 		Organization: "system:masters",
 		KeyUsage:     clientUsage(),
 	}
+
+	if scoped {
+		c.Organization = ""
+	}
+
+	return c
 }
 
 func defaultKubeAPIServerFrontProxyClientCertificate() *Certificate {
@@ -35,9 +35,16 @@ const (
 	// is will be renewed.
 	RenewThreshold = "720h"
 
+	// NotBeforeSkew is a default duration, by which start of the validity of generated
+	// certificates is moved to the past, to tolerate hosts with clocks being behind.
+	NotBeforeSkew = "5m"
+
 	// X509CertificatePEMHeader is a PEM format header used while encoding X.509 certificates.
 	X509CertificatePEMHeader = "CERTIFICATE"
 
+	// CSRPEMHeader is a PEM format header used while encoding certificate signing requests.
+	CSRPEMHeader = "CERTIFICATE REQUEST"
+
 	// RSAPrivateKeyPEMHeader is a PEM format header user while encoding RSA private keys.
 	RSAPrivateKeyPEMHeader = "RSA PRIVATE KEY"
 
@@ -98,12 +105,28 @@ type Certificate struct {
 	// be re-generated.
 	RenewThreshold string `json:"renewThreshold,omitempty"`
 
+	// NotBeforeSkew defines, how much start of the validity of generated certificate
+	// should be moved to the past. This prevents "certificate is not yet valid" errors on
+	// hosts, which clocks are slightly behind. It applies to both CA and other certificates.
+	// Set to '0s' to make certificates valid from the time of generation.
+	//
+	// Example value: '5m'.
+	NotBeforeSkew string `json:"notBeforeSkew,omitempty"`
+
 	// CommonName defined CN field for the certificate.
 	CommonName string `json:"commonName,omitempty"`
 
 	// CA controls if certificate should be self-signed while generated.
 	CA bool `json:"ca,omitempty"`
 
+	// MaxPathLen limits, how many intermediate CA certificates may follow this CA certificate
+	// in the certificate chain, using path length constraint in the basic constraints extension.
+	// Setting it to 0 prevents the CA from issuing further sub-CAs. If not set, path length is
+	// not constrained. It is ignored for non-CA certificates.
+	//
+	// Example value: '0'.
+	MaxPathLen *int `json:"maxPathLen,omitempty"`
+
 	// KeyUsage is a list of key usages. Valid values are:
 	// - "digital_signature"
 	// - "content_commitment"
@@ -134,6 +157,27 @@ type Certificate struct {
 	// DNSNames defines extra hostnames, which will be valid for the certificate.
 	DNSNames []string `json:"dnsNames,omitempty"`
 
+	// ExternalSigning enables mode, where certificates are signed by an external CA, for example
+	// enterprise CA or HSM. In this mode, only private key and certificate signing request (CSR)
+	// are generated, which must be then signed externally and imported back using
+	// ImportSignedCertificate method. CA certificates are not generated at all, so externally
+	// issued CA certificates must be imported as well.
+	ExternalSigning bool `json:"externalSigning,omitempty"`
+
+	// VerifyGenerated controls, if newly generated certificate should be verified immediately
+	// after generation. Verification checks, that certificate matches it's private key, that
+	// it is signed by the CA and that it is currently valid.
+	VerifyGenerated bool `json:"verifyGenerated,omitempty"`
+
+	// GenerationRetries defines, how many times certificate generation should be retried,
+	// if verification of generated certificate fails. It has effect only when VerifyGenerated
+	// is enabled.
+	GenerationRetries int `json:"generationRetries,omitempty"`
+
+	// CSR stores generated certificate signing request, PEM encoded. It is only generated,
+	// when ExternalSigning is enabled and certificate has not been imported yet.
+	CSR string `json:"csr,omitempty"`
+
 	// X509Certificate stores generated certificate in X.509 certificate format, PEM encoded.
 	X509Certificate types.Certificate `json:"x509Certificate,omitempty"`
 
@@ -200,26 +244,112 @@ func caCertificate(cn string) *Certificate {
 	}
 }
 
+// CertEventAction describes, what happened with the certificate.
+type CertEventAction string
+
+const (
+	// CertEventIssued is an action used, when new certificate has been issued.
+	CertEventIssued CertEventAction = "issued"
+
+	// CertEventRenewed is an action used, when existing certificate has been replaced
+	// with the new one.
+	CertEventRenewed CertEventAction = "renewed"
+)
+
+// CertEvent describes (re)generated certificate.
+type CertEvent struct {
+	// Name is a name of the certificate, e.g. 'kube-apiserver-server'.
+	Name string
+
+	// Action describes, what happened with the certificate.
+	Action CertEventAction
+
+	// NotAfter is an expiry time of the new certificate.
+	NotAfter time.Time
+}
+
 type certificateRequest struct {
+	Name         string
 	Target       *Certificate
 	CA           *Certificate
 	Certificates []*Certificate
+	OnGenerated  func(CertEvent)
 }
 
 func buildAndGenerate(crs ...*certificateRequest) error {
 	for _, cr := range crs {
+		r, err := cr.generate()
+		if err != nil {
+			return err
+		}
+
+		old := cr.Target.X509Certificate
+
+		*cr.Target = *r
+
+		if err := cr.notify(old); err != nil {
+			return fmt.Errorf("failed to notify about generated certificate: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// generate builds and generates the certificate. If verification of generated certificates
+// is enabled, newly generated certificate is verified and generation is retried configured
+// number of times, if verification fails.
+func (cr *certificateRequest) generate() (*Certificate, error) {
+	var verifyErr error
+
+	for attempt := 0; ; attempt++ {
+		// Build the certificate from scratch on every attempt, so nothing generated in
+		// previous attempt is reused.
 		r, err := buildCertificate(cr.Certificates...)
 		if err != nil {
-			return fmt.Errorf("failed to build certificate configuration: %w", err)
+			return nil, fmt.Errorf("failed to build certificate configuration: %w", err)
+		}
+
+		if attempt > r.GenerationRetries {
+			return nil, fmt.Errorf("generated certificate failed verification after %d attempt(s): %w", attempt, verifyErr)
 		}
 
 		if err := r.Generate(cr.CA); err != nil {
-			return fmt.Errorf("failed to generate the certificate: %w", err)
+			return nil, fmt.Errorf("failed to generate the certificate: %w", err)
 		}
 
-		*cr.Target = *r
+		if !r.VerifyGenerated || r.X509Certificate == cr.Target.X509Certificate {
+			return r, nil
+		}
+
+		if verifyErr = r.verify(cr.CA); verifyErr == nil {
+			return r, nil
+		}
+	}
+}
+
+// notify calls configured callback, if the certificate has been issued or renewed, based on
+// the previous X.509 certificate.
+func (cr *certificateRequest) notify(old types.Certificate) error {
+	if cr.OnGenerated == nil || old == cr.Target.X509Certificate {
+		return nil
+	}
+
+	cert, err := cr.Target.decodeX509Certificate()
+	if err != nil {
+		return fmt.Errorf("failed to decode generated certificate: %w", err)
 	}
 
+	a := CertEventRenewed
+	if old == "" {
+		a = CertEventIssued
+	}
+
+	cr.OnGenerated(CertEvent{
+		Name:     cr.Name,
+		Action:   a,
+		NotAfter: cert.NotAfter,
+	})
+
 	return nil
 }
 
@@ -277,6 +407,7 @@ func buildCertificate(certs ...*Certificate) (*Certificate, error) {
 		RSABits:          RSABits,
 		ValidityDuration: ValidityDuration,
 		RenewThreshold:   RenewThreshold,
+		NotBeforeSkew:    NotBeforeSkew,
 	}
 
 	for _, c := range certs {
@@ -376,12 +507,31 @@ func (c *Certificate) Validate() error {
 		return fmt.Errorf("failed to parse validity duration %q for certificate: %w", c.ValidityDuration, err)
 	}
 
+	if c.NotBeforeSkew != "" {
+		skew, err := time.ParseDuration(c.NotBeforeSkew)
+		if err != nil {
+			return fmt.Errorf("failed to parse not before skew %q for certificate: %w", c.NotBeforeSkew, err)
+		}
+
+		if skew < 0 {
+			return fmt.Errorf("not before skew can't be negative, got %s", skew)
+		}
+	}
+
 	for _, i := range c.IPAddresses {
 		if ip := net.ParseIP(i); ip == nil {
 			return fmt.Errorf("failed parsing IP address %q", i)
 		}
 	}
 
+	if c.GenerationRetries < 0 {
+		return fmt.Errorf("generation retries can't be negative, got %d", c.GenerationRetries)
+	}
+
+	if c.MaxPathLen != nil && *c.MaxPathLen < 0 {
+		return fmt.Errorf("max path length can't be negative, got %d", *c.MaxPathLen)
+	}
+
 	if c.RSABits == 0 {
 		return fmt.Errorf("RSA bits can't be 0")
 	}
@@ -419,6 +569,11 @@ func (c *Certificate) generateX509Certificate(k *rsa.PrivateKey, ca *Certificate
 
 	vd, _ := time.ParseDuration(c.ValidityDuration)
 
+	// Not before skew is optional, so ignore parsing errors, as empty value means no skew.
+	skew, _ := time.ParseDuration(c.NotBeforeSkew)
+
+	now := time.Now()
+
 	ku, eku := c.decodeKeyUsage()
 
 	cert := x509.Certificate{
@@ -427,8 +582,8 @@ func (c *Certificate) generateX509Certificate(k *rsa.PrivateKey, ca *Certificate
 			Organization: []string{c.Organization},
 			CommonName:   c.CommonName,
 		},
-		NotBefore: time.Now(),
-		NotAfter:  time.Now().Add(vd),
+		NotBefore: now.Add(-skew),
+		NotAfter:  now.Add(vd),
 
 		KeyUsage:              ku,
 		ExtKeyUsage:           eku,
@@ -441,6 +596,12 @@ func (c *Certificate) generateX509Certificate(k *rsa.PrivateKey, ca *Certificate
 		cert.IPAddresses = append(cert.IPAddresses, net.ParseIP(i))
 	}
 
+	// Without MaxPathLenZero set, path length 0 is treated as unconstrained.
+	if c.CA && c.MaxPathLen != nil {
+		cert.MaxPathLen = *c.MaxPathLen
+		cert.MaxPathLenZero = *c.MaxPathLen == 0
+	}
+
 	pk := k
 	caCert := &cert
 
@@ -526,11 +687,18 @@ func (c *Certificate) persistX509Certificate(der []byte) error {
 // - Renewing X.509 certificate after RSA private key renewal.
 //
 // - Renewing issued certificate during CA renewal.
+//
+// If external signing is enabled, certificate signing request is generated instead of X.509
+// certificate and CA certificates are skipped.
 func (c *Certificate) Generate(ca *Certificate) error {
 	if err := c.Validate(); err != nil {
 		return fmt.Errorf("failed validating the certificate: %w", err)
 	}
 
+	if c.ExternalSigning {
+		return c.generateForExternalSigning()
+	}
+
 	k, err := c.getPrivateKey()
 	if err != nil {
 		return fmt.Errorf("failed getting private key: %w", err)
@@ -542,3 +710,117 @@ func (c *Certificate) Generate(ca *Certificate) error {
 
 	return nil
 }
+
+// generateForExternalSigning generates private key and certificate signing request for the
+// certificate, if it has not been signed yet. CA certificates are skipped, as they are
+// managed externally.
+func (c *Certificate) generateForExternalSigning() error {
+	if c.CA || c.X509Certificate != "" {
+		return nil
+	}
+
+	k, err := c.getPrivateKey()
+	if err != nil {
+		return fmt.Errorf("failed getting private key: %w", err)
+	}
+
+	if c.CSR != "" {
+		return nil
+	}
+
+	return c.generateCSR(k)
+}
+
+// generateCSR generates certificate signing request for the certificate using given private key.
+// Key usages are not included, as they are decided by the signer.
+func (c *Certificate) generateCSR(k *rsa.PrivateKey) error {
+	template := x509.CertificateRequest{
+		Subject: pkix.Name{
+			Organization: []string{c.Organization},
+			CommonName:   c.CommonName,
+		},
+		DNSNames: c.DNSNames,
+	}
+
+	for _, i := range c.IPAddresses {
+		template.IPAddresses = append(template.IPAddresses, net.ParseIP(i))
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, &template, k)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate signing request: %w", err)
+	}
+
+	var buf bytes.Buffer
+
+	if err := pem.Encode(&buf, &pem.Block{Type: CSRPEMHeader, Bytes: der}); err != nil {
+		return fmt.Errorf("failed to encode certificate signing request: %w", err)
+	}
+
+	c.CSR = buf.String()
+
+	return nil
+}
+
+// ImportSignedCertificate imports externally signed X.509 certificate, PEM encoded. If the
+// private key of the certificate has been generated, signed certificate must match it. After
+// importing, certificate signing request is removed, as it is no longer needed.
+func (c *Certificate) ImportSignedCertificate(certificate string) error {
+	s := &Certificate{
+		X509Certificate: types.Certificate(certificate),
+	}
+
+	cert, err := s.decodeX509Certificate()
+	if err != nil {
+		return fmt.Errorf("failed decoding signed certificate: %w", err)
+	}
+
+	if c.PrivateKey != "" {
+		k, err := c.decodePrivateKey()
+		if err != nil {
+			return fmt.Errorf("failed decoding private key: %w", err)
+		}
+
+		pub, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok || pub.N.Cmp(k.N) != 0 || pub.E != k.E {
+			return fmt.Errorf("signed certificate does not match the private key")
+		}
+	}
+
+	c.X509Certificate = s.X509Certificate
+	c.CSR = ""
+
+	return nil
+}
+
+// verify verifies X.509 certificate against it's private key and given CA certificate. If CA
+// is not given, certificate must be self-signed. Certificate must be also currently valid.
+func (c *Certificate) verify(ca *Certificate) error {
+	cert, pk, err := c.decodeKeypair()
+	if err != nil {
+		return fmt.Errorf("failed to decode keypair: %w", err)
+	}
+
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok || pub.N.Cmp(pk.N) != 0 || pub.E != pk.E {
+		return fmt.Errorf("certificate does not match private key")
+	}
+
+	parent := cert
+
+	if ca != nil {
+		if parent, err = ca.decodeX509Certificate(); err != nil {
+			return fmt.Errorf("failed to decode CA certificate: %w", err)
+		}
+	}
+
+	if err := parent.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		return fmt.Errorf("certificate is not signed by the CA: %w", err)
+	}
+
+	if now := time.Now(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return fmt.Errorf("certificate is not valid now, it's valid from %s to %s", cert.NotBefore, cert.NotAfter)
+	}
+
+	return nil
+}
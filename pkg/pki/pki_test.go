@@ -1,11 +1,21 @@
 package pki
 
 import (
+	"crypto/rand"
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
 )
 
 func TestGenerate(t *testing.T) {
@@ -249,3 +259,870 @@ func TestValidateRSABits(t *testing.T) {
 		t.Fatalf("certificate with 0 RSA bits should be invalid")
 	}
 }
+
+func TestValidateNotBeforeSkew(t *testing.T) {
+	t.Parallel()
+
+	for _, skew := range []string{"doh", "-5m"} {
+		c := &Certificate{
+			ValidityDuration: "24h",
+			RSABits:          RSABits,
+			NotBeforeSkew:    skew,
+		}
+
+		if err := c.Validate(); err == nil {
+			t.Errorf("certificate with not before skew %q should be invalid", skew)
+		}
+	}
+}
+
+func TestGenerateNotBeforeSkew(t *testing.T) {
+	t.Parallel()
+
+	pki := &PKI{
+		RootCA: &Certificate{
+			NotBeforeSkew: "1h",
+		},
+		Etcd: &Etcd{
+			Peers: map[string]string{
+				"controller01": "192.168.1.10",
+			},
+		},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("generating PKI should succeed, got: %v", err)
+	}
+
+	ca, err := pki.RootCA.decodeX509Certificate()
+	if err != nil {
+		t.Fatalf("decoding root CA certificate should succeed, got: %v", err)
+	}
+
+	if time.Since(ca.NotBefore) < time.Hour {
+		t.Errorf("root CA certificate should be valid since at least one hour ago, got %s", ca.NotBefore)
+	}
+
+	peer, err := pki.Etcd.PeerCertificates["controller01"].decodeX509Certificate()
+	if err != nil {
+		t.Fatalf("decoding etcd peer certificate should succeed, got: %v", err)
+	}
+
+	if s := time.Since(peer.NotBefore); s < 5*time.Minute || s >= time.Hour {
+		t.Errorf("etcd peer certificate should use default not before skew, got %s", peer.NotBefore)
+	}
+}
+
+func TestGenerateKubernetesCertificateEvents(t *testing.T) {
+	t.Parallel()
+
+	events := map[string]CertEvent{}
+
+	pki := &PKI{
+		Kubernetes: &Kubernetes{
+			OnCertificateEvent: func(e CertEvent) {
+				events[e.Name] = e
+			},
+		},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("generating valid PKI should work, got: %v", err)
+	}
+
+	if len(events) != 9 {
+		t.Fatalf("expected 9 certificate events, got %d: %+v", len(events), events)
+	}
+
+	e, ok := events["kube-apiserver-server"]
+	if !ok {
+		t.Fatalf("expected event for kube-apiserver server certificate")
+	}
+
+	if e.Action != CertEventIssued {
+		t.Fatalf("expected action %q, got %q", CertEventIssued, e.Action)
+	}
+
+	if !e.NotAfter.After(time.Now()) {
+		t.Fatalf("expected expiry time in the future, got %v", e.NotAfter)
+	}
+
+	events = map[string]CertEvent{}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("re-generating PKI should work, got: %v", err)
+	}
+
+	if len(events) != 0 {
+		t.Fatalf("no events should be emitted when no certificates were changed, got: %+v", events)
+	}
+}
+
+func TestGenerateKubeletServingCA(t *testing.T) {
+	t.Parallel()
+
+	pki := &PKI{
+		Kubernetes: &Kubernetes{},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("generating valid PKI should work, got: %v", err)
+	}
+
+	if pki.Kubernetes.KubeletServingCA != nil {
+		t.Fatalf("kubelet serving CA should not be generated by default")
+	}
+
+	pki.Kubernetes.KubeletServingCA = &Certificate{}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("generating PKI with kubelet serving CA should work, got: %v", err)
+	}
+
+	c := pki.Kubernetes.KubeletServingCA
+	if c.X509Certificate == "" || c.PrivateKey == "" {
+		t.Fatalf("kubelet serving CA should be generated when requested")
+	}
+
+	if c.X509Certificate == pki.Kubernetes.CA.X509Certificate {
+		t.Fatalf("kubelet serving CA should be different from Kubernetes CA")
+	}
+}
+
+func TestGenerateScopedKubeletCertificate(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		scoped  bool
+		masters bool
+	}{
+		"default": {masters: true},
+		"scoped":  {scoped: true},
+	}
+
+	for n, c := range cases {
+		c := c
+
+		t.Run(n, func(t *testing.T) {
+			t.Parallel()
+
+			pki := &PKI{
+				Kubernetes: &Kubernetes{
+					KubeAPIServer: &KubeAPIServer{
+						ScopedKubeletCertificate: c.scoped,
+					},
+				},
+			}
+
+			if err := pki.Generate(); err != nil {
+				t.Fatalf("Generating PKI should succeed, got: %v", err)
+			}
+
+			cert, err := pki.Kubernetes.KubeAPIServer.KubeletCertificate.decodeX509Certificate()
+			if err != nil {
+				t.Fatalf("Decoding kubelet client certificate should succeed, got: %v", err)
+			}
+
+			if cert.Subject.CommonName != KubeAPIServerKubeletClientCN {
+				t.Fatalf("Expected CN %q, got %q", KubeAPIServerKubeletClientCN, cert.Subject.CommonName)
+			}
+
+			masters := false
+
+			for _, o := range cert.Subject.Organization {
+				if o == "system:masters" {
+					masters = true
+				}
+			}
+
+			if masters != c.masters {
+				t.Fatalf("Expected system:masters membership to be %t, got organizations %v", c.masters, cert.Subject.Organization)
+			}
+		})
+	}
+}
+
+// Summary() tests.
+func TestKubernetesSummary(t *testing.T) {
+	t.Parallel()
+
+	pki := &PKI{
+		Kubernetes: &Kubernetes{
+			KubeAPIServer: &KubeAPIServer{
+				ServerIPs:     []string{"10.0.0.1"},
+				ExternalNames: []string{"api.example.com"},
+			},
+		},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("Generating PKI should succeed, got: %v", err)
+	}
+
+	s, err := pki.Kubernetes.Summary()
+	if err != nil {
+		t.Fatalf("Getting summary should succeed, got: %v", err)
+	}
+
+	names := []string{}
+
+	for _, cs := range s {
+		names = append(names, cs.Name)
+
+		if cs.Name != "kube-apiserver-server" {
+			continue
+		}
+
+		if !cs.NotAfter.After(cs.NotBefore) {
+			t.Errorf("Certificate validity should be included")
+		}
+
+		if cs.Issuer == "" || cs.Subject == "" {
+			t.Errorf("Certificate issuer and subject should be included")
+		}
+
+		if diff := cmp.Diff([]string{"127.0.0.1", "10.0.0.1"}, cs.IPAddresses); diff != "" {
+			t.Errorf("Unexpected IP addresses: %s", diff)
+		}
+	}
+
+	expectedNames := []string{
+		"kubernetes-ca",
+		"kubernetes-front-proxy-ca",
+		"kube-apiserver-server",
+		"kube-apiserver-kubelet-client",
+		"kube-apiserver-front-proxy-client",
+		"admin",
+		"kube-controller-manager",
+		"kube-scheduler",
+		"service-account",
+	}
+
+	if diff := cmp.Diff(expectedNames, names); diff != "" {
+		t.Fatalf("Unexpected certificates in summary: %s", diff)
+	}
+}
+
+func TestKubernetesSummaryNotGenerated(t *testing.T) {
+	t.Parallel()
+
+	k := &Kubernetes{}
+
+	s, err := k.Summary()
+	if err != nil {
+		t.Fatalf("Getting summary should succeed, got: %v", err)
+	}
+
+	if len(s) != 0 {
+		t.Fatalf("Summary should not include certificates, which are not generated, got: %+v", s)
+	}
+}
+
+func TestKubernetesSummaryBadCertificate(t *testing.T) {
+	t.Parallel()
+
+	k := &Kubernetes{
+		CA: &Certificate{
+			X509Certificate: "foo",
+		},
+	}
+
+	if _, err := k.Summary(); err == nil {
+		t.Fatalf("Getting summary should fail for malformed certificate")
+	}
+}
+
+type fakeSecretApplier struct {
+	secrets map[string]*v1.Secret
+	err     error
+	lock    sync.Mutex
+}
+
+func (f *fakeSecretApplier) ApplySecret(s *v1.Secret) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.secrets[s.Name] = s
+
+	return nil
+}
+
+// ExportToSecrets() tests.
+func TestKubernetesExportToSecrets(t *testing.T) {
+	t.Parallel()
+
+	pki := &PKI{
+		Kubernetes: &Kubernetes{},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("Generating PKI should succeed, got: %v", err)
+	}
+
+	f := &fakeSecretApplier{
+		secrets: map[string]*v1.Secret{},
+	}
+
+	if err := pki.Kubernetes.ExportToSecrets(f, "kube-system"); err != nil {
+		t.Fatalf("Exporting PKI to secrets should succeed, got: %v", err)
+	}
+
+	if len(f.secrets) != len(pki.Kubernetes.certificates()) {
+		t.Fatalf("Expected secret for every certificate, got %d secrets", len(f.secrets))
+	}
+
+	s, ok := f.secrets["kube-apiserver-front-proxy-client"]
+	if !ok {
+		t.Fatalf("Secret for kube-apiserver front proxy client certificate should be created")
+	}
+
+	if s.Namespace != "kube-system" {
+		t.Errorf("Secret should be created in given namespace, got %q", s.Namespace)
+	}
+
+	if s.Type != v1.SecretTypeTLS {
+		t.Errorf("Secret should have TLS type, got %q", s.Type)
+	}
+
+	expectedData := map[string][]byte{
+		"tls.crt": []byte(pki.Kubernetes.KubeAPIServer.FrontProxyClientCertificate.X509Certificate),
+		"tls.key": []byte(pki.Kubernetes.KubeAPIServer.FrontProxyClientCertificate.PrivateKey),
+		"ca.crt":  []byte(pki.Kubernetes.FrontProxyCA.X509Certificate),
+	}
+
+	if diff := cmp.Diff(expectedData, s.Data); diff != "" {
+		t.Fatalf("Unexpected secret data: %s", diff)
+	}
+
+	ca := f.secrets["kubernetes-ca"]
+
+	if string(ca.Data["ca.crt"]) != pki.Kubernetes.CA.X509Certificate {
+		t.Fatalf("CA secret should include CA certificate as CA certificate")
+	}
+}
+
+func TestKubernetesExportToSecretsNoNamespace(t *testing.T) {
+	t.Parallel()
+
+	k := &Kubernetes{}
+
+	if err := k.ExportToSecrets(&fakeSecretApplier{}, ""); err == nil {
+		t.Fatalf("Exporting PKI to secrets without namespace should fail")
+	}
+}
+
+func TestKubernetesExportToSecretsApplyFail(t *testing.T) {
+	t.Parallel()
+
+	pki := &PKI{
+		Kubernetes: &Kubernetes{},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("Generating PKI should succeed, got: %v", err)
+	}
+
+	f := &fakeSecretApplier{
+		err: fmt.Errorf("foo"),
+	}
+
+	if err := pki.Kubernetes.ExportToSecrets(f, "kube-system"); err == nil {
+		t.Fatalf("Exporting PKI to secrets should fail when applying secret fails")
+	}
+}
+
+type failingSecretApplier struct {
+	fail    map[string]bool
+	applied map[string]bool
+	lock    sync.Mutex
+}
+
+func (f *failingSecretApplier) ApplySecret(s *v1.Secret) error {
+	if f.fail[s.Name] {
+		return fmt.Errorf("foo")
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.applied[s.Name] = true
+
+	return nil
+}
+
+func TestKubernetesExportToSecretsAggregateErrors(t *testing.T) {
+	t.Parallel()
+
+	pki := &PKI{
+		Kubernetes: &Kubernetes{
+			ExportConcurrency: 3,
+		},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("Generating PKI should succeed, got: %v", err)
+	}
+
+	f := &failingSecretApplier{
+		fail: map[string]bool{
+			"kubernetes-ca": true,
+			"admin":         true,
+		},
+		applied: map[string]bool{},
+	}
+
+	err := pki.Kubernetes.ExportToSecrets(f, "kube-system")
+	if err == nil {
+		t.Fatalf("Exporting PKI to secrets should fail when applying secret fails")
+	}
+
+	for n := range f.fail {
+		if !strings.Contains(err.Error(), fmt.Sprintf("%q", n)) {
+			t.Errorf("Error should mention failed certificate %q, got: %v", n, err)
+		}
+	}
+
+	if len(f.applied) != len(pki.Kubernetes.certificates())-len(f.fail) {
+		t.Fatalf("All other secrets should be applied, got %d", len(f.applied))
+	}
+}
+
+func TestExportAllConcurrency(t *testing.T) {
+	t.Parallel()
+
+	ncs := make([]namedCertificate, 10)
+
+	var lock sync.Mutex
+
+	running := 0
+	max := 0
+
+	err := exportAll(ncs, 3, func(namedCertificate) error {
+		lock.Lock()
+		running++
+
+		if running > max {
+			max = running
+		}
+		lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Exporting should succeed, got: %v", err)
+	}
+
+	if max > 3 {
+		t.Fatalf("Expected at most 3 exports running at the same time, got %d", max)
+	}
+}
+
+// ExportToDirectory() tests.
+func TestKubernetesExportToDirectory(t *testing.T) {
+	t.Parallel()
+
+	pki := &PKI{
+		Kubernetes: &Kubernetes{},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("Generating PKI should succeed, got: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "pki-export")
+	if err != nil {
+		t.Fatalf("Creating temporary directory should succeed, got: %v", err)
+	}
+
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	if err := pki.Kubernetes.ExportToDirectory(dir); err != nil {
+		t.Fatalf("Exporting PKI to directory should succeed, got: %v", err)
+	}
+
+	p := filepath.Join(dir, "admin", "tls.key")
+
+	key, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatalf("Reading exported private key should succeed, got: %v", err)
+	}
+
+	if string(key) != string(pki.Kubernetes.AdminCertificate.PrivateKey) {
+		t.Fatalf("Exported private key should match admin certificate private key")
+	}
+
+	fi, err := os.Stat(p)
+	if err != nil {
+		t.Fatalf("Checking exported private key should succeed, got: %v", err)
+	}
+
+	if fi.Mode().Perm() != exportPrivateKeyMode {
+		t.Fatalf("Exported private key should have mode %o, got %o", exportPrivateKeyMode, fi.Mode().Perm())
+	}
+
+	ca, err := ioutil.ReadFile(filepath.Join(dir, "admin", "ca.crt"))
+	if err != nil {
+		t.Fatalf("Reading exported CA certificate should succeed, got: %v", err)
+	}
+
+	if string(ca) != string(pki.Kubernetes.CA.X509Certificate) {
+		t.Fatalf("Exported CA certificate should match Kubernetes CA certificate")
+	}
+}
+
+func TestKubernetesExportToDirectoryNoDirectory(t *testing.T) {
+	t.Parallel()
+
+	k := &Kubernetes{}
+
+	if err := k.ExportToDirectory(""); err == nil {
+		t.Fatalf("Exporting PKI to directory without directory should fail")
+	}
+}
+
+func TestKubernetesExportToDirectoryWriteFail(t *testing.T) {
+	t.Parallel()
+
+	pki := &PKI{
+		Kubernetes: &Kubernetes{
+			ExportConcurrency: 2,
+		},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("Generating PKI should succeed, got: %v", err)
+	}
+
+	f, err := ioutil.TempFile("", "pki-export")
+	if err != nil {
+		t.Fatalf("Creating temporary file should succeed, got: %v", err)
+	}
+
+	defer os.Remove(f.Name()) //nolint:errcheck
+
+	err = pki.Kubernetes.ExportToDirectory(f.Name())
+	if err == nil {
+		t.Fatalf("Exporting PKI into a file should fail")
+	}
+
+	if !strings.Contains(err.Error(), `"kubernetes-ca"`) || !strings.Contains(err.Error(), `"admin"`) {
+		t.Fatalf("Error should mention every failed certificate, got: %v", err)
+	}
+}
+
+// signCSR signs given PEM encoded certificate signing request using given CA certificate.
+func signCSR(t *testing.T, csr string, ca *Certificate) string {
+	t.Helper()
+
+	der, _ := pem.Decode([]byte(csr))
+	if der == nil {
+		t.Fatalf("CSR should be PEM encoded")
+	}
+
+	r, err := x509.ParseCertificateRequest(der.Bytes)
+	if err != nil {
+		t.Fatalf("Parsing CSR should succeed, got: %v", err)
+	}
+
+	caCert, caKey, err := ca.decodeKeypair()
+	if err != nil {
+		t.Fatalf("Decoding CA keypair should succeed, got: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      r.Subject,
+		DNSNames:     r.DNSNames,
+		IPAddresses:  r.IPAddresses,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	cert, err := x509.CreateCertificate(rand.Reader, template, caCert, r.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Signing CSR should succeed, got: %v", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: X509CertificatePEMHeader, Bytes: cert}))
+}
+
+// ExternalSigning tests.
+func TestGenerateExternalSigning(t *testing.T) {
+	t.Parallel()
+
+	pki := &PKI{
+		Certificate: Certificate{
+			ExternalSigning: true,
+		},
+		Kubernetes: &Kubernetes{},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("Generating PKI with external signing should succeed, got: %v", err)
+	}
+
+	if pki.Kubernetes.CA.X509Certificate != "" || pki.Kubernetes.CA.PrivateKey != "" || pki.Kubernetes.CA.CSR != "" {
+		t.Fatalf("CA certificate should not be generated with external signing")
+	}
+
+	c := pki.Kubernetes.KubeAPIServer.ServerCertificate
+
+	if c.X509Certificate != "" {
+		t.Fatalf("Certificate should not be signed with external signing")
+	}
+
+	if c.CSR == "" || c.PrivateKey == "" {
+		t.Fatalf("Private key and CSR should be generated with external signing")
+	}
+
+	ca := &Certificate{
+		CA:               true,
+		RSABits:          RSABits,
+		ValidityDuration: ValidityDuration,
+		KeyUsage:         caUsage(),
+	}
+
+	if err := ca.Generate(nil); err != nil {
+		t.Fatalf("Generating external CA should succeed, got: %v", err)
+	}
+
+	if err := c.ImportSignedCertificate(signCSR(t, c.CSR, ca)); err != nil {
+		t.Fatalf("Importing signed certificate should succeed, got: %v", err)
+	}
+
+	if c.CSR != "" {
+		t.Fatalf("CSR should be removed after importing signed certificate")
+	}
+
+	signed := c.X509Certificate
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("Generating PKI again should succeed, got: %v", err)
+	}
+
+	if pki.Kubernetes.KubeAPIServer.ServerCertificate.X509Certificate != signed {
+		t.Fatalf("Imported certificate should be preserved")
+	}
+}
+
+func TestImportSignedCertificateKeyMismatch(t *testing.T) {
+	t.Parallel()
+
+	ca := &Certificate{
+		CA:               true,
+		RSABits:          RSABits,
+		ValidityDuration: ValidityDuration,
+	}
+
+	if err := ca.Generate(nil); err != nil {
+		t.Fatalf("Generating CA should succeed, got: %v", err)
+	}
+
+	c := &Certificate{
+		RSABits:          RSABits,
+		ValidityDuration: ValidityDuration,
+		ExternalSigning:  true,
+	}
+
+	if err := c.Generate(nil); err != nil {
+		t.Fatalf("Generating CSR should succeed, got: %v", err)
+	}
+
+	if err := c.ImportSignedCertificate(string(ca.X509Certificate)); err == nil {
+		t.Fatalf("Importing certificate not matching private key should fail")
+	}
+}
+
+func TestImportSignedCertificateMalformed(t *testing.T) {
+	t.Parallel()
+
+	c := &Certificate{}
+
+	if err := c.ImportSignedCertificate("foo"); err == nil {
+		t.Fatalf("Importing malformed certificate should fail")
+	}
+}
+
+func TestValidateGenerationRetries(t *testing.T) {
+	t.Parallel()
+
+	c := &Certificate{
+		ValidityDuration:  "24h",
+		RSABits:           RSABits,
+		GenerationRetries: -1,
+	}
+
+	if err := c.Validate(); err == nil {
+		t.Fatalf("certificate with negative generation retries should be invalid")
+	}
+}
+
+func TestValidateMaxPathLen(t *testing.T) {
+	t.Parallel()
+
+	l := -1
+
+	c := &Certificate{
+		ValidityDuration: "24h",
+		RSABits:          RSABits,
+		MaxPathLen:       &l,
+	}
+
+	if err := c.Validate(); err == nil {
+		t.Fatalf("certificate with negative max path length should be invalid")
+	}
+}
+
+func TestGenerateMaxPathLen(t *testing.T) {
+	t.Parallel()
+
+	zero := 0
+	one := 1
+
+	pki := &PKI{
+		RootCA: &Certificate{
+			MaxPathLen: &one,
+		},
+		Etcd: &Etcd{
+			CA: &Certificate{
+				MaxPathLen: &zero,
+			},
+			Peers: map[string]string{
+				"controller01": "192.168.1.10",
+			},
+		},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("generating PKI should succeed, got: %v", err)
+	}
+
+	ca, err := pki.RootCA.decodeX509Certificate()
+	if err != nil {
+		t.Fatalf("decoding root CA certificate should succeed, got: %v", err)
+	}
+
+	if ca.MaxPathLen != 1 {
+		t.Errorf("root CA certificate should have max path length 1, got %d", ca.MaxPathLen)
+	}
+
+	etcdCA, err := pki.Etcd.CA.decodeX509Certificate()
+	if err != nil {
+		t.Fatalf("decoding etcd CA certificate should succeed, got: %v", err)
+	}
+
+	if etcdCA.MaxPathLen != 0 || !etcdCA.MaxPathLenZero {
+		t.Errorf("etcd CA certificate should not allow further sub-CAs, got max path length %d", etcdCA.MaxPathLen)
+	}
+
+	peer, err := pki.Etcd.PeerCertificates["controller01"].decodeX509Certificate()
+	if err != nil {
+		t.Fatalf("decoding etcd peer certificate should succeed, got: %v", err)
+	}
+
+	if peer.MaxPathLen != -1 {
+		t.Errorf("etcd peer certificate should not have path length constraint, got %d", peer.MaxPathLen)
+	}
+}
+
+func TestGenerateVerifyGenerated(t *testing.T) {
+	t.Parallel()
+
+	pki := &PKI{
+		Certificate: Certificate{
+			VerifyGenerated:   true,
+			GenerationRetries: 2,
+		},
+		Etcd: &Etcd{
+			Peers: map[string]string{
+				"controller01": "192.168.1.10",
+			},
+		},
+	}
+
+	if err := pki.Generate(); err != nil {
+		t.Fatalf("generating PKI with verification should succeed, got: %v", err)
+	}
+}
+
+// generateCAs generates given number of independent CA certificates.
+func generateCAs(t *testing.T, n int) []*Certificate {
+	t.Helper()
+
+	cas := []*Certificate{}
+
+	for i := 0; i < n; i++ {
+		ca := caCertificate(RootCACN)
+		ca.RSABits = RSABits
+		ca.ValidityDuration = ValidityDuration
+
+		if err := ca.Generate(nil); err != nil {
+			t.Fatalf("generating CA should succeed, got: %v", err)
+		}
+
+		cas = append(cas, ca)
+	}
+
+	return cas
+}
+
+// brokenCA returns CA certificate, which private key does not match it's X.509 certificate,
+// so certificates signed by it fail verification.
+func brokenCA(t *testing.T) *Certificate {
+	t.Helper()
+
+	cas := generateCAs(t, 2)
+
+	return &Certificate{
+		X509Certificate: cas[0].X509Certificate,
+		PrivateKey:      cas[1].PrivateKey,
+	}
+}
+
+func TestBuildAndGenerateVerificationFail(t *testing.T) {
+	t.Parallel()
+
+	cr := &certificateRequest{
+		Target: &Certificate{},
+		CA:     brokenCA(t),
+		Certificates: []*Certificate{
+			{
+				CommonName:        "foo",
+				VerifyGenerated:   true,
+				GenerationRetries: 1,
+			},
+		},
+	}
+
+	if err := buildAndGenerate(cr); err == nil {
+		t.Fatalf("generating certificate signed by broken CA should fail")
+	}
+
+	if cr.Target.X509Certificate != "" {
+		t.Fatalf("certificate failing verification should not be persisted")
+	}
+}
+
+func TestVerifyWrongCA(t *testing.T) {
+	t.Parallel()
+
+	cas := generateCAs(t, 2)
+
+	if err := cas[0].verify(nil); err != nil {
+		t.Fatalf("verifying self-signed CA should succeed, got: %v", err)
+	}
+
+	if err := cas[0].verify(cas[1]); err == nil {
+		t.Fatalf("verifying certificate against wrong CA should fail")
+	}
+}
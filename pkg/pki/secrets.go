@@ -0,0 +1,96 @@
+package pki
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// SecretManagedByLabel is a label set on Secrets created from the PKI.
+	SecretManagedByLabel = "app.kubernetes.io/managed-by"
+
+	// SecretManagedByValue is a value of SecretManagedByLabel set on Secrets created from the PKI.
+	SecretManagedByValue = "flexkube"
+
+	// secretCAKey is a key in the Secret, which holds CA certificate.
+	secretCAKey = "ca.crt"
+)
+
+// SecretApplier creates or updates given Secret. It is implemented by Kubernetes client from
+// github.com/flexkube/libflexkube/pkg/kubernetes/client package.
+type SecretApplier interface {
+	ApplySecret(secret *v1.Secret) error
+}
+
+// secret returns Secret with given name and namespace containing the certificate, it's
+// private key and the CA certificate, using standard key names.
+func (nc namedCertificate) secret(namespace string) *v1.Secret {
+	ca := nc.ca
+
+	// CA certificates are signed by the root CA, which is not part of Kubernetes PKI,
+	// so use the CA certificate itself.
+	if ca == nil {
+		ca = nc.certificate
+	}
+
+	s := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nc.name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				SecretManagedByLabel: SecretManagedByValue,
+			},
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			v1.TLSCertKey: []byte(nc.certificate.X509Certificate),
+			secretCAKey:   []byte(ca.X509Certificate),
+		},
+	}
+
+	// kubernetes.io/tls type requires both certificate and private key to be set.
+	if nc.certificate.PrivateKey != "" {
+		s.Type = v1.SecretTypeTLS
+		s.Data[v1.TLSPrivateKeyKey] = []byte(nc.certificate.PrivateKey)
+	}
+
+	return s
+}
+
+// Secrets returns Kubernetes Secrets for all generated Kubernetes certificates in given
+// namespace. Each Secret is named after the certificate, e.g. 'kubernetes-ca' and
+// contains 'tls.crt', 'tls.key' and 'ca.crt' keys.
+//
+// Certificates, which has not been generated yet, are skipped.
+func (k *Kubernetes) Secrets(namespace string) []*v1.Secret {
+	r := []*v1.Secret{}
+
+	for _, nc := range k.generated() {
+		r = append(r, nc.secret(namespace))
+	}
+
+	return r
+}
+
+// ExportToSecrets writes all generated Kubernetes certificates with their private keys
+// into Secrets in given namespace using given client. Existing Secrets are updated, so
+// it can be called after every certificate rotation.
+//
+// Secrets are applied concurrently, respecting ExportConcurrency, so given client must be
+// safe for concurrent use. Failure to apply one Secret does not stop applying the others
+// and all failures are returned combined.
+func (k *Kubernetes) ExportToSecrets(c SecretApplier, namespace string) error {
+	if namespace == "" {
+		return fmt.Errorf("namespace must be set")
+	}
+
+	return exportAll(k.generated(), k.exportConcurrency(), func(nc namedCertificate) error {
+		if err := c.ApplySecret(nc.secret(namespace)); err != nil {
+			return fmt.Errorf("failed applying secret for certificate %q: %w", nc.name, err)
+		}
+
+		return nil
+	})
+}
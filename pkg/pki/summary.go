@@ -0,0 +1,130 @@
+package pki
+
+import (
+	"fmt"
+	"time"
+)
+
+// CertificateSummary contains metadata of the certificate, which is safe to share, for
+// example with auditors. It never contains private key material.
+type CertificateSummary struct {
+	// Name is a name of the certificate in the PKI, e.g. 'kube-apiserver-server'.
+	Name string `json:"name"`
+
+	// Subject is a distinguished name of the certificate subject.
+	Subject string `json:"subject"`
+
+	// Issuer is a distinguished name of the certificate issuer.
+	Issuer string `json:"issuer"`
+
+	// SerialNumber is a serial number of the certificate in hexadecimal format.
+	SerialNumber string `json:"serialNumber"`
+
+	// CA indicates, if certificate is a CA certificate.
+	CA bool `json:"ca,omitempty"`
+
+	// DNSNames is a list of DNS names included in certificate subject alternative names.
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// IPAddresses is a list of IP addresses included in certificate subject alternative names.
+	IPAddresses []string `json:"ipAddresses,omitempty"`
+
+	// NotBefore is a time, from which certificate is valid.
+	NotBefore time.Time `json:"notBefore"`
+
+	// NotAfter is a time, after which certificate is no longer valid.
+	NotAfter time.Time `json:"notAfter"`
+}
+
+// summary returns summary of the generated certificate with given name.
+func (c *Certificate) summary(name string) (CertificateSummary, error) {
+	cert, err := c.decodeX509Certificate()
+	if err != nil {
+		return CertificateSummary{}, fmt.Errorf("failed decoding certificate %q: %w", name, err)
+	}
+
+	ips := []string{}
+
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+
+	return CertificateSummary{
+		Name:         name,
+		Subject:      cert.Subject.String(),
+		Issuer:       cert.Issuer.String(),
+		SerialNumber: fmt.Sprintf("%x", cert.SerialNumber),
+		CA:           cert.IsCA,
+		DNSNames:     cert.DNSNames,
+		IPAddresses:  ips,
+		NotBefore:    cert.NotBefore,
+		NotAfter:     cert.NotAfter,
+	}, nil
+}
+
+// namedCertificate is a certificate with it's name in the PKI and the CA certificate,
+// which signed it. CA is nil for certificates signed by the root CA.
+type namedCertificate struct {
+	name        string
+	certificate *Certificate
+	ca          *Certificate
+}
+
+// certificates returns all Kubernetes certificates with their names, in a stable order.
+// Certificates, which are not configured, are not included.
+func (k *Kubernetes) certificates() []namedCertificate {
+	ncs := []namedCertificate{
+		{"kubernetes-ca", k.CA, nil},
+		{"kubernetes-front-proxy-ca", k.FrontProxyCA, nil},
+		{"kubernetes-kubelet-serving-ca", k.KubeletServingCA, nil},
+	}
+
+	if k.KubeAPIServer != nil {
+		ncs = append(ncs, []namedCertificate{
+			{"kube-apiserver-server", k.KubeAPIServer.ServerCertificate, k.CA},
+			{"kube-apiserver-kubelet-client", k.KubeAPIServer.KubeletCertificate, k.CA},
+			{"kube-apiserver-front-proxy-client", k.KubeAPIServer.FrontProxyClientCertificate, k.FrontProxyCA},
+		}...)
+	}
+
+	ncs = append(ncs, []namedCertificate{
+		{"admin", k.AdminCertificate, k.CA},
+		{"kube-controller-manager", k.KubeControllerManagerCertificate, k.CA},
+		{"kube-scheduler", k.KubeSchedulerCertificate, k.CA},
+		{"service-account", k.ServiceAccountCertificate, k.CA},
+	}...)
+
+	r := []namedCertificate{}
+
+	for _, nc := range ncs {
+		if nc.certificate != nil {
+			r = append(r, nc)
+		}
+	}
+
+	return r
+}
+
+// Summary returns subjects, subject alternative names, issuers and validity of all
+// generated Kubernetes certificates. Returned data does not include any private keys,
+// so it can be safely shared, e.g. for review or compliance purposes.
+//
+// Certificates, which has not been generated yet, are skipped.
+func (k *Kubernetes) Summary() ([]CertificateSummary, error) {
+	r := []CertificateSummary{}
+
+	for _, nc := range k.certificates() {
+		if nc.certificate.X509Certificate == "" {
+			continue
+		}
+
+		s, err := nc.certificate.summary(nc.name)
+		if err != nil {
+			return nil, err
+		}
+
+		r = append(r, s)
+	}
+
+	return r, nil
+}